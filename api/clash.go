@@ -279,6 +279,75 @@ func GetProxiesInGroup(baseURL, token, groupName string, logFile *os.File) ([]Pr
 	return proxies, nowProxy, nil
 }
 
+// SelectorGroup holds the members and the current choice of a selector group.
+type SelectorGroup struct {
+	Name string
+	Now  string
+	All  []string
+}
+
+// GetSelectorGroups retrieves all selector groups with their current choice from the Clash API.
+// Other group types (urltest etc.) are skipped since their choice cannot be switched.
+func GetSelectorGroups(baseURL, token string, logFile *os.File) (map[string]SelectorGroup, error) {
+	writeLog(logFile, "[%s] GET /proxies request started for selector groups.\n", time.Now().Format("2006-01-02 15:04:05"))
+
+	url := fmt.Sprintf("%s/proxies", baseURL)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(httpRequestTimeoutSeconds)*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create /proxies request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpClient.Do(req)
+	defer func() {
+		if resp != nil {
+			debuglog.RunAndLog("GetSelectorGroups: close response body", resp.Body.Close)
+		}
+	}()
+	if err != nil {
+		writeLog(logFile, "[%s] Error executing selector groups request: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return nil, fmt.Errorf("network timeout: connection timed out")
+		}
+		if opErr, ok := err.(*net.OpError); ok && opErr.Op == "dial" {
+			return nil, fmt.Errorf("network error: cannot connect to server")
+		}
+		return nil, fmt.Errorf("failed to execute /proxies request: %w", err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proxies response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		writeLog(logFile, "[%s] Unexpected status code for selector groups: %d, body: %s\n", time.Now().Format("2006-01-02 15:04:05"), resp.StatusCode, string(body))
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var raw struct {
+		Proxies map[string]struct {
+			Type string   `json:"type"`
+			Now  string   `json:"now"`
+			All  []string `json:"all"`
+		} `json:"proxies"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal /proxies response: %w", err)
+	}
+
+	groups := make(map[string]SelectorGroup)
+	for name, proxy := range raw.Proxies {
+		if !strings.EqualFold(proxy.Type, "Selector") {
+			continue
+		}
+		groups[name] = SelectorGroup{Name: name, Now: proxy.Now, All: proxy.All}
+	}
+	writeLog(logFile, "[%s] Found %d selector groups.\n", time.Now().Format("2006-01-02 15:04:05"), len(groups))
+	return groups, nil
+}
+
 // SwitchProxy switches the active proxy within the specified group.
 func SwitchProxy(baseURL, token, group, proxy string, logFile *os.File) error {
 	payloadStr := fmt.Sprintf("{\"name\":\"%s\"}", proxy)
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"singbox-launcher/internal/debuglog"
+)
+
+// ProbeVersion requests /version of the Clash API. Unlike TestAPIConnection it is bounded by ctx
+// and writes nothing to the API log, so it can run periodically (health watchdog).
+func ProbeVersion(ctx context.Context, baseURL, token string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/version", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create version request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("clash API: %w", err)
+	}
+	defer debuglog.RunAndLog("ProbeVersion: close response body", resp.Body.Close)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("clash API: unexpected status code %d", resp.StatusCode)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// ProbeDelay measures the delay of testURL through the proxy or group proxyName
+// (/proxies/{name}/delay): sing-box itself opens the connection, so the probe checks the core
+// and the current outbound. Returns the delay in milliseconds.
+func ProbeDelay(ctx context.Context, baseURL, token, proxyName, testURL string, timeout time.Duration) (int64, error) {
+	query := url.Values{}
+	query.Set("url", testURL)
+	query.Set("timeout", fmt.Sprintf("%d", timeout.Milliseconds()))
+	requestURL := fmt.Sprintf("%s/proxies/%s/delay?%s", baseURL, url.PathEscape(proxyName), query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create delay request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("delay via %s: %w", proxyName, err)
+	}
+	defer debuglog.RunAndLog("ProbeDelay: close response body", resp.Body.Close)
+
+	var data struct {
+		Delay   *int64 `json:"delay"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&data); err != nil && resp.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("delay via %s: invalid response: %w", proxyName, err)
+	}
+	if resp.StatusCode != http.StatusOK || data.Delay == nil {
+		if data.Message != "" {
+			return 0, fmt.Errorf("delay via %s: %s", proxyName, data.Message)
+		}
+		return 0, fmt.Errorf("delay via %s: unexpected status code %d", proxyName, resp.StatusCode)
+	}
+	return *data.Delay, nil
+}
@@ -0,0 +1,170 @@
+// Package cli implements the headless command-line mode of the launcher.
+//
+// Subcommands run the same core/config logic as the GUI, but Fyne is never initialized:
+// the result of every command is printed to stdout as one JSON object per line,
+// diagnostics go to stderr and to the usual log files next to the executable.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"singbox-launcher/core"
+)
+
+// Exit codes of the command-line mode.
+// ExitNotRunning follows the LSB convention for "status" (program is not running).
+const (
+	ExitOK         = 0
+	ExitFailure    = 1
+	ExitUsage      = 2
+	ExitNotRunning = 3
+)
+
+// Result is the JSON object printed to stdout for every command.
+type Result struct {
+	Command string      `json:"command"`
+	OK      bool        `json:"ok"`
+	Error   string      `json:"error,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// env is passed to command handlers.
+type env struct {
+	stdout io.Writer
+	stderr io.Writer
+	ac     *core.AppController
+}
+
+// command describes one subcommand. run returns the result data, the exit code and an error
+// (the error is reported in Result.Error; data is printed even when the command failed).
+type command struct {
+	usage   string
+	summary string
+	minArgs int
+	maxArgs int
+	run     func(e *env, args []string) (interface{}, int, error)
+}
+
+var commands = map[string]command{
+	"update": {
+		usage:   "update",
+		summary: "Download subscriptions and regenerate config.json (like \"Update\" in the Core Dashboard)",
+		run:     runUpdate,
+	},
+	"check": {
+		usage:   "check",
+		summary: "Validate @ParserConfig and config.json (JSON Schema and sing-box check)",
+		run:     runCheck,
+	},
+	"start": {
+		usage:   "start",
+		summary: "Run sing-box in the foreground with crash restarts and auto-update until SIGINT/SIGTERM",
+		run:     runStart,
+	},
+	"stop": {
+		usage:   "stop",
+		summary: "Stop a running sing-box process",
+		run:     runStop,
+	},
+	"status": {
+		usage:   "status",
+		summary: "Report whether sing-box is running, core version and config state",
+		run:     runStatus,
+	},
+	"download-core": {
+		usage:   "download-core [version]",
+		summary: "Download and install sing-box (latest version by default)",
+		maxArgs: 1,
+		run:     runDownloadCore,
+	},
+	"wizard-apply": {
+		usage:   "wizard-apply <state.json|state-id>",
+		summary: "Generate config.json from a saved wizard state without opening the wizard",
+		minArgs: 1,
+		maxArgs: 1,
+		run:     runWizardApply,
+	},
+}
+
+// newController creates the controller for commands; replaced in tests.
+var newController = core.NewHeadlessAppController
+
+// IsCommand reports whether arg is a subcommand of the command-line mode
+// (main uses it to decide whether to start the GUI).
+func IsCommand(arg string) bool {
+	if arg == "help" || arg == "-h" || arg == "--help" {
+		return true
+	}
+	_, ok := commands[arg]
+	return ok
+}
+
+// Run executes the subcommand in args[0] and returns the process exit code.
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stdout)
+		return ExitOK
+	}
+
+	name, cmdArgs := args[0], args[1:]
+	cmd, ok := commands[name]
+	if !ok {
+		printUsage(stderr)
+		return writeResult(stdout, name, nil, ExitUsage, fmt.Errorf("unknown command %q", name))
+	}
+	if len(cmdArgs) < cmd.minArgs || len(cmdArgs) > cmd.maxArgs {
+		return writeResult(stdout, name, nil, ExitUsage, fmt.Errorf("usage: %s", cmd.usage))
+	}
+
+	ac, err := newController()
+	if err != nil {
+		return writeResult(stdout, name, nil, ExitFailure, err)
+	}
+	e := &env{stdout: stdout, stderr: stderr, ac: ac}
+	data, code, err := cmd.run(e, cmdArgs)
+	if ac.FileService != nil {
+		ac.FileService.CloseLogFiles()
+	}
+	return writeResult(stdout, name, data, code, err)
+}
+
+// writeResult prints the command result as a JSON line and returns the exit code.
+// A command that failed without a specific code exits with ExitFailure.
+func writeResult(w io.Writer, name string, data interface{}, code int, err error) int {
+	if err != nil && code == ExitOK {
+		code = ExitFailure
+	}
+	res := Result{Command: name, OK: err == nil, Data: data}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if encErr := enc.Encode(res); encErr != nil {
+		return ExitFailure
+	}
+	return code
+}
+
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString("Usage: singbox-launcher <command> [args]\n")
+	sb.WriteString("       singbox-launcher [-start] [-tray]   (GUI)\n\nCommands:\n")
+	for _, name := range names {
+		cmd := commands[name]
+		fmt.Fprintf(&sb, "  %-36s %s\n", cmd.usage, cmd.summary)
+	}
+	sb.WriteString("\nEach command prints one JSON object per line: {\"command\", \"ok\", \"error\", \"data\"}.\n")
+	sb.WriteString("Exit codes: 0 - success, 1 - failure, 2 - invalid usage, 3 - sing-box is not running (status).\n")
+	_, _ = io.WriteString(w, sb.String())
+}
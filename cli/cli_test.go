@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"singbox-launcher/core"
+)
+
+func TestIsCommand(t *testing.T) {
+	for _, arg := range []string{"update", "check", "start", "stop", "status", "download-core", "wizard-apply", "help"} {
+		if !IsCommand(arg) {
+			t.Errorf("IsCommand(%q) = false", arg)
+		}
+	}
+	// GUI flags must not be taken for subcommands
+	for _, arg := range []string{"-start", "-tray", "", "config.json"} {
+		if IsCommand(arg) {
+			t.Errorf("IsCommand(%q) = true", arg)
+		}
+	}
+}
+
+func TestRun_UsageErrors(t *testing.T) {
+	created := false
+	restore := newController
+	newController = func() (*core.AppController, error) {
+		created = true
+		return nil, errors.New("controller must not be created")
+	}
+	defer func() { newController = restore }()
+
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"wizard-apply"}, ExitUsage},
+		{[]string{"status", "extra"}, ExitUsage},
+		{[]string{"download-core", "1.12.0", "extra"}, ExitUsage},
+		{[]string{"help"}, ExitOK},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := Run(tt.args, &stdout, &stderr); code != tt.code {
+			t.Errorf("Run(%v) = %d, want %d", tt.args, code, tt.code)
+		}
+		if tt.code == ExitUsage {
+			var res Result
+			if err := json.Unmarshal(stdout.Bytes(), &res); err != nil {
+				t.Fatalf("Run(%v) output is not JSON: %q", tt.args, stdout.String())
+			}
+			if res.OK || res.Command != tt.args[0] || !strings.HasPrefix(res.Error, "usage:") {
+				t.Errorf("Run(%v) result = %+v", tt.args, res)
+			}
+		}
+	}
+	if created {
+		t.Errorf("controller was created for an invalid command line")
+	}
+}
+
+func TestRun_ControllerError(t *testing.T) {
+	restore := newController
+	newController = func() (*core.AppController, error) {
+		return nil, errors.New("cannot open log files")
+	}
+	defer func() { newController = restore }()
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"status"}, &stdout, &stderr); code != ExitFailure {
+		t.Errorf("Run(status) = %d, want %d", code, ExitFailure)
+	}
+	if got := strings.TrimSpace(stdout.String()); got != `{"command":"status","ok":false,"error":"cannot open log files"}` {
+		t.Errorf("unexpected output: %s", got)
+	}
+}
@@ -0,0 +1,375 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"singbox-launcher/core"
+	"singbox-launcher/core/config"
+	"singbox-launcher/core/config/parser"
+	"singbox-launcher/core/config/schema"
+	"singbox-launcher/internal/debuglog"
+	wizardbusiness "singbox-launcher/ui/wizard/business"
+	wizardmodels "singbox-launcher/ui/wizard/models"
+	wizardtemplate "singbox-launcher/ui/wizard/template"
+)
+
+const (
+	// startGiveUpDelay is how long "start" waits for sing-box to come back after it exited
+	// and no crash restart is pending (the restart policy may also decide not to restart)
+	startGiveUpDelay = 10 * time.Second
+	// downloadTimeout matches the timeout of the download button in the Core Dashboard
+	downloadTimeout = 10 * time.Minute
+)
+
+// updateData is the result of "update".
+type updateData struct {
+	ConfigPath string                 `json:"config_path"`
+	NodesCount int                    `json:"nodes_count"`
+	Added      int                    `json:"nodes_added"`
+	Removed    int                    `json:"nodes_removed"`
+	Changed    int                    `json:"nodes_changed"`
+	RolledBack bool                   `json:"rolled_back,omitempty"`
+	Sources    []*config.SourceReport `json:"sources,omitempty"`
+}
+
+func runUpdate(e *env, _ []string) (interface{}, int, error) {
+	ac := e.ac
+	if _, err := os.Stat(ac.FileService.ConfigPath()); err != nil {
+		return nil, ExitFailure, fmt.Errorf("config.json not found: %s", ac.FileService.ConfigPath())
+	}
+
+	updateErr := ac.ConfigService.UpdateConfigFromSubscriptions()
+
+	data := &updateData{ConfigPath: ac.FileService.ConfigPath()}
+	if report, err := config.LoadParseReport(config.ParseReportPath(ac.FileService.ConfigPath())); err == nil {
+		data.NodesCount = report.NodesCount
+		data.Added, data.Removed, data.Changed = report.TotalChurn()
+		data.RolledBack = report.RolledBack
+		data.Sources = report.Sources
+	} else {
+		debuglog.WarnLog("cli update: failed to load parse report: %v", err)
+	}
+	if updateErr != nil {
+		return data, ExitFailure, updateErr
+	}
+	return data, ExitOK, nil
+}
+
+// checkData is the result of "check".
+type checkData struct {
+	ConfigPath   string   `json:"config_path"`
+	Sources      int      `json:"sources"`
+	Outbounds    int      `json:"outbounds"`
+	SchemaErrors []string `json:"schema_errors,omitempty"`
+	SingBoxCheck string   `json:"singbox_check"` // "ok", "failed" or "skipped"
+	SingBoxError string   `json:"singbox_error,omitempty"`
+}
+
+func runCheck(e *env, _ []string) (interface{}, int, error) {
+	ac := e.ac
+	data := &checkData{ConfigPath: ac.FileService.ConfigPath(), SingBoxCheck: "skipped"}
+
+	raw, err := os.ReadFile(ac.FileService.ConfigPath())
+	if err != nil {
+		return data, ExitFailure, fmt.Errorf("failed to read config.json: %w", err)
+	}
+
+	// @ParserConfig: JSON Schema first (positions are more useful than a decode error), then the parser itself
+	var problems []string
+	if block, err := parser.ExtractParserConfigBlock(raw); err != nil {
+		problems = append(problems, fmt.Sprintf("@ParserConfig: %v", err))
+	} else {
+		for _, schemaErr := range schema.ValidateParserConfig([]byte(block)) {
+			data.SchemaErrors = append(data.SchemaErrors, schemaErr.Error())
+		}
+		if len(data.SchemaErrors) > 0 {
+			problems = append(problems, fmt.Sprintf("@ParserConfig does not match schema: %d error(s)", len(data.SchemaErrors)))
+		}
+		if parserConfig, err := parser.ExtractParserConfig(ac.FileService.ConfigPath()); err != nil {
+			problems = append(problems, fmt.Sprintf("@ParserConfig: %v", err))
+		} else {
+			data.Sources = len(parserConfig.ParserConfig.Proxies)
+			data.Outbounds = len(parserConfig.ParserConfig.Outbounds)
+		}
+	}
+
+	// sing-box check needs the core; without it only @ParserConfig is checked
+	if _, err := os.Stat(ac.FileService.SingboxPath); err == nil {
+		if err := config.ValidateConfigWithSingBox(ac.FileService.ConfigPath(), ac.FileService.SingboxPath); err != nil {
+			data.SingBoxCheck = "failed"
+			data.SingBoxError = err.Error()
+			problems = append(problems, "sing-box check failed")
+		} else {
+			data.SingBoxCheck = "ok"
+		}
+	}
+
+	if len(problems) > 0 {
+		return data, ExitFailure, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return data, ExitOK, nil
+}
+
+// processData is the result of "start" and "stop".
+type processData struct {
+	PID        int    `json:"pid,omitempty"`
+	State      string `json:"state"` // "running" or "stopped"
+	WasRunning *bool  `json:"was_running,omitempty"`
+	Signal     string `json:"signal,omitempty"`
+}
+
+// runStart runs sing-box in the foreground like the GUI does (crash restarts, auto-update,
+// control API if enabled) until the launcher receives SIGINT/SIGTERM or sing-box stops and is not restarted.
+// One result line is printed once sing-box is started, another one when it stops.
+func runStart(e *env, _ []string) (interface{}, int, error) {
+	ac := e.ac
+	if running, pid := ac.ProcessService.FindRunningProcess(); running {
+		return &processData{PID: pid, State: "running"}, ExitFailure, fmt.Errorf("sing-box is already running (PID %d)", pid)
+	}
+	if _, err := os.Stat(ac.FileService.ConfigPath()); err != nil {
+		return nil, ExitFailure, fmt.Errorf("config.json not found: %s", ac.FileService.ConfigPath())
+	}
+	if _, err := os.Stat(ac.FileService.SingboxPath); err != nil {
+		return nil, ExitFailure, fmt.Errorf("sing-box not found at %s (run download-core)", ac.FileService.SingboxPath)
+	}
+	if conflicts, err := ac.ProcessService.FindPortConflicts(); err == nil && len(conflicts) > 0 {
+		return nil, ExitFailure, &core.PortConflictError{Conflicts: conflicts}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	ac.ProcessService.Start()
+	if !ac.RunningState.IsRunning() {
+		return nil, ExitFailure, fmt.Errorf("failed to start sing-box, see logs/sing-box.log")
+	}
+	writeResult(e.stdout, "start", &processData{PID: trackedPID(ac), State: "running"}, ExitOK, nil)
+	ac.StartAutoUpdate()
+	if err := ac.StartControlAPI(); err != nil {
+		fmt.Fprintf(e.stderr, "control API: %v\n", err)
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	var stoppedAt time.Time
+	for {
+		select {
+		case sig := <-signals:
+			debuglog.InfoLog("cli start: received %v, stopping sing-box", sig)
+			ac.GracefulExit()
+			return &processData{State: "stopped", Signal: sig.String()}, ExitOK, nil
+		case <-ticker.C:
+			if _, pending := ac.ProcessService.PendingRestart(); pending || ac.RunningState.IsRunning() {
+				stoppedAt = time.Time{}
+				continue
+			}
+			if stoppedAt.IsZero() {
+				stoppedAt = time.Now()
+				continue
+			}
+			if time.Since(stoppedAt) >= startGiveUpDelay {
+				ac.GracefulExit()
+				return &processData{State: "stopped"}, ExitFailure, fmt.Errorf("sing-box stopped and was not restarted%s, see logs/sing-box.log", lastCrashDiagnosis(ac))
+			}
+		}
+	}
+}
+
+// lastCrashDiagnosis returns ": <explanation>" of the last crash, if its output explained it.
+func lastCrashDiagnosis(ac *core.AppController) string {
+	crashes := ac.ProcessService.CrashHistory()
+	if len(crashes) == 0 || crashes[len(crashes)-1].Diagnosis == "" {
+		return ""
+	}
+	return ": " + crashes[len(crashes)-1].Diagnosis
+}
+
+// trackedPID returns the PID of the sing-box process started by this controller.
+func trackedPID(ac *core.AppController) int {
+	ac.CmdMutex.Lock()
+	defer ac.CmdMutex.Unlock()
+	if ac.SingboxCmd != nil && ac.SingboxCmd.Process != nil {
+		return ac.SingboxCmd.Process.Pid
+	}
+	return 0
+}
+
+func runStop(e *env, _ []string) (interface{}, int, error) {
+	running, pid := e.ac.ProcessService.FindRunningProcess()
+	if !running {
+		return &processData{State: "stopped", WasRunning: boolPtr(false)}, ExitOK, nil
+	}
+	if err := e.ac.ProcessService.StopPID(pid); err != nil {
+		return &processData{PID: pid, State: "running", WasRunning: boolPtr(true)}, ExitFailure, fmt.Errorf("failed to stop sing-box (PID %d): %w", pid, err)
+	}
+	return &processData{PID: pid, State: "stopped", WasRunning: boolPtr(true)}, ExitOK, nil
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+// statusData is the result of "status".
+type statusData struct {
+	Running      bool        `json:"running"`
+	PID          int         `json:"pid,omitempty"`
+	CorePath     string      `json:"core_path"`
+	CoreVersion  string      `json:"core_version,omitempty"`
+	CoreError    string      `json:"core_error,omitempty"`
+	ConfigPath   string      `json:"config_path"`
+	ConfigExists bool        `json:"config_exists"`
+	LastUpdated  string      `json:"last_updated,omitempty"`
+	LastUpdate   *lastUpdate `json:"last_update,omitempty"`
+}
+
+// lastUpdate summarizes the parse report of the last subscription update.
+type lastUpdate struct {
+	FinishedAt time.Time `json:"finished_at"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	NodesCount int       `json:"nodes_count"`
+}
+
+func runStatus(e *env, _ []string) (interface{}, int, error) {
+	ac := e.ac
+	data := &statusData{
+		CorePath:   ac.FileService.SingboxPath,
+		ConfigPath: ac.FileService.ConfigPath(),
+	}
+	if running, pid := ac.ProcessService.FindRunningProcess(); running {
+		data.Running, data.PID = true, pid
+	}
+
+	if version, err := ac.GetInstalledCoreVersion(); err != nil {
+		data.CoreError = err.Error()
+	} else {
+		data.CoreVersion = version
+	}
+
+	if _, err := os.Stat(ac.FileService.ConfigPath()); err == nil {
+		data.ConfigExists = true
+		if parserConfig, err := parser.ExtractParserConfig(ac.FileService.ConfigPath()); err == nil {
+			data.LastUpdated = parserConfig.ParserConfig.Parser.LastUpdated
+		}
+		if report, err := config.LoadParseReport(config.ParseReportPath(ac.FileService.ConfigPath())); err == nil {
+			data.LastUpdate = &lastUpdate{
+				FinishedAt: report.FinishedAt,
+				Success:    report.Success,
+				Error:      report.Error,
+				NodesCount: report.NodesCount,
+			}
+		}
+	}
+
+	if !data.Running {
+		return data, ExitNotRunning, nil
+	}
+	return data, ExitOK, nil
+}
+
+// downloadData is the result of "download-core".
+type downloadData struct {
+	Version  string `json:"version"`
+	CorePath string `json:"core_path"`
+}
+
+// runDownloadCore downloads sing-box; progress is printed to stderr, the result to stdout.
+func runDownloadCore(e *env, args []string) (interface{}, int, error) {
+	ac := e.ac
+	version := ""
+	if len(args) == 1 {
+		version = strings.TrimPrefix(args[0], "v")
+	} else {
+		latest, err := ac.GetLatestCoreVersion()
+		if err != nil {
+			return nil, ExitFailure, fmt.Errorf("failed to get latest version: %w", err)
+		}
+		version = latest
+	}
+	data := &downloadData{Version: version, CorePath: ac.FileService.SingboxPath}
+
+	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	progressChan := make(chan core.DownloadProgress, 10)
+	go ac.DownloadCore(ctx, version, progressChan)
+
+	var downloadErr error
+	for progress := range progressChan {
+		fmt.Fprintf(e.stderr, "[%3d%%] %s\n", progress.Progress, progress.Message)
+		if progress.Status == "error" {
+			downloadErr = progress.Error
+		}
+	}
+	if downloadErr != nil {
+		return data, ExitFailure, downloadErr
+	}
+	return data, ExitOK, nil
+}
+
+// wizardApplyData is the result of "wizard-apply".
+type wizardApplyData struct {
+	ConfigPath   string `json:"config_path"`
+	Nodes        int    `json:"nodes"`
+	Selectors    int    `json:"selectors"`
+	SingBoxCheck string `json:"singbox_check"` // "ok", "failed" or "skipped"
+}
+
+// runWizardApply runs the wizard save without a window. The argument is a path to a state file
+// (*.json) or an ID of a state saved in bin/wizard_states/.
+func runWizardApply(e *env, args []string) (interface{}, int, error) {
+	ac := e.ac
+	fileService := &wizardbusiness.FileServiceAdapter{FileService: ac.FileService}
+	stateStore := wizardbusiness.NewStateStore(fileService)
+
+	var stateFile *wizardmodels.WizardStateFile
+	var err error
+	if strings.HasSuffix(strings.ToLower(args[0]), ".json") {
+		stateFile, err = stateStore.LoadStateFile(args[0])
+	} else {
+		stateFile, err = stateStore.LoadWizardState(args[0])
+	}
+	if err != nil {
+		return nil, ExitFailure, err
+	}
+
+	templateData, err := wizardtemplate.LoadTemplateData(ac.FileService.ExecDir)
+	if err != nil {
+		return nil, ExitFailure, fmt.Errorf("failed to load wizard template: %w", err)
+	}
+	model := wizardmodels.NewWizardModel()
+	model.TemplateData = templateData
+
+	result, err := wizardbusiness.ApplyWizardState(model, stateFile,
+		&wizardbusiness.ConfigServiceAdapter{CoreConfigService: ac.ConfigService},
+		fileService, ac.FileService.SingboxPath)
+	if err != nil {
+		return nil, ExitFailure, err
+	}
+
+	data := &wizardApplyData{
+		ConfigPath:   result.ConfigPath,
+		Nodes:        result.Nodes,
+		Selectors:    result.Selectors,
+		SingBoxCheck: "ok",
+	}
+	if _, err := os.Stat(ac.FileService.SingboxPath); err != nil {
+		// ValidateConfigWithSingBox skips the check when the core is not installed
+		data.SingBoxCheck = "skipped"
+	}
+	if result.ValidationError != nil {
+		// config.json is written anyway, same as in the wizard (the previous version is in the history)
+		data.SingBoxCheck = "failed"
+		return data, ExitFailure, fmt.Errorf("sing-box check failed: %w", result.ValidationError)
+	}
+	return data, ExitOK, nil
+}
@@ -1,217 +1,422 @@
 package core
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/url"
 	"time"
 
 	"fyne.io/fyne/v2"
 
+	"singbox-launcher/core/config"
 	"singbox-launcher/core/config/parser"
+	"singbox-launcher/core/config/subscription"
+	"singbox-launcher/core/services"
 	"singbox-launcher/internal/debuglog"
 	"singbox-launcher/internal/dialogs"
 )
 
 // Constants for auto-update configuration
 const (
-	autoUpdateMinInterval   = 10 * time.Minute // Minimum check interval
-	autoUpdateRetryInterval = 10 * time.Second // Interval between retry attempts
-	autoUpdateMaxRetries    = 10               // Maximum consecutive failed attempts
-	autoUpdateDefaultReload = "4h"             // Default reload interval if not specified
+	autoUpdateMinInterval  = 10 * time.Minute // Minimum check interval
+	autoUpdateMaxInterval  = 1 * time.Hour    // Maximum check interval (picks up config changes)
+	autoUpdateMaxAttempts  = 6                // Consecutive failed attempts before the cool-down
+	autoUpdateCoolDown     = 1 * time.Hour    // Pause after autoUpdateMaxAttempts failures
+	autoUpdateWaitTick     = 1 * time.Minute  // Granularity of waits (wall clock, connectivity probes)
+	autoUpdateRetryInitial = 10 * time.Second // Delay before the first retry
+	autoUpdateRetryMax     = 5 * time.Minute  // Upper bound of the retry delay
 )
 
+// autoUpdateBackoff is the retry policy of automatic updates: 10s, 20s, 40s ... up to 5 minutes, ±20%
+var autoUpdateBackoff = backoffPolicy{
+	Initial:    autoUpdateRetryInitial,
+	Max:        autoUpdateRetryMax,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// autoUpdateClock abstracts time for the auto-update loop (replaced by a fake clock in tests)
+type autoUpdateClock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the system clock. Now strips the monotonic reading, so deadlines are compared
+// by wall clock and a deadline that passed while the computer was asleep is noticed on wake-up.
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now().Round(0) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// backoffPolicy is an exponential backoff with jitter
+type backoffPolicy struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64 // Relative spread, 0.2 = ±20%
+}
+
+// Delay returns the delay before retry number attempt (1-based).
+// random returns a value in [0, 1); 0.5 means no jitter.
+func (p backoffPolicy) Delay(attempt int, random func() float64) time.Duration {
+	delay := float64(p.Initial)
+	for i := 1; i < attempt && delay < float64(p.Max); i++ {
+		delay *= p.Multiplier
+	}
+	if delay > float64(p.Max) {
+		delay = float64(p.Max)
+	}
+	if p.Jitter > 0 && random != nil {
+		delay *= 1 + p.Jitter*(2*random()-1)
+	}
+	return time.Duration(delay)
+}
+
+// autoUpdateRunner is the auto-update loop with its dependencies injected, so the retry policy,
+// the cool-down and the connectivity trigger can be tested without network and real time
+type autoUpdateRunner struct {
+	ctx         context.Context
+	clock       autoUpdateClock
+	state       *services.StateService
+	backoff     backoffPolicy
+	maxAttempts int
+	coolDown    time.Duration
+	tick        time.Duration
+	random      func() float64
+
+	update     func() error                      // Runs the update (subscriptions due for reload)
+	isDue      func(now time.Time) bool          // Whether an update is needed at now
+	nextCheck  func(now time.Time) time.Duration // How long to wait before the next check
+	inProgress func() bool                       // Whether an update (manual or automatic) is running
+	online     func() bool                       // Whether subscription servers are reachable
+	onPaused   func(failures int, until time.Time)
+}
+
 // startAutoUpdateLoop runs a background goroutine that periodically checks and updates configuration
-// Uses dynamic interval: max(10 minutes, parser.reload from config)
-// Handles errors with retries (10 attempts, 10 seconds between retries)
+// Every subscription has its own reload (source reload or parser.reload: interval or cron expression);
+// the loop wakes up when the next subscription is due and refreshes only the due ones.
+// No updates are run during parser.quiet_hours.
+// Failed updates are retried with exponential backoff (10s up to 5 minutes, with jitter); after
+// 6 consecutive failures auto-update pauses for an hour and then resumes by itself.
+// An overdue update runs as soon as connectivity returns (e.g. after resume from sleep).
 // Resumes after successful manual update
 func (ac *AppController) startAutoUpdateLoop() {
 	debuglog.InfoLog("Auto-update: Starting auto-update loop")
+	ac.newAutoUpdateRunner().run()
+	debuglog.InfoLog("Auto-update: Context cancelled, stopping loop")
+}
 
-	for {
-		// Check if context is cancelled
-		select {
-		case <-ac.ctx.Done():
-			debuglog.InfoLog("Auto-update: Context cancelled, stopping loop")
-			return
-		default:
-		}
+// newAutoUpdateRunner wires the auto-update loop to the controller
+func (ac *AppController) newAutoUpdateRunner() *autoUpdateRunner {
+	return &autoUpdateRunner{
+		ctx:         ac.ctx,
+		clock:       realClock{},
+		state:       ac.StateService,
+		backoff:     autoUpdateBackoff,
+		maxAttempts: autoUpdateMaxAttempts,
+		coolDown:    autoUpdateCoolDown,
+		tick:        autoUpdateWaitTick,
+		random:      rand.Float64,
+		update:      ac.ConfigService.autoUpdateConfigFromSubscriptions,
+		isDue: func(now time.Time) bool {
+			needsUpdate, err := ac.shouldAutoUpdate(now)
+			if err != nil {
+				// Don't stop auto-update on check errors, just skip this check
+				debuglog.WarnLog("Auto-update: Failed to check if update needed: %v, skipping this check", err)
+				return false
+			}
+			return needsUpdate
+		},
+		nextCheck: ac.calculateAutoUpdateInterval,
+		inProgress: func() bool {
+			ac.ParserMutex.Lock()
+			defer ac.ParserMutex.Unlock()
+			return ac.ParserRunning
+		},
+		online: ac.subscriptionsReachable,
+		onPaused: func(failures int, until time.Time) {
+			message := fmt.Sprintf("Automatic configuration update failed %d times in a row and is paused until %s. It will resume by itself; use manual update to retry now.",
+				failures, until.Local().Format("15:04"))
+			if !ac.hasUIWithApp() {
+				return // Headless mode: no Fyne application to post the dialog to
+			}
+			fyne.Do(func() {
+				dialogs.ShowAutoHideInfo(ac.UIService.Application, ac.UIService.MainWindow, "Auto-update", message)
+			})
+		},
+	}
+}
 
-		// Check if auto-update is enabled
-		if !ac.StateService.IsAutoUpdateEnabled() {
-			// Auto-update is stopped, wait and check again
-			select {
-			case <-ac.ctx.Done():
+// run is the auto-update loop; returns when the context is cancelled
+func (r *autoUpdateRunner) run() {
+	for r.ctx.Err() == nil {
+		// Auto-update is stopped (e.g. no config), wait and check again
+		if !r.state.IsAutoUpdateEnabled() {
+			if !r.sleep(r.tick) {
 				return
-			case <-time.After(1 * time.Minute):
-				continue
 			}
+			continue
 		}
 
-		// Calculate check interval from config
-		checkInterval, err := ac.calculateAutoUpdateInterval()
-		if err != nil {
-			debuglog.WarnLog("Auto-update: Failed to calculate interval: %v, using default", err)
-			checkInterval = autoUpdateMinInterval
+		// Paused after repeated failures: wait for the end of the cool-down (or for connectivity to return)
+		now := r.clock.Now()
+		if until := r.state.GetAutoUpdatePausedUntil(); !until.IsZero() {
+			if now.Before(until) {
+				debuglog.DebugLog("Auto-update: Paused until %s", until.Format(time.RFC3339))
+				if !r.waitForNextCheck(until.Sub(now)) {
+					return
+				}
+				continue
+			}
+			r.state.ResumeAutoUpdate()
+			debuglog.InfoLog("Auto-update: Cool-down is over, resuming")
 		}
 
-		debuglog.DebugLog("Auto-update: Calculated interval: %v (min: %v)", checkInterval, autoUpdateMinInterval)
-
-		// Check if update is needed immediately (before waiting)
-		// Use the same calculated interval to avoid duplicate function call
-		requiredInterval := checkInterval
-
-		needsUpdate, err := ac.shouldAutoUpdate(requiredInterval)
-		if err != nil {
-			debuglog.WarnLog("Auto-update: Failed to check if update needed: %v, skipping this check", err)
-			// Don't stop auto-update on check errors, just skip this check and wait
-		} else if needsUpdate {
-			// Update is needed - check if already in progress
-			ac.ParserMutex.Lock()
-			updateInProgress := ac.ParserRunning
-			ac.ParserMutex.Unlock()
-
-			if !updateInProgress {
+		if r.isDue(now) {
+			if r.inProgress() {
+				debuglog.DebugLog("Auto-update: Update already in progress, skipping")
+			} else {
 				debuglog.InfoLog("Auto-update: Update needed, attempting update...")
-				success := ac.attemptAutoUpdateWithRetries(autoUpdateRetryInterval, autoUpdateMaxRetries)
-				if success {
-					// Success - error counter already reset in attemptAutoUpdateWithRetries
-					ac.StateService.ResumeAutoUpdate()
-					debuglog.InfoLog("Auto-update: Resumed after successful update")
+				if r.attemptWithRetries() {
 					debuglog.InfoLog("Auto-update: Completed successfully, error counter reset")
-				} else {
-					// Failed after all retries - check if we reached max consecutive failures
-					failedAttempts := ac.StateService.GetAutoUpdateFailedAttempts()
-					if failedAttempts >= autoUpdateMaxRetries {
-						ac.StateService.SetAutoUpdateEnabled(false)
-						debuglog.WarnLog("Auto-update: Stopped after %d consecutive failed attempts", failedAttempts)
-						fyne.Do(func() {
-							if ac.hasUIWithApp() {
-								dialogs.ShowAutoHideInfo(ac.UIService.Application, ac.UIService.MainWindow, "Auto-update", "Automatic configuration update stopped after 10 failed attempts. Use manual update.")
-							}
-						})
+				} else if failedAttempts := r.state.GetAutoUpdateFailedAttempts(); failedAttempts >= r.maxAttempts {
+					until := r.clock.Now().Add(r.coolDown)
+					r.state.PauseAutoUpdate(until)
+					debuglog.WarnLog("Auto-update: Paused for %v after %d consecutive failed attempts", r.coolDown, failedAttempts)
+					if r.onPaused != nil {
+						r.onPaused(failedAttempts, until)
 					}
+					continue
 				}
-			} else {
-				debuglog.DebugLog("Auto-update: Update already in progress, skipping")
 			}
-		} else {
-			debuglog.DebugLog("Auto-update: Update not needed yet, will check again in %v", checkInterval)
 		}
 
-		// Wait for check interval before next check
-		select {
-		case <-ac.ctx.Done():
+		// Calculate check interval from config (after a possible update)
+		checkInterval := r.nextCheck(r.clock.Now())
+		debuglog.DebugLog("Auto-update: Next check in %v (min: %v, max: %v)", checkInterval, autoUpdateMinInterval, autoUpdateMaxInterval)
+		if !r.waitForNextCheck(checkInterval) {
 			return
-		case <-time.After(checkInterval):
-			// Time for next check
 		}
 	}
 }
 
-// calculateAutoUpdateInterval calculates the check interval: max(10 minutes, parser.reload)
-// Returns the interval to use for checking if update is needed
-func (ac *AppController) calculateAutoUpdateInterval() (time.Duration, error) {
-	// Read ParserConfig from file
-	config, err := parser.ExtractParserConfig(ac.FileService.ConfigPath)
-	if err != nil {
-		// If config doesn't exist or can't be read, use default
-		defaultDuration, _ := time.ParseDuration(autoUpdateDefaultReload)
-		return maxDuration(autoUpdateMinInterval, defaultDuration), nil
+// attemptWithRetries attempts to update configuration, retrying failures with backoff
+// Returns true if update succeeded, false if all attempts failed or the update was not approved
+func (r *autoUpdateRunner) attemptWithRetries() bool {
+	for attempt := 1; attempt <= r.maxAttempts; attempt++ {
+		debuglog.InfoLog("Auto-update: Attempting update (attempt %d/%d)", attempt, r.maxAttempts)
+
+		err := r.update()
+		if err == nil {
+			r.state.ResumeAutoUpdate()
+			return true
+		}
+		if errors.Is(err, config.ErrUpdateCancelled) {
+			// User rejected the update - not a failure, don't retry until the next scheduled check
+			debuglog.InfoLog("Auto-update: Update was not approved, skipping until next check")
+			return false
+		}
+
+		r.state.IncrementAutoUpdateFailedAttempts()
+		debuglog.WarnLog("Auto-update: Failed (attempt %d/%d, total consecutive failures: %d): %v",
+			attempt, r.maxAttempts, r.state.GetAutoUpdateFailedAttempts(), err)
+
+		if attempt < r.maxAttempts {
+			delay := r.backoff.Delay(attempt, r.random)
+			debuglog.DebugLog("Auto-update: Retrying in %v...", delay)
+			if !r.sleep(delay) {
+				return false
+			}
+		}
 	}
+	return false
+}
 
-	// Get reload value from config
-	reloadStr := config.ParserConfig.Parser.Reload
-	if reloadStr == "" {
-		// Use default if not specified
-		defaultDuration, _ := time.ParseDuration(autoUpdateDefaultReload)
-		return maxDuration(autoUpdateMinInterval, defaultDuration), nil
+// sleep waits for d; returns false if the context was cancelled
+func (r *autoUpdateRunner) sleep(d time.Duration) bool {
+	select {
+	case <-r.ctx.Done():
+		return false
+	case <-r.clock.After(d):
+		return true
 	}
+}
 
-	// Parse reload string to duration
-	reloadDuration, err := time.ParseDuration(reloadStr)
-	if err != nil {
-		debuglog.WarnLog("Auto-update: Failed to parse reload duration '%s': %v, using default", reloadStr, err)
-		defaultDuration, _ := time.ParseDuration(autoUpdateDefaultReload)
-		return maxDuration(autoUpdateMinInterval, defaultDuration), nil
+// waitForNextCheck waits for interval measured by wall clock, in short ticks: a timer does not run
+// while the computer sleeps, so after resume the overdue check happens within a tick.
+// While an update is pending (paused or overdue) connectivity is probed every tick and the wait
+// ends early when the network comes back. Returns false if the context was cancelled.
+func (r *autoUpdateRunner) waitForNextCheck(interval time.Duration) bool {
+	now := r.clock.Now()
+	deadline := now.Add(interval)
+	pending := func(now time.Time) bool {
+		return !r.state.GetAutoUpdatePausedUntil().IsZero() || r.isDue(now)
 	}
+	wasOnline := true
+	if pending(now) {
+		wasOnline = r.online()
+	}
+
+	last := now
+	for {
+		wait := deadline.Sub(now)
+		if wait <= 0 {
+			return true
+		}
+		if wait > r.tick {
+			wait = r.tick
+		}
+		if !r.sleep(wait) {
+			return false
+		}
+
+		now = r.clock.Now()
+		if gap := now.Sub(last); gap > 2*r.tick {
+			debuglog.InfoLog("Auto-update: Clock jumped by %v (resume from sleep?)", gap.Round(time.Second))
+		}
+		last = now
+		if !now.Before(deadline) {
+			return true
+		}
 
-	// Return max(10 minutes, reload)
-	return maxDuration(autoUpdateMinInterval, reloadDuration), nil
+		if !pending(now) {
+			wasOnline = true
+			continue
+		}
+		online := r.online()
+		if online && !wasOnline {
+			debuglog.InfoLog("Auto-update: Connectivity restored, running overdue update")
+			if !r.state.GetAutoUpdatePausedUntil().IsZero() {
+				r.state.ResumeAutoUpdate()
+			}
+			return true
+		}
+		wasOnline = online
+	}
 }
 
-// maxDuration returns the maximum of two durations
-func maxDuration(a, b time.Duration) time.Duration {
-	if a > b {
-		return a
+// subscriptionsReachable checks that the host of the first subscription accepts TCP connections.
+// Returns true if there are no subscriptions (nothing to wait for).
+func (ac *AppController) subscriptionsReachable() bool {
+	parserConfig, err := parser.ExtractParserConfig(ac.FileService.ConfigPath())
+	if err != nil {
+		return true
+	}
+	for _, proxySource := range parserConfig.ParserConfig.Proxies {
+		if !proxySource.IsEnabled() || !subscription.IsSubscriptionURL(proxySource.Source) {
+			continue
+		}
+		u, err := url.Parse(proxySource.Source)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		port := u.Port()
+		if port == "" {
+			port = "443"
+			if u.Scheme == "http" {
+				port = "80"
+			}
+		}
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(u.Hostname(), port), NetworkDialTimeout)
+		if err != nil {
+			debuglog.DebugLog("Auto-update: %s is unreachable: %v", u.Hostname(), err)
+			return false
+		}
+		conn.Close()
+		return true
 	}
-	return b
+	return true
 }
 
-// shouldAutoUpdate checks if configuration update is needed
-// Returns true if elapsed time since last_updated >= required interval
-func (ac *AppController) shouldAutoUpdate(requiredInterval time.Duration) (bool, error) {
-	// Read ParserConfig from file
-	config, err := parser.ExtractParserConfig(ac.FileService.ConfigPath)
+// calculateAutoUpdateInterval calculates how long to wait before the next check: until the next
+// subscription is due for reload (or until quiet hours end), clamped to [10 minutes, 1 hour]
+func (ac *AppController) calculateAutoUpdateInterval(now time.Time) time.Duration {
+	parserConfig, err := parser.ExtractParserConfig(ac.FileService.ConfigPath())
 	if err != nil {
-		// If config doesn't exist, update is needed
-		return true, nil
+		return autoUpdateMinInterval
 	}
 
-	// Check last_updated
-	lastUpdatedStr := config.ParserConfig.Parser.LastUpdated
-	if lastUpdatedStr == "" {
-		// No last_updated - update is needed
-		return true, nil
+	due, next := sourceReloadPlan(parserConfig, ac.ConfigService.subscriptionCache().FetchedAt, now)
+	wait := autoUpdateMaxInterval
+	if len(due) > 0 {
+		wait = 0
+	} else if !next.IsZero() {
+		wait = next.Sub(now)
+	}
+	if quietHours := ac.quietHours(parserConfig); quietHours.Contains(now.Add(wait)) {
+		wait = quietHours.End(now.Add(wait)).Sub(now)
 	}
+	return clampDuration(wait, autoUpdateMinInterval, autoUpdateMaxInterval)
+}
 
-	// Parse last_updated timestamp
-	lastUpdated, err := time.Parse(time.RFC3339, lastUpdatedStr)
+// clampDuration limits d to [lo, hi]
+func clampDuration(d, lo, hi time.Duration) time.Duration {
+	if d < lo {
+		return lo
+	}
+	if d > hi {
+		return hi
+	}
+	return d
+}
+
+// shouldAutoUpdate checks if configuration update is needed:
+// at least one subscription is due for reload and now is outside parser.quiet_hours
+func (ac *AppController) shouldAutoUpdate(now time.Time) (bool, error) {
+	parserConfig, err := parser.ExtractParserConfig(ac.FileService.ConfigPath())
 	if err != nil {
-		debuglog.WarnLog("Auto-update: Failed to parse last_updated '%s': %v", lastUpdatedStr, err)
-		// If parsing fails, assume update is needed
+		// If config doesn't exist, update is needed
 		return true, nil
 	}
 
-	// Calculate elapsed time
-	elapsed := time.Since(lastUpdated.UTC())
-	debuglog.DebugLog("Auto-update: Checking if update needed (last_updated: %s, elapsed: %v, required: %v)", lastUpdatedStr, elapsed, requiredInterval)
+	if ac.quietHours(parserConfig).Contains(now) {
+		debuglog.DebugLog("Auto-update: Quiet hours (%s), skipping", parserConfig.ParserConfig.Parser.QuietHours)
+		return false, nil
+	}
 
-	// Check if elapsed >= required interval
-	return elapsed >= requiredInterval, nil
+	due, next := sourceReloadPlan(parserConfig, ac.ConfigService.subscriptionCache().FetchedAt, now)
+	debuglog.DebugLog("Auto-update: %d subscription(s) due for reload, next due at %s", len(due), next.Format(time.RFC3339))
+	return len(due) > 0, nil
 }
 
-// attemptAutoUpdateWithRetries attempts to update configuration with retries
-// Returns true if update succeeded, false if all retries failed
-func (ac *AppController) attemptAutoUpdateWithRetries(retryInterval time.Duration, maxRetries int) bool {
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		debuglog.InfoLog("Auto-update: Attempting update (attempt %d/%d)", attempt, maxRetries)
+// quietHours parses parser.quiet_hours (an invalid value disables quiet hours)
+func (ac *AppController) quietHours(parserConfig *config.ParserConfig) config.QuietHours {
+	quietHours, err := config.ParseQuietHours(parserConfig.ParserConfig.Parser.QuietHours)
+	if err != nil {
+		debuglog.WarnLog("Auto-update: %v, quiet hours ignored", err)
+	}
+	return quietHours
+}
 
-		// Call UpdateConfigFromSubscriptions synchronously
-		err := ac.ConfigService.UpdateConfigFromSubscriptions()
-		if err == nil {
-			// Success - reset error counter
-			ac.StateService.ResetAutoUpdateFailedAttempts()
-			return true
+// sourceReloadPlan returns subscription URLs that are due for reload at now and the time when the next
+// subscription becomes due. fetchedAt returns the time of the last successful download of a URL;
+// a subscription that was never downloaded is due immediately. Disabled sources and sources with connections only are never due.
+func sourceReloadPlan(parserConfig *config.ParserConfig, fetchedAt func(url string) (time.Time, bool), now time.Time) (map[string]bool, time.Time) {
+	due := make(map[string]bool)
+	var next time.Time
+	globalReload := parserConfig.ParserConfig.Parser.Reload
+	for _, proxySource := range parserConfig.ParserConfig.Proxies {
+		if !proxySource.IsEnabled() || !subscription.IsSubscriptionURL(proxySource.Source) {
+			continue
 		}
-
-		// Error occurred - increment error counter
-		ac.StateService.IncrementAutoUpdateFailedAttempts()
-		currentAttempts := ac.StateService.GetAutoUpdateFailedAttempts()
-
-		debuglog.WarnLog("Auto-update: Failed (attempt %d/%d, total consecutive failures: %d): %v", attempt, maxRetries, currentAttempts, err)
-
-		if attempt < maxRetries {
-			// Wait before retry (except for last attempt)
-			debuglog.DebugLog("Auto-update: Retrying in %v...", retryInterval)
-			select {
-			case <-ac.ctx.Done():
-				return false
-			case <-time.After(retryInterval):
-				// Continue to next attempt
-			}
+		schedule, err := proxySource.ReloadSchedule(globalReload)
+		if err != nil {
+			debuglog.WarnLog("Auto-update: %v, using default %s", err, config.DefaultReload)
+			schedule, _ = config.ParseReloadSchedule(config.DefaultReload)
+		}
+		lastFetched, _ := fetchedAt(proxySource.Source)
+		sourceNext := schedule.Next(lastFetched)
+		if !sourceNext.After(now) {
+			due[proxySource.Source] = true
+		}
+		if next.IsZero() || sourceNext.Before(next) {
+			next = sourceNext
 		}
 	}
-
-	// All retries failed
-	return false
+	return due, next
 }
 
 // resumeAutoUpdate resumes automatic updates after successful manual update
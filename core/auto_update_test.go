@@ -0,0 +1,244 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"singbox-launcher/core/config"
+	"singbox-launcher/core/services"
+)
+
+func TestSourceReloadPlan(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	parserConfig := &config.ParserConfig{}
+	parserConfig.ParserConfig.Parser.Reload = "24h"
+	parserConfig.ParserConfig.Proxies = []config.ProxySource{
+		{Source: "https://fast.example.com/sub", Reload: "1h"},
+		{Source: "https://stable.example.com/sub"},
+		{Source: "https://new.example.com/sub"},
+		{Connections: []string{"trojan://pass@one.example.com:443#one"}},
+	}
+	fetched := map[string]time.Time{
+		"https://fast.example.com/sub":   now.Add(-90 * time.Minute),
+		"https://stable.example.com/sub": now.Add(-2 * time.Hour),
+	}
+	fetchedAt := func(url string) (time.Time, bool) {
+		last, ok := fetched[url]
+		return last, ok
+	}
+
+	due, _ := sourceReloadPlan(parserConfig, fetchedAt, now)
+	if len(due) != 2 || !due["https://fast.example.com/sub"] || !due["https://new.example.com/sub"] {
+		t.Errorf("expected fast and never downloaded sources to be due, got %v", due)
+	}
+
+	// After refreshing the due sources the next one is the hourly source
+	fetched["https://fast.example.com/sub"] = now
+	fetched["https://new.example.com/sub"] = now
+	due, next := sourceReloadPlan(parserConfig, fetchedAt, now)
+	if len(due) != 0 {
+		t.Errorf("expected nothing due, got %v", due)
+	}
+	if want := now.Add(time.Hour); !next.Equal(want) {
+		t.Errorf("next = %s, want %s", next, want)
+	}
+}
+
+func TestClampDuration(t *testing.T) {
+	if got := clampDuration(time.Minute, autoUpdateMinInterval, autoUpdateMaxInterval); got != autoUpdateMinInterval {
+		t.Errorf("got %v", got)
+	}
+	if got := clampDuration(5*time.Hour, autoUpdateMinInterval, autoUpdateMaxInterval); got != autoUpdateMaxInterval {
+		t.Errorf("got %v", got)
+	}
+	if got := clampDuration(30*time.Minute, autoUpdateMinInterval, autoUpdateMaxInterval); got != 30*time.Minute {
+		t.Errorf("got %v", got)
+	}
+}
+
+// fakeClock is a clock that advances instantly when waited on
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func newTestRunner(ctx context.Context, clock *fakeClock) *autoUpdateRunner {
+	return &autoUpdateRunner{
+		ctx:         ctx,
+		clock:       clock,
+		state:       services.NewStateService(),
+		backoff:     backoffPolicy{Initial: 10 * time.Second, Max: time.Minute, Multiplier: 2},
+		maxAttempts: 3,
+		coolDown:    time.Hour,
+		tick:        time.Minute,
+		update:      func() error { return nil },
+		isDue:       func(time.Time) bool { return false },
+		nextCheck:   func(time.Time) time.Duration { return 10 * time.Minute },
+		inProgress:  func() bool { return false },
+		online:      func() bool { return true },
+	}
+}
+
+func TestBackoffPolicy_Delay(t *testing.T) {
+	policy := backoffPolicy{Initial: 10 * time.Second, Max: 5 * time.Minute, Multiplier: 2, Jitter: 0.2}
+	noJitter := func() float64 { return 0.5 }
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, 80 * time.Second, 160 * time.Second, 5 * time.Minute, 5 * time.Minute}
+	for i, expected := range want {
+		if got := policy.Delay(i+1, noJitter); got != expected {
+			t.Errorf("attempt %d: got %v, want %v", i+1, got, expected)
+		}
+	}
+
+	low := policy.Delay(1, func() float64 { return 0 })
+	high := policy.Delay(1, func() float64 { return 0.999999 })
+	if low != 8*time.Second || high < 11900*time.Millisecond || high > 12*time.Second {
+		t.Errorf("jitter bounds: got %v..%v, want 8s..12s", low, high)
+	}
+}
+
+func TestAutoUpdateRunner_RetriesWithBackoff(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)}
+	runner := newTestRunner(context.Background(), clock)
+	calls := 0
+	runner.update = func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("network is unreachable")
+		}
+		return nil
+	}
+
+	start := clock.now
+	if !runner.attemptWithRetries() {
+		t.Fatal("expected the third attempt to succeed")
+	}
+	if elapsed := clock.now.Sub(start); elapsed != 30*time.Second {
+		t.Errorf("retries took %v, want 10s + 20s", elapsed)
+	}
+	if failures := runner.state.GetAutoUpdateFailedAttempts(); failures != 0 {
+		t.Errorf("failed attempts not reset: %d", failures)
+	}
+}
+
+func TestAutoUpdateRunner_CancelledIsNotFailure(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)}
+	runner := newTestRunner(context.Background(), clock)
+	calls := 0
+	runner.update = func() error {
+		calls++
+		return config.ErrUpdateCancelled
+	}
+	if runner.attemptWithRetries() || calls != 1 || runner.state.GetAutoUpdateFailedAttempts() != 0 {
+		t.Errorf("rejected update must not be retried or counted, calls = %d", calls)
+	}
+}
+
+func TestAutoUpdateRunner_PausesAndResumesAfterCoolDown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := &fakeClock{now: time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)}
+	runner := newTestRunner(ctx, clock)
+	runner.isDue = func(time.Time) bool { return true }
+
+	var attempts []time.Time
+	var pausedUntil time.Time
+	runner.onPaused = func(failures int, until time.Time) {
+		if failures != 3 {
+			t.Errorf("paused after %d failures, want 3", failures)
+		}
+		pausedUntil = until
+	}
+	runner.update = func() error {
+		attempts = append(attempts, clock.now)
+		if len(attempts) == 4 {
+			cancel()
+			return nil
+		}
+		return errors.New("server error")
+	}
+	runner.run()
+
+	if len(attempts) != 4 {
+		t.Fatalf("expected 3 failed attempts and one after the cool-down, got %d", len(attempts))
+	}
+	if pausedUntil.IsZero() {
+		t.Fatal("auto-update was not paused")
+	}
+	if attempts[3].Before(pausedUntil) {
+		t.Errorf("attempt at %s is before the end of the cool-down %s", attempts[3], pausedUntil)
+	}
+	if !runner.state.IsAutoUpdateEnabled() || !runner.state.GetAutoUpdatePausedUntil().IsZero() {
+		t.Error("auto-update must be enabled and not paused after the cool-down")
+	}
+}
+
+func TestAutoUpdateRunner_ConnectivityRestoredEndsWait(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)}
+	runner := newTestRunner(context.Background(), clock)
+	runner.state.PauseAutoUpdate(clock.now.Add(time.Hour))
+	probes := 0
+	runner.online = func() bool {
+		probes++
+		return probes > 3 // Offline for the first probes, then back online
+	}
+
+	start := clock.now
+	if !runner.waitForNextCheck(time.Hour) {
+		t.Fatal("wait was cancelled")
+	}
+	if elapsed := clock.now.Sub(start); elapsed != 3*time.Minute {
+		t.Errorf("wait ended after %v, want 3m (when connectivity returned)", elapsed)
+	}
+	if !runner.state.GetAutoUpdatePausedUntil().IsZero() {
+		t.Error("pause must be lifted when connectivity returns")
+	}
+}
+
+func TestAutoUpdateRunner_WaitNoticesWallClockJump(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)}
+	runner := newTestRunner(context.Background(), clock)
+	probes := 0
+	runner.online = func() bool {
+		probes++
+		return true
+	}
+
+	// The computer sleeps through the deadline: the next tick sees it has passed
+	start := clock.now
+	runner.clock = &sleepingClock{fakeClock: clock, sleepAt: 1, sleepFor: 2 * time.Hour}
+	if !runner.waitForNextCheck(time.Hour) {
+		t.Fatal("wait was cancelled")
+	}
+	if elapsed := clock.now.Sub(start); elapsed > 2*time.Hour+time.Minute {
+		t.Errorf("overdue check was not noticed after wake-up, waited %v", elapsed)
+	}
+	if probes != 0 {
+		t.Errorf("connectivity must not be probed when nothing is pending, probes = %d", probes)
+	}
+}
+
+// sleepingClock simulates suspend: the sleepAt-th wait takes sleepFor longer than requested
+type sleepingClock struct {
+	*fakeClock
+	waits    int
+	sleepAt  int
+	sleepFor time.Duration
+}
+
+func (c *sleepingClock) After(d time.Duration) <-chan time.Time {
+	c.waits++
+	if c.waits == c.sleepAt {
+		d += c.sleepFor
+	}
+	return c.fakeClock.After(d)
+}
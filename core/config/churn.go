@@ -0,0 +1,252 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"singbox-launcher/internal/constants"
+)
+
+// NodeIdentity describes what a node points to: its endpoint and a hash of its credentials.
+// Credentials are never stored in plain text.
+type NodeIdentity struct {
+	Endpoint    string `json:"endpoint"`
+	Credentials string `json:"credentials,omitempty"`
+}
+
+// NodeChange describes a node that kept its tag but changed endpoint and/or credentials
+type NodeChange struct {
+	Tag         string   `json:"tag"`
+	Fields      []string `json:"fields"` // "endpoint", "credentials"
+	OldEndpoint string   `json:"old_endpoint,omitempty"`
+	NewEndpoint string   `json:"new_endpoint,omitempty"`
+}
+
+// NodeChurn is the difference between two snapshots of a source
+type NodeChurn struct {
+	Added   []string     `json:"added,omitempty"`
+	Removed []string     `json:"removed,omitempty"`
+	Changed []NodeChange `json:"changed,omitempty"`
+}
+
+// IsEmpty reports whether the churn contains no changes
+func (c *NodeChurn) IsEmpty() bool {
+	return c == nil || (len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0)
+}
+
+// ChurnEvent is one entry of the node churn timeline
+type ChurnEvent struct {
+	Time    time.Time `json:"time"`
+	Source  int       `json:"source"` // 1-based source index at the time of the update
+	URLHash string    `json:"url_hash,omitempty"`
+	Name    string    `json:"name,omitempty"` // Source name at the time of the update (version 5)
+	NodeChurn
+}
+
+// NodeHistory keeps the last known node identities per source and the churn timeline.
+// It is saved as JSON next to config.json (see NodeHistoryPath).
+type NodeHistory struct {
+	Sources  map[string]map[string]NodeIdentity `json:"sources"` // source key -> node tag -> identity
+	Timeline []ChurnEvent                       `json:"timeline"`
+}
+
+// credentialKeys are outbound fields that are treated as node credentials
+var credentialKeys = []string{"password", "method", "user", "username", "private_key", "auth_str"}
+
+// NodeHistoryPath returns path of the node history file next to config.json
+func NodeHistoryPath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), constants.NodeHistoryFileName)
+}
+
+// nodeIdentityOf builds identity of a parsed node
+func nodeIdentityOf(node *ParsedNode) NodeIdentity {
+	identity := NodeIdentity{
+		Endpoint: node.Scheme + "://" + net.JoinHostPort(node.Server, strconv.Itoa(node.Port)),
+	}
+
+	h := sha256.New()
+	h.Write([]byte(node.UUID))
+	hasCredentials := node.UUID != ""
+	for _, key := range credentialKeys {
+		if value, ok := node.Outbound[key]; ok {
+			fmt.Fprintf(h, "\x00%s=%v", key, value)
+			hasCredentials = true
+		}
+	}
+	if hasCredentials {
+		identity.Credentials = hex.EncodeToString(h.Sum(nil))[:12]
+	}
+	return identity
+}
+
+// snapshotNodes builds tag -> identity map for nodes of one source
+func snapshotNodes(nodes []*ParsedNode) map[string]NodeIdentity {
+	snapshot := make(map[string]NodeIdentity, len(nodes))
+	for _, node := range nodes {
+		snapshot[node.Tag] = nodeIdentityOf(node)
+	}
+	return snapshot
+}
+
+// diffNodeSnapshots compares two snapshots of the same source. Results are sorted by tag.
+func diffNodeSnapshots(previous, current map[string]NodeIdentity) *NodeChurn {
+	churn := &NodeChurn{}
+	for tag, cur := range current {
+		prev, existed := previous[tag]
+		if !existed {
+			churn.Added = append(churn.Added, tag)
+			continue
+		}
+		var fields []string
+		if prev.Endpoint != cur.Endpoint {
+			fields = append(fields, "endpoint")
+		}
+		if prev.Credentials != cur.Credentials {
+			fields = append(fields, "credentials")
+		}
+		if len(fields) > 0 {
+			change := NodeChange{Tag: tag, Fields: fields}
+			if prev.Endpoint != cur.Endpoint {
+				change.OldEndpoint = prev.Endpoint
+				change.NewEndpoint = cur.Endpoint
+			}
+			churn.Changed = append(churn.Changed, change)
+		}
+	}
+	for tag := range previous {
+		if _, exists := current[tag]; !exists {
+			churn.Removed = append(churn.Removed, tag)
+		}
+	}
+	sort.Strings(churn.Added)
+	sort.Strings(churn.Removed)
+	sort.Slice(churn.Changed, func(i, j int) bool { return churn.Changed[i].Tag < churn.Changed[j].Tag })
+	return churn
+}
+
+// sourceHistoryKey returns key of a source in NodeHistory (URL hash or index for connection-only sources)
+func sourceHistoryKey(sourceReport *SourceReport) string {
+	if sourceReport.URLHash != "" {
+		return sourceReport.URLHash
+	}
+	return fmt.Sprintf("connections-%d", sourceReport.Index)
+}
+
+// TrackNodeChurn compares node snapshots collected in report with the stored history,
+// writes the churn into report sources and appends non-empty changes to the timeline.
+// Sources that failed to load keep their previous snapshot; a source seen for the first time
+// only records a baseline. Sources no longer present in ParserConfig are dropped from history.
+func TrackNodeChurn(history *NodeHistory, report *ParseReport, now time.Time) {
+	if history.Sources == nil {
+		history.Sources = make(map[string]map[string]NodeIdentity)
+	}
+
+	present := make(map[string]bool, len(report.Sources))
+	for _, sourceReport := range report.Sources {
+		key := sourceHistoryKey(sourceReport)
+		present[key] = true
+		if sourceReport.nodes == nil {
+			continue
+		}
+
+		previous, known := history.Sources[key]
+		history.Sources[key] = sourceReport.nodes
+		if !known {
+			continue
+		}
+
+		churn := diffNodeSnapshots(previous, sourceReport.nodes)
+		if churn.IsEmpty() {
+			continue
+		}
+		sourceReport.Churn = churn
+		history.Timeline = append(history.Timeline, ChurnEvent{
+			Time:      now,
+			Source:    sourceReport.Index,
+			URLHash:   sourceReport.URLHash,
+			Name:      sourceReport.Name,
+			NodeChurn: *churn,
+		})
+	}
+
+	for key := range history.Sources {
+		if !present[key] {
+			delete(history.Sources, key)
+		}
+	}
+	if extra := len(history.Timeline) - constants.MaxNodeHistoryEvents; extra > 0 {
+		history.Timeline = history.Timeline[extra:]
+	}
+}
+
+// updateNodeHistory loads node history next to config.json, tracks churn for report and saves it back
+func updateNodeHistory(configPath string, report *ParseReport) {
+	path := NodeHistoryPath(configPath)
+	history, err := LoadNodeHistory(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Parser: Warning: Failed to load node history, starting a new one: %v", err)
+		}
+		history = &NodeHistory{}
+	}
+
+	TrackNodeChurn(history, report, time.Now())
+
+	for _, sourceReport := range report.Sources {
+		if !sourceReport.Churn.IsEmpty() {
+			log.Printf("Parser: Source %d node churn: %d added, %d removed, %d changed",
+				sourceReport.Index, len(sourceReport.Churn.Added), len(sourceReport.Churn.Removed), len(sourceReport.Churn.Changed))
+		}
+	}
+
+	if err := SaveNodeHistory(path, history); err != nil {
+		log.Printf("Parser: Warning: Failed to save node history: %v", err)
+	}
+}
+
+// SaveNodeHistory writes history as indented JSON to path
+func SaveNodeHistory(path string, history *NodeHistory) error {
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal node history: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write node history: %w", err)
+	}
+	return nil
+}
+
+// LoadNodeHistory reads history from path
+func LoadNodeHistory(path string) (*NodeHistory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var history NodeHistory
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse node history: %w", err)
+	}
+	return &history, nil
+}
+
+// TotalChurn sums churn of all sources in the report
+func (r *ParseReport) TotalChurn() (added, removed, changed int) {
+	for _, sourceReport := range r.Sources {
+		if sourceReport.Churn == nil {
+			continue
+		}
+		added += len(sourceReport.Churn.Added)
+		removed += len(sourceReport.Churn.Removed)
+		changed += len(sourceReport.Churn.Changed)
+	}
+	return added, removed, changed
+}
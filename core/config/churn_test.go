@@ -0,0 +1,60 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackNodeChurn(t *testing.T) {
+	node := func(tag, server, uuid string) *ParsedNode {
+		return &ParsedNode{Tag: tag, Scheme: "vless", Server: server, Port: 443, UUID: uuid, Outbound: map[string]interface{}{}}
+	}
+	makeReport := func(nodes ...*ParsedNode) *ParseReport {
+		src := NewSourceReport(ProxySource{Source: "https://example.com/sub"}, 0)
+		src.nodes = snapshotNodes(nodes)
+		return &ParseReport{Sources: []*SourceReport{src}}
+	}
+
+	history := &NodeHistory{}
+
+	// First run only records a baseline
+	first := makeReport(node("a", "a.example.com", "u1"), node("b", "b.example.com", "u1"), node("c", "c.example.com", "u1"))
+	TrackNodeChurn(history, first, time.Now())
+	if first.Sources[0].Churn != nil || len(history.Timeline) != 0 {
+		t.Fatalf("first run must not report churn: %+v", first.Sources[0].Churn)
+	}
+
+	// Second run: a unchanged, b moved, c removed, d added
+	second := makeReport(node("a", "a.example.com", "u1"), node("b", "b2.example.com", "u1"), node("d", "d.example.com", "u1"))
+	TrackNodeChurn(history, second, time.Now())
+	churn := second.Sources[0].Churn
+	if churn == nil {
+		t.Fatalf("expected churn on second run")
+	}
+	if len(churn.Added) != 1 || churn.Added[0] != "d" {
+		t.Errorf("unexpected added: %v", churn.Added)
+	}
+	if len(churn.Removed) != 1 || churn.Removed[0] != "c" {
+		t.Errorf("unexpected removed: %v", churn.Removed)
+	}
+	if len(churn.Changed) != 1 || churn.Changed[0].Tag != "b" || churn.Changed[0].Fields[0] != "endpoint" {
+		t.Errorf("unexpected changed: %+v", churn.Changed)
+	}
+	if len(history.Timeline) != 1 {
+		t.Errorf("expected 1 timeline event, got %d", len(history.Timeline))
+	}
+
+	// Credential rotation is detected; failed source keeps previous snapshot
+	third := makeReport(node("a", "a.example.com", "u2"), node("b", "b2.example.com", "u1"), node("d", "d.example.com", "u1"))
+	TrackNodeChurn(history, third, time.Now())
+	if c := third.Sources[0].Churn; c == nil || len(c.Changed) != 1 || c.Changed[0].Fields[0] != "credentials" {
+		t.Errorf("expected credentials change, got %+v", c)
+	}
+
+	failed := makeReport()
+	failed.Sources[0].nodes = nil
+	TrackNodeChurn(history, failed, time.Now())
+	if failed.Sources[0].Churn != nil || len(history.Sources[sourceHistoryKey(failed.Sources[0])]) != 3 {
+		t.Errorf("failed source must keep previous snapshot")
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DefaultControlAPIListen is the address of the local control API when "listen" is not set
+const DefaultControlAPIListen = "127.0.0.1:9095"
+
+// controlAPIUnixPrefix marks a Unix socket path in ControlAPISettings.Listen
+const controlAPIUnixPrefix = "unix:"
+
+// ControlAPISettings configures the local control API of the launcher.
+// Stored in launcher_state.json ("control_api"); the API is disabled unless enabled is set.
+type ControlAPISettings struct {
+	Enabled bool   `json:"enabled"`
+	Listen  string `json:"listen,omitempty"` // "127.0.0.1:9095" or "unix:/path/to/launcher.sock" (empty = DefaultControlAPIListen)
+	Token   string `json:"token,omitempty"`  // Bearer token, generated on first start if empty
+}
+
+// Endpoint returns the network ("tcp" or "unix") and the address to listen on.
+// TCP addresses must be loopback: the API controls the VPN and must not be reachable from the network.
+func (s ControlAPISettings) Endpoint() (network, address string, err error) {
+	listen := strings.TrimSpace(s.Listen)
+	if listen == "" {
+		listen = DefaultControlAPIListen
+	}
+	if strings.HasPrefix(listen, controlAPIUnixPrefix) {
+		path := strings.TrimPrefix(listen, controlAPIUnixPrefix)
+		if path == "" {
+			return "", "", fmt.Errorf("control_api.listen: empty unix socket path")
+		}
+		return "unix", path, nil
+	}
+
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "", "", fmt.Errorf("control_api.listen: %w", err)
+	}
+	if port == "" {
+		return "", "", fmt.Errorf("control_api.listen: port is required")
+	}
+	if host != "localhost" {
+		ip := net.ParseIP(host)
+		if ip == nil || !ip.IsLoopback() {
+			return "", "", fmt.Errorf("control_api.listen: %q is not a loopback address", host)
+		}
+	}
+	return "tcp", listen, nil
+}
+
+// GenerateControlAPIToken returns a random token for the control API
+func GenerateControlAPIToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate control API token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestControlAPISettings_Endpoint(t *testing.T) {
+	tests := []struct {
+		listen  string
+		network string
+		address string
+		wantErr bool
+	}{
+		{"", "tcp", DefaultControlAPIListen, false},
+		{"127.0.0.1:8080", "tcp", "127.0.0.1:8080", false},
+		{"localhost:8080", "tcp", "localhost:8080", false},
+		{"[::1]:8080", "tcp", "[::1]:8080", false},
+		{"unix:/run/launcher.sock", "unix", "/run/launcher.sock", false},
+		{"0.0.0.0:8080", "", "", true},
+		{"192.168.1.10:8080", "", "", true},
+		{":8080", "", "", true},
+		{"127.0.0.1", "", "", true},
+		{"unix:", "", "", true},
+	}
+	for _, tt := range tests {
+		network, address, err := ControlAPISettings{Listen: tt.listen}.Endpoint()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Endpoint(%q) error = %v, wantErr %v", tt.listen, err, tt.wantErr)
+			continue
+		}
+		if network != tt.network || address != tt.address {
+			t.Errorf("Endpoint(%q) = %s %s, want %s %s", tt.listen, network, address, tt.network, tt.address)
+		}
+	}
+}
+
+func TestUpdateLauncherState_KeepsOtherSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "launcher_state.json")
+	state := &LauncherState{
+		Selections: map[string]SelectorChoice{"proxy-out": {Tag: "de-1"}},
+		ControlAPI: &ControlAPISettings{Enabled: true, Token: "secret"},
+	}
+	if err := SaveLauncherState(path, state); err != nil {
+		t.Fatal(err)
+	}
+
+	err := UpdateLauncherState(path, func(s *LauncherState) {
+		s.Selections = map[string]SelectorChoice{"proxy-out": {Tag: "nl-1"}}
+	})
+	if err != nil {
+		t.Fatalf("UpdateLauncherState: %v", err)
+	}
+
+	loaded, err := LoadLauncherState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Selections["proxy-out"].Tag != "nl-1" {
+		t.Errorf("selection not updated: %+v", loaded.Selections)
+	}
+	if loaded.ControlAPI == nil || loaded.ControlAPI.Token != "secret" || !loaded.ControlAPI.Enabled {
+		t.Errorf("control_api section lost: %+v", loaded.ControlAPI)
+	}
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"log"
+)
+
+// nodeDetour returns the detour tag currently set on the node ("" if none).
+func nodeDetour(node *ParsedNode) string {
+	if node.Outbound == nil {
+		return ""
+	}
+	detour, _ := node.Outbound["detour"].(string)
+	return detour
+}
+
+// setNodeDetour sets sing-box "detour" on the node outbound.
+func setNodeDetour(node *ParsedNode, detour string) {
+	if node.Outbound == nil {
+		node.Outbound = make(map[string]interface{})
+	}
+	node.Outbound["detour"] = detour
+}
+
+// applySourceDetour sets detour from ProxySource on every node of the source.
+func applySourceDetour(nodes []*ParsedNode, detour string) {
+	if detour == "" {
+		return
+	}
+	for _, node := range nodes {
+		setNodeDetour(node, detour)
+	}
+}
+
+// applyOutboundDetour sets detour from an outbound template on its filtered nodes.
+// Nodes that already have a detour (from their source or an earlier outbound) are left untouched.
+func applyOutboundDetour(nodes []*ParsedNode, outboundConfig OutboundConfig) {
+	if outboundConfig.Detour == "" {
+		return
+	}
+	for _, node := range nodes {
+		existing := nodeDetour(node)
+		if existing == "" {
+			setNodeDetour(node, outboundConfig.Detour)
+			continue
+		}
+		if existing != outboundConfig.Detour {
+			log.Printf("Parser: Warning: Node '%s' already has detour '%s', ignoring detour '%s' from outbound '%s'",
+				node.Tag, existing, outboundConfig.Detour, outboundConfig.Tag)
+		}
+	}
+}
+
+// declaredOutboundTags returns tags of all outbounds declared in ParserConfig (global and local,
+// including group_by templates and parents and outbounds of disabled sources), before expansion.
+func declaredOutboundTags(parserConfig *ParserConfig) map[string]bool {
+	declared := make(map[string]bool)
+	add := func(outbounds []OutboundConfig) {
+		for _, outboundConfig := range outbounds {
+			declared[outboundConfig.Tag] = true
+			if outboundConfig.GroupBy != nil && outboundConfig.GroupBy.Parent != nil && outboundConfig.GroupBy.Parent.Tag != "" {
+				declared[outboundConfig.GroupBy.Parent.Tag] = true
+			}
+		}
+	}
+	for _, proxySource := range parserConfig.ParserConfig.Proxies {
+		add(proxySource.Outbounds)
+	}
+	add(parserConfig.ParserConfig.Outbounds)
+	return declared
+}
+
+// dropUnresolvedDetours removes detours whose target is not in the generated outbound set:
+// a selector skipped as empty, a group_by template that produced no group or an outbound
+// of a disabled source. Nodes and valid selectors (after pass 2) resolve a detour. A tag the
+// parser neither declares nor generates is an outbound from the rest of config.json (like
+// constants in addOutbounds) and is kept.
+// Returns the tags of nodes whose detour was removed.
+func dropUnresolvedDetours(allNodes []*ParsedNode, outboundsInfo map[string]*outboundInfo, declared map[string]bool) []string {
+	nodeTags := make(map[string]bool, len(allNodes))
+	for _, node := range allNodes {
+		nodeTags[node.Tag] = true
+	}
+
+	removed := make([]string, 0)
+	for _, node := range allNodes {
+		detour := nodeDetour(node)
+		if detour == "" || nodeTags[detour] {
+			continue
+		}
+		info, generated := outboundsInfo[detour]
+		if generated && info.isValid {
+			continue
+		}
+		if !generated && !declared[detour] {
+			continue
+		}
+		log.Printf("Parser: Warning: Detour target '%s' of node '%s' is not generated (empty or disabled outbound). Detour removed.",
+			detour, node.Tag)
+		delete(node.Outbound, "detour")
+		removed = append(removed, node.Tag)
+	}
+	return removed
+}
+
+// breakDetourCycles detects cycles created by node detours and removes the offending detours.
+// The dependency graph contains edges selector -> member (filtered nodes and addOutbounds)
+// and node -> detour. A detour creates a cycle if the node is reachable from its detour target
+// (e.g. a node detoured through a selector that contains the node itself).
+// Returns the tags of nodes whose detour was removed.
+func breakDetourCycles(allNodes []*ParsedNode, outboundsInfo map[string]*outboundInfo) []string {
+	edges := make(map[string][]string, len(outboundsInfo)+len(allNodes))
+	for tag, info := range outboundsInfo {
+		for _, addTag := range info.config.AddOutbounds {
+			edges[tag] = append(edges[tag], addTag)
+		}
+		for _, node := range info.filteredNodes {
+			edges[tag] = append(edges[tag], node.Tag)
+		}
+	}
+
+	nodesByTag := make(map[string]*ParsedNode, len(allNodes))
+	for _, node := range allNodes {
+		nodesByTag[node.Tag] = node
+	}
+
+	// Detour edges are read from nodes on every lookup, so removed detours are not followed afterwards
+	successors := func(tag string) []string {
+		result := edges[tag]
+		if node, ok := nodesByTag[tag]; ok {
+			if detour := nodeDetour(node); detour != "" {
+				result = append(append([]string{}, result...), detour)
+			}
+		}
+		return result
+	}
+
+	reachable := func(from, target string) bool {
+		visited := make(map[string]bool)
+		stack := []string{from}
+		for len(stack) > 0 {
+			current := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if current == target {
+				return true
+			}
+			if visited[current] {
+				continue
+			}
+			visited[current] = true
+			stack = append(stack, successors(current)...)
+		}
+		return false
+	}
+
+	removed := make([]string, 0)
+	for _, node := range allNodes {
+		detour := nodeDetour(node)
+		if detour == "" {
+			continue
+		}
+		if reachable(detour, node.Tag) {
+			log.Printf("Parser: Warning: Detour cycle detected: node '%s' -> '%s' leads back to the node. Detour removed.",
+				node.Tag, detour)
+			delete(node.Outbound, "detour")
+			removed = append(removed, node.Tag)
+		}
+	}
+	return removed
+}
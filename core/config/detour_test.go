@@ -0,0 +1,118 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetour_SourceAndOutbound(t *testing.T) {
+	nodes := map[int][]*ParsedNode{
+		0: {{Tag: "entry", Scheme: "vless", Server: "a.example.com", Port: 443, Outbound: map[string]interface{}{}}},
+		1: {
+			{Tag: "🇩🇪 exit", Scheme: "vless", Server: "b.example.com", Port: 443, Outbound: map[string]interface{}{}},
+			{Tag: "🇳🇱 exit", Scheme: "vless", Server: "c.example.com", Port: 443, Outbound: map[string]interface{}{}},
+		},
+	}
+	pc := &ParserConfig{}
+	pc.ParserConfig.Proxies = []ProxySource{
+		{Source: "a"},
+		{Source: "b", Detour: "entry"},
+	}
+	pc.ParserConfig.Outbounds = []OutboundConfig{
+		{Tag: "chain-out", Type: "selector", Filters: map[string]interface{}{"tag": "/exit/i"}, Detour: "other"},
+	}
+
+	loadNodes := func(_ ProxySource, _ map[string]int, _ func(float64, string), idx, _ int, _ *SourceReport) ([]*ParsedNode, error) {
+		return nodes[idx], nil
+	}
+	result, err := GenerateOutboundsFromParserConfig(pc, map[string]int{}, nil, loadNodes, nil)
+	if err != nil {
+		t.Fatalf("GenerateOutboundsFromParserConfig failed: %v", err)
+	}
+
+	joined := strings.Join(result.OutboundsJSON, "\n")
+	if strings.Count(joined, `"detour":"entry"`) != 2 {
+		t.Errorf("expected source detour on both exit nodes, got:\n%s", joined)
+	}
+	if strings.Contains(joined, `"detour":"other"`) {
+		t.Errorf("source detour should take precedence over outbound detour:\n%s", joined)
+	}
+}
+
+func TestDetour_UnresolvedTargetsDropped(t *testing.T) {
+	nodes := map[int][]*ParsedNode{
+		0: {{Tag: "exit-1", Scheme: "vless", Server: "a.example.com", Port: 443, Outbound: map[string]interface{}{}}},
+		1: {{Tag: "exit-2", Scheme: "vless", Server: "b.example.com", Port: 443, Outbound: map[string]interface{}{}}},
+		2: {{Tag: "exit-3", Scheme: "vless", Server: "c.example.com", Port: 443, Outbound: map[string]interface{}{}}},
+	}
+	disabled := false
+	pc := &ParserConfig{}
+	pc.ParserConfig.Proxies = []ProxySource{
+		{Source: "a", Detour: "entry-auto"}, // Selector left empty by its filters
+		{Source: "b", Detour: "off-select"}, // Local outbound of a disabled source
+		{Source: "c", Detour: "static-out"}, // Outbound outside the parser block
+		{Source: "d", Enabled: &disabled, Outbounds: []OutboundConfig{{Tag: "off-select", Type: "selector"}}},
+	}
+	pc.ParserConfig.Outbounds = []OutboundConfig{
+		{Tag: "entry-auto", Type: "urltest", Filters: map[string]interface{}{"tag": "/^entry/i"}},
+	}
+
+	loadNodes := func(_ ProxySource, _ map[string]int, _ func(float64, string), idx, _ int, _ *SourceReport) ([]*ParsedNode, error) {
+		return nodes[idx], nil
+	}
+	result, err := GenerateOutboundsFromParserConfig(pc, map[string]int{}, nil, loadNodes, nil)
+	if err != nil {
+		t.Fatalf("GenerateOutboundsFromParserConfig failed: %v", err)
+	}
+
+	joined := strings.Join(result.OutboundsJSON, "\n")
+	if strings.Contains(joined, `"detour":"entry-auto"`) || strings.Contains(joined, `"detour":"off-select"`) {
+		t.Errorf("detours to outbounds that are not generated must be dropped:\n%s", joined)
+	}
+	if !strings.Contains(joined, `"detour":"static-out"`) {
+		t.Errorf("detour to an outbound outside ParserConfig must be kept:\n%s", joined)
+	}
+}
+
+func TestBreakDetourCycles(t *testing.T) {
+	t.Run("detour through selector containing the node", func(t *testing.T) {
+		node := &ParsedNode{Tag: "n1", Outbound: map[string]interface{}{"detour": "group"}}
+		other := &ParsedNode{Tag: "n2", Outbound: map[string]interface{}{}}
+		outboundsInfo := map[string]*outboundInfo{
+			"group": {config: OutboundConfig{Tag: "group"}, filteredNodes: []*ParsedNode{node, other}},
+		}
+
+		removed := breakDetourCycles([]*ParsedNode{node, other}, outboundsInfo)
+		if len(removed) != 1 || removed[0] != "n1" {
+			t.Errorf("expected detour of n1 removed, got %v", removed)
+		}
+		if nodeDetour(node) != "" {
+			t.Errorf("detour should be removed from n1")
+		}
+	})
+
+	t.Run("mutual node detours", func(t *testing.T) {
+		a := &ParsedNode{Tag: "a", Outbound: map[string]interface{}{"detour": "b"}}
+		b := &ParsedNode{Tag: "b", Outbound: map[string]interface{}{"detour": "a"}}
+
+		removed := breakDetourCycles([]*ParsedNode{a, b}, map[string]*outboundInfo{})
+		if len(removed) != 1 {
+			t.Errorf("expected exactly one detour removed to break the cycle, got %v", removed)
+		}
+		if nodeDetour(b) != "a" {
+			t.Errorf("second detour should be kept once the cycle is broken")
+		}
+	})
+
+	t.Run("acyclic chain is kept", func(t *testing.T) {
+		a := &ParsedNode{Tag: "a", Outbound: map[string]interface{}{"detour": "group"}}
+		b := &ParsedNode{Tag: "b", Outbound: map[string]interface{}{}}
+		outboundsInfo := map[string]*outboundInfo{
+			"group": {config: OutboundConfig{Tag: "group", AddOutbounds: []string{"direct-out"}}, filteredNodes: []*ParsedNode{b}},
+		}
+
+		if removed := breakDetourCycles([]*ParsedNode{a, b}, outboundsInfo); len(removed) != 0 {
+			t.Errorf("expected no detours removed, got %v", removed)
+		}
+	})
+}
@@ -163,6 +163,11 @@ func GenerateNodeJSON(node *ParsedNode) (string, error) {
 		parts = append(parts, fmt.Sprintf(`"flow":%q`, node.Flow))
 	}
 
+	// packet_encoding (if present)
+	if packetEncoding, ok := node.Outbound["packet_encoding"].(string); ok && packetEncoding != "" {
+		parts = append(parts, fmt.Sprintf(`"packet_encoding":%q`, packetEncoding))
+	}
+
 	// 7. tls (if present) - with correct field order
 	if tlsData, ok := node.Outbound["tls"].(map[string]interface{}); ok {
 		var tlsParts []string
@@ -221,6 +226,21 @@ func GenerateNodeJSON(node *ParsedNode) (string, error) {
 		parts = append(parts, fmt.Sprintf(`"tls":%s`, tlsJSON))
 	}
 
+	// 8. dial fields (if present, e.g. from ProxySource.override)
+	for _, key := range []string{"bind_interface", "connect_timeout", "domain_strategy"} {
+		if value, ok := node.Outbound[key].(string); ok && value != "" {
+			parts = append(parts, fmt.Sprintf(`%q:%q`, key, value))
+		}
+	}
+	if tfo, ok := node.Outbound["tcp_fast_open"].(bool); ok {
+		parts = append(parts, fmt.Sprintf(`"tcp_fast_open":%v`, tfo))
+	}
+
+	// 9. detour (if present)
+	if detour, ok := node.Outbound["detour"].(string); ok && detour != "" {
+		parts = append(parts, fmt.Sprintf(`"detour":%q`, detour))
+	}
+
 	// Build final JSON
 	jsonStr := "{" + strings.Join(parts, ",") + "}"
 	return fmt.Sprintf("\t// %s\n\t%s,", node.Label, jsonStr), nil
@@ -508,10 +528,13 @@ func GenerateSelector(allNodes []*ParsedNode, outboundConfig OutboundConfig) (st
 // The three-pass algorithm ensures that dynamic addOutbounds are only added if they are valid (non-empty):
 //
 // Pass 1: Creates outboundsInfo map for all selectors and counts only filtered nodes (without addOutbounds).
+// Detours from ProxySource and outbound templates are assigned to nodes here.
 //
 // Pass 2: Performs topological sorting to process selectors in dependency order, then calculates total
 //
 //	outboundCount for each selector (filteredNodes + valid addOutbounds). Sets isValid flag for each selector.
+//	Then detours to outbounds that are not generated and detours that would create a cycle
+//	(node -> detour -> ... -> node) are removed, and node JSON is generated.
 //
 // Pass 3: Generates JSON only for valid selectors, filtering addOutbounds to include only:
 //   - Dynamic selectors that are valid (isValid == true)
@@ -524,7 +547,8 @@ func GenerateSelector(allNodes []*ParsedNode, outboundConfig OutboundConfig) (st
 //   - parserConfig: The parser configuration containing proxy sources and outbound definitions
 //   - tagCounts: Map for tracking tag usage counts (passed to loadNodesFunc)
 //   - progressCallback: Optional callback for progress updates (progress 0-100, status message)
-//   - loadNodesFunc: Function to load and parse nodes from a ProxySource
+//   - loadNodesFunc: Function to load and parse nodes from a ProxySource (fills SourceReport if not nil)
+//   - report: Optional parse report to fill with per-source and per-selector statistics (may be nil)
 //
 // Returns:
 //   - OutboundGenerationResult with generated JSON strings and statistics
@@ -533,13 +557,16 @@ func GenerateOutboundsFromParserConfig(
 	parserConfig *ParserConfig,
 	tagCounts map[string]int,
 	progressCallback func(float64, string),
-	loadNodesFunc func(ProxySource, map[string]int, func(float64, string), int, int) ([]*ParsedNode, error),
+	loadNodesFunc func(ProxySource, map[string]int, func(float64, string), int, int, *SourceReport) ([]*ParsedNode, error),
+	report *ParseReport,
 ) (*OutboundGenerationResult, error) {
 	// Step 1: Process all proxy sources and collect nodes
 	allNodes := make([]*ParsedNode, 0)
 	nodesBySource := make(map[int][]*ParsedNode) // Map source index to its nodes
+	sourceIndexOf := make(map[*ParsedNode]int)   // Map node to its source index (used by group_by "source")
 
 	totalSources := len(parserConfig.ParserConfig.Proxies)
+	disabledSources := 0
 	if progressCallback != nil {
 		progressCallback(10, fmt.Sprintf("Processing %d sources...", totalSources))
 	}
@@ -550,51 +577,71 @@ func GenerateOutboundsFromParserConfig(
 				fmt.Sprintf("Processing source %d/%d...", i+1, totalSources))
 		}
 
-		nodesFromSource, err := loadNodesFunc(proxySource, tagCounts, progressCallback, i, totalSources)
+		// Resolve global parser.limit into the source passed to the loader (per-source limit takes precedence)
+		sourceToLoad := proxySource
+		limit := proxySource.NodeLimit(parserConfig.ParserConfig.Parser.NodeLimit())
+		sourceToLoad.Limit = &limit
+
+		var sourceReport *SourceReport
+		if report != nil {
+			sourceReport = NewSourceReport(proxySource, i)
+			report.Sources = append(report.Sources, sourceReport)
+		}
+
+		// Disabled sources keep their settings but produce no nodes and no local outbounds
+		if !proxySource.IsEnabled() {
+			disabledSources++
+			log.Printf("GenerateOutboundsFromParserConfig: Source %d/%d (%s) is disabled, skipping", i+1, totalSources, proxySource.DisplayName(i))
+			continue
+		}
+
+		// A failed subscription may still return the nodes from its connections: they are used,
+		// but the source stays out of the node churn history
+		nodesFromSource, err := loadNodesFunc(sourceToLoad, tagCounts, progressCallback, i, totalSources, sourceReport)
 		if err != nil {
+			if sourceReport != nil {
+				sourceReport.Error = err.Error()
+			}
 			log.Printf("GenerateOutboundsFromParserConfig: Error processing source %d/%d: %v", i+1, totalSources, err)
-			continue
+			if len(nodesFromSource) == 0 {
+				continue
+			}
+		} else if sourceReport != nil {
+			sourceReport.nodes = snapshotNodes(nodesFromSource)
 		}
 
 		if len(nodesFromSource) > 0 {
+			if problems := applySourceOverride(nodesFromSource, proxySource.Override); len(problems) > 0 && sourceReport != nil {
+				sourceReport.OverrideErrors = problems
+			}
+			applySourceDetour(nodesFromSource, proxySource.Detour)
 			allNodes = append(allNodes, nodesFromSource...)
 			nodesBySource[i] = nodesFromSource
+			for _, node := range nodesFromSource {
+				sourceIndexOf[node] = i
+			}
 		}
 	}
 
 	if len(allNodes) == 0 {
-		return nil, fmt.Errorf("no nodes parsed from any source")
-	}
-
-	// Step 2: Generate JSON for all nodes
-	if progressCallback != nil {
-		progressCallback(40, fmt.Sprintf("Generating JSON for %d nodes...", len(allNodes)))
-	}
-
-	selectorsJSON := make([]string, 0)
-	nodesCount := 0
-
-	for _, node := range allNodes {
-		nodeJSON, err := GenerateNodeJSON(node)
-		if err != nil {
-			log.Printf("GenerateOutboundsFromParserConfig: Warning: Failed to generate JSON for node %s: %v", node.Tag, err)
-			continue
+		if totalSources > 0 && disabledSources == totalSources {
+			return nil, fmt.Errorf("all sources are disabled")
 		}
-		selectorsJSON = append(selectorsJSON, nodeJSON)
-		nodesCount++
+		return nil, fmt.Errorf("no nodes parsed from any source")
 	}
 
-	// Step 3: Pass 1 - Create outboundsInfo and count nodes only
+	// Step 2: Pass 1 - Create outboundsInfo and count nodes only
 	// Build map of all dynamically created outbounds (local + global)
 	outboundsInfo := make(map[string]*outboundInfo)
 
 	if progressCallback != nil {
-		progressCallback(60, "Analyzing outbounds (pass 1)...")
+		progressCallback(40, "Analyzing outbounds (pass 1)...")
 	}
 
-	// Process local selectors (per source)
+	// Expand group_by templates into concrete outbounds (each with its own node pool)
+	localOutbounds := make(map[int][]expandedOutbound)
 	for i, proxySource := range parserConfig.ParserConfig.Proxies {
-		if len(proxySource.Outbounds) == 0 {
+		if len(proxySource.Outbounds) == 0 || !proxySource.IsEnabled() {
 			continue
 		}
 
@@ -602,9 +649,16 @@ func GenerateOutboundsFromParserConfig(
 		if !ok {
 			sourceNodes = []*ParsedNode{}
 		}
+		localOutbounds[i] = expandOutbounds(proxySource.Outbounds, sourceNodes, sourceIndexOf)
+	}
+	globalOutbounds := expandOutbounds(parserConfig.ParserConfig.Outbounds, allNodes, sourceIndexOf)
 
-		for _, outboundConfig := range proxySource.Outbounds {
-			filteredNodes := filterNodesForSelector(sourceNodes, outboundConfig.Filters)
+	// Process local selectors (per source)
+	for i := range parserConfig.ParserConfig.Proxies {
+		for _, expanded := range localOutbounds[i] {
+			outboundConfig := expanded.config
+			filteredNodes := filterNodesForSelector(expanded.nodes, outboundConfig.Filters)
+			applyOutboundDetour(filteredNodes, outboundConfig)
 
 			// Check for duplicate tags (local selector with same tag as existing one)
 			if existingInfo, exists := outboundsInfo[outboundConfig.Tag]; exists {
@@ -629,8 +683,10 @@ func GenerateOutboundsFromParserConfig(
 	}
 
 	// Process global selectors
-	for _, outboundConfig := range parserConfig.ParserConfig.Outbounds {
-		filteredNodes := filterNodesForSelector(allNodes, outboundConfig.Filters)
+	for _, expanded := range globalOutbounds {
+		outboundConfig := expanded.config
+		filteredNodes := filterNodesForSelector(expanded.nodes, outboundConfig.Filters)
+		applyOutboundDetour(filteredNodes, outboundConfig)
 
 		// Check for duplicate tags (global selector with same tag as existing local one)
 		if existingInfo, exists := outboundsInfo[outboundConfig.Tag]; exists {
@@ -655,12 +711,12 @@ func GenerateOutboundsFromParserConfig(
 		}
 	}
 
-	// Step 4: Pass 2 - Topological sort and count total outboundCount (nodes + valid addOutbounds)
+	// Step 3: Pass 2 - Topological sort and count total outboundCount (nodes + valid addOutbounds)
 	// This pass uses Kahn's algorithm for topological sorting to ensure dependencies are processed
 	// before dependents. This is necessary because the outboundCount of a selector depends on
 	// the outboundCount of its addOutbounds (if they are dynamic selectors).
 	if progressCallback != nil {
-		progressCallback(70, "Calculating outbound dependencies (pass 2)...")
+		progressCallback(60, "Calculating outbound dependencies (pass 2)...")
 	}
 
 	// Build dependency graph (only dynamic dependencies, not constants)
@@ -755,6 +811,33 @@ func GenerateOutboundsFromParserConfig(
 			processedCount, len(outboundsInfo), unprocessed)
 	}
 
+	// Step 4: Generate JSON for all nodes (after pass 2, when detours are final)
+	// Detours to outbounds that are not generated (empty selectors, disabled sources) are dropped;
+	// detours add node -> outbound edges to the dependency graph, so drop those that would create a cycle
+	dropUnresolvedDetours(allNodes, outboundsInfo, declaredOutboundTags(parserConfig))
+	breakDetourCycles(allNodes, outboundsInfo)
+
+	if progressCallback != nil {
+		progressCallback(70, fmt.Sprintf("Generating JSON for %d nodes...", len(allNodes)))
+	}
+
+	selectorsJSON := make([]string, 0)
+	nodesCount := 0
+
+	for _, node := range allNodes {
+		nodeJSON, err := GenerateNodeJSON(node)
+		if err != nil {
+			log.Printf("GenerateOutboundsFromParserConfig: Warning: Failed to generate JSON for node %s: %v", node.Tag, err)
+			continue
+		}
+		selectorsJSON = append(selectorsJSON, nodeJSON)
+		nodesCount++
+	}
+
+	if report != nil {
+		report.Selectors = buildSelectorReports(parserConfig, localOutbounds, globalOutbounds, outboundsInfo)
+	}
+
 	// Step 5: Pass 3 - Generate JSON only for valid selectors with filtered addOutbounds
 	localSelectorsCount := 0
 	globalSelectorsCount := 0
@@ -764,17 +847,9 @@ func GenerateOutboundsFromParserConfig(
 	}
 
 	// Generate local selectors (per source)
-	for i, proxySource := range parserConfig.ParserConfig.Proxies {
-		if len(proxySource.Outbounds) == 0 {
-			continue
-		}
-
-		sourceNodes, ok := nodesBySource[i]
-		if !ok {
-			sourceNodes = []*ParsedNode{}
-		}
-
-		for _, outboundConfig := range proxySource.Outbounds {
+	for i := range parserConfig.ParserConfig.Proxies {
+		for _, expanded := range localOutbounds[i] {
+			outboundConfig := expanded.config
 			info, exists := outboundsInfo[outboundConfig.Tag]
 			if !exists {
 				continue
@@ -786,7 +861,7 @@ func GenerateOutboundsFromParserConfig(
 				continue
 			}
 
-			selectorJSON, err := GenerateSelectorWithFilteredAddOutbounds(sourceNodes, outboundConfig, outboundsInfo)
+			selectorJSON, err := GenerateSelectorWithFilteredAddOutbounds(expanded.nodes, outboundConfig, outboundsInfo)
 			if err != nil {
 				log.Printf("GenerateOutboundsFromParserConfig: Warning: Failed to generate local selector %s for source %d: %v",
 					outboundConfig.Tag, i+1, err)
@@ -800,7 +875,8 @@ func GenerateOutboundsFromParserConfig(
 	}
 
 	// Generate global selectors
-	for _, outboundConfig := range parserConfig.ParserConfig.Outbounds {
+	for _, expanded := range globalOutbounds {
+		outboundConfig := expanded.config
 		info, exists := outboundsInfo[outboundConfig.Tag]
 		if !exists {
 			continue
@@ -812,7 +888,7 @@ func GenerateOutboundsFromParserConfig(
 			continue
 		}
 
-		selectorJSON, err := GenerateSelectorWithFilteredAddOutbounds(allNodes, outboundConfig, outboundsInfo)
+		selectorJSON, err := GenerateSelectorWithFilteredAddOutbounds(expanded.nodes, outboundConfig, outboundsInfo)
 		if err != nil {
 			log.Printf("GenerateOutboundsFromParserConfig: Warning: Failed to generate global selector %s: %v",
 				outboundConfig.Tag, err)
@@ -832,6 +908,40 @@ func GenerateOutboundsFromParserConfig(
 	}, nil
 }
 
+// buildSelectorReports collects per-selector statistics after pass 2 (in generation order: local, then global)
+func buildSelectorReports(
+	parserConfig *ParserConfig,
+	localOutbounds map[int][]expandedOutbound,
+	globalOutbounds []expandedOutbound,
+	outboundsInfo map[string]*outboundInfo,
+) []SelectorReport {
+	reports := make([]SelectorReport, 0, len(outboundsInfo))
+	add := func(outboundConfig OutboundConfig, sourceIndex int) {
+		info, exists := outboundsInfo[outboundConfig.Tag]
+		if !exists {
+			return
+		}
+		reports = append(reports, SelectorReport{
+			Tag:       outboundConfig.Tag,
+			Type:      outboundConfig.Type,
+			Local:     sourceIndex > 0,
+			Source:    sourceIndex,
+			Nodes:     len(info.filteredNodes),
+			Outbounds: info.outboundCount,
+			Valid:     info.isValid,
+		})
+	}
+	for i := range parserConfig.ParserConfig.Proxies {
+		for _, expanded := range localOutbounds[i] {
+			add(expanded.config, i+1)
+		}
+	}
+	for _, expanded := range globalOutbounds {
+		add(expanded.config, 0)
+	}
+	return reports
+}
+
 // Helper functions for filtering
 
 func filterNodesForSelector(allNodes []*ParsedNode, filter interface{}) []*ParsedNode {
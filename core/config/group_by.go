@@ -0,0 +1,165 @@
+package config
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// groupPlaceholder is substituted with the group value in tag and comment of a group_by template
+const groupPlaceholder = "{$group}"
+
+// expandedOutbound is an outbound after group_by expansion together with the pool of nodes
+// its filters are applied to (source nodes for local, all nodes for global, group subset for generated groups).
+type expandedOutbound struct {
+	config OutboundConfig
+	nodes  []*ParsedNode
+}
+
+// expandOutbounds expands group_by templates in outbounds.
+// Regular outbounds are returned as-is with the given node pool.
+// Each group_by template is replaced by one outbound per distinct group value (in order of first appearance)
+// and, if configured, by a parent outbound that lists all generated groups.
+func expandOutbounds(outbounds []OutboundConfig, pool []*ParsedNode, sourceIndexOf map[*ParsedNode]int) []expandedOutbound {
+	result := make([]expandedOutbound, 0, len(outbounds))
+	for _, outboundConfig := range outbounds {
+		if outboundConfig.GroupBy == nil {
+			result = append(result, expandedOutbound{config: outboundConfig, nodes: pool})
+			continue
+		}
+		result = append(result, expandGroupBy(outboundConfig, pool, sourceIndexOf)...)
+	}
+	return result
+}
+
+// expandGroupBy generates concrete outbounds for a single group_by template.
+func expandGroupBy(template OutboundConfig, pool []*ParsedNode, sourceIndexOf map[*ParsedNode]int) []expandedOutbound {
+	groupBy := template.GroupBy
+
+	var re *regexp.Regexp
+	if groupBy.Pattern != "" {
+		compiled, err := compileGroupPattern(groupBy.Pattern)
+		if err != nil {
+			log.Printf("Parser: Invalid group_by pattern %s in '%s': %v", groupBy.Pattern, template.Tag, err)
+			return nil
+		}
+		re = compiled
+	}
+
+	if !strings.Contains(template.Tag, groupPlaceholder) {
+		log.Printf("Parser: Warning: group_by template '%s' has no %s in tag, appending group value", template.Tag, groupPlaceholder)
+	}
+
+	// Apply template filters first, then split the remaining nodes into groups
+	candidates := filterNodesForSelector(pool, template.Filters)
+
+	groupOrder := make([]string, 0)
+	groupNodes := make(map[string][]*ParsedNode)
+	for _, node := range candidates {
+		value, ok := getGroupValue(node, groupBy.Key, re, sourceIndexOf)
+		if !ok {
+			continue
+		}
+		if _, exists := groupNodes[value]; !exists {
+			groupOrder = append(groupOrder, value)
+		}
+		groupNodes[value] = append(groupNodes[value], node)
+	}
+
+	result := make([]expandedOutbound, 0, len(groupOrder)+1)
+	generatedTags := make([]string, 0, len(groupOrder))
+	for _, value := range groupOrder {
+		generated := template
+		generated.GroupBy = nil
+		generated.Tag = applyGroupTemplate(template.Tag, value, true)
+		generated.Comment = applyGroupTemplate(template.Comment, value, false)
+		generatedTags = append(generatedTags, generated.Tag)
+		result = append(result, expandedOutbound{config: generated, nodes: groupNodes[value]})
+	}
+	log.Printf("Parser: group_by template '%s' produced %d groups: %v", template.Tag, len(generatedTags), generatedTags)
+
+	if groupBy.Parent != nil && groupBy.Parent.Tag != "" {
+		parent := *groupBy.Parent
+		parent.GroupBy = nil
+		parent.Filters = nil
+		if parent.Type == "" {
+			parent.Type = "selector"
+		}
+		parent.AddOutbounds = append(append([]string{}, generatedTags...), groupBy.Parent.AddOutbounds...)
+		// Parent contains only groups (and its own addOutbounds), never nodes directly
+		result = append(result, expandedOutbound{config: parent, nodes: []*ParsedNode{}})
+	}
+
+	return result
+}
+
+// getGroupValue returns the group value of a node for the given key and optional pattern.
+// Returns false if the node doesn't belong to any group.
+func getGroupValue(node *ParsedNode, key string, re *regexp.Regexp, sourceIndexOf map[*ParsedNode]int) (string, bool) {
+	var value string
+	switch key {
+	case "source":
+		idx, ok := sourceIndexOf[node]
+		if !ok {
+			return "", false
+		}
+		value = strconv.Itoa(idx + 1)
+	case "flag":
+		value = extractFlagEmoji(node.Tag)
+	case "":
+		value = node.Tag
+	default:
+		value = getNodeValue(node, key)
+	}
+
+	if re != nil {
+		match := re.FindStringSubmatch(value)
+		if match == nil {
+			return "", false
+		}
+		value = match[0]
+		if len(match) > 1 {
+			value = match[1]
+		}
+	}
+
+	value = strings.TrimSpace(value)
+	return value, value != ""
+}
+
+// compileGroupPattern compiles a group_by pattern.
+// Supports both plain regex and the "/regex/i" form used in filters.
+func compileGroupPattern(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/i") && len(pattern) > 3 {
+		return regexp.Compile("(?i)" + strings.TrimSuffix(strings.TrimPrefix(pattern, "/"), "/i"))
+	}
+	return regexp.Compile(pattern)
+}
+
+// applyGroupTemplate substitutes {$group} in s.
+// If appendIfMissing is set and s has no placeholder, the value is appended with "-".
+func applyGroupTemplate(s, value string, appendIfMissing bool) string {
+	if strings.Contains(s, groupPlaceholder) {
+		return strings.ReplaceAll(s, groupPlaceholder, value)
+	}
+	if appendIfMissing {
+		return s + "-" + value
+	}
+	return s
+}
+
+// extractFlagEmoji returns the first flag emoji (pair of regional indicator symbols) found in s.
+func extractFlagEmoji(s string) string {
+	runes := []rune(s)
+	for i := 0; i+1 < len(runes); i++ {
+		if isRegionalIndicator(runes[i]) && isRegionalIndicator(runes[i+1]) {
+			return string(runes[i : i+2])
+		}
+	}
+	return ""
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func groupByTestNodes() map[int][]*ParsedNode {
+	return map[int][]*ParsedNode{
+		0: {
+			{Tag: "🇩🇪 Frankfurt", Scheme: "vless", Server: "de1.example.com", Port: 443, Outbound: map[string]interface{}{}},
+			{Tag: "🇩🇪 Berlin", Scheme: "trojan", Server: "de2.example.com", Port: 443, Outbound: map[string]interface{}{}},
+		},
+		1: {
+			{Tag: "🇳🇱 Amsterdam", Scheme: "vless", Server: "nl.example.com", Port: 443, Outbound: map[string]interface{}{}},
+			{Tag: "No flag", Scheme: "ss", Server: "x.example.com", Port: 8388, Outbound: map[string]interface{}{}},
+		},
+	}
+}
+
+func generateWithGroupBy(t *testing.T, outbounds []OutboundConfig) []string {
+	t.Helper()
+	nodes := groupByTestNodes()
+	pc := &ParserConfig{}
+	pc.ParserConfig.Proxies = []ProxySource{{Source: "a"}, {Source: "b"}}
+	pc.ParserConfig.Outbounds = outbounds
+
+	loadNodes := func(_ ProxySource, _ map[string]int, _ func(float64, string), idx, _ int, _ *SourceReport) ([]*ParsedNode, error) {
+		return nodes[idx], nil
+	}
+	result, err := GenerateOutboundsFromParserConfig(pc, map[string]int{}, nil, loadNodes, nil)
+	if err != nil {
+		t.Fatalf("GenerateOutboundsFromParserConfig failed: %v", err)
+	}
+	return result.OutboundsJSON
+}
+
+func findOutbound(outbounds []string, tag string) string {
+	for _, o := range outbounds {
+		if strings.Contains(o, fmt.Sprintf(`"tag":%q`, tag)) {
+			return o
+		}
+	}
+	return ""
+}
+
+func TestGroupBy_Flag(t *testing.T) {
+	outbounds := generateWithGroupBy(t, []OutboundConfig{{
+		Tag:     "auto-{$group}",
+		Type:    "urltest",
+		GroupBy: &GroupByConfig{Key: "flag", Parent: &OutboundConfig{Tag: "countries"}},
+	}})
+
+	de := findOutbound(outbounds, "auto-🇩🇪")
+	if de == "" || !strings.Contains(de, "Frankfurt") || !strings.Contains(de, "Berlin") || strings.Contains(de, "Amsterdam") {
+		t.Errorf("unexpected DE group: %q", de)
+	}
+	if nl := findOutbound(outbounds, "auto-🇳🇱"); nl == "" || !strings.Contains(nl, "Amsterdam") {
+		t.Errorf("unexpected NL group: %q", nl)
+	}
+	if strings.Contains(strings.Join(outbounds, "\n"), "No flag\"]") {
+		t.Errorf("node without flag should not be grouped")
+	}
+
+	parent := findOutbound(outbounds, "countries")
+	if !strings.Contains(parent, `"type":"selector"`) || !strings.Contains(parent, `"outbounds":["auto-🇩🇪","auto-🇳🇱"]`) {
+		t.Errorf("unexpected parent selector: %q", parent)
+	}
+}
+
+func TestGroupBy_SourceAndScheme(t *testing.T) {
+	t.Run("source index", func(t *testing.T) {
+		outbounds := generateWithGroupBy(t, []OutboundConfig{{
+			Tag:     "src-{$group}",
+			Type:    "selector",
+			GroupBy: &GroupByConfig{Key: "source"},
+		}})
+		if s := findOutbound(outbounds, "src-1"); !strings.Contains(s, "Berlin") || strings.Contains(s, "Amsterdam") {
+			t.Errorf("unexpected source 1 group: %q", s)
+		}
+		if s := findOutbound(outbounds, "src-2"); !strings.Contains(s, "No flag") {
+			t.Errorf("unexpected source 2 group: %q", s)
+		}
+	})
+
+	t.Run("scheme with filters and regex capture", func(t *testing.T) {
+		outbounds := generateWithGroupBy(t, []OutboundConfig{{
+			Tag:     "host-{$group}",
+			Type:    "selector",
+			Filters: map[string]interface{}{"scheme": "vless"},
+			GroupBy: &GroupByConfig{Key: "host", Pattern: `^([a-z]+)\d*\.`},
+		}})
+		if s := findOutbound(outbounds, "host-de"); !strings.Contains(s, "Frankfurt") || strings.Contains(s, "Berlin") {
+			t.Errorf("unexpected host-de group: %q", s)
+		}
+		if s := findOutbound(outbounds, "host-x"); s != "" {
+			t.Errorf("filtered-out node produced a group: %q", s)
+		}
+	})
+}
+
+func TestExtractFlagEmoji(t *testing.T) {
+	tests := map[string]string{
+		"🇩🇪 Frankfurt": "🇩🇪",
+		"Node 🇺🇸 fast": "🇺🇸",
+		"plain":        "",
+	}
+	for in, want := range tests {
+		if got := extractFlagEmoji(in); got != want {
+			t.Errorf("extractFlagEmoji(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Defaults of the health watchdog
+const (
+	DefaultHealthCheckInterval         = 30 * time.Second
+	DefaultHealthCheckTimeout          = 5 * time.Second
+	DefaultHealthCheckFailureThreshold = 3
+)
+
+// HealthCheckSettings configures the watchdog that restarts a running but unresponsive sing-box.
+// Stored in launcher_state.json ("health_check"); empty fields take the defaults.
+type HealthCheckSettings struct {
+	Enabled          *bool  `json:"enabled,omitempty"`           // Default true (only probes the Clash API unless url is set)
+	Interval         string `json:"interval,omitempty"`          // Time between probes ("30s")
+	Timeout          string `json:"timeout,omitempty"`           // Timeout of one probe ("5s")
+	FailureThreshold int    `json:"failure_threshold,omitempty"` // Consecutive failed probes before the restart (3)
+	URL              string `json:"url,omitempty"`               // Connectivity URL fetched through the core (logged only, never restarts), e.g. "https://www.gstatic.com/generate_204"
+}
+
+// HealthCheck is the resolved health watchdog configuration
+type HealthCheck struct {
+	Enabled          bool
+	Interval         time.Duration
+	Timeout          time.Duration
+	FailureThreshold int
+	URL              string // Empty = no connectivity probe
+}
+
+// DefaultHealthCheck returns the configuration used when launcher_state.json has no "health_check"
+func DefaultHealthCheck() HealthCheck {
+	return HealthCheck{
+		Enabled:          true,
+		Interval:         DefaultHealthCheckInterval,
+		Timeout:          DefaultHealthCheckTimeout,
+		FailureThreshold: DefaultHealthCheckFailureThreshold,
+	}
+}
+
+// Resolve validates the settings and fills in the defaults
+func (s HealthCheckSettings) Resolve() (HealthCheck, error) {
+	check := DefaultHealthCheck()
+	if s.Enabled != nil {
+		check.Enabled = *s.Enabled
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"interval", s.Interval, &check.Interval},
+		{"timeout", s.Timeout, &check.Timeout},
+	} {
+		if strings.TrimSpace(field.value) == "" {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(field.value))
+		if err != nil {
+			return check, fmt.Errorf("health_check.%s: %w", field.name, err)
+		}
+		if d <= 0 {
+			return check, fmt.Errorf("health_check.%s: must be positive", field.name)
+		}
+		*field.dest = d
+	}
+	if check.Timeout > check.Interval {
+		return check, fmt.Errorf("health_check.timeout: must not exceed interval (%v)", check.Interval)
+	}
+
+	if s.FailureThreshold < 0 {
+		return check, fmt.Errorf("health_check.failure_threshold: must not be negative")
+	}
+	if s.FailureThreshold > 0 {
+		check.FailureThreshold = s.FailureThreshold
+	}
+
+	if raw := strings.TrimSpace(s.URL); raw != "" {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return check, fmt.Errorf("health_check.url: %q is not an http(s) URL", s.URL)
+		}
+		check.URL = raw
+	}
+	return check, nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHealthCheckSettings_Resolve(t *testing.T) {
+	disabled := false
+
+	check, err := HealthCheckSettings{}.Resolve()
+	if err != nil || check != DefaultHealthCheck() {
+		t.Fatalf("empty settings: %+v, err = %v; want defaults", check, err)
+	}
+
+	check, err = HealthCheckSettings{Enabled: &disabled, Interval: "1m", Timeout: "10s", FailureThreshold: 5, URL: "https://www.gstatic.com/generate_204"}.Resolve()
+	want := HealthCheck{Enabled: false, Interval: time.Minute, Timeout: 10 * time.Second, FailureThreshold: 5, URL: "https://www.gstatic.com/generate_204"}
+	if err != nil || check != want {
+		t.Errorf("Resolve = %+v, err = %v; want %+v", check, err, want)
+	}
+
+	for _, s := range []HealthCheckSettings{
+		{Interval: "soon"},
+		{Timeout: "0s"},
+		{Interval: "5s", Timeout: "10s"},
+		{FailureThreshold: -1},
+		{URL: "ftp://example.com"},
+		{URL: "https://"},
+	} {
+		if _, err := s.Resolve(); err == nil {
+			t.Errorf("Resolve(%+v) must fail", s)
+		}
+	}
+}
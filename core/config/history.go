@@ -0,0 +1,249 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"singbox-launcher/internal/constants"
+)
+
+// Triggers of config history snapshots
+const (
+	HistoryTriggerInitial = "initial" // Config as it was before the first recorded change
+	HistoryTriggerWizard  = "wizard"  // Saved by the configuration wizard
+	HistoryTriggerUpdate  = "update"  // Written by subscription update
+	HistoryTriggerRestore = "restore" // Restored from history
+)
+
+// HistoryEntry describes one snapshot of config.json
+type HistoryEntry struct {
+	ID         string    `json:"id"` // Also the snapshot file name without extension
+	Trigger    string    `json:"trigger"`
+	Time       time.Time `json:"time"`
+	NodesCount int       `json:"nodes_count"`
+	SHA256     string    `json:"sha256"`
+	Size       int64     `json:"size"`
+}
+
+// HistoryPolicy limits how many snapshots are kept
+type HistoryPolicy struct {
+	MaxCount int           // Maximum number of snapshots (<= 0 uses the default)
+	MaxAge   time.Duration // Snapshots older than this are removed (0 = no age limit)
+}
+
+// HistoryPolicy returns snapshot retention policy from parser settings
+func (s ParserSettings) HistoryPolicy() HistoryPolicy {
+	policy := HistoryPolicy{MaxCount: s.HistoryLimit}
+	if s.HistoryMaxAge != "" {
+		if age, err := time.ParseDuration(s.HistoryMaxAge); err == nil {
+			policy.MaxAge = age
+		} else {
+			log.Printf("Parser: Warning: invalid history_max_age '%s': %v", s.HistoryMaxAge, err)
+		}
+	}
+	return policy
+}
+
+// ConfigHistoryDir returns directory with config.json snapshots
+func ConfigHistoryDir(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), constants.ConfigHistoryDirName)
+}
+
+// ListConfigHistory returns snapshots of config.json, newest first
+func ListConfigHistory(configPath string) ([]HistoryEntry, error) {
+	entries, err := loadHistoryIndex(configPath)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.After(entries[j].Time) })
+	return entries, nil
+}
+
+// ReadConfigSnapshot returns content of a snapshot
+func ReadConfigSnapshot(configPath, id string) ([]byte, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid snapshot id %q", id)
+	}
+	data, err := os.ReadFile(filepath.Join(ConfigHistoryDir(configPath), id+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", id, err)
+	}
+	return data, nil
+}
+
+// EnsureHistoryBaseline records the current config.json as the "initial" snapshot
+// if the history is empty. Call it before overwriting config.json.
+func EnsureHistoryBaseline(configPath string, policy HistoryPolicy) {
+	entries, err := loadHistoryIndex(configPath)
+	if err != nil || len(entries) > 0 {
+		return
+	}
+	if _, err := os.Stat(configPath); err != nil {
+		return
+	}
+	if _, err := RecordConfigVersion(configPath, HistoryTriggerInitial, policy); err != nil {
+		log.Printf("Parser: Warning: Failed to record initial config snapshot: %v", err)
+	}
+}
+
+// RecordConfigVersion stores the current config.json as a snapshot with metadata and applies retention.
+// If the content is identical to the newest snapshot, nothing is stored and the newest entry is returned.
+func RecordConfigVersion(configPath, trigger string, policy HistoryPolicy) (*HistoryEntry, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	entries, err := loadHistoryIndex(configPath)
+	if err != nil {
+		log.Printf("Parser: Warning: Failed to load config history index, starting a new one: %v", err)
+		entries = nil
+	}
+
+	sum := sha256.Sum256(data)
+	sha := hex.EncodeToString(sum[:])
+	if n := len(entries); n > 0 && entries[n-1].SHA256 == sha {
+		return &entries[n-1], nil
+	}
+
+	now := time.Now()
+	entry := HistoryEntry{
+		ID:         uniqueSnapshotID(entries, now),
+		Trigger:    trigger,
+		Time:       now,
+		NodesCount: countConfigNodes(string(data)),
+		SHA256:     sha,
+		Size:       int64(len(data)),
+	}
+
+	dir := ConfigHistoryDir(configPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+	if err := WriteFileAtomic(filepath.Join(dir, entry.ID+".json"), data, nil); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	entries = pruneHistory(dir, append(entries, entry), policy, now)
+	if err := saveHistoryIndex(configPath, entries); err != nil {
+		return nil, err
+	}
+	log.Printf("Parser: Config snapshot %s recorded (trigger: %s, nodes: %d)", entry.ID, trigger, entry.NodesCount)
+	return &entry, nil
+}
+
+// RestoreConfigSnapshot replaces config.json with a snapshot. The candidate is checked by validate
+// (if set) before replacing; the restored version is recorded in history with the "restore" trigger.
+func RestoreConfigSnapshot(configPath, id string, policy HistoryPolicy, validate ConfigValidator) error {
+	data, err := ReadConfigSnapshot(configPath, id)
+	if err != nil {
+		return err
+	}
+	EnsureHistoryBaseline(configPath, policy)
+	if err := WriteFileAtomic(configPath, data, validate); err != nil {
+		return err
+	}
+	if _, err := RecordConfigVersion(configPath, HistoryTriggerRestore, policy); err != nil {
+		log.Printf("Parser: Warning: Failed to record restored config snapshot: %v", err)
+	}
+	return nil
+}
+
+// countConfigNodes counts generated nodes in the @ParserSTART block (0 if there is no valid block)
+func countConfigNodes(configStr string) int {
+	startIdx, endIdx, err := findParserBlock(configStr)
+	if err != nil {
+		return 0
+	}
+	outbounds, err := parseOutboundsBlock(configStr[startIdx+len(parserStartMarker) : endIdx])
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, outbound := range outbounds {
+		if outboundMembers(outbound) == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// uniqueSnapshotID builds a sortable id from time, adding a suffix if it is already taken
+func uniqueSnapshotID(entries []HistoryEntry, now time.Time) string {
+	base := now.Format("20060102-150405")
+	id := base
+	for n := 2; ; n++ {
+		taken := false
+		for _, entry := range entries {
+			if entry.ID == id {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// pruneHistory removes snapshots exceeding the policy (oldest first) and returns the remaining entries.
+// The newest snapshot is always kept.
+func pruneHistory(dir string, entries []HistoryEntry, policy HistoryPolicy, now time.Time) []HistoryEntry {
+	maxCount := policy.MaxCount
+	if maxCount <= 0 {
+		maxCount = constants.DefaultConfigHistoryLimit
+	}
+
+	kept := make([]HistoryEntry, 0, len(entries))
+	for i, entry := range entries {
+		isNewest := i == len(entries)-1
+		tooMany := len(entries)-i > maxCount
+		tooOld := policy.MaxAge > 0 && now.Sub(entry.Time) > policy.MaxAge
+		if !isNewest && (tooMany || tooOld) {
+			if err := os.Remove(filepath.Join(dir, entry.ID+".json")); err != nil && !os.IsNotExist(err) {
+				log.Printf("Parser: Warning: Failed to remove old config snapshot %s: %v", entry.ID, err)
+			}
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// loadHistoryIndex reads history index (oldest first); missing index means empty history
+func loadHistoryIndex(configPath string) ([]HistoryEntry, error) {
+	data, err := os.ReadFile(filepath.Join(ConfigHistoryDir(configPath), constants.ConfigHistoryIndexName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history index: %w", err)
+	}
+	var entries []HistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse history index: %w", err)
+	}
+	return entries, nil
+}
+
+// saveHistoryIndex writes history index
+func saveHistoryIndex(configPath string, entries []HistoryEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal history index: %w", err)
+	}
+	path := filepath.Join(ConfigHistoryDir(configPath), constants.ConfigHistoryIndexName)
+	if err := WriteFileAtomic(path, data, nil); err != nil {
+		return fmt.Errorf("failed to write history index: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeHistoryTestConfig(t *testing.T, path string, nodes ...string) {
+	t.Helper()
+	var block strings.Builder
+	for _, tag := range nodes {
+		block.WriteString(`{"tag":"` + tag + `","type":"vless","server":"s","server_port":443},` + "\n")
+	}
+	block.WriteString(`{"tag":"proxy-out","type":"selector","outbounds":["direct-out"]},` + "\n")
+	content := "{\"outbounds\":[\n/** @ParserSTART */\n" + block.String() + "/** @ParserEND */\n]}\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigHistory_RecordPruneRestore(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	policy := HistoryPolicy{MaxCount: 3}
+
+	writeHistoryTestConfig(t, configPath, "a")
+	EnsureHistoryBaseline(configPath, policy)
+	EnsureHistoryBaseline(configPath, policy) // no-op when history already exists
+
+	entries, err := ListConfigHistory(configPath)
+	if err != nil {
+		t.Fatalf("ListConfigHistory failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Trigger != HistoryTriggerInitial || entries[0].NodesCount != 1 {
+		t.Fatalf("unexpected baseline: %+v", entries)
+	}
+	baselineID := entries[0].ID
+
+	// Identical content is not recorded twice
+	if _, err := RecordConfigVersion(configPath, HistoryTriggerUpdate, policy); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ = ListConfigHistory(configPath); len(entries) != 1 {
+		t.Fatalf("identical config must not create a new snapshot, got %d", len(entries))
+	}
+
+	for _, nodes := range [][]string{{"a", "b"}, {"a", "b", "c"}, {"c"}} {
+		writeHistoryTestConfig(t, configPath, nodes...)
+		if _, err := RecordConfigVersion(configPath, HistoryTriggerUpdate, policy); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, _ = ListConfigHistory(configPath)
+	if len(entries) != 3 {
+		t.Fatalf("expected history pruned to 3 entries, got %d", len(entries))
+	}
+	if entries[0].NodesCount != 1 || entries[1].NodesCount != 3 {
+		t.Errorf("entries must be newest first: %+v", entries)
+	}
+	if _, err := ReadConfigSnapshot(configPath, baselineID); err == nil {
+		t.Errorf("pruned snapshot file should be removed")
+	}
+
+	// Restore the version with 3 nodes
+	if err := RestoreConfigSnapshot(configPath, entries[1].ID, policy, nil); err != nil {
+		t.Fatalf("RestoreConfigSnapshot failed: %v", err)
+	}
+	data, _ := os.ReadFile(configPath)
+	if countConfigNodes(string(data)) != 3 {
+		t.Errorf("config.json was not restored")
+	}
+	entries, _ = ListConfigHistory(configPath)
+	if entries[0].Trigger != HistoryTriggerRestore {
+		t.Errorf("restored version should be recorded with restore trigger, got %+v", entries[0])
+	}
+}
+
+func TestPruneHistory_MaxAge(t *testing.T) {
+	now := time.Now()
+	entries := []HistoryEntry{
+		{ID: "old", Time: now.Add(-48 * time.Hour)},
+		{ID: "recent", Time: now.Add(-time.Hour)},
+		{ID: "newest", Time: now.Add(-72 * time.Hour)}, // newest entry is always kept
+	}
+	kept := pruneHistory(t.TempDir(), entries, HistoryPolicy{MaxAge: 24 * time.Hour}, now)
+	if len(kept) != 2 || kept[0].ID != "recent" || kept[1].ID != "newest" {
+		t.Errorf("unexpected kept entries: %+v", kept)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	a := "1\n2\n3\n4\n5"
+	b := "1\n2\nthree\n4\n5\n6"
+	var ops strings.Builder
+	for _, line := range DiffLines(a, b) {
+		ops.WriteByte(line.Op)
+	}
+	if got := ops.String(); got != "  -+  +" {
+		t.Errorf("unexpected diff ops %q", got)
+	}
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"singbox-launcher/core/config/schema"
+)
+
+// utf8BOM is skipped at the start of config.json
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// HistoryTriggerPort marks a config written to move an inbound off a port taken by another program
+const HistoryTriggerPort = "port"
+
+// InboundListen is an inbound of config.json that listens on a port
+type InboundListen struct {
+	Index  int // Position in the inbounds array
+	Tag    string
+	Type   string
+	Listen string // Listen address, "" = all interfaces
+	Port   int
+}
+
+// Network returns the network the inbound listens on: QUIC-based inbounds use UDP
+func (in InboundListen) Network() string {
+	switch in.Type {
+	case "hysteria", "hysteria2", "tuic":
+		return "udp"
+	}
+	return "tcp"
+}
+
+// Address returns the listen address as host:port
+func (in InboundListen) Address() string {
+	return net.JoinHostPort(in.Listen, strconv.Itoa(in.Port))
+}
+
+// Name returns the tag of the inbound, or its type and index if it has no tag
+func (in InboundListen) Name() string {
+	if in.Tag != "" {
+		return in.Tag
+	}
+	return fmt.Sprintf("%s[%d]", in.Type, in.Index)
+}
+
+// PortConflict is an inbound whose port is taken by another program
+type PortConflict struct {
+	Inbound   InboundListen
+	OwnerPID  int    // 0 if the owner could not be identified
+	OwnerName string // Executable name of the owner
+	FreePort  int    // A free port to move the inbound to (0 if none was found)
+}
+
+func (c PortConflict) String() string {
+	owner := "another program"
+	if c.OwnerPID != 0 {
+		owner = fmt.Sprintf("%s (PID %d)", c.OwnerName, c.OwnerPID)
+	}
+	return fmt.Sprintf("Port %s of inbound %s is in use by %s", c.Inbound.Address(), c.Inbound.Name(), owner)
+}
+
+// PortConflictAction is the user's decision on port conflicts
+type PortConflictAction int
+
+const (
+	PortConflictCancel     PortConflictAction = iota // Do not start sing-box
+	PortConflictKillOwner                            // Stop the programs holding the ports
+	PortConflictChangePort                           // Move the inbounds to FreePort
+)
+
+// GetInboundListens extracts the inbounds with listen_port from config.json
+func GetInboundListens(configPath string) ([]InboundListen, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var config struct {
+		Inbounds []struct {
+			Type       string `json:"type"`
+			Tag        string `json:"tag"`
+			Listen     string `json:"listen"`
+			ListenPort int    `json:"listen_port"`
+		} `json:"inbounds"`
+	}
+	if err := json.Unmarshal(schema.StripComments(bytes.TrimPrefix(data, utf8BOM)), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var inbounds []InboundListen
+	for i, inbound := range config.Inbounds {
+		if inbound.ListenPort <= 0 {
+			continue
+		}
+		inbounds = append(inbounds, InboundListen{
+			Index:  i,
+			Tag:    inbound.Tag,
+			Type:   inbound.Type,
+			Listen: inbound.Listen,
+			Port:   inbound.ListenPort,
+		})
+	}
+	return inbounds, nil
+}
+
+// SetInboundListenPort replaces listen_port of the inbound at index in config.json text.
+// Only the number is replaced, comments and formatting are kept.
+func SetInboundListenPort(data []byte, index, port int) ([]byte, error) {
+	body := bytes.TrimPrefix(data, utf8BOM)
+	prefix := data[:len(data)-len(body)]
+
+	start, ok := schema.ValueOffset(body, fmt.Sprintf("/inbounds/%d/listen_port", index))
+	if !ok {
+		return nil, fmt.Errorf("inbounds[%d].listen_port not found", index)
+	}
+	end := start
+	for end < len(body) && body[end] >= '0' && body[end] <= '9' {
+		end++
+	}
+	if end == start {
+		return nil, fmt.Errorf("inbounds[%d].listen_port is not a number", index)
+	}
+
+	out := make([]byte, 0, len(data)+5)
+	out = append(out, prefix...)
+	out = append(out, body[:start]...)
+	out = append(out, strconv.Itoa(port)...)
+	out = append(out, body[end:]...)
+	return out, nil
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const inboundPortsConfig = `{
+  // Local proxy
+  "inbounds": [
+    { "type": "tun", "tag": "tun-in", "interface_name": "singbox-tun0" },
+    { "type": "mixed", "tag": "mixed-in", "listen": "127.0.0.1", "listen_port": 2080 }, // system proxy
+    { "type": "hysteria2", "listen": "::", "listen_port": 443, },
+  ],
+}`
+
+func TestGetInboundListens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(inboundPortsConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	inbounds, err := GetInboundListens(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []InboundListen{
+		{Index: 1, Tag: "mixed-in", Type: "mixed", Listen: "127.0.0.1", Port: 2080},
+		{Index: 2, Type: "hysteria2", Listen: "::", Port: 443},
+	}
+	if !reflect.DeepEqual(inbounds, want) {
+		t.Fatalf("GetInboundListens = %+v, want %+v", inbounds, want)
+	}
+	if inbounds[0].Address() != "127.0.0.1:2080" || inbounds[0].Network() != "tcp" {
+		t.Errorf("mixed inbound: %s %s", inbounds[0].Network(), inbounds[0].Address())
+	}
+	if inbounds[1].Address() != "[::]:443" || inbounds[1].Network() != "udp" || inbounds[1].Name() != "hysteria2[2]" {
+		t.Errorf("hysteria2 inbound: %s %s %s", inbounds[1].Network(), inbounds[1].Address(), inbounds[1].Name())
+	}
+}
+
+func TestSetInboundListenPort(t *testing.T) {
+	data := append([]byte("\xef\xbb\xbf"), inboundPortsConfig...)
+	out, err := SetInboundListenPort(data, 1, 12080)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append([]byte("\xef\xbb\xbf"), `{
+  // Local proxy
+  "inbounds": [
+    { "type": "tun", "tag": "tun-in", "interface_name": "singbox-tun0" },
+    { "type": "mixed", "tag": "mixed-in", "listen": "127.0.0.1", "listen_port": 12080 }, // system proxy
+    { "type": "hysteria2", "listen": "::", "listen_port": 443, },
+  ],
+}`...)
+	if string(out) != string(want) {
+		t.Errorf("SetInboundListenPort =\n%s\nwant\n%s", out, want)
+	}
+
+	if _, err := SetInboundListenPort(data, 0, 12080); err == nil {
+		t.Error("SetInboundListenPort of an inbound without listen_port succeeded")
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"singbox-launcher/core/config/schema"
+)
+
+// InstanceResources are the system resources sing-box takes when it runs a config. Instances running
+// side by side must not share them: a second listener on the same port or a second TUN interface
+// with the same name makes sing-box fail at start.
+type InstanceResources struct {
+	Listens       []InboundListen
+	TunInterfaces []string // interface_name of tun inbounds; unnamed interfaces are named by sing-box
+	ClashAPI      string   // experimental.clash_api.external_controller (host:port), "" if disabled
+}
+
+// GetInstanceResources reads the listen ports, TUN interface names and the Clash API address of config.json
+func GetInstanceResources(configPath string) (InstanceResources, error) {
+	listens, err := GetInboundListens(configPath)
+	if err != nil {
+		return InstanceResources{}, err
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return InstanceResources{}, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var config struct {
+		Inbounds []struct {
+			Type          string `json:"type"`
+			InterfaceName string `json:"interface_name"`
+		} `json:"inbounds"`
+		Experimental struct {
+			ClashAPI struct {
+				ExternalController string `json:"external_controller"`
+			} `json:"clash_api"`
+		} `json:"experimental"`
+	}
+	if err := json.Unmarshal(schema.StripComments(bytes.TrimPrefix(data, utf8BOM)), &config); err != nil {
+		return InstanceResources{}, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	resources := InstanceResources{
+		Listens:  listens,
+		ClashAPI: config.Experimental.ClashAPI.ExternalController,
+	}
+	for _, inbound := range config.Inbounds {
+		if inbound.Type == "tun" && inbound.InterfaceName != "" {
+			resources.TunInterfaces = append(resources.TunInterfaces, inbound.InterfaceName)
+		}
+	}
+	return resources, nil
+}
+
+// resourceListen is a listening socket of an instance
+type resourceListen struct {
+	network string
+	host    string
+	port    int
+	name    string
+}
+
+// listens returns the inbound listeners and the Clash API controller
+func (r InstanceResources) listens() []resourceListen {
+	var listens []resourceListen
+	for _, inbound := range r.Listens {
+		listens = append(listens, resourceListen{
+			network: inbound.Network(),
+			host:    inbound.Listen,
+			port:    inbound.Port,
+			name:    "inbound " + inbound.Name(),
+		})
+	}
+	if host, portText, err := net.SplitHostPort(r.ClashAPI); err == nil {
+		if port, err := strconv.Atoi(portText); err == nil && port > 0 {
+			listens = append(listens, resourceListen{network: "tcp", host: host, port: port, name: "Clash API"})
+		}
+	}
+	return listens
+}
+
+// ResourceConflicts describes the resources used by both configs: ports both listen on
+// (all interfaces overlap with any address) and TUN interfaces with the same name
+func ResourceConflicts(a, b InstanceResources) []string {
+	var conflicts []string
+	otherListens := b.listens()
+	for _, listen := range a.listens() {
+		for _, other := range otherListens {
+			if listen.network == other.network && listen.port == other.port && hostsOverlap(listen.host, other.host) {
+				conflicts = append(conflicts, fmt.Sprintf("%s port %d (%s, %s)", strings.ToUpper(listen.network), listen.port, listen.name, other.name))
+			}
+		}
+	}
+	for _, name := range a.TunInterfaces {
+		for _, other := range b.TunInterfaces {
+			if strings.EqualFold(name, other) {
+				conflicts = append(conflicts, fmt.Sprintf("TUN interface %q", name))
+			}
+		}
+	}
+	return conflicts
+}
+
+// hostsOverlap reports whether two listen addresses can take the same port
+func hostsOverlap(a, b string) bool {
+	if isWildcardHost(a) || isWildcardHost(b) {
+		return true
+	}
+	if ipA, ipB := net.ParseIP(a), net.ParseIP(b); ipA != nil && ipB != nil {
+		return ipA.Equal(ipB)
+	}
+	return strings.EqualFold(a, b)
+}
+
+// isWildcardHost reports whether a listen address means all interfaces
+func isWildcardHost(host string) bool {
+	if host == "" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsUnspecified()
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGetInstanceResources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := inboundPortsConfig[:len(inboundPortsConfig)-2] + `
+  "experimental": { "clash_api": { "external_controller": "127.0.0.1:9090", "secret": "s" } },
+}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resources, err := GetInstanceResources(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources.Listens) != 2 || resources.ClashAPI != "127.0.0.1:9090" {
+		t.Errorf("listens = %+v, clash API = %q", resources.Listens, resources.ClashAPI)
+	}
+	if !reflect.DeepEqual(resources.TunInterfaces, []string{"singbox-tun0"}) {
+		t.Errorf("TUN interfaces = %v", resources.TunInterfaces)
+	}
+}
+
+func TestResourceConflicts(t *testing.T) {
+	tun := InstanceResources{
+		Listens:       []InboundListen{{Index: 1, Tag: "mixed-in", Type: "mixed", Listen: "127.0.0.1", Port: 2080}},
+		TunInterfaces: []string{"singbox-tun0"},
+		ClashAPI:      "127.0.0.1:9090",
+	}
+
+	socks := InstanceResources{
+		Listens:  []InboundListen{{Tag: "socks-in", Type: "socks", Listen: "127.0.0.1", Port: 1080}},
+		ClashAPI: "127.0.0.1:9091",
+	}
+	if conflicts := ResourceConflicts(tun, socks); len(conflicts) != 0 {
+		t.Errorf("separate ports and controllers: conflicts %v", conflicts)
+	}
+
+	// All interfaces overlap with loopback; the Clash API controller is a listener too; TUN names match
+	clash := InstanceResources{
+		Listens:       []InboundListen{{Tag: "socks-in", Type: "socks", Port: 2080}},
+		TunInterfaces: []string{"SingBox-Tun0"},
+		ClashAPI:      "127.0.0.1:9090",
+	}
+	want := []string{
+		"TCP port 2080 (inbound mixed-in, inbound socks-in)",
+		"TCP port 9090 (Clash API, Clash API)",
+		`TUN interface "singbox-tun0"`,
+	}
+	if conflicts := ResourceConflicts(tun, clash); !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("conflicts = %q, want %q", conflicts, want)
+	}
+
+	// Same port number over UDP and TCP, or on different addresses, does not conflict
+	quic := InstanceResources{Listens: []InboundListen{{Type: "hysteria2", Listen: "127.0.0.1", Port: 2080}}}
+	other := InstanceResources{Listens: []InboundListen{{Type: "mixed", Listen: "192.168.1.10", Port: 2080}}}
+	if conflicts := ResourceConflicts(tun, quic); len(conflicts) != 0 {
+		t.Errorf("UDP and TCP: conflicts %v", conflicts)
+	}
+	if conflicts := ResourceConflicts(tun, other); len(conflicts) != 0 {
+		t.Errorf("different addresses: conflicts %v", conflicts)
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import "strings"
+
+// DiffLine is one line of a line-based diff
+type DiffLine struct {
+	Op   byte   // ' ' unchanged, '-' removed, '+' added
+	Text string // Line without trailing newline
+}
+
+// maxDiffMatrixCells limits the LCS table; larger changed regions are shown as remove+add
+const maxDiffMatrixCells = 4_000_000
+
+// DiffLines returns a line diff between a and b. Common prefix and suffix are trimmed before
+// running LCS on the changed middle, so small edits of large configs stay cheap.
+func DiffLines(a, b string) []DiffLine {
+	oldLines := strings.Split(strings.ReplaceAll(a, "\r\n", "\n"), "\n")
+	newLines := strings.Split(strings.ReplaceAll(b, "\r\n", "\n"), "\n")
+
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	result := make([]DiffLine, 0, len(newLines))
+	for _, line := range oldLines[:prefix] {
+		result = append(result, DiffLine{Op: ' ', Text: line})
+	}
+	result = append(result, diffMiddle(oldLines[prefix:len(oldLines)-suffix], newLines[prefix:len(newLines)-suffix])...)
+	for _, line := range oldLines[len(oldLines)-suffix:] {
+		result = append(result, DiffLine{Op: ' ', Text: line})
+	}
+	return result
+}
+
+// diffMiddle diffs the changed region with a classic LCS table
+func diffMiddle(oldLines, newLines []string) []DiffLine {
+	n, m := len(oldLines), len(newLines)
+	result := make([]DiffLine, 0, n+m)
+	if n*m > maxDiffMatrixCells {
+		for _, line := range oldLines {
+			result = append(result, DiffLine{Op: '-', Text: line})
+		}
+		for _, line := range newLines {
+			result = append(result, DiffLine{Op: '+', Text: line})
+		}
+		return result
+	}
+
+	// lcs[i][j] = LCS length of oldLines[i:] and newLines[j:]
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case oldLines[i] == newLines[j]:
+			result = append(result, DiffLine{Op: ' ', Text: oldLines[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, DiffLine{Op: '-', Text: oldLines[i]})
+			i++
+		default:
+			result = append(result, DiffLine{Op: '+', Text: newLines[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		result = append(result, DiffLine{Op: '-', Text: oldLines[i]})
+	}
+	for ; j < m; j++ {
+		result = append(result, DiffLine{Op: '+', Text: newLines[j]})
+	}
+	return result
+}
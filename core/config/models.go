@@ -1,44 +1,171 @@
 package config
 
 import (
+	"fmt"
 	"net/url"
+	"strings"
 	"time"
 )
 
 // ParserConfigVersion is the current version of ParserConfig format
-const ParserConfigVersion = 4
+const ParserConfigVersion = 5
 
 // SubscriptionUserAgent is the User-Agent string used for fetching subscriptions
 // Using neutral User-Agent to avoid server detecting sing-box and returning JSON config
 const SubscriptionUserAgent = "SubscriptionParserClient"
 
-// MaxNodesPerSubscription limits the maximum number of nodes parsed from a single subscription
-// This prevents memory issues with very large subscriptions
-const MaxNodesPerSubscription = 500
-
 // ParserConfig represents the configuration structure from @ParserConfig block
-// Clean structure for version 4 (legacy versions are migrated automatically)
+// Clean structure for version 5 (legacy versions are migrated automatically)
 type ParserConfig struct {
 	ParserConfig struct {
 		Version   int              `json:"version,omitempty"`
 		Proxies   []ProxySource    `json:"proxies"`
 		Outbounds []OutboundConfig `json:"outbounds"`
-		Parser    struct {
-			Reload      string `json:"reload,omitempty"`       // Интервал автоматического обновления
-			LastUpdated string `json:"last_updated,omitempty"` // Время последнего обновления (RFC3339, UTC)
-		} `json:"parser,omitempty"`
+		Parser    ParserSettings   `json:"parser,omitempty"`
 	} `json:"ParserConfig"`
 }
 
+// ParserSettings represents the "parser" section of ParserConfig (global parser settings)
+type ParserSettings struct {
+	Reload      string `json:"reload,omitempty"`       // Интервал ("4h") или cron-выражение ("0 6 * * *") автоматического обновления
+	LastUpdated string `json:"last_updated,omitempty"` // Время последнего обновления (RFC3339, UTC)
+	Limit       *int   `json:"limit,omitempty"`        // Лимит узлов на источник по умолчанию (0 = без ограничений, нет = DefaultNodeLimit)
+	// Автообновление требует подтверждения, если удаляется больше указанного процента узлов (0 = не требуется)
+	ApprovalThreshold int `json:"approval_threshold,omitempty"`
+	// История версий config.json: сколько снимков хранить (0 = по умолчанию) и максимальный возраст ("720h", пусто = без ограничения)
+	HistoryLimit  int    `json:"history_limit,omitempty"`
+	HistoryMaxAge string `json:"history_max_age,omitempty"`
+	// Что делать с запущенным sing-box после успешного обновления: "" / "off", "restart" или "reload"
+	ApplyOnUpdate string `json:"apply_on_update,omitempty"`
+	// Окно, в которое автоматическое обновление не выполняется ("23:00-07:00", локальное время)
+	QuietHours string `json:"quiet_hours,omitempty"`
+}
+
+// DefaultNodeLimit is the node limit of a source when neither the source nor parser.limit sets one
+const DefaultNodeLimit = 500
+
+// NodeLimit returns parser.limit, DefaultNodeLimit if it is not set (0 = unlimited)
+func (s ParserSettings) NodeLimit() int {
+	if s.Limit == nil {
+		return DefaultNodeLimit
+	}
+	return *s.Limit
+}
+
+// Values of parser.apply_on_update
+const (
+	ApplyOnUpdateOff     = "off"     // Do not touch the running process (default)
+	ApplyOnUpdateRestart = "restart" // Restart sing-box with the new config
+	ApplyOnUpdateReload  = "reload"  // Ask sing-box to reload the config (SIGHUP), restart where unsupported
+)
+
+// ApplyPolicy returns the normalized parser.apply_on_update value.
+// Unknown values are treated as ApplyOnUpdateOff.
+func (s ParserSettings) ApplyPolicy() string {
+	switch strings.ToLower(strings.TrimSpace(s.ApplyOnUpdate)) {
+	case ApplyOnUpdateRestart:
+		return ApplyOnUpdateRestart
+	case ApplyOnUpdateReload:
+		return ApplyOnUpdateReload
+	default:
+		return ApplyOnUpdateOff
+	}
+}
+
 // ProxySource represents a proxy subscription source
 type ProxySource struct {
-	Source      string              `json:"source,omitempty"`
-	Connections []string            `json:"connections,omitempty"`
-	Skip        []map[string]string `json:"skip,omitempty"`
-	Outbounds   []OutboundConfig    `json:"outbounds,omitempty"`   // Local outbounds for this source (version 4)
-	TagPrefix   string              `json:"tag_prefix,omitempty"`  // Prefix to add to all node tags from this source
-	TagPostfix  string              `json:"tag_postfix,omitempty"` // Postfix to add to all node tags from this source
-	TagMask     string              `json:"tag_mask,omitempty"`    // Mask to replace entire tag (ignores tag_prefix and tag_postfix if set)
+	ID          string                 `json:"id,omitempty"`      // Stable identifier of the source (version 5, assigned automatically)
+	Name        string                 `json:"name,omitempty"`    // Human-readable name shown in the wizard and reports (version 5)
+	Enabled     *bool                  `json:"enabled,omitempty"` // false = source is kept but not used (version 5, missing = enabled)
+	Source      string                 `json:"source,omitempty"`
+	Connections []string               `json:"connections,omitempty"`
+	Skip        []map[string]string    `json:"skip,omitempty"`
+	Outbounds   []OutboundConfig       `json:"outbounds,omitempty"`   // Local outbounds for this source (version 4)
+	TagPrefix   string                 `json:"tag_prefix,omitempty"`  // Prefix to add to all node tags from this source
+	TagPostfix  string                 `json:"tag_postfix,omitempty"` // Postfix to add to all node tags from this source
+	TagMask     string                 `json:"tag_mask,omitempty"`    // Mask to replace entire tag (ignores tag_prefix and tag_postfix if set)
+	Detour      string                 `json:"detour,omitempty"`      // Outbound tag set as "detour" on every node from this source
+	Override    map[string]interface{} `json:"override,omitempty"`    // Fields deep-merged into every node outbound (whitelisted, see OverrideWhitelist)
+	Limit       *int                   `json:"limit,omitempty"`       // Max nodes from this source (0 = unlimited, missing = parser.limit)
+	Reload      string                 `json:"reload,omitempty"`      // Reload interval or cron expression for this source (missing = parser.reload)
+}
+
+// NodeLimit returns the effective node limit for this source (0 = unlimited).
+// The per-source limit takes precedence over globalLimit (parser.limit).
+func (ps *ProxySource) NodeLimit(globalLimit int) int {
+	limit := globalLimit
+	if ps.Limit != nil {
+		limit = *ps.Limit
+	}
+	if limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// IsEnabled reports whether the source is used when generating the config
+func (ps *ProxySource) IsEnabled() bool {
+	return ps.Enabled == nil || *ps.Enabled
+}
+
+// SetEnabled enables or disables the source. Enabled sources drop the flag (missing = enabled).
+func (ps *ProxySource) SetEnabled(enabled bool) {
+	if enabled {
+		ps.Enabled = nil
+		return
+	}
+	disabled := false
+	ps.Enabled = &disabled
+}
+
+// DisplayName returns the name of the source at the given 0-based index:
+// its name, otherwise the subscription host, otherwise "source #N".
+func (ps *ProxySource) DisplayName(index int) string {
+	if name := strings.TrimSpace(ps.Name); name != "" {
+		return name
+	}
+	if ps.Source != "" {
+		if u, err := url.Parse(ps.Source); err == nil && u.Hostname() != "" {
+			return u.Hostname()
+		}
+	}
+	return fmt.Sprintf("source #%d", index+1)
+}
+
+// SourceID derives an identifier from the source content (subscription URL or connections),
+// so the same ParserConfig always gets the same IDs until they are written back.
+func SourceID(ps ProxySource) string {
+	content := ps.Source
+	if content == "" {
+		content = strings.Join(ps.Connections, "\n")
+	}
+	if content == "" {
+		return "src"
+	}
+	return "src-" + HashSourceURL(content)[:8]
+}
+
+// AssignSourceIDs sets an ID on every source that has none. IDs stay unique:
+// a derived ID that is already taken gets a numeric suffix.
+func AssignSourceIDs(proxies []ProxySource) {
+	used := make(map[string]bool, len(proxies))
+	for _, ps := range proxies {
+		if ps.ID != "" {
+			used[ps.ID] = true
+		}
+	}
+	for i := range proxies {
+		if proxies[i].ID != "" {
+			continue
+		}
+		base := SourceID(proxies[i])
+		id := base
+		for n := 2; used[id]; n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		proxies[i].ID = id
+		used[id] = true
+	}
 }
 
 // OutboundConfig represents an outbound selector configuration (version 3)
@@ -58,7 +185,18 @@ type OutboundConfig struct {
 	AddOutbounds     []string               `json:"addOutbounds,omitempty"`
 	PreferredDefault map[string]interface{} `json:"preferredDefault,omitempty"`
 	Comment          string                 `json:"comment,omitempty"`
-	Wizard           interface{}            `json:"wizard,omitempty"` // Supports both "hide" (string) and {"hide":true, "required":2} (object) for backward compatibility
+	Wizard           interface{}            `json:"wizard,omitempty"`   // Supports both "hide" (string) and {"hide":true, "required":2} (object) for backward compatibility
+	GroupBy          *GroupByConfig         `json:"group_by,omitempty"` // Template: one outbound per distinct group value (tag may contain {$group})
+	Detour           string                 `json:"detour,omitempty"`   // Outbound tag set as "detour" on every node of this outbound (source detour takes precedence)
+}
+
+// GroupByConfig describes a group_by outbound template.
+// Nodes are split by the value of Key (optionally narrowed by the first capture of Pattern),
+// and one outbound is generated per distinct value. Tag and comment of the template may use {$group}.
+type GroupByConfig struct {
+	Key     string          `json:"key,omitempty"`     // tag, host, label, scheme, fragment, comment, source or flag (default: tag)
+	Pattern string          `json:"pattern,omitempty"` // Optional regex ("re" or "/re/i"); first capture group (or whole match) becomes the group value
+	Parent  *OutboundConfig `json:"parent,omitempty"`  // Optional parent outbound listing all generated groups
 }
 
 // IsWizardHidden checks if outbound should be hidden from wizard
@@ -121,6 +259,7 @@ type ParsedNode struct {
 // NormalizeParserConfig normalizes ParserConfig structure:
 // - Ensures version is set to ParserConfigVersion
 // - Sets default reload to "4h" if not specified
+// - Assigns IDs to sources that have none
 // - Optionally updates last_updated timestamp (if updateLastUpdated is true)
 // Note: Migration is handled in ExtractParserConfig
 // This function works with already-migrated clean ParserConfig
@@ -138,6 +277,8 @@ func NormalizeParserConfig(parserConfig *ParserConfig, updateLastUpdated bool) {
 		parserConfig.ParserConfig.Parser.Reload = "4h"
 	}
 
+	AssignSourceIDs(parserConfig.ParserConfig.Proxies)
+
 	// Optionally update last_updated timestamp
 	if updateLastUpdated {
 		parserConfig.ParserConfig.Parser.LastUpdated = time.Now().UTC().Format(time.RFC3339)
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAssignSourceIDs(t *testing.T) {
+	proxies := []ProxySource{
+		{Source: "https://a.example.com/sub"},
+		{ID: "mine", Source: "https://b.example.com/sub"},
+		{Source: "https://a.example.com/sub"}, // Duplicate source gets a suffixed id
+		{Connections: []string{"trojan://pass@one.example.com:443#one"}},
+	}
+	AssignSourceIDs(proxies)
+
+	if proxies[1].ID != "mine" {
+		t.Errorf("existing id changed: %q", proxies[1].ID)
+	}
+	if !strings.HasPrefix(proxies[0].ID, "src-") || proxies[2].ID != proxies[0].ID+"-2" {
+		t.Errorf("unexpected ids for duplicate sources: %q, %q", proxies[0].ID, proxies[2].ID)
+	}
+	seen := make(map[string]bool)
+	for _, ps := range proxies {
+		if ps.ID == "" || seen[ps.ID] {
+			t.Errorf("id %q is empty or not unique", ps.ID)
+		}
+		seen[ps.ID] = true
+	}
+
+	// Derived ids are stable
+	again := []ProxySource{{Source: "https://a.example.com/sub"}}
+	AssignSourceIDs(again)
+	if again[0].ID != proxies[0].ID {
+		t.Errorf("id is not stable: %q vs %q", again[0].ID, proxies[0].ID)
+	}
+}
+
+func TestProxySource_EnabledAndDisplayName(t *testing.T) {
+	ps := ProxySource{Source: "https://sub.example.com/path?token=secret"}
+	if !ps.IsEnabled() {
+		t.Error("source without the flag must be enabled")
+	}
+	ps.SetEnabled(false)
+	if ps.IsEnabled() || ps.Enabled == nil {
+		t.Error("source must be disabled")
+	}
+	ps.SetEnabled(true)
+	if !ps.IsEnabled() || ps.Enabled != nil {
+		t.Error("enabling must drop the flag")
+	}
+
+	if got := ps.DisplayName(0); got != "sub.example.com" {
+		t.Errorf("DisplayName = %q, want host", got)
+	}
+	ps.Name = "Work"
+	if got := ps.DisplayName(0); got != "Work" {
+		t.Errorf("DisplayName = %q, want name", got)
+	}
+	connections := ProxySource{Connections: []string{"vless://x@y:443"}}
+	if got := connections.DisplayName(2); got != "source #3" {
+		t.Errorf("DisplayName = %q", got)
+	}
+}
+
+func TestGenerateOutbounds_SkipsDisabledSource(t *testing.T) {
+	pc := &ParserConfig{}
+	disabled := ProxySource{
+		Source:    "b",
+		Name:      "Backup",
+		Outbounds: []OutboundConfig{{Tag: "backup-select", Type: "selector"}},
+	}
+	disabled.SetEnabled(false)
+	pc.ParserConfig.Proxies = []ProxySource{{Source: "a"}, disabled}
+	pc.ParserConfig.Outbounds = []OutboundConfig{{Tag: "proxy-out", Type: "selector"}}
+
+	loaded := 0
+	loadNodes := func(_ ProxySource, _ map[string]int, _ func(float64, string), idx, _ int, _ *SourceReport) ([]*ParsedNode, error) {
+		loaded++
+		return []*ParsedNode{{Tag: "node-" + string(rune('a'+idx)), Scheme: "vless", Server: "s.example.com", Port: 443, Outbound: map[string]interface{}{}}}, nil
+	}
+	report := &ParseReport{}
+	result, err := GenerateOutboundsFromParserConfig(pc, map[string]int{}, nil, loadNodes, report)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if loaded != 1 {
+		t.Errorf("disabled source was loaded, loads = %d", loaded)
+	}
+	joined := strings.Join(result.OutboundsJSON, "\n")
+	if strings.Contains(joined, "node-b") || strings.Contains(joined, "backup-select") {
+		t.Errorf("disabled source produced outbounds:\n%s", joined)
+	}
+	if len(report.Sources) != 2 || !report.Sources[1].Disabled || report.Sources[1].Name != "Backup" {
+		t.Errorf("disabled source not reported: %+v", report.Sources)
+	}
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OverrideWhitelist lists outbound fields (dot-separated paths) that ProxySource.override may set.
+// Everything else is ignored: override is meant for dial/TLS tuning, not for rewriting node credentials.
+var OverrideWhitelist = map[string]string{
+	"tcp_fast_open":        "bool",
+	"domain_strategy":      "domain_strategy",
+	"connect_timeout":      "duration",
+	"bind_interface":       "string",
+	"packet_encoding":      "string",
+	"tls.utls.fingerprint": "string",
+}
+
+// domainStrategies are the values sing-box accepts for the dial field "domain_strategy"
+var domainStrategies = []string{"prefer_ipv4", "prefer_ipv6", "ipv4_only", "ipv6_only"}
+
+// ApplyOutboundOverride deep-merges whitelisted fields from override into a node outbound.
+// Override may be nested ({"tls":{"utls":{"fingerprint":"chrome"}}}) or use dotted keys ("tls.utls.fingerprint").
+// TLS fields are applied only to nodes that already have TLS.
+// Returns paths that were ignored (not whitelisted, wrong type or not applicable).
+func ApplyOutboundOverride(outbound map[string]interface{}, override map[string]interface{}) []string {
+	if len(override) == 0 || outbound == nil {
+		return nil
+	}
+
+	flat := make(map[string]interface{})
+	flattenOverride("", override, flat)
+
+	paths := make([]string, 0, len(flat))
+	for path := range flat {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	ignored := make([]string, 0)
+	for _, path := range paths {
+		value := flat[path]
+		expectedType, ok := OverrideWhitelist[path]
+		if !ok || checkOverrideValue(value, expectedType) != nil {
+			ignored = append(ignored, path)
+			continue
+		}
+
+		keys := strings.Split(path, ".")
+		if keys[0] == "tls" {
+			if _, hasTLS := outbound["tls"].(map[string]interface{}); !hasTLS {
+				ignored = append(ignored, path)
+				continue
+			}
+		}
+		setOutboundPath(outbound, keys, value)
+	}
+	return ignored
+}
+
+// ValidateOverride checks ProxySource.override and returns a message for every field that is
+// not allowed or has an invalid value (such fields are ignored when the override is applied).
+func ValidateOverride(override map[string]interface{}) []string {
+	flat := make(map[string]interface{})
+	flattenOverride("", override, flat)
+
+	paths := make([]string, 0, len(flat))
+	for path := range flat {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	problems := make([]string, 0)
+	for _, path := range paths {
+		expectedType, ok := OverrideWhitelist[path]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("override.%s: field is not allowed", path))
+			continue
+		}
+		if err := checkOverrideValue(flat[path], expectedType); err != nil {
+			problems = append(problems, fmt.Sprintf("override.%s: %v", path, err))
+		}
+	}
+	return problems
+}
+
+// applySourceOverride applies ProxySource.override to every node of the source.
+// Returns the problems of the override (see ValidateOverride); TLS fields skipped for nodes
+// without TLS are expected and not reported.
+func applySourceOverride(nodes []*ParsedNode, override map[string]interface{}) []string {
+	if len(override) == 0 {
+		return nil
+	}
+	problems := ValidateOverride(override)
+	for _, problem := range problems {
+		log.Printf("Parser: Warning: %s, ignored", problem)
+	}
+	for _, node := range nodes {
+		if node.Outbound == nil {
+			node.Outbound = make(map[string]interface{})
+		}
+		ApplyOutboundOverride(node.Outbound, override)
+	}
+	return problems
+}
+
+func flattenOverride(prefix string, value map[string]interface{}, out map[string]interface{}) {
+	for key, v := range value {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if nested, ok := v.(map[string]interface{}); ok {
+			flattenOverride(path, nested, out)
+			continue
+		}
+		out[path] = v
+	}
+}
+
+// checkOverrideValue checks that value is valid for a whitelisted field of expectedType
+func checkOverrideValue(value interface{}, expectedType string) error {
+	if expectedType == "bool" {
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%v is not a boolean", value)
+		}
+		return nil
+	}
+
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return fmt.Errorf("%v is not a non-empty string", value)
+	}
+	switch expectedType {
+	case "string":
+		return nil
+	case "domain_strategy":
+		for _, strategy := range domainStrategies {
+			if s == strategy {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of %s", s, strings.Join(domainStrategies, ", "))
+	case "duration":
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration (e.g. \"5s\")", s)
+		}
+		if d <= 0 {
+			return fmt.Errorf("%q must be positive", s)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown field type %s", expectedType)
+	}
+}
+
+// setOutboundPath sets value at keys, creating intermediate maps as needed.
+// A newly created "utls" object is enabled, otherwise sing-box ignores the fingerprint.
+func setOutboundPath(outbound map[string]interface{}, keys []string, value interface{}) {
+	current := outbound
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			if key == "utls" {
+				next["enabled"] = true
+			}
+			current[key] = next
+		}
+		current = next
+	}
+	current[keys[len(keys)-1]] = value
+}
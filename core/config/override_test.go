@@ -0,0 +1,112 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyOutboundOverride(t *testing.T) {
+	t.Run("whitelisted fields are deep-merged", func(t *testing.T) {
+		outbound := map[string]interface{}{
+			"tls": map[string]interface{}{
+				"enabled": true,
+				"utls":    map[string]interface{}{"enabled": true, "fingerprint": "random"},
+			},
+		}
+		ignored := ApplyOutboundOverride(outbound, map[string]interface{}{
+			"tcp_fast_open":   true,
+			"domain_strategy": "prefer_ipv4",
+			"tls":             map[string]interface{}{"utls": map[string]interface{}{"fingerprint": "chrome"}},
+		})
+		if len(ignored) != 0 {
+			t.Errorf("unexpected ignored fields: %v", ignored)
+		}
+		if outbound["tcp_fast_open"] != true || outbound["domain_strategy"] != "prefer_ipv4" {
+			t.Errorf("dial fields not applied: %v", outbound)
+		}
+		utls := outbound["tls"].(map[string]interface{})["utls"].(map[string]interface{})
+		if utls["fingerprint"] != "chrome" || utls["enabled"] != true {
+			t.Errorf("fingerprint not merged: %v", utls)
+		}
+	})
+
+	t.Run("non-whitelisted and wrong types are ignored", func(t *testing.T) {
+		outbound := map[string]interface{}{"server": "example.com"}
+		ignored := ApplyOutboundOverride(outbound, map[string]interface{}{
+			"server":        "evil.example.com",
+			"tcp_fast_open": "yes",
+		})
+		if strings.Join(ignored, ",") != "server,tcp_fast_open" {
+			t.Errorf("unexpected ignored fields: %v", ignored)
+		}
+		if outbound["server"] != "example.com" {
+			t.Errorf("server must not be overridden")
+		}
+	})
+
+	t.Run("tls override skipped for nodes without tls", func(t *testing.T) {
+		outbound := map[string]interface{}{}
+		ignored := ApplyOutboundOverride(outbound, map[string]interface{}{"tls.utls.fingerprint": "chrome"})
+		if len(ignored) != 1 {
+			t.Errorf("expected tls override to be ignored, got %v", ignored)
+		}
+		if _, ok := outbound["tls"]; ok {
+			t.Errorf("tls must not be created for nodes without tls")
+		}
+	})
+}
+
+func TestValidateOverride(t *testing.T) {
+	problems := ValidateOverride(map[string]interface{}{
+		"domain_strategy": "ipv4",
+		"connect_timeout": "5 seconds",
+		"tcp_fast_open":   true,
+		"server":          "evil.example.com",
+	})
+	if len(problems) != 3 ||
+		!strings.HasPrefix(problems[0], "override.connect_timeout:") ||
+		!strings.HasPrefix(problems[1], "override.domain_strategy:") ||
+		problems[2] != "override.server: field is not allowed" {
+		t.Errorf("problems = %q", problems)
+	}
+	if problems := ValidateOverride(map[string]interface{}{"domain_strategy": "ipv6_only", "connect_timeout": "1m30s"}); len(problems) != 0 {
+		t.Errorf("valid override reported: %q", problems)
+	}
+	if problems := ValidateOverride(map[string]interface{}{"connect_timeout": "-5s"}); len(problems) != 1 {
+		t.Errorf("negative timeout not reported: %q", problems)
+	}
+
+	// Invalid values are not applied and end up in the parse report
+	pc := &ParserConfig{}
+	pc.ParserConfig.Proxies = []ProxySource{{Source: "a", Override: map[string]interface{}{"domain_strategy": "ipv4", "connect_timeout": "10s"}}}
+	node := &ParsedNode{Tag: "n1", Scheme: "vless", Server: "a.example.com", Port: 443, Outbound: map[string]interface{}{}}
+	loadNodes := func(ProxySource, map[string]int, func(float64, string), int, int, *SourceReport) ([]*ParsedNode, error) {
+		return []*ParsedNode{node}, nil
+	}
+	report := &ParseReport{}
+	if _, err := GenerateOutboundsFromParserConfig(pc, map[string]int{}, nil, loadNodes, report); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := node.Outbound["domain_strategy"]; ok || node.Outbound["connect_timeout"] != "10s" {
+		t.Errorf("outbound = %v", node.Outbound)
+	}
+	if errs := report.Sources[0].OverrideErrors; len(errs) != 1 || !strings.HasPrefix(errs[0], "override.domain_strategy:") {
+		t.Errorf("override errors in report = %q", errs)
+	}
+}
+
+func TestGenerateNodeJSON_OverrideFields(t *testing.T) {
+	node := &ParsedNode{
+		Tag: "n1", Scheme: "trojan", Server: "example.com", Port: 443, UUID: "pass",
+		Outbound: map[string]interface{}{},
+	}
+	ApplyOutboundOverride(node.Outbound, map[string]interface{}{"tcp_fast_open": true, "bind_interface": "eth0"})
+
+	nodeJSON, err := GenerateNodeJSON(node)
+	if err != nil {
+		t.Fatalf("GenerateNodeJSON failed: %v", err)
+	}
+	if !strings.Contains(nodeJSON, `"bind_interface":"eth0"`) || !strings.Contains(nodeJSON, `"tcp_fast_open":true`) {
+		t.Errorf("override fields missing in node JSON: %s", nodeJSON)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"singbox-launcher/core/config"
+	"singbox-launcher/core/config/schema"
 )
 
 // MaxConfigFileSize defines the maximum allowed size for config.json file
@@ -47,6 +48,12 @@ func ExtractParserConfig(configPath string) (*config.ParserConfig, error) {
 		return nil, fmt.Errorf("extracted @ParserConfig JSON size (%d bytes) exceeds maximum (%d bytes)", len(jsonContent), MaxConfigFileSize)
 	}
 
+	// Schema problems are only logged: the block is still loaded (unknown fields are ignored).
+	// Lines and columns are relative to the @ParserConfig block.
+	for _, schemaErr := range schema.ValidateParserConfig([]byte(jsonContent)) {
+		log.Printf("ExtractParserConfig: @ParserConfig schema: %v", schemaErr)
+	}
+
 	// Extract version from JSON to check if migration is needed
 	currentVersion := ExtractVersion(jsonContent)
 
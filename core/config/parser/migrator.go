@@ -15,10 +15,7 @@ type v1ParserConfig struct {
 	ParserConfig struct {
 		Proxies   []config.ProxySource    `json:"proxies"`
 		Outbounds []config.OutboundConfig `json:"outbounds"`
-		Parser    struct {
-			Reload      string `json:"reload,omitempty"`
-			LastUpdated string `json:"last_updated,omitempty"`
-		} `json:"parser,omitempty"`
+		Parser    config.ParserSettings   `json:"parser,omitempty"`
 	} `json:"ParserConfig"`
 }
 
@@ -44,13 +41,10 @@ type v2OutboundConfig struct {
 // Version 2 has version inside ParserConfig and outbounds with nested structure
 type v2ParserConfig struct {
 	ParserConfig struct {
-		Version   int                  `json:"version,omitempty"`
-		Proxies   []config.ProxySource `json:"proxies"`
-		Outbounds []v2OutboundConfig   `json:"outbounds"` // Version 2 format with nested outbounds
-		Parser    struct {
-			Reload      string `json:"reload,omitempty"`
-			LastUpdated string `json:"last_updated,omitempty"`
-		} `json:"parser,omitempty"`
+		Version   int                   `json:"version,omitempty"`
+		Proxies   []config.ProxySource  `json:"proxies"`
+		Outbounds []v2OutboundConfig    `json:"outbounds"` // Version 2 format with nested outbounds
+		Parser    config.ParserSettings `json:"parser,omitempty"`
 	} `json:"ParserConfig"`
 }
 
@@ -73,6 +67,7 @@ func NewConfigMigrator() *ConfigMigrator {
 	migrator.RegisterMigration(1, migrateV1ToV2)
 	migrator.RegisterMigration(2, migrateV2ToV3)
 	migrator.RegisterMigration(3, migrateV3ToV4)
+	migrator.RegisterMigration(4, migrateV4ToV5)
 
 	return migrator
 }
@@ -178,13 +173,10 @@ func migrateV1ToV2(jsonContent string) (string, error) {
 	// Convert to version 2 structure
 	v2 := v2ParserConfig{
 		ParserConfig: struct {
-			Version   int                  `json:"version,omitempty"`
-			Proxies   []config.ProxySource `json:"proxies"`
-			Outbounds []v2OutboundConfig   `json:"outbounds"`
-			Parser    struct {
-				Reload      string `json:"reload,omitempty"`
-				LastUpdated string `json:"last_updated,omitempty"`
-			} `json:"parser,omitempty"`
+			Version   int                   `json:"version,omitempty"`
+			Proxies   []config.ProxySource  `json:"proxies"`
+			Outbounds []v2OutboundConfig    `json:"outbounds"`
+			Parser    config.ParserSettings `json:"parser,omitempty"`
 		}{
 			Version:   2,
 			Proxies:   v1.ParserConfig.Proxies,
@@ -245,10 +237,7 @@ func migrateV2ToV3(jsonContent string) (string, error) {
 			Version   int                     `json:"version,omitempty"`
 			Proxies   []config.ProxySource    `json:"proxies"`
 			Outbounds []config.OutboundConfig `json:"outbounds"`
-			Parser    struct {
-				Reload      string `json:"reload,omitempty"`
-				LastUpdated string `json:"last_updated,omitempty"`
-			} `json:"parser,omitempty"`
+			Parser    config.ParserSettings   `json:"parser,omitempty"`
 		}{
 			Version:   3,
 			Proxies:   v2.ParserConfig.Proxies,
@@ -330,3 +319,22 @@ func migrateV3ToV4(jsonContent string) (string, error) {
 	log.Printf("migrateV3ToV4: Successfully migrated from version 3 to version 4")
 	return string(resultJSON), nil
 }
+
+// migrateV4ToV5 migrates JSON content from version 4 to version 5
+// Version 5 adds "id", "name" and "enabled" to ProxySource; every source gets an ID
+// derived from its content, sources stay enabled and unnamed.
+// Takes JSON string, returns migrated JSON string
+func migrateV4ToV5(jsonContent string) (string, error) {
+	var v4 config.ParserConfig
+	if err := json.Unmarshal([]byte(jsonContent), &v4); err != nil {
+		return "", fmt.Errorf("failed to parse version 4 config: %w", err)
+	}
+	v4.ParserConfig.Version = 5
+	config.AssignSourceIDs(v4.ParserConfig.Proxies)
+	resultJSON, err := json.MarshalIndent(v4, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal version 5 config: %w", err)
+	}
+	log.Printf("migrateV4ToV5: Successfully migrated from version 4 to version 5 (%d sources)", len(v4.ParserConfig.Proxies))
+	return string(resultJSON), nil
+}
@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"singbox-launcher/core/config"
+)
+
+func TestMigrateV4ToV5(t *testing.T) {
+	v4 := `{
+  "ParserConfig": {
+    "version": 4,
+    "proxies": [
+      {"source": "https://a.example.com/sub", "tag_prefix": "A:"},
+      {"connections": ["trojan://pass@one.example.com:443#one"]}
+    ],
+    "outbounds": [{"tag": "proxy-out", "type": "selector"}]
+  }
+}`
+	parserConfig, err := NewConfigMigrator().MigrateRaw(v4, 4, config.ParserConfigVersion)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if parserConfig.ParserConfig.Version != 5 {
+		t.Errorf("version = %d, want 5", parserConfig.ParserConfig.Version)
+	}
+	proxies := parserConfig.ParserConfig.Proxies
+	if len(proxies) != 2 || proxies[0].TagPrefix != "A:" {
+		t.Fatalf("sources not preserved: %+v", proxies)
+	}
+	for i, ps := range proxies {
+		if !strings.HasPrefix(ps.ID, "src-") || !ps.IsEnabled() || ps.Name != "" {
+			t.Errorf("source %d: unexpected v5 fields: id=%q enabled=%v name=%q", i, ps.ID, ps.IsEnabled(), ps.Name)
+		}
+	}
+	if proxies[0].ID == proxies[1].ID {
+		t.Errorf("ids are not unique: %q", proxies[0].ID)
+	}
+
+	// Migration is deterministic, so ids don't change until the config is written back
+	again, err := NewConfigMigrator().MigrateRaw(v4, 4, config.ParserConfigVersion)
+	if err != nil || again.ParserConfig.Proxies[0].ID != proxies[0].ID {
+		t.Errorf("migration is not deterministic: %v", err)
+	}
+}
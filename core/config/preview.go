@@ -0,0 +1,216 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/muhammadmuzzammil1998/jsonc"
+)
+
+// Markers of the generated outbounds block in config.json
+const (
+	parserStartMarker = "/** @ParserSTART */"
+	parserEndMarker   = "/** @ParserEND */"
+)
+
+// ErrUpdateCancelled is returned by UpdateConfigFromSubscriptions when the preview was not approved
+var ErrUpdateCancelled = errors.New("configuration update cancelled")
+
+// ApprovalFunc decides whether a computed update may be written to config.json.
+// It may block (e.g. while the user reviews the diff).
+type ApprovalFunc func(diff *ConfigDiff) bool
+
+// SelectorChange describes membership changes of a selector/urltest present in both blocks
+type SelectorChange struct {
+	Tag     string   `json:"tag"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// ConfigDiff is the difference between the current and the new @ParserSTART block
+type ConfigDiff struct {
+	OutboundsAdded   []string         `json:"outbounds_added,omitempty"`
+	OutboundsRemoved []string         `json:"outbounds_removed,omitempty"`
+	SelectorsChanged []SelectorChange `json:"selectors_changed,omitempty"`
+	NodesBefore      int              `json:"nodes_before"`
+	NodesAfter       int              `json:"nodes_after"`
+	NodesRemoved     int              `json:"nodes_removed"`
+}
+
+// IsEmpty reports whether the new block changes nothing
+func (d *ConfigDiff) IsEmpty() bool {
+	return len(d.OutboundsAdded) == 0 && len(d.OutboundsRemoved) == 0 && len(d.SelectorsChanged) == 0
+}
+
+// RemovedNodesPercent returns share of current nodes (0-100) that the update removes
+func (d *ConfigDiff) RemovedNodesPercent() float64 {
+	if d.NodesBefore == 0 {
+		return 0
+	}
+	return float64(d.NodesRemoved) * 100 / float64(d.NodesBefore)
+}
+
+// findParserBlock returns positions of @ParserSTART and @ParserEND markers in config content
+func findParserBlock(configStr string) (startIdx, endIdx int, err error) {
+	startIdx = strings.Index(configStr, parserStartMarker)
+	endIdx = strings.Index(configStr, parserEndMarker)
+
+	if startIdx == -1 || endIdx == -1 {
+		return 0, 0, fmt.Errorf("markers @ParserSTART or @ParserEND not found in config.json")
+	}
+	if endIdx <= startIdx {
+		return 0, 0, fmt.Errorf("invalid marker positions")
+	}
+	return startIdx, endIdx, nil
+}
+
+// ReadParserBlock returns current content between @ParserSTART and @ParserEND markers
+func ReadParserBlock(configPath string) (string, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read config file: %w", err)
+	}
+	configStr := string(data)
+	startIdx, endIdx, err := findParserBlock(configStr)
+	if err != nil {
+		return "", err
+	}
+	return configStr[startIdx+len(parserStartMarker) : endIdx], nil
+}
+
+// WriteParserBlock replaces content between @ParserSTART and @ParserEND markers as is,
+// leaving the rest of config.json (including @ParserConfig) untouched.
+// Used to roll back the outbounds block previously read with ReadParserBlock.
+func WriteParserBlock(configPath, block string) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	configStr := string(data)
+	startIdx, endIdx, err := findParserBlock(configStr)
+	if err != nil {
+		return err
+	}
+	newContent := configStr[:startIdx+len(parserStartMarker)] + block + configStr[endIdx:]
+	return WriteFileAtomic(configPath, []byte(newContent), nil)
+}
+
+var trailingCommaRegex = regexp.MustCompile(`,(\s*[\]\}])`)
+
+// parseOutboundsBlock parses generated outbounds block (JSONC objects separated by commas)
+func parseOutboundsBlock(content string) ([]map[string]interface{}, error) {
+	clean := jsonc.ToJSON([]byte("[" + content + "]"))
+	clean = trailingCommaRegex.ReplaceAll(clean, []byte("$1"))
+
+	var outbounds []map[string]interface{}
+	if err := json.Unmarshal(clean, &outbounds); err != nil {
+		return nil, fmt.Errorf("failed to parse outbounds block: %w", err)
+	}
+	return outbounds, nil
+}
+
+// outboundMembers returns members of a selector/urltest, or nil for regular nodes
+func outboundMembers(outbound map[string]interface{}) []string {
+	raw, ok := outbound["outbounds"].([]interface{})
+	if !ok {
+		return nil
+	}
+	members := make([]string, 0, len(raw))
+	for _, member := range raw {
+		if tag, ok := member.(string); ok {
+			members = append(members, tag)
+		}
+	}
+	return members
+}
+
+// DiffParserBlocks compares current and new @ParserSTART blocks
+func DiffParserBlocks(oldContent, newContent string) (*ConfigDiff, error) {
+	oldOutbounds, err := parseOutboundsBlock(oldContent)
+	if err != nil {
+		return nil, fmt.Errorf("current config: %w", err)
+	}
+	newOutbounds, err := parseOutboundsBlock(newContent)
+	if err != nil {
+		return nil, fmt.Errorf("new config: %w", err)
+	}
+
+	oldByTag := make(map[string]map[string]interface{}, len(oldOutbounds))
+	for _, outbound := range oldOutbounds {
+		if tag, _ := outbound["tag"].(string); tag != "" {
+			oldByTag[tag] = outbound
+		}
+	}
+	newByTag := make(map[string]map[string]interface{}, len(newOutbounds))
+	for _, outbound := range newOutbounds {
+		if tag, _ := outbound["tag"].(string); tag != "" {
+			newByTag[tag] = outbound
+		}
+	}
+
+	diff := &ConfigDiff{}
+	for _, outbound := range oldOutbounds {
+		tag, _ := outbound["tag"].(string)
+		isNode := outboundMembers(outbound) == nil
+		if isNode {
+			diff.NodesBefore++
+		}
+		if _, exists := newByTag[tag]; !exists {
+			diff.OutboundsRemoved = append(diff.OutboundsRemoved, tag)
+			if isNode {
+				diff.NodesRemoved++
+			}
+		}
+	}
+	for _, outbound := range newOutbounds {
+		tag, _ := outbound["tag"].(string)
+		members := outboundMembers(outbound)
+		if members == nil {
+			diff.NodesAfter++
+		}
+		old, existed := oldByTag[tag]
+		if !existed {
+			diff.OutboundsAdded = append(diff.OutboundsAdded, tag)
+			continue
+		}
+		if members == nil {
+			continue
+		}
+		if change := diffMembers(tag, outboundMembers(old), members); change != nil {
+			diff.SelectorsChanged = append(diff.SelectorsChanged, *change)
+		}
+	}
+	return diff, nil
+}
+
+// diffMembers compares selector members; returns nil if membership is unchanged
+func diffMembers(tag string, oldMembers, newMembers []string) *SelectorChange {
+	oldSet := make(map[string]bool, len(oldMembers))
+	for _, member := range oldMembers {
+		oldSet[member] = true
+	}
+	newSet := make(map[string]bool, len(newMembers))
+	for _, member := range newMembers {
+		newSet[member] = true
+	}
+
+	change := &SelectorChange{Tag: tag}
+	for _, member := range newMembers {
+		if !oldSet[member] {
+			change.Added = append(change.Added, member)
+		}
+	}
+	for _, member := range oldMembers {
+		if !newSet[member] {
+			change.Removed = append(change.Removed, member)
+		}
+	}
+	if len(change.Added) == 0 && len(change.Removed) == 0 {
+		return nil
+	}
+	return change
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const previewOldBlock = `
+	// 🇩🇪 a
+	{"tag":"a","type":"vless","server":"a.example.com","server_port":443},
+	// 🇳🇱 b
+	{"tag":"b","type":"vless","server":"b.example.com","server_port":443},
+	{"tag":"c","type":"trojan","server":"c.example.com","server_port":443},
+	{"tag":"proxy-out","type":"selector","outbounds":["a","b","c","direct-out"]},
+	{"tag":"old-group","type":"urltest","outbounds":["c"]},
+`
+
+func TestDiffParserBlocks(t *testing.T) {
+	newBlock := `
+	{"tag":"a","type":"vless","server":"a.example.com","server_port":443},
+	{"tag":"d","type":"vless","server":"d.example.com","server_port":443},
+	{"tag":"proxy-out","type":"selector","outbounds":["a","d","direct-out"]},
+`
+	diff, err := DiffParserBlocks(previewOldBlock, newBlock)
+	if err != nil {
+		t.Fatalf("DiffParserBlocks failed: %v", err)
+	}
+
+	if strings.Join(diff.OutboundsAdded, ",") != "d" {
+		t.Errorf("unexpected added: %v", diff.OutboundsAdded)
+	}
+	if strings.Join(diff.OutboundsRemoved, ",") != "b,c,old-group" {
+		t.Errorf("unexpected removed: %v", diff.OutboundsRemoved)
+	}
+	if diff.NodesBefore != 3 || diff.NodesAfter != 2 || diff.NodesRemoved != 2 {
+		t.Errorf("unexpected node counts: %+v", diff)
+	}
+	if p := diff.RemovedNodesPercent(); p < 66 || p > 67 {
+		t.Errorf("unexpected removed percent: %v", p)
+	}
+	if len(diff.SelectorsChanged) != 1 {
+		t.Fatalf("expected 1 selector change, got %+v", diff.SelectorsChanged)
+	}
+	change := diff.SelectorsChanged[0]
+	if change.Tag != "proxy-out" || strings.Join(change.Added, ",") != "d" || strings.Join(change.Removed, ",") != "b,c" {
+		t.Errorf("unexpected selector change: %+v", change)
+	}
+
+	same, err := DiffParserBlocks(previewOldBlock, previewOldBlock)
+	if err != nil {
+		t.Fatalf("DiffParserBlocks failed: %v", err)
+	}
+	if !same.IsEmpty() {
+		t.Errorf("identical blocks must produce an empty diff: %+v", same)
+	}
+}
+
+func TestUpdateConfigFromSubscriptions_ApprovalRejected(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	original := "{\n\"outbounds\": [\n/** @ParserSTART */" + previewOldBlock + "/** @ParserEND */\n]\n}\n"
+	if err := os.WriteFile(configPath, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pc := &ParserConfig{}
+	pc.ParserConfig.Proxies = []ProxySource{{Source: "a"}}
+	pc.ParserConfig.Outbounds = []OutboundConfig{{Tag: "proxy-out", Type: "selector"}}
+	loadNodes := func(_ ProxySource, _ map[string]int, _ func(float64, string), _, _ int, _ *SourceReport) ([]*ParsedNode, error) {
+		return []*ParsedNode{{Tag: "a", Scheme: "vless", Server: "a.example.com", Port: 443, Outbound: map[string]interface{}{}}}, nil
+	}
+
+	var seen *ConfigDiff
+	err := UpdateConfigFromSubscriptions(configPath, pc, nil, loadNodes, UpdateOptions{Approve: func(diff *ConfigDiff) bool {
+		seen = diff
+		return false
+	}})
+	if !errors.Is(err, ErrUpdateCancelled) {
+		t.Fatalf("expected ErrUpdateCancelled, got %v", err)
+	}
+	if seen == nil || seen.NodesRemoved != 2 {
+		t.Errorf("approval callback should receive the diff, got %+v", seen)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != original {
+		t.Errorf("config.json must not change when the update is rejected")
+	}
+}
+
+func TestWriteParserBlock_RoundTrip(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	original := "{\n  /** @ParserConfig\n{\"ParserConfig\":{\"parser\":{\"last_updated\":\"new\"}}}\n*/\n  \"outbounds\": [\n    /** @ParserSTART */" +
+		previewOldBlock + "    /** @ParserEND */\n  ]\n}\n"
+	if err := os.WriteFile(configPath, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := ReadParserBlock(configPath)
+	if err != nil {
+		t.Fatalf("ReadParserBlock failed: %v", err)
+	}
+	if err := WriteParserBlock(configPath, "\n{\"tag\":\"x\",\"type\":\"direct\"},\n"); err != nil {
+		t.Fatalf("WriteParserBlock failed: %v", err)
+	}
+	if err := WriteParserBlock(configPath, block); err != nil {
+		t.Fatalf("WriteParserBlock (restore) failed: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != original {
+		t.Errorf("config not restored byte-for-byte:\n%s", data)
+	}
+}
+
+func TestParserSettings_ApplyPolicy(t *testing.T) {
+	cases := map[string]string{
+		"":          ApplyOnUpdateOff,
+		"off":       ApplyOnUpdateOff,
+		"Restart":   ApplyOnUpdateRestart,
+		" reload ":  ApplyOnUpdateReload,
+		"something": ApplyOnUpdateOff,
+	}
+	for value, want := range cases {
+		if got := (ParserSettings{ApplyOnUpdate: value}).ApplyPolicy(); got != want {
+			t.Errorf("ApplyPolicy(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"singbox-launcher/internal/constants"
+)
+
+// DefaultProfileName is the profile of bin/config.json. It always exists and cannot be deleted.
+const DefaultProfileName = "default"
+
+var (
+	profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 ._-]{0,31}$`)
+	// Names that cannot be used as directory names on Windows
+	reservedProfileNames = regexp.MustCompile(`(?i)^(con|prn|aux|nul|com[1-9]|lpt[1-9])$`)
+)
+
+// ProfilesDir returns the directory with named profiles next to the default config.json
+func ProfilesDir(baseConfigPath string) string {
+	return filepath.Join(filepath.Dir(baseConfigPath), constants.ProfilesDirName)
+}
+
+// ProfileConfigPath returns config.json of a profile. A named profile has its own directory, so its
+// launcher_state.json (selector choices), config history, caches and wizard state are separate too.
+func ProfileConfigPath(baseConfigPath, name string) string {
+	if name == "" || name == DefaultProfileName {
+		return baseConfigPath
+	}
+	return filepath.Join(ProfilesDir(baseConfigPath), name, constants.ConfigFileName)
+}
+
+// ValidateProfileName checks that a new profile can be created under name on all platforms
+func ValidateProfileName(name string) error {
+	if strings.EqualFold(name, DefaultProfileName) {
+		return fmt.Errorf("profile name %q is reserved", name)
+	}
+	if !profileNamePattern.MatchString(name) || strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") || reservedProfileNames.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use up to 32 letters, digits, spaces, '.', '-' or '_'", name)
+	}
+	return nil
+}
+
+// ListProfiles returns the default profile followed by the named profiles in alphabetical order
+func ListProfiles(baseConfigPath string) ([]string, error) {
+	profiles := []string{DefaultProfileName}
+	entries, err := os.ReadDir(ProfilesDir(baseConfigPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return profiles, nil
+		}
+		return profiles, fmt.Errorf("failed to read profiles: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && ValidateProfileName(entry.Name()) == nil {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+	return profiles, nil
+}
+
+// ProfileExists reports whether the profile can be switched to
+func ProfileExists(baseConfigPath, name string) bool {
+	if name == DefaultProfileName {
+		return true
+	}
+	if ValidateProfileName(name) != nil {
+		return false
+	}
+	info, err := os.Stat(filepath.Dir(ProfileConfigPath(baseConfigPath, name)))
+	return err == nil && info.IsDir()
+}
+
+// CreateProfile creates a named profile. If copyFrom is set and has a config.json, the new profile
+// starts with a copy of it (including ParserConfig); otherwise it is empty and is set up by the wizard.
+func CreateProfile(baseConfigPath, name, copyFrom string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if ProfileExists(baseConfigPath, name) {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+	configPath := ProfileConfigPath(baseConfigPath, name)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	if copyFrom == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(ProfileConfigPath(baseConfigPath, copyFrom))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read config of profile %q: %w", copyFrom, err)
+	}
+	if err := WriteFileAtomic(configPath, data, nil); err != nil {
+		return fmt.Errorf("failed to copy config: %w", err)
+	}
+	return nil
+}
+
+// DeleteProfile removes a named profile with all its files
+func DeleteProfile(baseConfigPath, name string) error {
+	if name == DefaultProfileName {
+		return fmt.Errorf("the default profile cannot be deleted")
+	}
+	if !ProfileExists(baseConfigPath, name) {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	if err := os.RemoveAll(filepath.Dir(ProfileConfigPath(baseConfigPath, name))); err != nil {
+		return fmt.Errorf("failed to delete profile %q: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProfiles_CreateListDelete(t *testing.T) {
+	baseConfigPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(baseConfigPath, []byte(`{"log": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if profiles, err := ListProfiles(baseConfigPath); err != nil || !reflect.DeepEqual(profiles, []string{DefaultProfileName}) {
+		t.Fatalf("ListProfiles without profiles = %v, %v", profiles, err)
+	}
+	if err := CreateProfile(baseConfigPath, "office", DefaultProfileName); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateProfile(baseConfigPath, "Home", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateProfile(baseConfigPath, "office", ""); err == nil {
+		t.Error("CreateProfile of an existing profile succeeded")
+	}
+
+	if profiles, err := ListProfiles(baseConfigPath); err != nil || !reflect.DeepEqual(profiles, []string{DefaultProfileName, "Home", "office"}) {
+		t.Errorf("ListProfiles = %v, %v", profiles, err)
+	}
+	if data, err := os.ReadFile(ProfileConfigPath(baseConfigPath, "office")); err != nil || string(data) != `{"log": {}}` {
+		t.Errorf("config of the copied profile = %q, %v", data, err)
+	}
+	if _, err := os.Stat(ProfileConfigPath(baseConfigPath, "Home")); !os.IsNotExist(err) {
+		t.Errorf("empty profile has a config.json: %v", err)
+	}
+	if ProfileConfigPath(baseConfigPath, DefaultProfileName) != baseConfigPath {
+		t.Errorf("default profile config = %s", ProfileConfigPath(baseConfigPath, DefaultProfileName))
+	}
+
+	if err := DeleteProfile(baseConfigPath, "office"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteProfile(baseConfigPath, DefaultProfileName); err == nil {
+		t.Error("DeleteProfile of the default profile succeeded")
+	}
+	if ProfileExists(baseConfigPath, "office") || !ProfileExists(baseConfigPath, "Home") {
+		t.Error("ProfileExists after delete is wrong")
+	}
+}
+
+func TestValidateProfileName(t *testing.T) {
+	for _, name := range []string{"office", "Home 2", "travel_eu-1", "v1.2"} {
+		if err := ValidateProfileName(name); err != nil {
+			t.Errorf("ValidateProfileName(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "default", "Default", "../bin", "a/b", "trailing.", ".hidden", "NUL", "com1", "this name is much too long for a profile"} {
+		if err := ValidateProfileName(name); err == nil {
+			t.Errorf("ValidateProfileName(%q) accepted an invalid name", name)
+		}
+	}
+}
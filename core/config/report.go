@@ -0,0 +1,157 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"singbox-launcher/internal/constants"
+)
+
+// ParseReport is a structured report of a single subscription update.
+// It is saved as JSON next to config.json (see ParseReportPath) and shown in the "Last update" dialog.
+type ParseReport struct {
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt time.Time        `json:"finished_at"`
+	DurationMs int64            `json:"duration_ms"`
+	Success    bool             `json:"success"`
+	Error      string           `json:"error,omitempty"`
+	NodesCount int              `json:"nodes_count"`
+	Sources    []*SourceReport  `json:"sources"`
+	Selectors  []SelectorReport `json:"selectors"`
+	Diff       *ConfigDiff      `json:"diff,omitempty"`        // Set when the update was previewed before writing
+	RolledBack bool             `json:"rolled_back,omitempty"` // New config failed sing-box check, previous one kept
+}
+
+// SourceReport contains statistics for one ProxySource.
+// The subscription URL itself is not stored (it usually contains a private token), only its hash.
+type SourceReport struct {
+	Index          int            `json:"index"`                  // 1-based index in ParserConfig.proxies
+	ID             string         `json:"id,omitempty"`           // Source id (version 5)
+	Name           string         `json:"name,omitempty"`         // Source name (version 5)
+	Disabled       bool           `json:"disabled,omitempty"`     // Source is disabled ("enabled": false) and was not loaded
+	URLHash        string         `json:"url_hash,omitempty"`     // Short sha256 of subscription URL
+	Connections    int            `json:"connections,omitempty"`  // Number of direct connections in source
+	HTTPStatus     int            `json:"http_status,omitempty"`  // HTTP status of subscription (0 if request failed)
+	Bytes          int64          `json:"bytes"`                  // Raw bytes downloaded
+	Lines          int            `json:"lines"`                  // Non-empty lines processed (subscription + connections)
+	Parsed         int            `json:"parsed"`                 // Nodes parsed successfully
+	Skipped        int            `json:"skipped"`                // Nodes skipped by "skip" filters
+	SkippedByLimit int            `json:"skipped_by_limit"`       // Nodes skipped due to node limit
+	Failed         int            `json:"failed"`                 // Lines that failed to parse
+	FailReasons    map[string]int `json:"fail_reasons,omitempty"` // Failure reason -> count
+	Dedups         int            `json:"dedups"`                 // Tags renamed because of duplicates
+	DurationMs     int64          `json:"duration_ms"`
+	Error          string         `json:"error,omitempty"`           // Fetch/decode error (source-level)
+	FromCache      bool           `json:"from_cache,omitempty"`      // Not due for reload, nodes taken from the subscription cache
+	Churn          *NodeChurn     `json:"churn,omitempty"`           // Changes since the previous successful update
+	OverrideErrors []string       `json:"override_errors,omitempty"` // Invalid or not allowed override fields (ignored)
+
+	nodes map[string]NodeIdentity // Snapshot of loaded nodes (nil if source failed), used for churn tracking
+}
+
+// SelectorReport contains the result of generating one selector/urltest.
+type SelectorReport struct {
+	Tag       string `json:"tag"`
+	Type      string `json:"type"`
+	Local     bool   `json:"local,omitempty"`
+	Source    int    `json:"source,omitempty"` // 1-based source index for local selectors
+	Nodes     int    `json:"nodes"`            // Nodes matched by filters
+	Outbounds int    `json:"outbounds"`        // Total outbounds (nodes + valid addOutbounds)
+	Valid     bool   `json:"valid"`            // false if selector was empty and skipped
+}
+
+// ParseReportPath returns path of the parse report file next to config.json
+func ParseReportPath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), constants.ParseReportFileName)
+}
+
+// HashSourceURL returns a short, stable hash of a subscription URL for reports
+func HashSourceURL(url string) string {
+	if url == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.TrimSpace(url)))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// NewSourceReport creates a report for a source at the given 0-based index
+func NewSourceReport(proxySource ProxySource, index int) *SourceReport {
+	return &SourceReport{
+		Index:       index + 1,
+		ID:          proxySource.ID,
+		Name:        proxySource.Name,
+		Disabled:    !proxySource.IsEnabled(),
+		URLHash:     HashSourceURL(proxySource.Source),
+		Connections: len(proxySource.Connections),
+	}
+}
+
+// AddFailure records a failed line with its reason. Safe to call on nil report.
+func (r *SourceReport) AddFailure(err error) {
+	if r == nil {
+		return
+	}
+	r.Failed++
+	if r.FailReasons == nil {
+		r.FailReasons = make(map[string]int)
+	}
+	r.FailReasons[failureReason(err)]++
+}
+
+// failureReason reduces an error to a short reason suitable for grouping:
+// the message before the first ": " (details like URIs and values are dropped).
+func failureReason(err error) string {
+	if err == nil {
+		return "unknown"
+	}
+	reason := err.Error()
+	if idx := strings.Index(reason, ": "); idx > 0 {
+		reason = reason[:idx]
+	}
+	const maxReasonLength = 80
+	if len(reason) > maxReasonLength {
+		reason = reason[:maxReasonLength] + "..."
+	}
+	return reason
+}
+
+// Finish sets finish time, duration and result of the report
+func (r *ParseReport) Finish(err error) {
+	r.FinishedAt = time.Now()
+	r.DurationMs = r.FinishedAt.Sub(r.StartedAt).Milliseconds()
+	r.Success = err == nil
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+// SaveParseReport writes report as indented JSON to path
+func SaveParseReport(path string, report *ParseReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal parse report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write parse report: %w", err)
+	}
+	return nil
+}
+
+// LoadParseReport reads report from path
+func LoadParseReport(path string) (*ParseReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report ParseReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report: %w", err)
+	}
+	return &report, nil
+}
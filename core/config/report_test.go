@@ -0,0 +1,71 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceReportAddFailure(t *testing.T) {
+	var nilReport *SourceReport
+	nilReport.AddFailure(errors.New("ignored")) // must not panic
+
+	r := NewSourceReport(ProxySource{Source: "https://example.com/sub?token=secret"}, 0)
+	if r.Index != 1 || len(r.URLHash) != 12 {
+		t.Fatalf("unexpected report header: %+v", r)
+	}
+	r.AddFailure(errors.New("unsupported scheme: foo://bar"))
+	r.AddFailure(errors.New("unsupported scheme: baz://qux"))
+	r.AddFailure(nil)
+
+	if r.Failed != 3 {
+		t.Errorf("expected 3 failures, got %d", r.Failed)
+	}
+	if r.FailReasons["unsupported scheme"] != 2 || r.FailReasons["unknown"] != 1 {
+		t.Errorf("unexpected fail reasons: %v", r.FailReasons)
+	}
+}
+
+func TestParseReport_SelectorsAndRoundTrip(t *testing.T) {
+	pc := &ParserConfig{}
+	pc.ParserConfig.Proxies = []ProxySource{{Source: "a"}}
+	pc.ParserConfig.Outbounds = []OutboundConfig{
+		{Tag: "all", Type: "selector"},
+		{Tag: "none", Type: "urltest", Filters: map[string]interface{}{"tag": "/nomatch/"}},
+	}
+	loadNodes := func(_ ProxySource, _ map[string]int, _ func(float64, string), _, _ int, r *SourceReport) ([]*ParsedNode, error) {
+		r.Parsed = 1
+		return []*ParsedNode{{Tag: "n1", Scheme: "vless", Server: "a.example.com", Port: 443, Outbound: map[string]interface{}{}}}, nil
+	}
+
+	report := &ParseReport{}
+	if _, err := GenerateOutboundsFromParserConfig(pc, map[string]int{}, nil, loadNodes, report); err != nil {
+		t.Fatalf("GenerateOutboundsFromParserConfig failed: %v", err)
+	}
+	report.Finish(nil)
+
+	if len(report.Sources) != 1 || report.Sources[0].Parsed != 1 {
+		t.Fatalf("unexpected source reports: %+v", report.Sources)
+	}
+	if len(report.Selectors) != 2 {
+		t.Fatalf("expected 2 selector reports, got %+v", report.Selectors)
+	}
+	if !report.Selectors[0].Valid || report.Selectors[0].Nodes != 1 {
+		t.Errorf("selector 'all' should be valid with 1 node: %+v", report.Selectors[0])
+	}
+	if report.Selectors[1].Valid {
+		t.Errorf("selector 'none' should be invalid: %+v", report.Selectors[1])
+	}
+
+	path := filepath.Join(t.TempDir(), "parse_report.json")
+	if err := SaveParseReport(path, report); err != nil {
+		t.Fatalf("SaveParseReport failed: %v", err)
+	}
+	loaded, err := LoadParseReport(path)
+	if err != nil {
+		t.Fatalf("LoadParseReport failed: %v", err)
+	}
+	if !loaded.Success || len(loaded.Selectors) != 2 || loaded.Sources[0].URLHash != report.Sources[0].URLHash {
+		t.Errorf("report did not round-trip: %+v", loaded)
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Restart modes of RestartPolicySettings.Mode
+const (
+	RestartModeOnFailure = "on-failure" // Restart after a crash (non-zero exit), up to max_attempts in a row
+	RestartModeAlways    = "always"     // Restart after any exit not requested by the user, without an attempt limit
+	RestartModeNever     = "never"      // Never restart, only report the crash
+)
+
+// Defaults of the crash restart policy (match the behavior before the policy was configurable)
+const (
+	DefaultRestartMaxAttempts  = 3
+	DefaultRestartInitialDelay = 2 * time.Second
+	DefaultRestartMaxDelay     = 1 * time.Minute
+	DefaultRestartResetAfter   = 3 * time.Minute
+)
+
+// RestartPolicySettings configures how the launcher restarts sing-box after it exits unexpectedly.
+// Stored in launcher_state.json ("restart_policy"); empty fields take the defaults.
+type RestartPolicySettings struct {
+	Mode         string `json:"mode,omitempty"`          // "on-failure" (default), "always" or "never"
+	MaxAttempts  *int   `json:"max_attempts,omitempty"`  // Consecutive restarts before giving up, 0 = unlimited ("on-failure" only)
+	InitialDelay string `json:"initial_delay,omitempty"` // Delay before the first restart, doubled on every next attempt ("2s")
+	MaxDelay     string `json:"max_delay,omitempty"`     // Upper bound of the delay ("1m")
+	ResetAfter   string `json:"reset_after,omitempty"`   // Run time after which the attempt counter is reset ("3m")
+}
+
+// RestartPolicy is the resolved crash restart policy
+type RestartPolicy struct {
+	Mode         string
+	MaxAttempts  int // 0 = unlimited
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	ResetAfter   time.Duration
+}
+
+// DefaultRestartPolicy returns the policy used when launcher_state.json has no "restart_policy"
+func DefaultRestartPolicy() RestartPolicy {
+	return RestartPolicy{
+		Mode:         RestartModeOnFailure,
+		MaxAttempts:  DefaultRestartMaxAttempts,
+		InitialDelay: DefaultRestartInitialDelay,
+		MaxDelay:     DefaultRestartMaxDelay,
+		ResetAfter:   DefaultRestartResetAfter,
+	}
+}
+
+// Resolve validates the settings and fills in the defaults
+func (s RestartPolicySettings) Resolve() (RestartPolicy, error) {
+	policy := DefaultRestartPolicy()
+
+	switch mode := strings.TrimSpace(s.Mode); mode {
+	case "":
+	case RestartModeOnFailure, RestartModeAlways, RestartModeNever:
+		policy.Mode = mode
+	default:
+		return policy, fmt.Errorf("restart_policy.mode: unknown mode %q (expected %q, %q or %q)", s.Mode, RestartModeOnFailure, RestartModeAlways, RestartModeNever)
+	}
+
+	if s.MaxAttempts != nil {
+		if *s.MaxAttempts < 0 {
+			return policy, fmt.Errorf("restart_policy.max_attempts: must not be negative")
+		}
+		policy.MaxAttempts = *s.MaxAttempts
+	}
+	if policy.Mode == RestartModeAlways {
+		policy.MaxAttempts = 0
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"initial_delay", s.InitialDelay, &policy.InitialDelay},
+		{"max_delay", s.MaxDelay, &policy.MaxDelay},
+		{"reset_after", s.ResetAfter, &policy.ResetAfter},
+	} {
+		if strings.TrimSpace(field.value) == "" {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(field.value))
+		if err != nil {
+			return policy, fmt.Errorf("restart_policy.%s: %w", field.name, err)
+		}
+		if d <= 0 {
+			return policy, fmt.Errorf("restart_policy.%s: must be positive", field.name)
+		}
+		*field.dest = d
+	}
+	if policy.MaxDelay < policy.InitialDelay {
+		policy.MaxDelay = policy.InitialDelay
+	}
+	return policy, nil
+}
+
+// RestartsAfter reports whether the policy restarts sing-box after it exited by itself.
+// graceful is true for exit code 0.
+func (p RestartPolicy) RestartsAfter(graceful bool) bool {
+	switch p.Mode {
+	case RestartModeNever:
+		return false
+	case RestartModeAlways:
+		return true
+	}
+	return !graceful
+}
+
+// AttemptsExhausted reports whether restart number attempt (1-based) exceeds the limit
+func (p RestartPolicy) AttemptsExhausted(attempt int) bool {
+	return p.MaxAttempts > 0 && attempt > p.MaxAttempts
+}
+
+// String describes the policy for the UI, e.g. "on-failure, up to 3 attempts, 2s…1m0s"
+func (p RestartPolicy) String() string {
+	switch {
+	case p.Mode == RestartModeNever:
+		return RestartModeNever
+	case p.MaxAttempts > 0:
+		return fmt.Sprintf("%s, up to %d attempts, %v…%v", p.Mode, p.MaxAttempts, p.InitialDelay, p.MaxDelay)
+	}
+	return fmt.Sprintf("%s, unlimited attempts, %v…%v", p.Mode, p.InitialDelay, p.MaxDelay)
+}
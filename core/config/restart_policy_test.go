@@ -0,0 +1,70 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestartPolicySettings_Resolve(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	policy, err := RestartPolicySettings{}.Resolve()
+	if err != nil || policy != DefaultRestartPolicy() {
+		t.Fatalf("empty settings: %+v, err = %v; want defaults", policy, err)
+	}
+
+	policy, err = RestartPolicySettings{Mode: "on-failure", MaxAttempts: intPtr(0), InitialDelay: "500ms", MaxDelay: "10s", ResetAfter: "1h"}.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	want := RestartPolicy{Mode: RestartModeOnFailure, MaxAttempts: 0, InitialDelay: 500 * time.Millisecond, MaxDelay: 10 * time.Second, ResetAfter: time.Hour}
+	if policy != want {
+		t.Errorf("Resolve = %+v, want %+v", policy, want)
+	}
+
+	// "always" has no attempt limit; a max_delay below initial_delay is raised to it
+	policy, err = RestartPolicySettings{Mode: "always", MaxAttempts: intPtr(5), InitialDelay: "30s", MaxDelay: "5s"}.Resolve()
+	if err != nil || policy.MaxAttempts != 0 || policy.MaxDelay != 30*time.Second {
+		t.Errorf("always: %+v, err = %v", policy, err)
+	}
+
+	for _, s := range []RestartPolicySettings{
+		{Mode: "sometimes"},
+		{MaxAttempts: intPtr(-1)},
+		{InitialDelay: "2"},
+		{MaxDelay: "-1s"},
+		{ResetAfter: "0s"},
+	} {
+		if _, err := s.Resolve(); err == nil {
+			t.Errorf("Resolve(%+v) must fail", s)
+		}
+	}
+}
+
+func TestRestartPolicy_RestartsAfter(t *testing.T) {
+	tests := []struct {
+		mode     string
+		graceful bool
+		want     bool
+	}{
+		{RestartModeOnFailure, false, true},
+		{RestartModeOnFailure, true, false},
+		{RestartModeAlways, false, true},
+		{RestartModeAlways, true, true},
+		{RestartModeNever, false, false},
+		{RestartModeNever, true, false},
+	}
+	for _, tt := range tests {
+		if got := (RestartPolicy{Mode: tt.mode}).RestartsAfter(tt.graceful); got != tt.want {
+			t.Errorf("%s.RestartsAfter(%v) = %v, want %v", tt.mode, tt.graceful, got, tt.want)
+		}
+	}
+
+	limited := RestartPolicy{Mode: RestartModeOnFailure, MaxAttempts: 3}
+	if limited.AttemptsExhausted(3) || !limited.AttemptsExhausted(4) {
+		t.Errorf("AttemptsExhausted with max_attempts 3 is wrong")
+	}
+	if (RestartPolicy{Mode: RestartModeOnFailure}).AttemptsExhausted(100) {
+		t.Errorf("max_attempts 0 must be unlimited")
+	}
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"singbox-launcher/internal/debuglog"
+	"singbox-launcher/internal/platform"
+)
+
+// ErrConfigCheckFailed is returned when the candidate config is rejected by `sing-box check`.
+// In this case the previous config.json is kept unchanged.
+var ErrConfigCheckFailed = errors.New("sing-box config check failed")
+
+// ConfigValidator checks a candidate config file before it replaces config.json
+type ConfigValidator func(candidatePath string) error
+
+// ValidateConfigWithSingBox validates configuration file using sing-box check command.
+// Works on all platforms (Windows, macOS, Linux) with console window hidden.
+// Returns nil if valid or if sing-box is not available (graceful degradation).
+func ValidateConfigWithSingBox(configPath, singBoxPath string) error {
+	// Skip validation if sing-box path is not provided
+	if singBoxPath == "" {
+		debuglog.DebugLog("Skipping sing-box validation: singBoxPath is empty")
+		return nil
+	}
+
+	// Check if sing-box executable exists
+	if _, err := os.Stat(singBoxPath); os.IsNotExist(err) {
+		debuglog.DebugLog("Skipping sing-box validation: executable not found at %s", singBoxPath)
+		return nil
+	}
+
+	// Prepare command
+	cmd := exec.Command(singBoxPath, "check", "-c", configPath)
+
+	// Hide console window on all platforms
+	platform.PrepareCommand(cmd)
+
+	// Capture output
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	debuglog.DebugLog("Running validation: %s check -c %s", singBoxPath, configPath)
+
+	// Run validation
+	err := cmd.Run()
+
+	if err != nil {
+		// Validation failed - extract meaningful error message
+		errorMsg := stderr.String()
+		if errorMsg == "" {
+			errorMsg = stdout.String()
+		}
+		if errorMsg == "" {
+			errorMsg = err.Error()
+		}
+
+		debuglog.ErrorLog("Config validation failed: %v", err)
+		debuglog.LogTextFragment("ConfigValidator", debuglog.LevelError,
+			"Validation error output", errorMsg, 500)
+
+		return fmt.Errorf("sing-box config validation failed: %s", errorMsg)
+	}
+
+	debuglog.InfoLog("Config validation passed successfully")
+
+	return nil
+}
+
+// WriteFileAtomic replaces path with data without ever leaving a partially written file:
+// data is written to a temp file in the same directory, fsynced, optionally validated
+// and then renamed over path. If validation fails, path is left untouched.
+func WriteFileAtomic(path string, data []byte, validate ConfigValidator) error {
+	return WriteFileAtomicMode(path, data, 0644, validate)
+}
+
+// WriteFileAtomicMode is WriteFileAtomic that creates the file with permissions perm
+// (e.g. 0600 for files holding secrets)
+func WriteFileAtomicMode(path string, data []byte, perm os.FileMode, validate ConfigValidator) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	// Remove the temp file on any failure; after a successful rename it no longer exists
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("failed to set temp file permissions: %w", err)
+	}
+
+	if validate != nil {
+		if err := validate(tmpPath); err != nil {
+			return fmt.Errorf("%w, previous config kept: %v", ErrConfigCheckFailed, err)
+		}
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(path), err)
+	}
+	syncDir(dir)
+	return nil
+}
+
+// syncDir flushes directory metadata (the rename) to disk. Best effort: not supported on Windows.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
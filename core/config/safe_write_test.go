@@ -0,0 +1,54 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("validator rejects candidate", func(t *testing.T) {
+		var checked string
+		err := WriteFileAtomic(path, []byte("broken"), func(candidatePath string) error {
+			checked = candidatePath
+			data, _ := os.ReadFile(candidatePath)
+			if string(data) != "broken" {
+				t.Errorf("validator should see the candidate content, got %q", data)
+			}
+			return errors.New("unknown field")
+		})
+		if !errors.Is(err, ErrConfigCheckFailed) {
+			t.Fatalf("expected ErrConfigCheckFailed, got %v", err)
+		}
+		if checked == path {
+			t.Errorf("validator must check a temp file, not config.json")
+		}
+		if data, _ := os.ReadFile(path); string(data) != "old" {
+			t.Errorf("config.json must be kept, got %q", data)
+		}
+	})
+
+	t.Run("successful replace", func(t *testing.T) {
+		if err := WriteFileAtomic(path, []byte("new"), func(string) error { return nil }); err != nil {
+			t.Fatalf("WriteFileAtomic failed: %v", err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != "new" {
+			t.Errorf("config.json should be replaced, got %q", data)
+		}
+	})
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temp files must be cleaned up, dir contains %d entries", len(entries))
+	}
+}
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultReload is the reload interval used when neither the source nor parser.reload set one
+const DefaultReload = "4h"
+
+// cronMaxSteps bounds the search for the next cron tick (enough for any valid expression within years)
+const cronMaxSteps = 100000
+
+// ReloadSchedule describes when a source must be downloaded again:
+// either a fixed interval ("4h") or a cron-like expression ("0 6,18 * * *").
+type ReloadSchedule struct {
+	Interval time.Duration
+	cron     *cronSchedule
+}
+
+// cronSchedule is a parsed 5-field cron expression: minute hour day-of-month month day-of-week
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// ParseReloadSchedule parses a duration or a 5-field cron expression
+func ParseReloadSchedule(value string) (ReloadSchedule, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		value = DefaultReload
+	}
+	if fields := strings.Fields(value); len(fields) == 5 {
+		cron, err := parseCron(fields)
+		if err != nil {
+			return ReloadSchedule{}, fmt.Errorf("invalid cron expression %q: %w", value, err)
+		}
+		return ReloadSchedule{cron: cron}, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return ReloadSchedule{}, fmt.Errorf("invalid reload %q: expected duration (\"4h\") or cron expression (\"0 6 * * *\")", value)
+	}
+	if interval <= 0 {
+		return ReloadSchedule{}, fmt.Errorf("invalid reload %q: must be positive", value)
+	}
+	return ReloadSchedule{Interval: interval}, nil
+}
+
+// IsCron reports whether the schedule is a cron expression
+func (r ReloadSchedule) IsCron() bool {
+	return r.cron != nil
+}
+
+// Next returns the time the source becomes due after it was downloaded at last.
+// A zero last means the source was never downloaded and is due immediately.
+func (r ReloadSchedule) Next(last time.Time) time.Time {
+	if last.IsZero() {
+		return last
+	}
+	if r.cron != nil {
+		return r.cron.next(last)
+	}
+	return last.Add(r.Interval)
+}
+
+// Due reports whether the source downloaded at last must be refreshed at now
+func (r ReloadSchedule) Due(last, now time.Time) bool {
+	return !r.Next(last).After(now)
+}
+
+// ReloadSchedule returns the effective reload schedule of the source:
+// its own reload, otherwise parser.reload, otherwise DefaultReload.
+func (ps *ProxySource) ReloadSchedule(globalReload string) (ReloadSchedule, error) {
+	if strings.TrimSpace(ps.Reload) != "" {
+		return ParseReloadSchedule(ps.Reload)
+	}
+	return ParseReloadSchedule(globalReload)
+}
+
+// parseCron parses the five cron fields
+func parseCron(fields []string) (*cronSchedule, error) {
+	var c cronSchedule
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // 7 is Sunday as well as 0
+	}
+	c.domAny = fields[2] == "*"
+	c.dowAny = fields[4] == "*"
+	return &c, nil
+}
+
+// parseCronField parses a comma-separated list of "*", "N", "A-B" with an optional "/step" into a bit set
+func parseCronField(field string, minValue, maxValue int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[idx+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rangePart = part[:idx]
+		}
+
+		lo, hi := minValue, maxValue
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			value, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", rangePart)
+			}
+			lo, hi = value, value
+			if step > 1 {
+				hi = maxValue // "N/step" means from N to max
+			}
+		}
+		if lo < minValue || hi > maxValue || lo > hi {
+			return 0, fmt.Errorf("%q is out of range %d-%d", rangePart, minValue, maxValue)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// dayMatches applies the cron rule: if both day fields are restricted, either may match
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dowMatch
+	case c.dowAny:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+// next returns the first tick strictly after t (in t's location)
+func (c *cronSchedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for i := 0; i < cronMaxSteps; i++ {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	// Expression never matches (e.g. "0 0 31 2 *") - never due
+	return time.Date(9999, 1, 1, 0, 0, 0, 0, loc)
+}
+
+// QuietHours is a daily window ("23:00-07:00", local time) when automatic updates are not run
+type QuietHours struct {
+	start, end int // minutes since midnight
+}
+
+// ParseQuietHours parses "HH:MM-HH:MM". An empty value yields an empty window.
+func ParseQuietHours(value string) (QuietHours, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return QuietHours{}, nil
+	}
+	bounds := strings.SplitN(value, "-", 2)
+	if len(bounds) != 2 {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours %q: expected \"HH:MM-HH:MM\"", value)
+	}
+	start, err := parseClock(bounds[0])
+	if err != nil {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours %q: %w", value, err)
+	}
+	end, err := parseClock(bounds[1])
+	if err != nil {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours %q: %w", value, err)
+	}
+	return QuietHours{start: start, end: end}, nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains reports whether t (in its own location) falls into the window. The window may cross midnight.
+func (q QuietHours) Contains(t time.Time) bool {
+	if q.start == q.end {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if q.start < q.end {
+		return minute >= q.start && minute < q.end
+	}
+	return minute >= q.start || minute < q.end
+}
+
+// End returns the end of the window containing t (t itself if t is outside the window)
+func (q QuietHours) End(t time.Time) time.Time {
+	if !q.Contains(t) {
+		return t
+	}
+	end := time.Date(t.Year(), t.Month(), t.Day(), q.end/60, q.end%60, 0, 0, t.Location())
+	if !end.After(t) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseReloadSchedule_Interval(t *testing.T) {
+	schedule, err := ParseReloadSchedule("1h")
+	if err != nil {
+		t.Fatalf("ParseReloadSchedule failed: %v", err)
+	}
+	last := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	if schedule.Due(last, last.Add(59*time.Minute)) {
+		t.Error("must not be due before the interval passed")
+	}
+	if !schedule.Due(last, last.Add(time.Hour)) {
+		t.Error("must be due after the interval passed")
+	}
+	if !schedule.Due(time.Time{}, last) {
+		t.Error("never downloaded source must be due")
+	}
+
+	if def, _ := ParseReloadSchedule(""); def.Interval != 4*time.Hour {
+		t.Errorf("empty reload must default to %s, got %v", DefaultReload, def.Interval)
+	}
+	for _, invalid := range []string{"soon", "-1h", "61 * * * *", "* * * *"} {
+		if _, err := ParseReloadSchedule(invalid); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestParseReloadSchedule_Cron(t *testing.T) {
+	tests := []struct {
+		expr string
+		last time.Time
+		want time.Time
+	}{
+		// Daily at 06:00 and 18:00
+		{"0 6,18 * * *", time.Date(2025, 3, 10, 7, 30, 0, 0, time.UTC), time.Date(2025, 3, 10, 18, 0, 0, 0, time.UTC)},
+		{"0 6,18 * * *", time.Date(2025, 3, 10, 18, 0, 0, 0, time.UTC), time.Date(2025, 3, 11, 6, 0, 0, 0, time.UTC)},
+		// Every 15 minutes
+		{"*/15 * * * *", time.Date(2025, 3, 10, 7, 31, 10, 0, time.UTC), time.Date(2025, 3, 10, 7, 45, 0, 0, time.UTC)},
+		// Weekdays at 09:30 (2025-03-14 is Friday)
+		{"30 9 * * 1-5", time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC), time.Date(2025, 3, 17, 9, 30, 0, 0, time.UTC)},
+		// Sundays as 7, month boundary
+		{"0 0 * * 7", time.Date(2025, 3, 29, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 30, 0, 0, 0, 0, time.UTC)},
+		// First day of month, year boundary
+		{"0 3 1 * *", time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseReloadSchedule(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseReloadSchedule(%q) failed: %v", tt.expr, err)
+		}
+		if !schedule.IsCron() {
+			t.Fatalf("%q must be parsed as cron", tt.expr)
+		}
+		if got := schedule.Next(tt.last); !got.Equal(tt.want) {
+			t.Errorf("%q after %s: got %s, want %s", tt.expr, tt.last, got, tt.want)
+		}
+	}
+}
+
+func TestProxySourceReloadSchedule(t *testing.T) {
+	source := ProxySource{Reload: "1h"}
+	if schedule, _ := source.ReloadSchedule("24h"); schedule.Interval != time.Hour {
+		t.Errorf("source reload must take precedence, got %v", schedule.Interval)
+	}
+	if schedule, _ := (&ProxySource{}).ReloadSchedule("24h"); schedule.Interval != 24*time.Hour {
+		t.Errorf("parser.reload must be used, got %v", schedule.Interval)
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	quiet, err := ParseQuietHours("23:00-07:00")
+	if err != nil {
+		t.Fatalf("ParseQuietHours failed: %v", err)
+	}
+	at := func(hour, minute int) time.Time { return time.Date(2025, 3, 10, hour, minute, 0, 0, time.UTC) }
+
+	for _, tt := range []struct {
+		t     time.Time
+		quiet bool
+	}{
+		{at(22, 59), false}, {at(23, 0), true}, {at(3, 0), true}, {at(6, 59), true}, {at(7, 0), false}, {at(12, 0), false},
+	} {
+		if got := quiet.Contains(tt.t); got != tt.quiet {
+			t.Errorf("Contains(%s) = %v, want %v", tt.t.Format("15:04"), got, tt.quiet)
+		}
+	}
+	if end := quiet.End(at(23, 30)); !end.Equal(time.Date(2025, 3, 11, 7, 0, 0, 0, time.UTC)) {
+		t.Errorf("End after midnight: got %s", end)
+	}
+	if end := quiet.End(at(3, 0)); !end.Equal(at(7, 0)) {
+		t.Errorf("End same day: got %s", end)
+	}
+
+	if empty, _ := ParseQuietHours(""); empty.Contains(at(3, 0)) {
+		t.Error("empty quiet hours must not contain anything")
+	}
+	if _, err := ParseQuietHours("23:00"); err == nil {
+		t.Error("expected error for missing end")
+	}
+}
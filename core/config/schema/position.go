@@ -0,0 +1,110 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// locate walks JSON data and records the offset of every value by its JSON pointer
+func locate(data []byte) (map[string]int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	offsets := make(map[string]int)
+	if err := walkValue(dec, data, "", offsets); err != nil {
+		return offsets, err
+	}
+	return offsets, nil
+}
+
+// walkValue records the offset of the next value and of all nested values
+func walkValue(dec *json.Decoder, data []byte, pointer string, offsets map[string]int) error {
+	offsets[pointer] = valueStart(data, int(dec.InputOffset()))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+
+	switch delim {
+	case '{':
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := keyTok.(string)
+			if err := walkValue(dec, data, pointer+"/"+escapePointerToken(key), offsets); err != nil {
+				return err
+			}
+		}
+	case '[':
+		for i := 0; dec.More(); i++ {
+			if err := walkValue(dec, data, pointer+"/"+strconv.Itoa(i), offsets); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = dec.Token() // Closing delimiter
+	return err
+}
+
+// ValueOffset returns the offset of the value at a JSON pointer (e.g. "/inbounds/0/listen_port")
+// in JSON(C) data, so the value can be replaced without touching comments and formatting
+func ValueOffset(data []byte, pointer string) (int, bool) {
+	offsets, _ := locate(StripComments(data))
+	offset, ok := offsets[pointer]
+	return offset, ok
+}
+
+// valueStart skips whitespace and separators the decoder has not consumed yet
+func valueStart(data []byte, offset int) int {
+	for offset < len(data) {
+		switch data[offset] {
+		case ' ', '\t', '\n', '\r', ':', ',':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+// escapePointerToken escapes a key for use in a JSON pointer (RFC 6901)
+func escapePointerToken(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// offsetOf returns the offset of the value at pointer, falling back to the closest parent
+func offsetOf(offsets map[string]int, pointer string) int {
+	for {
+		if offset, ok := offsets[pointer]; ok {
+			return offset
+		}
+		idx := strings.LastIndex(pointer, "/")
+		if idx < 0 {
+			return 0
+		}
+		pointer = pointer[:idx]
+	}
+}
+
+// Position converts a byte offset into a 1-based line and column (column counted in characters)
+func Position(data []byte, offset int) (line, column int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	column = utf8.RuneCount(before[lineStart:]) + 1
+	return line, column
+}
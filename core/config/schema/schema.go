@@ -0,0 +1,535 @@
+// Package schema validates the @ParserConfig block and wizard_template.json against JSON Schemas
+// shipped with the launcher. Errors carry a JSON pointer to the offending value and its line and
+// column in the original (JSONC) text.
+//
+// Only the subset of JSON Schema used by the shipped schemas is supported: type, properties,
+// required, additionalProperties, items, enum, pattern, minimum, maximum, minLength, minItems,
+// anyOf and $ref (to $defs of the same or another shipped schema).
+package schema
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Names of the shipped schema files
+const (
+	ParserConfigSchemaFile   = "parser_config.schema.json"
+	WizardTemplateSchemaFile = "wizard_template.schema.json"
+)
+
+//go:embed parser_config.schema.json wizard_template.schema.json
+var schemaFiles embed.FS
+
+// Schema is a JSON Schema node
+type Schema struct {
+	Type                 typeList           `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+	Description          string             `json:"description,omitempty"`
+
+	boolean *bool          // Set for the boolean schemas true/false
+	pattern *regexp.Regexp // Compiled Pattern
+	file    string         // Schema file the node belongs to (for $ref resolution)
+}
+
+// typeList is "type" given either as a string or as an array of strings
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = typeList{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = list
+	return nil
+}
+
+// UnmarshalJSON accepts boolean schemas (true = anything, false = nothing)
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.Equal(trimmed, []byte("true")) || bytes.Equal(trimmed, []byte("false")) {
+		value := trimmed[0] == 't'
+		s.boolean = &value
+		return nil
+	}
+	type plain Schema
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// ValidationError is a single schema violation
+type ValidationError struct {
+	Pointer string `json:"pointer"` // JSON pointer of the value ("" = document root)
+	Line    int    `json:"line"`    // 1-based line in the original text (0 if unknown)
+	Column  int    `json:"column"`  // 1-based column in characters
+	Message string `json:"message"`
+
+	offset int
+}
+
+// Error formats the error as "line L, col C: /pointer: message"
+func (e ValidationError) Error() string {
+	pointer := e.Pointer
+	if pointer == "" {
+		pointer = "(root)"
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d, col %d: %s: %s", e.Line, e.Column, pointer, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", pointer, e.Message)
+}
+
+var (
+	loadOnce sync.Once
+	roots    map[string]*Schema
+	loadErr  error
+)
+
+// loadSchemas parses and prepares the embedded schema files once
+func loadSchemas() (map[string]*Schema, error) {
+	loadOnce.Do(func() {
+		roots = make(map[string]*Schema)
+		for _, name := range []string{ParserConfigSchemaFile, WizardTemplateSchemaFile} {
+			data, err := schemaFiles.ReadFile(name)
+			if err != nil {
+				loadErr = fmt.Errorf("schema %s: %w", name, err)
+				return
+			}
+			root := &Schema{}
+			if err := json.Unmarshal(data, root); err != nil {
+				loadErr = fmt.Errorf("schema %s: %w", name, err)
+				return
+			}
+			if err := prepare(root, name); err != nil {
+				loadErr = fmt.Errorf("schema %s: %w", name, err)
+				return
+			}
+			roots[name] = root
+		}
+	})
+	return roots, loadErr
+}
+
+// prepare compiles patterns and remembers the file of every node
+func prepare(s *Schema, file string) error {
+	if s == nil {
+		return nil
+	}
+	s.file = file
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	children := []*Schema{s.AdditionalProperties, s.Items}
+	children = append(children, s.AnyOf...)
+	for _, child := range s.Properties {
+		children = append(children, child)
+	}
+	for _, child := range s.Defs {
+		children = append(children, child)
+	}
+	for _, child := range children {
+		if err := prepare(child, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve follows $ref ("#/$defs/name" or "file.schema.json#/$defs/name")
+func resolve(s *Schema) (*Schema, error) {
+	for depth := 0; s.Ref != ""; depth++ {
+		if depth > 32 {
+			return nil, fmt.Errorf("$ref loop at %q", s.Ref)
+		}
+		file, fragment, _ := strings.Cut(s.Ref, "#")
+		if file == "" {
+			file = s.file
+		}
+		root, ok := roots[file]
+		if !ok {
+			return nil, fmt.Errorf("unknown schema %q in $ref", file)
+		}
+		target := root
+		if fragment != "" {
+			name, found := strings.CutPrefix(fragment, "/$defs/")
+			if !found || root.Defs[name] == nil {
+				return nil, fmt.Errorf("unresolved $ref %q", s.Ref)
+			}
+			target = root.Defs[name]
+		}
+		s = target
+	}
+	return s, nil
+}
+
+// ValidateParserConfig validates ParserConfig JSON(C) text ({"ParserConfig": {...}})
+func ValidateParserConfig(data []byte) []ValidationError {
+	return ValidateDocument(ParserConfigSchemaFile, data)
+}
+
+// ValidateWizardTemplate validates the contents of wizard_template.json
+func ValidateWizardTemplate(data []byte) []ValidationError {
+	return ValidateDocument(WizardTemplateSchemaFile, data)
+}
+
+// ValidateDocument validates JSON(C) data against a shipped schema.
+// Syntax errors are reported as a single error with the position of the problem.
+// Errors are sorted by their position in the text.
+func ValidateDocument(schemaFile string, data []byte) []ValidationError {
+	schemas, err := loadSchemas()
+	if err != nil {
+		return []ValidationError{{Message: fmt.Sprintf("schema unavailable: %v", err)}}
+	}
+	root, ok := schemas[schemaFile]
+	if !ok {
+		return []ValidationError{{Message: fmt.Sprintf("unknown schema %q", schemaFile)}}
+	}
+
+	stripped := StripComments(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if bomLength := len(data) - len(stripped); bomLength > 0 {
+		// Keep offsets relative to the original data
+		stripped = append(bytes.Repeat([]byte(" "), bomLength), stripped...)
+	}
+
+	value, err := decode(stripped)
+	if err != nil {
+		return []ValidationError{syntaxError(data, err)}
+	}
+	offsets, _ := locate(stripped)
+
+	v := &validator{}
+	v.validate(value, root, "")
+
+	result := make([]ValidationError, 0, len(v.errors))
+	seen := make(map[string]bool)
+	for _, e := range v.errors {
+		key := e.Pointer + "\x00" + e.Message
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		e.offset = offsetOf(offsets, e.Pointer)
+		e.Line, e.Column = Position(data, e.offset)
+		result = append(result, e)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].offset < result[j].offset })
+	return result
+}
+
+// decode parses a single JSON value keeping numbers as json.Number
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	if rest := bytes.TrimLeft(data[dec.InputOffset():], " \t\r\n"); len(rest) > 0 {
+		return nil, &json.SyntaxError{Offset: int64(len(data) - len(rest))}
+	}
+	return value, nil
+}
+
+// syntaxError converts a decoding error into a positioned ValidationError
+func syntaxError(data []byte, err error) ValidationError {
+	offset := len(data)
+	message := err.Error()
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset = int(syntaxErr.Offset)
+		if message == "" {
+			message = "unexpected data after the top-level value"
+		}
+	} else if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		message = "unexpected end of JSON"
+	}
+	result := ValidationError{Message: "invalid JSON: " + message, offset: offset}
+	result.Line, result.Column = Position(data, offset)
+	return result
+}
+
+// validator collects violations while walking a document
+type validator struct {
+	errors []ValidationError
+}
+
+func (v *validator) addf(pointer, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+// validate checks value against s and records violations under pointer
+func (v *validator) validate(value interface{}, s *Schema, pointer string) {
+	if s == nil {
+		return
+	}
+	if s.boolean != nil {
+		if !*s.boolean {
+			v.addf(pointer, "value is not allowed here")
+		}
+		return
+	}
+	s, err := resolve(s)
+	if err != nil {
+		v.addf(pointer, "schema error: %v", err)
+		return
+	}
+
+	if len(s.AnyOf) > 0 {
+		v.validateAnyOf(value, s, pointer)
+	}
+
+	if len(s.Type) > 0 && !typeMatches(value, s.Type) {
+		v.addf(pointer, "expected %s, got %s", strings.Join(s.Type, " or "), jsonType(value))
+		return
+	}
+
+	if len(s.Enum) > 0 && !enumContains(s.Enum, value) {
+		allowed := make([]string, len(s.Enum))
+		for i, option := range s.Enum {
+			encoded, _ := json.Marshal(option)
+			allowed[i] = string(encoded)
+		}
+		v.addf(pointer, "value %s is not one of: %s", describe(value), strings.Join(allowed, ", "))
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(typed, s, pointer)
+	case []interface{}:
+		if s.MinItems != nil && len(typed) < *s.MinItems {
+			v.addf(pointer, "expected at least %d item(s), got %d", *s.MinItems, len(typed))
+		}
+		for i, item := range typed {
+			v.validate(item, s.Items, fmt.Sprintf("%s/%d", pointer, i))
+		}
+	case string:
+		if s.MinLength != nil && len([]rune(typed)) < *s.MinLength {
+			if *s.MinLength == 1 {
+				v.addf(pointer, "must not be empty")
+			} else {
+				v.addf(pointer, "must be at least %d characters long", *s.MinLength)
+			}
+		}
+		if s.pattern != nil && !s.pattern.MatchString(typed) {
+			if s.Description != "" {
+				v.addf(pointer, "invalid value %q: %s", typed, s.Description)
+			} else {
+				v.addf(pointer, "value %q does not match pattern %s", typed, s.Pattern)
+			}
+		}
+	case json.Number:
+		number, _ := typed.Float64()
+		if s.Minimum != nil && number < *s.Minimum {
+			v.addf(pointer, "must be >= %s", formatNumber(*s.Minimum))
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			v.addf(pointer, "must be <= %s", formatNumber(*s.Maximum))
+		}
+	}
+}
+
+// validateObject checks required, properties and additionalProperties
+func (v *validator) validateObject(object map[string]interface{}, s *Schema, pointer string) {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			v.addf(pointer, "missing required property %q", name)
+		}
+	}
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		childPointer := pointer + "/" + escapePointerToken(key)
+		if property, ok := s.Properties[key]; ok {
+			v.validate(object[key], property, childPointer)
+			continue
+		}
+		if s.AdditionalProperties == nil {
+			continue
+		}
+		if s.AdditionalProperties.boolean != nil && !*s.AdditionalProperties.boolean {
+			if suggestion := closestProperty(key, s.Properties); suggestion != "" {
+				v.addf(childPointer, "unknown property %q (did you mean %q?)", key, suggestion)
+			} else {
+				v.addf(childPointer, "unknown property %q", key)
+			}
+			continue
+		}
+		v.validate(object[key], s.AdditionalProperties, childPointer)
+	}
+}
+
+// validateAnyOf requires at least one alternative to match. If exactly one alternative accepts
+// the type of the value, its errors are reported (they are the most precise ones).
+func (v *validator) validateAnyOf(value interface{}, s *Schema, pointer string) {
+	var candidates [][]ValidationError
+	var types []string
+	for _, alternative := range s.AnyOf {
+		branch := &validator{}
+		branch.validate(value, alternative, pointer)
+		if len(branch.errors) == 0 {
+			return
+		}
+		resolved, err := resolve(alternative)
+		if err == nil && len(resolved.Type) > 0 {
+			types = append(types, resolved.Type...)
+			if !typeMatches(value, resolved.Type) {
+				continue
+			}
+		}
+		candidates = append(candidates, branch.errors)
+	}
+	if len(candidates) == 1 {
+		v.errors = append(v.errors, candidates[0]...)
+		return
+	}
+	if len(candidates) == 0 && len(types) > 0 {
+		v.addf(pointer, "expected %s, got %s", strings.Join(types, " or "), jsonType(value))
+		return
+	}
+	v.addf(pointer, "value does not match any of the allowed forms")
+}
+
+// jsonType returns the JSON type name of a decoded value
+func jsonType(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if isInteger(typed) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// typeMatches reports whether value has one of the types ("integer" is a "number" too)
+func typeMatches(value interface{}, types typeList) bool {
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// isInteger reports whether a number has no fractional part
+func isInteger(number json.Number) bool {
+	if _, err := number.Int64(); err == nil {
+		return true
+	}
+	f, err := number.Float64()
+	return err == nil && f == math.Trunc(f)
+}
+
+// enumContains compares value with enum options by their JSON encoding
+func enumContains(options []interface{}, value interface{}) bool {
+	encodedValue := normalizedJSON(value)
+	for _, option := range options {
+		if normalizedJSON(option) == encodedValue {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizedJSON(value interface{}) string {
+	if number, ok := value.(json.Number); ok {
+		if f, err := number.Float64(); err == nil {
+			value = f
+		}
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+// describe formats a value for messages (long values are shortened)
+func describe(value interface{}) string {
+	encoded, _ := json.Marshal(value)
+	if len(encoded) > 60 {
+		return string(encoded[:57]) + "..."
+	}
+	return string(encoded)
+}
+
+func formatNumber(f float64) string {
+	if f == math.Trunc(f) {
+		return fmt.Sprintf("%d", int64(f))
+	}
+	return fmt.Sprintf("%g", f)
+}
+
+// closestProperty returns a known property that differs from key by at most two edits
+func closestProperty(key string, properties map[string]*Schema) string {
+	best, bestDistance := "", 3
+	for name := range properties {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(name)); d < bestDistance || (d == bestDistance && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
@@ -0,0 +1,135 @@
+package schema
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStripComments_KeepsOffsets(t *testing.T) {
+	input := "{\n  // comment \"with quotes\"\n  \"a\": \"http://x/*y*/\", /* block\n  comment */ \"b\": [1, 2,],\n}"
+	out := StripComments([]byte(input))
+
+	if len(out) != len(input) {
+		t.Fatalf("length changed: %d vs %d", len(out), len(input))
+	}
+	if strings.Count(string(out), "\n") != strings.Count(input, "\n") {
+		t.Errorf("newlines were not preserved: %q", out)
+	}
+	if !strings.Contains(string(out), `"http://x/*y*/"`) {
+		t.Errorf("comment markers inside a string were stripped: %q", out)
+	}
+	if errs := ValidateDocument(ParserConfigSchemaFile, []byte(`{"ParserConfig": {"proxies": [], /* x */}}`)); len(errs) != 0 {
+		t.Errorf("JSONC with comments and trailing commas should be valid, got %v", errs)
+	}
+}
+
+func TestPosition(t *testing.T) {
+	data := []byte("ab\nсd\nef")
+	tests := []struct {
+		offset, line, column int
+	}{
+		{0, 1, 1},
+		{2, 1, 3},
+		{3, 2, 1},
+		{5, 2, 2}, // "с" is two bytes but one character
+		{len(data) + 10, 3, 3},
+	}
+	for _, tt := range tests {
+		line, column := Position(data, tt.offset)
+		if line != tt.line || column != tt.column {
+			t.Errorf("Position(%d) = %d:%d, want %d:%d", tt.offset, line, column, tt.line, tt.column)
+		}
+	}
+}
+
+func TestValidateParserConfig_ReportsPointerAndPosition(t *testing.T) {
+	input := `{
+  // Source list
+  "ParserConfig": {
+    "version": 5,
+    "proxies": [
+      { "source": "https://example.com/sub", "limit": "10" }
+    ],
+    "outbounds": [
+      { "tag": "proxy-out" }
+    ],
+    "parser": { "relaod": "4h", "quiet_hours": "late" }
+  }
+}`
+	errs := ValidateParserConfig([]byte(input))
+
+	expect := []struct {
+		pointer, message string
+		line, column     int
+	}{
+		{"/ParserConfig/proxies/0/limit", "expected integer, got string", 6, 55},
+		{"/ParserConfig/outbounds/0", `missing required property "type"`, 9, 7},
+		{"/ParserConfig/parser/quiet_hours", "HH:MM-HH:MM", 11, 48},
+		{"/ParserConfig/parser/relaod", `did you mean "reload"`, 11, 27},
+	}
+	if len(errs) != len(expect) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expect), len(errs), errs)
+	}
+	for _, want := range expect {
+		found := false
+		for _, e := range errs {
+			if e.Pointer == want.pointer && strings.Contains(e.Message, want.message) {
+				found = true
+				if e.Line != want.line || e.Column != want.column {
+					t.Errorf("%s: position %d:%d, want %d:%d", e.Pointer, e.Line, e.Column, want.line, want.column)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("no error %q at %s in %v", want.message, want.pointer, errs)
+		}
+	}
+	for i := 1; i < len(errs); i++ {
+		if errs[i].Line < errs[i-1].Line {
+			t.Errorf("errors are not sorted by position: %v", errs)
+		}
+	}
+}
+
+func TestValidateParserConfig_SyntaxError(t *testing.T) {
+	errs := ValidateParserConfig([]byte("{\n  \"ParserConfig\": {\n    \"proxies\": [}\n}"))
+	if len(errs) != 1 {
+		t.Fatalf("expected a single syntax error, got %v", errs)
+	}
+	if errs[0].Line != 3 || !strings.HasPrefix(errs[0].Message, "invalid JSON") {
+		t.Errorf("unexpected syntax error: %v", errs[0])
+	}
+}
+
+func TestValidateParserConfig_WizardForms(t *testing.T) {
+	valid := `{"ParserConfig": {"outbounds": [
+		{"tag": "a", "type": "selector", "wizard": "hide"},
+		{"tag": "b", "type": "selector", "wizard": {"hide": true, "required": 2}}
+	]}}`
+	if errs := ValidateParserConfig([]byte(valid)); len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+
+	invalid := `{"ParserConfig": {"outbounds": [{"tag": "a", "type": "selector", "wizard": {"required": "2"}}]}}`
+	errs := ValidateParserConfig([]byte(invalid))
+	if len(errs) != 1 || errs[0].Pointer != "/ParserConfig/outbounds/0/wizard/required" {
+		t.Errorf("expected an error for wizard.required, got %v", errs)
+	}
+}
+
+func TestValidateWizardTemplate_ShippedTemplate(t *testing.T) {
+	data, err := os.ReadFile("../../../bin/wizard_template.json")
+	if err != nil {
+		t.Skipf("wizard_template.json not available: %v", err)
+	}
+	if errs := ValidateWizardTemplate(data); len(errs) != 0 {
+		t.Errorf("shipped wizard_template.json does not match the schema: %v", errs)
+	}
+
+	broken := `{"parser_config": {"proxies": []}, "config": {}, "params": [{"name": "inbounds", "platforms": ["android"], "value": []}]}`
+	errs := ValidateWizardTemplate([]byte(broken))
+	if len(errs) != 1 || errs[0].Pointer != "/params/0/platforms/0" {
+		t.Errorf("expected an error for an unknown platform, got %v", errs)
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"singbox-launcher/internal/constants"
+)
+
+// SelectorChoice is a proxy manually chosen in a selector group.
+// Identity is set for subscription nodes so the choice survives renames of the node tag.
+type SelectorChoice struct {
+	Tag      string        `json:"tag"`
+	Identity *NodeIdentity `json:"identity,omitempty"`
+	Time     time.Time     `json:"time"`
+}
+
+// LauncherState is launcher state persisted next to config.json between runs
+type LauncherState struct {
+	Selections    map[string]SelectorChoice `json:"selections,omitempty"`     // selector group tag -> choice
+	ControlAPI    *ControlAPISettings       `json:"control_api,omitempty"`    // Local control API (opt-in)
+	RestartPolicy *RestartPolicySettings    `json:"restart_policy,omitempty"` // Crash restart policy of sing-box
+	HealthCheck   *HealthCheckSettings      `json:"health_check,omitempty"`   // Health watchdog of the running sing-box
+	Profile       string                    `json:"profile,omitempty"`        // Active configuration profile (bin/launcher_state.json only)
+}
+
+// LauncherStatePath returns path of the launcher state file next to config.json
+func LauncherStatePath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), constants.LauncherStateFileName)
+}
+
+// LoadLauncherState reads launcher state from path. A missing file yields an empty state.
+func LoadLauncherState(path string) (*LauncherState, error) {
+	state := &LauncherState{Selections: make(map[string]SelectorChoice)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return state, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return &LauncherState{Selections: make(map[string]SelectorChoice)}, fmt.Errorf("failed to parse launcher state: %w", err)
+	}
+	if state.Selections == nil {
+		state.Selections = make(map[string]SelectorChoice)
+	}
+	return state, nil
+}
+
+// SaveLauncherState writes launcher state to path atomically.
+// The file holds the control API token, so it is readable by the owner only.
+func SaveLauncherState(path string, state *LauncherState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal launcher state: %w", err)
+	}
+	if err := WriteFileAtomicMode(path, data, 0600, nil); err != nil {
+		return fmt.Errorf("failed to write launcher state: %w", err)
+	}
+	return nil
+}
+
+// launcherStateLocks serializes UpdateLauncherState per file (path -> *sync.Mutex)
+var launcherStateLocks sync.Map
+
+// UpdateLauncherState reads the launcher state, applies update and writes it back,
+// so that a writer of one section does not drop the others. Concurrent updates of
+// the same file are serialized.
+func UpdateLauncherState(path string, update func(state *LauncherState)) error {
+	lock, _ := launcherStateLocks.LoadOrStore(filepath.Clean(path), &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	state, err := LoadLauncherState(path)
+	if err != nil {
+		return err
+	}
+	update(state)
+	return SaveLauncherState(path, state)
+}
+
+// LoadNodeIdentities returns identities of subscription nodes by tag, taken from node history
+// of the last successful update. Nodes that are not from subscriptions are absent.
+func LoadNodeIdentities(configPath string) map[string]NodeIdentity {
+	identities := make(map[string]NodeIdentity)
+	history, err := LoadNodeHistory(NodeHistoryPath(configPath))
+	if err != nil {
+		return identities
+	}
+	for _, nodes := range history.Sources {
+		for tag, identity := range nodes {
+			identities[tag] = identity
+		}
+	}
+	return identities
+}
+
+// NewSelectorChoice builds a choice of tag, attaching the node identity if it is known
+func NewSelectorChoice(tag string, identities map[string]NodeIdentity, now time.Time) SelectorChoice {
+	choice := SelectorChoice{Tag: tag, Time: now.UTC()}
+	if identity, ok := identities[tag]; ok {
+		choice.Identity = &identity
+	}
+	return choice
+}
+
+// ResolveSelectorChoice finds the group member that corresponds to a saved choice.
+// The identity (endpoint and credentials) wins over the tag text, so a renamed node is found
+// under its new tag and a tag reused for a different server is not picked. Falls back to
+// the same endpoint with other credentials and then to the tag alone.
+// Returns false if the chosen node is gone.
+func ResolveSelectorChoice(choice SelectorChoice, members []string, identities map[string]NodeIdentity) (string, bool) {
+	tagPresent := false
+	for _, member := range members {
+		if member == choice.Tag {
+			tagPresent = true
+			break
+		}
+	}
+	if choice.Identity == nil {
+		return choice.Tag, tagPresent
+	}
+
+	if identity, ok := identities[choice.Tag]; tagPresent && (!ok || identity == *choice.Identity) {
+		return choice.Tag, true
+	}
+	for _, member := range members {
+		if identity, ok := identities[member]; ok && identity == *choice.Identity {
+			return member, true
+		}
+	}
+	for _, member := range members {
+		if identity, ok := identities[member]; ok && identity.Endpoint == choice.Identity.Endpoint {
+			return member, true
+		}
+	}
+	if tagPresent {
+		return choice.Tag, true
+	}
+	return "", false
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResolveSelectorChoice(t *testing.T) {
+	de := NodeIdentity{Endpoint: "vless://de.example.com:443", Credentials: "aaaaaaaaaaaa"}
+	nl := NodeIdentity{Endpoint: "vless://nl.example.com:443", Credentials: "bbbbbbbbbbbb"}
+	choice := SelectorChoice{Tag: "🇩🇪 Germany", Identity: &de}
+
+	tests := []struct {
+		name       string
+		members    []string
+		identities map[string]NodeIdentity
+		want       string
+		found      bool
+	}{
+		{"same tag and identity", []string{"🇩🇪 Germany", "🇳🇱 NL"}, map[string]NodeIdentity{"🇩🇪 Germany": de, "🇳🇱 NL": nl}, "🇩🇪 Germany", true},
+		{"renamed node", []string{"🇩🇪 Germany 2", "🇳🇱 NL"}, map[string]NodeIdentity{"🇩🇪 Germany 2": de, "🇳🇱 NL": nl}, "🇩🇪 Germany 2", true},
+		{"tag reused for another server", []string{"🇩🇪 Germany", "DE new"}, map[string]NodeIdentity{"🇩🇪 Germany": nl, "DE new": de}, "DE new", true},
+		{"credentials rotated", []string{"DE"}, map[string]NodeIdentity{"DE": {Endpoint: de.Endpoint, Credentials: "cccccccccccc"}}, "DE", true},
+		{"node removed", []string{"🇳🇱 NL", "direct-out"}, map[string]NodeIdentity{"🇳🇱 NL": nl}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := ResolveSelectorChoice(choice, tt.members, tt.identities)
+			if got != tt.want || found != tt.found {
+				t.Errorf("ResolveSelectorChoice() = %q, %v; want %q, %v", got, found, tt.want, tt.found)
+			}
+		})
+	}
+
+	// Choice without identity (e.g. direct-out or another selector) is matched by tag only
+	plain := SelectorChoice{Tag: "auto"}
+	if got, found := ResolveSelectorChoice(plain, []string{"auto", "direct-out"}, nil); !found || got != "auto" {
+		t.Errorf("plain choice: got %q, %v", got, found)
+	}
+	if _, found := ResolveSelectorChoice(plain, []string{"direct-out"}, nil); found {
+		t.Error("plain choice must not be found when the tag is gone")
+	}
+}
+
+func TestLauncherState_SaveLoad(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	path := LauncherStatePath(configPath)
+
+	state, err := LoadLauncherState(path)
+	if err != nil || len(state.Selections) != 0 {
+		t.Fatalf("missing file must give empty state, got %+v, %v", state, err)
+	}
+
+	identities := map[string]NodeIdentity{"de": {Endpoint: "vless://de.example.com:443"}}
+	state.Selections["proxy-out"] = NewSelectorChoice("de", identities, time.Now())
+	state.Selections["streaming"] = NewSelectorChoice("direct-out", identities, time.Now())
+	if err := SaveLauncherState(path, state); err != nil {
+		t.Fatalf("SaveLauncherState failed: %v", err)
+	}
+
+	loaded, err := LoadLauncherState(path)
+	if err != nil {
+		t.Fatalf("LoadLauncherState failed: %v", err)
+	}
+	if got := loaded.Selections["proxy-out"]; got.Tag != "de" || got.Identity == nil || got.Identity.Endpoint != "vless://de.example.com:443" {
+		t.Errorf("unexpected proxy-out choice: %+v", got)
+	}
+	if got := loaded.Selections["streaming"]; got.Tag != "direct-out" || got.Identity != nil {
+		t.Errorf("unexpected streaming choice: %+v", got)
+	}
+
+	// The state holds the control API token: readable by the owner only
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("launcher state permissions = %o, want 600", perm)
+		}
+	}
+}
+
+func TestUpdateLauncherState_Concurrent(t *testing.T) {
+	path := LauncherStatePath(filepath.Join(t.TempDir(), "config.json"))
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := UpdateLauncherState(path, func(state *LauncherState) {
+				group := fmt.Sprintf("group-%d", i)
+				state.Selections[group] = SelectorChoice{Tag: group}
+			})
+			if err != nil {
+				t.Errorf("UpdateLauncherState %d: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	state, err := LoadLauncherState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Selections) != writers {
+		t.Errorf("%d of %d concurrent updates survived", len(state.Selections), writers)
+	}
+}
@@ -0,0 +1,96 @@
+package subscription
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SubscriptionCache keeps decoded lines of the last successful download of every subscription,
+// so sources that are not due for reload can be rebuilt without downloading them again.
+// The modification time of a cache file is the time of the download.
+type SubscriptionCache struct {
+	Dir string
+}
+
+// NewSubscriptionCache creates a cache stored in dir
+func NewSubscriptionCache(dir string) *SubscriptionCache {
+	return &SubscriptionCache{Dir: dir}
+}
+
+// path returns the cache file of url (the URL itself is not stored)
+func (c *SubscriptionCache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:8])+".txt")
+}
+
+// FetchedAt returns the time of the last successful download of url
+func (c *SubscriptionCache) FetchedAt(url string) (time.Time, bool) {
+	info, err := os.Stat(c.path(url))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return info.ModTime(), true
+}
+
+// ReadLines streams cached lines of url to handleLine. Returns the cache file size.
+func (c *SubscriptionCache) ReadLines(url string, handleLine func(line string)) (int64, error) {
+	f, err := os.Open(c.path(url))
+	if err != nil {
+		return 0, fmt.Errorf("subscription cache: %w", err)
+	}
+	defer f.Close()
+
+	counter := &countingReader{r: f}
+	scanner := bufio.NewScanner(counter)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSubscriptionLineLength)
+	for scanner.Scan() {
+		handleLine(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return counter.n, fmt.Errorf("subscription cache: %w", err)
+	}
+	return counter.n, nil
+}
+
+// FetchLines downloads url like FetchSubscriptionLines and stores decoded lines in the cache.
+// The cache is replaced only if the whole subscription was downloaded successfully.
+func (c *SubscriptionCache) FetchLines(url string, handleLine func(line string)) (int64, error) {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return FetchSubscriptionLines(url, handleLine)
+	}
+	tmp, err := os.CreateTemp(c.Dir, ".fetch-*.tmp")
+	if err != nil {
+		return FetchSubscriptionLines(url, handleLine)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op after successful rename
+
+	writer := bufio.NewWriter(tmp)
+	var writeErr error
+	bytesRead, err := FetchSubscriptionLines(url, func(line string) {
+		if writeErr == nil {
+			_, writeErr = writer.WriteString(line + "\n")
+		}
+		handleLine(line)
+	})
+	if writeErr == nil {
+		writeErr = writer.Flush()
+	}
+	if closeErr := tmp.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	if err != nil || writeErr != nil {
+		return bytesRead, err
+	}
+	if err := os.Rename(tmpPath, c.path(url)); err != nil {
+		// The nodes were loaded, only caching failed
+		log.Printf("Parser: Warning: failed to cache subscription: %v", err)
+	}
+	return bytesRead, nil
+}
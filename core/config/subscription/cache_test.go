@@ -0,0 +1,57 @@
+package subscription
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"singbox-launcher/core/config"
+)
+
+// TestSubscriptionCache_FetchAndReuse tests that a downloaded subscription is rebuilt from cache
+// with the same nodes and that a failed download keeps the previous cache
+func TestSubscriptionCache_FetchAndReuse(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			_, _ = w.Write([]byte("trojan://pass@one.example.com:443#one\ntrojan://pass@two.example.com:443#two\n"))
+		}
+	}))
+	defer server.Close()
+
+	cache := NewSubscriptionCache(t.TempDir())
+	source := config.ProxySource{Source: server.URL + "/sub"}
+
+	if _, ok := cache.FetchedAt(source.Source); ok {
+		t.Fatal("empty cache must not report a download time")
+	}
+
+	fetched, err := LoadNodesFromSourceWithFetcher(cache.FetchLines, source, map[string]int{}, nil, 0, 1, nil)
+	if err != nil || len(fetched) != 2 {
+		t.Fatalf("expected 2 downloaded nodes, got %d (%v)", len(fetched), err)
+	}
+	if _, ok := cache.FetchedAt(source.Source); !ok {
+		t.Fatal("successful download must be cached")
+	}
+
+	// Server fails now: cache must stay intact
+	status = http.StatusInternalServerError
+	report := &config.SourceReport{}
+	if _, err := LoadNodesFromSourceWithFetcher(cache.FetchLines, source, map[string]int{}, nil, 0, 1, report); err == nil {
+		t.Fatal("failed download must return an error")
+	}
+	if report.Error == "" {
+		t.Error("failed download must be reported")
+	}
+
+	cached, err := LoadNodesFromSourceWithFetcher(cache.ReadLines, source, map[string]int{}, nil, 0, 1, nil)
+	if err != nil || len(cached) != 2 {
+		t.Fatalf("expected 2 cached nodes, got %d (%v)", len(cached), err)
+	}
+	for i := range cached {
+		if cached[i].Tag != fetched[i].Tag || cached[i].Server != fetched[i].Server {
+			t.Errorf("node %d differs: cached %s/%s, fetched %s/%s", i, cached[i].Tag, cached[i].Server, fetched[i].Tag, fetched[i].Server)
+		}
+	}
+}
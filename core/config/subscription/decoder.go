@@ -1,8 +1,10 @@
 package subscription
 
 import (
+	"bufio"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"log"
 	"strings"
 	"unicode/utf8"
@@ -84,3 +86,105 @@ func DecodeSubscriptionContent(content []byte) ([]byte, error) {
 
 	return nil, fmt.Errorf("failed to decode base64 content: %w", err)
 }
+
+// maxSubscriptionLineLength ограничивает длину одной строки подписки при потоковом чтении
+const maxSubscriptionLineLength = 1024 * 1024 // 1 MB
+
+// subscriptionSniffSize - сколько байт читается заранее для определения формата подписки
+const subscriptionSniffSize = 4096
+
+// DecodeSubscriptionStream декодирует подписку (base64 или plain text) потоково
+// и передаёт каждую непустую строку в handleLine по мере чтения, не загружая весь ответ в память.
+// Формат определяется по первым байтам: наличие "://" - plain text, "{"/"[" - JSON (ошибка), иначе base64
+// (стандартный или URL-safe, с паддингом или без, с переносами строк или без).
+func DecodeSubscriptionStream(r io.Reader, handleLine func(line string)) error {
+	br := bufio.NewReaderSize(r, subscriptionSniffSize)
+	head, err := br.Peek(subscriptionSniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return fmt.Errorf("failed to read subscription content: %w", err)
+	}
+
+	headStr := strings.TrimSpace(string(head))
+	if headStr == "" {
+		return fmt.Errorf("subscription content is empty")
+	}
+
+	var lines io.Reader
+	switch {
+	case strings.Contains(headStr, "://"):
+		log.Printf("[DEBUG] DecodeSubscriptionStream: Detected plain text subscription (contains '://')")
+		lines = br
+	case strings.HasPrefix(headStr, "{") || strings.HasPrefix(headStr, "["):
+		log.Printf("[DEBUG] DecodeSubscriptionStream: Content is JSON configuration, not a subscription list")
+		return fmt.Errorf("subscription URL returned JSON configuration instead of subscription list (base64 or plain text links)")
+	case !isBase64Text(headStr):
+		return fmt.Errorf("failed to decode base64 content: unexpected characters")
+	default:
+		log.Printf("[DEBUG] DecodeSubscriptionStream: Detected base64 subscription")
+		lines = base64.NewDecoder(base64.RawStdEncoding, &base64NormalizingReader{r: br})
+	}
+
+	scanner := bufio.NewScanner(lines)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSubscriptionLineLength)
+	lineCount := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		lineCount++
+		handleLine(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to decode subscription content after %d line(s): %w", lineCount, err)
+	}
+	if lineCount == 0 {
+		return fmt.Errorf("decoded content is empty")
+	}
+
+	log.Printf("[DEBUG] DecodeSubscriptionStream: successfully decoded: %d line(s)", lineCount)
+	return nil
+}
+
+// isBase64Text проверяет, что строка состоит только из символов base64 (оба алфавита), паддинга и пробелов.
+func isBase64Text(s string) bool {
+	for _, c := range s {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '+', c == '/', c == '-', c == '_', c == '=':
+		case c == '\n', c == '\r', c == ' ', c == '\t':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// base64NormalizingReader приводит поток base64 к стандартному алфавиту без паддинга:
+// удаляет пробелы/переводы строк и "=", заменяет URL-safe символы ("-" -> "+", "_" -> "/").
+type base64NormalizingReader struct {
+	r io.Reader
+}
+
+func (n *base64NormalizingReader) Read(p []byte) (int, error) {
+	for {
+		read, err := n.r.Read(p)
+		out := 0
+		for _, c := range p[:read] {
+			switch c {
+			case '\n', '\r', ' ', '\t', '=':
+				continue
+			case '-':
+				c = '+'
+			case '_':
+				c = '/'
+			}
+			p[out] = c
+			out++
+		}
+		// Не возвращаем (0, nil), если весь прочитанный блок состоял из пропускаемых символов
+		if out > 0 || err != nil {
+			return out, err
+		}
+	}
+}
@@ -79,3 +79,61 @@ func TestDecodeSubscriptionContent(t *testing.T) {
 		})
 	}
 }
+
+// TestDecodeSubscriptionStream tests streaming decoding of subscription content
+func TestDecodeSubscriptionStream(t *testing.T) {
+	links := "vless://a@example.com:443#one\r\nvmess://test\n\ntrojan://p@example.com:443#three\n"
+	longBase64 := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("ss://node@example.com:8388#n\n", 500)))
+
+	tests := []struct {
+		name          string
+		content       string
+		expectError   bool
+		expectedLines int
+	}{
+		{name: "Plain text", content: links, expectedLines: 3},
+		{name: "Base64 standard with padding", content: base64.StdEncoding.EncodeToString([]byte(links)), expectedLines: 3},
+		{name: "Base64 URL-safe without padding", content: base64.RawURLEncoding.EncodeToString([]byte(links)), expectedLines: 3},
+		{name: "Base64 wrapped in lines", content: wrapLines(longBase64, 76), expectedLines: 500},
+		{name: "JSON configuration", content: `{"outbounds": []}`, expectError: true},
+		{name: "Empty content", content: "  \n ", expectError: true},
+		{name: "Garbage", content: "<html>not a subscription</html>", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lines []string
+			err := DecodeSubscriptionStream(strings.NewReader(tt.content), func(line string) {
+				lines = append(lines, line)
+			})
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(lines) != tt.expectedLines {
+				t.Errorf("Expected %d lines, got %d: %v", tt.expectedLines, len(lines), lines)
+			}
+			for _, line := range lines {
+				if !strings.Contains(line, "://") || strings.ContainsAny(line, "\r\n") {
+					t.Errorf("Unexpected line %q", line)
+				}
+			}
+		})
+	}
+}
+
+func wrapLines(s string, width int) string {
+	var b strings.Builder
+	for len(s) > width {
+		b.WriteString(s[:width])
+		b.WriteString("\n")
+		s = s[width:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
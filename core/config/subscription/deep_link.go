@@ -0,0 +1,82 @@
+package subscription
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// deepLinkHosts maps supported deep-link schemes to the action ("host") they must carry
+var deepLinkHosts = map[string]string{
+	"sing-box":  "import-remote-profile", // sing-box://import-remote-profile?url=<url>#<name>
+	"clash":     "install-config",        // clash://install-config?url=<url>&name=<name>
+	"clashmeta": "install-config",        // clashmeta://install-config?url=<url>&name=<name>
+}
+
+// DeepLink is a subscription import link published by providers
+type DeepLink struct {
+	URL  string // Subscription URL (http:// or https://)
+	Name string // Profile name suggested by the provider (may be empty)
+}
+
+// DeepLinkSchemes returns the URL schemes of supported deep links (for registering the launcher as their handler)
+func DeepLinkSchemes() []string {
+	schemes := make([]string, 0, len(deepLinkHosts))
+	for scheme := range deepLinkHosts {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// IsDeepLink checks if the input uses one of the supported deep-link schemes
+func IsDeepLink(input string) bool {
+	trimmed := strings.ToLower(strings.TrimSpace(input))
+	for scheme := range deepLinkHosts {
+		if strings.HasPrefix(trimmed, scheme+"://") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseDeepLink extracts the subscription URL and the profile name from a deep link:
+// sing-box://import-remote-profile?url=...#name or clash://install-config?url=...&name=...
+func ParseDeepLink(input string) (*DeepLink, error) {
+	u, err := url.Parse(strings.TrimSpace(input))
+	if err != nil {
+		return nil, fmt.Errorf("invalid deep link: %w", err)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	host, ok := deepLinkHosts[scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported deep link scheme %q", u.Scheme)
+	}
+	// Some providers write the action as a path: sing-box:///import-remote-profile
+	action := u.Host
+	if action == "" {
+		action = strings.Trim(u.Path, "/")
+	}
+	if !strings.EqualFold(action, host) {
+		return nil, fmt.Errorf("unsupported %s:// action %q (expected %q)", scheme, action, host)
+	}
+
+	query := u.Query()
+	link := &DeepLink{URL: strings.TrimSpace(query.Get("url"))}
+	if link.URL == "" {
+		return nil, fmt.Errorf("%s:// link has no url parameter", scheme)
+	}
+	if !IsSubscriptionURL(link.URL) {
+		return nil, fmt.Errorf("%s:// link: subscription URL must start with http:// or https://", scheme)
+	}
+	if _, err := url.Parse(link.URL); err != nil {
+		return nil, fmt.Errorf("%s:// link: invalid subscription URL: %w", scheme, err)
+	}
+
+	link.Name = strings.TrimSpace(query.Get("name"))
+	if link.Name == "" {
+		link.Name = strings.TrimSpace(u.Fragment)
+	}
+	return link, nil
+}
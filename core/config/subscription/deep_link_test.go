@@ -0,0 +1,78 @@
+package subscription
+
+import (
+	"testing"
+)
+
+func TestParseDeepLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantURL  string
+		wantName string
+		wantErr  bool
+	}{
+		{
+			name:     "sing-box with name in fragment",
+			input:    "sing-box://import-remote-profile?url=https%3A%2F%2Fexample.com%2Fsub%3Ftoken%3Dabc%26flag%3Dsingbox#My%20VPN",
+			wantURL:  "https://example.com/sub?token=abc&flag=singbox",
+			wantName: "My VPN",
+		},
+		{
+			name:    "sing-box without name",
+			input:   "sing-box://import-remote-profile?url=https://example.com/sub",
+			wantURL: "https://example.com/sub",
+		},
+		{
+			name:     "clash install-config",
+			input:    "clash://install-config?url=https%3A%2F%2Fexample.com%2Fclash&name=Provider",
+			wantURL:  "https://example.com/clash",
+			wantName: "Provider",
+		},
+		{
+			name:    "clashmeta with upper-case scheme",
+			input:   "ClashMeta://install-config?url=http%3A%2F%2Fexample.com%2Fs",
+			wantURL: "http://example.com/s",
+		},
+		{
+			name:    "action as path",
+			input:   "sing-box:///import-remote-profile?url=https%3A%2F%2Fexample.com%2Fsub",
+			wantURL: "https://example.com/sub",
+		},
+		{name: "wrong action", input: "clash://import-remote-profile?url=https://example.com/sub", wantErr: true},
+		{name: "missing url", input: "sing-box://import-remote-profile?name=x", wantErr: true},
+		{name: "non-http url", input: "clash://install-config?url=file%3A%2F%2F%2Fetc%2Fpasswd", wantErr: true},
+		{name: "unsupported scheme", input: "vless://uuid@host:443", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := ParseDeepLink(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %+v", link)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if link.URL != tt.wantURL || link.Name != tt.wantName {
+				t.Errorf("Got URL=%q name=%q, want URL=%q name=%q", link.URL, link.Name, tt.wantURL, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestIsDeepLink(t *testing.T) {
+	for _, input := range []string{"sing-box://import-remote-profile?url=x", " clash://install-config?url=x", "clashmeta://install-config"} {
+		if !IsDeepLink(input) {
+			t.Errorf("IsDeepLink(%q) = false", input)
+		}
+	}
+	for _, input := range []string{"https://example.com", "vless://uuid@host:443", "sing-box", ""} {
+		if IsDeepLink(input) {
+			t.Errorf("IsDeepLink(%q) = true", input)
+		}
+	}
+}
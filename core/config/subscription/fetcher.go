@@ -14,6 +14,9 @@ import (
 // NetworkRequestTimeout defines the timeout for network requests
 const NetworkRequestTimeout = 30 * time.Second
 
+// maxResponseSize limits the subscription response size to prevent memory exhaustion
+const maxResponseSize = 10 * 1024 * 1024 // 10 MB
+
 // CreateHTTPClientFunc is a function variable that should be set to core.CreateHTTPClient
 var CreateHTTPClientFunc func(timeout time.Duration) *http.Client
 
@@ -23,12 +26,9 @@ var IsNetworkErrorFunc func(err error) bool
 // GetNetworkErrorMessageFunc is a function variable that should be set to core.GetNetworkErrorMessage
 var GetNetworkErrorMessageFunc func(err error) string
 
-// FetchSubscription fetches subscription content from URL and decodes it
-// Returns decoded content and error if fetch or decode fails
-func FetchSubscription(url string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), NetworkRequestTimeout)
-	defer cancel()
-
+// openSubscription performs GET request for subscription URL and checks HTTP status.
+// Caller must close response body.
+func openSubscription(ctx context.Context, url string) (*http.Response, error) {
 	// Use simple HTTP client if CreateHTTPClientFunc not set
 	var client *http.Client
 	if CreateHTTPClientFunc != nil {
@@ -46,12 +46,10 @@ func FetchSubscription(url string) ([]byte, error) {
 	req.Header.Set("User-Agent", config.SubscriptionUserAgent)
 
 	resp, err := client.Do(req)
-	defer func() {
+	if err != nil {
 		if resp != nil {
-			debuglog.RunAndLog("FetchSubscription: close response body", resp.Body.Close)
+			debuglog.RunAndLog("openSubscription: close response body", resp.Body.Close)
 		}
-	}()
-	if err != nil {
 		if IsNetworkErrorFunc != nil && IsNetworkErrorFunc(err) {
 			return nil, fmt.Errorf("network error: %s", GetNetworkErrorMessageFunc(err))
 		}
@@ -59,11 +57,35 @@ func FetchSubscription(url string) ([]byte, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("subscription server returned status %d", resp.StatusCode)
+		debuglog.RunAndLog("openSubscription: close response body", resp.Body.Close)
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode}
+	}
+
+	return resp, nil
+}
+
+// HTTPStatusError is returned when subscription server responds with non-200 status
+type HTTPStatusError struct {
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("subscription server returned status %d", e.StatusCode)
+}
+
+// FetchSubscription fetches subscription content from URL and decodes it
+// Returns decoded content and error if fetch or decode fails
+func FetchSubscription(url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), NetworkRequestTimeout)
+	defer cancel()
+
+	resp, err := openSubscription(ctx, url)
+	if err != nil {
+		return nil, err
 	}
+	defer debuglog.RunAndLog("FetchSubscription: close response body", resp.Body.Close)
 
 	// Limit response size to prevent memory exhaustion
-	const maxResponseSize = 10 * 1024 * 1024 // 10 MB
 	limitedReader := io.LimitReader(resp.Body, maxResponseSize+1)
 
 	content, err := io.ReadAll(limitedReader)
@@ -96,3 +118,43 @@ func FetchSubscription(url string) ([]byte, error) {
 
 	return decoded, nil
 }
+
+// FetchSubscriptionLines fetches subscription from URL and streams decoded lines to handleLine
+// without buffering the whole response (see DecodeSubscriptionStream).
+// Returns number of raw bytes read from the server. A response larger than maxResponseSize is an error.
+func FetchSubscriptionLines(url string, handleLine func(line string)) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), NetworkRequestTimeout)
+	defer cancel()
+
+	resp, err := openSubscription(ctx, url)
+	if err != nil {
+		return 0, err
+	}
+	defer debuglog.RunAndLog("FetchSubscriptionLines: close response body", resp.Body.Close)
+
+	return readSubscriptionLines(resp.Body, handleLine)
+}
+
+// readSubscriptionLines decodes the subscription stream r, failing if it exceeds maxResponseSize
+func readSubscriptionLines(r io.Reader, handleLine func(line string)) (int64, error) {
+	counter := &countingReader{r: io.LimitReader(r, maxResponseSize+1)}
+	if err := DecodeSubscriptionStream(counter, handleLine); err != nil {
+		return counter.n, fmt.Errorf("FetchSubscriptionLines: %w", err)
+	}
+	if counter.n > maxResponseSize {
+		return counter.n, fmt.Errorf("FetchSubscriptionLines: subscription content too large (exceeds %d bytes)", maxResponseSize)
+	}
+	return counter.n, nil
+}
+
+// countingReader counts bytes read from the underlying reader
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -1,8 +1,10 @@
 package subscription
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -51,14 +53,35 @@ func LogDuplicateTagStatistics(tagCounts map[string]int, logPrefix string) {
 	}
 }
 
+// LinesFetcher streams decoded lines of a subscription to handleLine and returns the number of bytes read.
+// FetchSubscriptionLines downloads the subscription; SubscriptionCache methods use the local cache.
+type LinesFetcher func(url string, handleLine func(line string)) (int64, error)
+
 // LoadNodesFromSource loads and processes nodes from a config.ProxySource
 // Handles subscriptions, legacy direct links, and connections
 // Returns list of parsed nodes with processed tags
+// If report is not nil, it is filled with per-source statistics (bytes, parsed/skipped/failed counts, dedups)
 func LoadNodesFromSource(
 	proxySource config.ProxySource,
 	tagCounts map[string]int,
 	progressCallback func(float64, string),
 	subscriptionIndex, totalSubscriptions int,
+	report *config.SourceReport,
+) ([]*config.ParsedNode, error) {
+	return LoadNodesFromSourceWithFetcher(FetchSubscriptionLines, proxySource, tagCounts, progressCallback, subscriptionIndex, totalSubscriptions, report)
+}
+
+// LoadNodesFromSourceWithFetcher is LoadNodesFromSource that reads subscription lines with fetchLines
+// (e.g. from SubscriptionCache for sources that are not due for reload).
+// If the subscription cannot be fetched, its nodes are dropped, but the nodes from Connections
+// are still returned together with the fetch error.
+func LoadNodesFromSourceWithFetcher(
+	fetchLines LinesFetcher,
+	proxySource config.ProxySource,
+	tagCounts map[string]int,
+	progressCallback func(float64, string),
+	subscriptionIndex, totalSubscriptions int,
+	report *config.SourceReport,
 ) ([]*config.ParsedNode, error) {
 	startTime := time.Now()
 	log.Printf("[DEBUG] LoadNodesFromSource: START source %d/%d at %s",
@@ -67,6 +90,33 @@ func LoadNodesFromSource(
 	nodes := make([]*config.ParsedNode, 0)
 	nodesFromThisSource := 0
 	skippedDueToLimit := 0
+	limit := proxySource.NodeLimit(config.DefaultNodeLimit) // Global parser.limit is resolved by the caller (0 = unlimited)
+	limitReached := func() bool {
+		return limit > 0 && nodesFromThisSource >= limit
+	}
+	if report == nil {
+		report = &config.SourceReport{} // Collect statistics anyway, simplifies the code below
+	}
+	defer func() {
+		report.Parsed = len(nodes)
+		report.SkippedByLimit = skippedDueToLimit
+		report.DurationMs = time.Since(startTime).Milliseconds()
+	}()
+
+	// addNode applies prefix/postfix/mask to the tag, makes it unique and appends the node
+	var addedTags []string // Tags counted in tagCounts, released if the subscription nodes are dropped
+	var fetchErr error     // Subscription download error; Connections are processed anyway
+	addNode := func(node *config.ParsedNode) {
+		// Apply prefix, postfix, or mask to tag if specified (with variable substitution)
+		tag := applyTagPrefixPostfix(node, proxySource.TagPrefix, proxySource.TagPostfix, proxySource.TagMask, nodesFromThisSource+1)
+		addedTags = append(addedTags, tag)
+		node.Tag = MakeTagUnique(tag, tagCounts, "Parser")
+		if node.Tag != tag {
+			report.Dedups++
+		}
+		nodes = append(nodes, node)
+		nodesFromThisSource++
+	}
 
 	// Process subscription from Source field
 	if proxySource.Source != "" {
@@ -78,74 +128,75 @@ func LoadNodesFromSource(
 					fmt.Sprintf("Downloading subscription %d/%d: %s", subscriptionIndex+1, totalSubscriptions, proxySource.Source))
 			}
 
+			// Download, decode and parse subscription line by line (streaming, without buffering the whole response)
 			fetchStartTime := time.Now()
 			log.Printf("[DEBUG] LoadNodesFromSource: Fetching subscription %d/%d: %s",
 				subscriptionIndex+1, totalSubscriptions, proxySource.Source)
-			content, err := FetchSubscription(proxySource.Source)
-			fetchDuration := time.Since(fetchStartTime)
-			if err != nil {
-				log.Printf("[DEBUG] LoadNodesFromSource: Failed to fetch subscription %d/%d (took %v): %v",
-					subscriptionIndex+1, totalSubscriptions, fetchDuration, err)
-				log.Printf("Parser: Error: Failed to fetch subscription from %s: %v", proxySource.Source, err)
-			} else if len(content) > 0 {
-				log.Printf("[DEBUG] LoadNodesFromSource: Fetched subscription %d/%d: %d bytes in %v",
-					subscriptionIndex+1, totalSubscriptions, len(content), fetchDuration)
 
-				if progressCallback != nil {
-					progressCallback(20+float64(subscriptionIndex)*50.0/float64(totalSubscriptions)+10.0/float64(totalSubscriptions),
-						fmt.Sprintf("Parsing subscription %d/%d: %s", subscriptionIndex+1, totalSubscriptions, proxySource.Source))
-				}
+			lineCount := 0
+			bytesRead, err := fetchLines(proxySource.Source, func(subLine string) {
+				lineCount++
+				report.Lines++
 
-				// Parse subscription content line by line
-				parseStartTime := time.Now()
-				// Normalize line endings (handle \r\n, \r, \n)
-				contentStr := string(content)
-				contentStr = strings.ReplaceAll(contentStr, "\r\n", "\n")
-				contentStr = strings.ReplaceAll(contentStr, "\r", "\n")
-				subscriptionLines := strings.Split(contentStr, "\n")
-				log.Printf("[DEBUG] LoadNodesFromSource: Parsing subscription %d/%d: %d lines",
-					subscriptionIndex+1, totalSubscriptions, len(subscriptionLines))
-
-				lineCount := 0
-				for _, subLine := range subscriptionLines {
-					subLine = strings.TrimSpace(subLine)
-					if subLine == "" {
-						continue
-					}
-					lineCount++
-
-					if nodesFromThisSource >= config.MaxNodesPerSubscription {
-						skippedDueToLimit++
-						if skippedDueToLimit == 1 {
-							log.Printf("[DEBUG] LoadNodesFromSource: Reached limit of %d nodes for subscription %d/%d",
-								config.MaxNodesPerSubscription, subscriptionIndex+1, totalSubscriptions)
-						}
-						continue
+				if limitReached() {
+					skippedDueToLimit++
+					if skippedDueToLimit == 1 {
+						log.Printf("[DEBUG] LoadNodesFromSource: Reached limit of %d nodes for subscription %d/%d",
+							limit, subscriptionIndex+1, totalSubscriptions)
 					}
+					return
+				}
 
-					nodeStartTime := time.Now()
-					node, err := ParseNode(subLine, proxySource.Skip)
-					if err != nil {
-						log.Printf("[DEBUG] LoadNodesFromSource: Failed to parse node %d from subscription %d/%d (took %v): %v",
-							lineCount, subscriptionIndex+1, totalSubscriptions, time.Since(nodeStartTime), err)
-						log.Printf("Parser: Warning: Failed to parse node from subscription %s: %v", proxySource.Source, err)
-						continue
-					}
+				nodeStartTime := time.Now()
+				node, err := ParseNode(subLine, proxySource.Skip)
+				if err != nil {
+					log.Printf("[DEBUG] LoadNodesFromSource: Failed to parse node %d from subscription %d/%d (took %v): %v",
+						lineCount, subscriptionIndex+1, totalSubscriptions, time.Since(nodeStartTime), err)
+					log.Printf("Parser: Warning: Failed to parse node from subscription %s: %v", proxySource.Source, err)
+					report.AddFailure(err)
+					return
+				}
 
-					if node != nil {
-						// Apply prefix, postfix, or mask to tag if specified (with variable substitution)
-						node.Tag = applyTagPrefixPostfix(node, proxySource.TagPrefix, proxySource.TagPostfix, proxySource.TagMask, nodesFromThisSource+1)
-						node.Tag = MakeTagUnique(node.Tag, tagCounts, "Parser")
-						nodes = append(nodes, node)
-						nodesFromThisSource++
-						if nodesFromThisSource%50 == 0 {
-							log.Printf("[DEBUG] LoadNodesFromSource: Parsed %d nodes from subscription %d/%d (elapsed: %v)",
-								nodesFromThisSource, subscriptionIndex+1, totalSubscriptions, time.Since(parseStartTime))
-						}
+				if node == nil {
+					report.Skipped++
+				} else {
+					addNode(node)
+					if nodesFromThisSource%50 == 0 {
+						log.Printf("[DEBUG] LoadNodesFromSource: Parsed %d nodes from subscription %d/%d (elapsed: %v)",
+							nodesFromThisSource, subscriptionIndex+1, totalSubscriptions, time.Since(fetchStartTime))
 					}
 				}
-				log.Printf("[DEBUG] LoadNodesFromSource: Parsed subscription %d/%d: %d nodes in %v (processed %d lines)",
-					subscriptionIndex+1, totalSubscriptions, nodesFromThisSource, time.Since(parseStartTime), lineCount)
+			})
+			report.Bytes = bytesRead
+			var statusErr *HTTPStatusError
+			switch {
+			case errors.As(err, &statusErr):
+				report.HTTPStatus = statusErr.StatusCode
+			case err == nil:
+				report.HTTPStatus = http.StatusOK
+			default:
+				report.HTTPStatus = 0 // Request or stream failed, the response is not usable
+			}
+			if err != nil {
+				report.Error = err.Error()
+				log.Printf("[DEBUG] LoadNodesFromSource: Failed to fetch subscription %d/%d (took %v, %d bytes, %d lines): %v",
+					subscriptionIndex+1, totalSubscriptions, time.Since(fetchStartTime), bytesRead, lineCount, err)
+				log.Printf("Parser: Error: Failed to fetch subscription from %s: %v", proxySource.Source, err)
+				// A stream that broke off partway has only a part of the servers: its nodes are dropped,
+				// so the caller keeps the subscription out of the config and out of the node churn history.
+				// Hand-entered Connections below are still used.
+				for _, tag := range addedTags {
+					if tagCounts[tag]--; tagCounts[tag] <= 0 {
+						delete(tagCounts, tag)
+					}
+				}
+				nodes = make([]*config.ParsedNode, 0)
+				nodesFromThisSource = 0
+				report.Dedups = 0
+				fetchErr = fmt.Errorf("failed to fetch subscription: %w", err)
+			} else {
+				log.Printf("[DEBUG] LoadNodesFromSource: Parsed subscription %d/%d: %d nodes from %d bytes in %v (processed %d lines)",
+					subscriptionIndex+1, totalSubscriptions, nodesFromThisSource, bytesRead, time.Since(fetchStartTime), lineCount)
 			}
 		} else if IsDirectLink(proxySource.Source) {
 			// Legacy format: direct link in Source
@@ -156,19 +207,19 @@ func LoadNodesFromSource(
 					fmt.Sprintf("Parsing direct link %d/%d", subscriptionIndex+1, totalSubscriptions))
 			}
 
-			if nodesFromThisSource < config.MaxNodesPerSubscription {
+			report.Lines++
+			if !limitReached() {
 				parseStartTime := time.Now()
 				node, err := ParseNode(proxySource.Source, proxySource.Skip)
 				if err != nil {
 					log.Printf("[DEBUG] LoadNodesFromSource: Failed to parse direct link (took %v): %v",
 						time.Since(parseStartTime), err)
 					log.Printf("Parser: Warning: Failed to parse direct link: %v", err)
-				} else if node != nil {
-					// Apply prefix, postfix, or mask to tag if specified (with variable substitution)
-					node.Tag = applyTagPrefixPostfix(node, proxySource.TagPrefix, proxySource.TagPostfix, proxySource.TagMask, nodesFromThisSource+1)
-					node.Tag = MakeTagUnique(node.Tag, tagCounts, "Parser")
-					nodes = append(nodes, node)
-					nodesFromThisSource++
+					report.AddFailure(err)
+				} else if node == nil {
+					report.Skipped++
+				} else {
+					addNode(node)
 					log.Printf("[DEBUG] LoadNodesFromSource: Parsed direct link in %v", time.Since(parseStartTime))
 				}
 			} else {
@@ -186,11 +237,13 @@ func LoadNodesFromSource(
 		if connection == "" {
 			continue
 		}
+		report.Lines++
 
 		if !IsDirectLink(connection) {
 			log.Printf("[DEBUG] LoadNodesFromSource: Invalid direct link format in connections %d/%d: %s",
 				connIndex+1, len(proxySource.Connections), connection)
 			log.Printf("Parser: Warning: Invalid direct link format in connections: %s", connection)
+			report.AddFailure(fmt.Errorf("invalid direct link format"))
 			continue
 		}
 
@@ -199,7 +252,7 @@ func LoadNodesFromSource(
 				fmt.Sprintf("Parsing direct link %d/%d (connection %d)", subscriptionIndex+1, totalSubscriptions, connIndex+1))
 		}
 
-		if nodesFromThisSource >= config.MaxNodesPerSubscription {
+		if limitReached() {
 			skippedDueToLimit++
 			continue
 		}
@@ -210,15 +263,14 @@ func LoadNodesFromSource(
 			log.Printf("[DEBUG] LoadNodesFromSource: Failed to parse connection %d/%d (took %v): %v",
 				connIndex+1, len(proxySource.Connections), time.Since(parseStartTime), err)
 			log.Printf("Parser: Warning: Failed to parse direct link from connections: %v", err)
+			report.AddFailure(err)
 			continue
 		}
 
-		if node != nil {
-			// Apply prefix, postfix, or mask to tag if specified (with variable substitution)
-			node.Tag = applyTagPrefixPostfix(node, proxySource.TagPrefix, proxySource.TagPostfix, proxySource.TagMask, nodesFromThisSource+1)
-			node.Tag = MakeTagUnique(node.Tag, tagCounts, "Parser")
-			nodes = append(nodes, node)
-			nodesFromThisSource++
+		if node == nil {
+			report.Skipped++
+		} else {
+			addNode(node)
 		}
 	}
 	if len(proxySource.Connections) > 0 {
@@ -230,13 +282,13 @@ func LoadNodesFromSource(
 		log.Printf("[DEBUG] LoadNodesFromSource: Source %d/%d exceeded limit, skipped %d nodes",
 			subscriptionIndex+1, totalSubscriptions, skippedDueToLimit)
 		log.Printf("Parser: Warning: Source exceeded limit of %d nodes. Skipped %d additional nodes.",
-			config.MaxNodesPerSubscription, skippedDueToLimit)
+			limit, skippedDueToLimit)
 	}
 
 	totalDuration := time.Since(startTime)
 	log.Printf("[DEBUG] LoadNodesFromSource: END source %d/%d (total duration: %v, nodes: %d)",
 		subscriptionIndex+1, totalSubscriptions, totalDuration, len(nodes))
-	return nodes, nil
+	return nodes, fetchErr
 }
 
 // applyTagPrefixPostfix applies prefix and postfix to a node tag if specified in ProxySource.
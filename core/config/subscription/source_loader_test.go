@@ -0,0 +1,137 @@
+package subscription
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"singbox-launcher/core/config"
+)
+
+// TestLoadNodesFromSource_Limit tests per-source node limit (0 = unlimited)
+func TestLoadNodesFromSource_Limit(t *testing.T) {
+	connections := []string{
+		"trojan://pass@one.example.com:443#one",
+		"trojan://pass@two.example.com:443#two",
+		"trojan://pass@three.example.com:443#three",
+	}
+	intPtr := func(v int) *int { return &v }
+
+	tests := []struct {
+		name     string
+		limit    *int
+		expected int
+	}{
+		{name: "No limit", limit: nil, expected: 3},
+		{name: "Zero means unlimited", limit: intPtr(0), expected: 3},
+		{name: "Limit 2", limit: intPtr(2), expected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := config.ProxySource{Connections: connections, Limit: tt.limit}
+			nodes, err := LoadNodesFromSource(source, map[string]int{}, nil, 0, 1, nil)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(nodes) != tt.expected {
+				t.Errorf("Expected %d nodes, got %d", tt.expected, len(nodes))
+			}
+		})
+	}
+}
+
+// TestProxySourceNodeLimit tests resolution of per-source and global limits
+func TestProxySourceNodeLimit(t *testing.T) {
+	five := 5
+	zero := 0
+	if got := (&config.ProxySource{}).NodeLimit(100); got != 100 {
+		t.Errorf("Expected global limit 100, got %d", got)
+	}
+	if got := (&config.ProxySource{Limit: &five}).NodeLimit(100); got != 5 {
+		t.Errorf("Expected per-source limit 5, got %d", got)
+	}
+	if got := (&config.ProxySource{Limit: &zero}).NodeLimit(100); got != 0 {
+		t.Errorf("Expected per-source unlimited (0), got %d", got)
+	}
+}
+
+// TestLoadNodesFromSource_BrokenStream tests that a subscription stream failing partway drops the whole source
+func TestLoadNodesFromSource_BrokenStream(t *testing.T) {
+	lines := []string{
+		"trojan://pass@one.example.com:443#one",
+		"trojan://pass@two.example.com:443#two",
+	}
+	brokenFetch := func(url string, handleLine func(line string)) (int64, error) {
+		for _, line := range lines {
+			handleLine(line)
+		}
+		return 512, errors.New("connection reset by peer")
+	}
+
+	tagCounts := map[string]int{}
+	report := &config.SourceReport{}
+	source := config.ProxySource{
+		Source:      "https://example.com/sub",
+		Connections: []string{"trojan://pass@three.example.com:443#three"},
+	}
+	nodes, err := LoadNodesFromSourceWithFetcher(brokenFetch, source, tagCounts, nil, 0, 1, report)
+	if err == nil {
+		t.Fatalf("expected an error, got %d nodes", len(nodes))
+	}
+	if len(nodes) != 1 || nodes[0].Tag != "three" || report.Parsed != 1 {
+		t.Errorf("nodes of a broken stream were kept: %d nodes, report.Parsed = %d", len(nodes), report.Parsed)
+	}
+	if report.HTTPStatus == 200 {
+		t.Errorf("a broken stream is reported as HTTP 200")
+	}
+	if len(tagCounts) != 1 || tagCounts["three"] != 1 {
+		t.Errorf("tags of dropped nodes are still counted: %v", tagCounts)
+	}
+}
+
+func TestLoadNodesFromSource_FailedFetchKeepsConnections(t *testing.T) {
+	failedFetch := func(url string, handleLine func(line string)) (int64, error) {
+		return 0, &HTTPStatusError{StatusCode: 503}
+	}
+
+	report := &config.SourceReport{}
+	source := config.ProxySource{
+		Source: "https://example.com/sub",
+		Connections: []string{
+			"trojan://pass@backup1.example.com:443#backup1",
+			"trojan://pass@backup2.example.com:443#backup2",
+		},
+	}
+	nodes, err := LoadNodesFromSourceWithFetcher(failedFetch, source, map[string]int{}, nil, 0, 1, report)
+	if err == nil || report.Error == "" || report.HTTPStatus != 503 {
+		t.Errorf("fetch error not reported: err = %v, report = %+v", err, report)
+	}
+	if len(nodes) != 2 || nodes[0].Tag != "backup1" || nodes[1].Tag != "backup2" {
+		t.Errorf("connections of a failed subscription: %d nodes", len(nodes))
+	}
+}
+
+// endlessReader returns the same subscription line forever
+type endlessReader struct{}
+
+func (endlessReader) Read(p []byte) (int, error) {
+	const line = "trojan://pass@one.example.com:443#one\n"
+	n := 0
+	for n < len(p) {
+		n += copy(p[n:], line)
+	}
+	return n, nil
+}
+
+// TestReadSubscriptionLines_SizeLimit tests that a response larger than maxResponseSize is rejected
+func TestReadSubscriptionLines_SizeLimit(t *testing.T) {
+	if _, err := readSubscriptionLines(endlessReader{}, func(string) {}); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("endless response: err = %v, want too large", err)
+	}
+	n, err := readSubscriptionLines(io.LimitReader(endlessReader{}, 1024), func(string) {})
+	if err != nil || n != 1024 {
+		t.Errorf("small response: n = %d, err = %v", n, err)
+	}
+}
@@ -2,11 +2,13 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // logDuplicateTagStatistics logs statistics about duplicate tags found during processing
@@ -26,22 +28,41 @@ func logDuplicateTagStatistics(tagCounts map[string]int, logPrefix string) {
 	}
 }
 
+// UpdateOptions holds optional hooks of UpdateConfigFromSubscriptions
+type UpdateOptions struct {
+	// Approve, if set, receives the diff of the new @ParserSTART block against the current one;
+	// the config is written only when it returns true, otherwise ErrUpdateCancelled is returned.
+	Approve ApprovalFunc
+	// Validate, if set, checks the candidate config (e.g. with `sing-box check`) before it replaces config.json
+	Validate ConfigValidator
+}
+
 // UpdateConfigFromSubscriptions updates config.json from subscriptions
 // This is the main function that coordinates the update process
 func UpdateConfigFromSubscriptions(
 	configPath string,
 	parserConfig *ParserConfig,
 	progressCallback func(float64, string),
-	loadNodesFunc func(ProxySource, map[string]int, func(float64, string), int, int) ([]*ParsedNode, error),
-) error {
+	loadNodesFunc func(ProxySource, map[string]int, func(float64, string), int, int, *SourceReport) ([]*ParsedNode, error),
+	opts UpdateOptions,
+) (err error) {
 	log.Println("Parser: Starting configuration update...")
 
+	// Structured report of this update, saved next to config.json regardless of the result
+	report := &ParseReport{StartedAt: time.Now()}
+	defer func() {
+		report.Finish(err)
+		if saveErr := SaveParseReport(ParseReportPath(configPath), report); saveErr != nil {
+			log.Printf("Parser: Warning: Failed to save parse report: %v", saveErr)
+		}
+	}()
+
 	// Step 2: Generate all outbounds using unified function
 	// Map to track unique tags and their counts
 	tagCounts := make(map[string]int)
 	log.Printf("Parser: Initializing tag deduplication tracker")
 
-	result, err := GenerateOutboundsFromParserConfig(parserConfig, tagCounts, progressCallback, loadNodesFunc)
+	result, err := GenerateOutboundsFromParserConfig(parserConfig, tagCounts, progressCallback, loadNodesFunc, report)
 	if err != nil {
 		if progressCallback != nil {
 			progressCallback(-1, fmt.Sprintf("Error: %v", err))
@@ -54,6 +75,7 @@ func UpdateConfigFromSubscriptions(
 
 	log.Printf("Parser: Generated %d nodes, %d local selectors, %d global selectors",
 		result.NodesCount, result.LocalSelectorsCount, result.GlobalSelectorsCount)
+	report.NodesCount = result.NodesCount
 
 	selectorsJSON := result.OutboundsJSON
 
@@ -65,19 +87,59 @@ func UpdateConfigFromSubscriptions(
 		return fmt.Errorf("no content generated - cannot write empty result to config")
 	}
 
+	content := strings.Join(selectorsJSON, "\n")
+
+	// Optional preview: let the caller approve the diff before overwriting the block
+	if opts.Approve != nil {
+		if progressCallback != nil {
+			progressCallback(85, "Waiting for approval...")
+		}
+		currentContent, err := ReadParserBlock(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to read current config for preview: %w", err)
+		}
+		diff, err := DiffParserBlocks(currentContent, content)
+		if err != nil {
+			return fmt.Errorf("failed to build update preview: %w", err)
+		}
+		report.Diff = diff
+		log.Printf("Parser: Preview: %d outbounds added, %d removed (%d of %d nodes), %d selectors changed",
+			len(diff.OutboundsAdded), len(diff.OutboundsRemoved), diff.NodesRemoved, diff.NodesBefore, len(diff.SelectorsChanged))
+		if !opts.Approve(diff) {
+			log.Println("Parser: Update was not approved, config.json is left unchanged")
+			if progressCallback != nil {
+				progressCallback(100, "Update cancelled, configuration not changed")
+			}
+			return ErrUpdateCancelled
+		}
+	}
+
 	// Step 3: Write to file
 	if progressCallback != nil {
 		progressCallback(90, "Writing to config file...")
 	}
 
-	content := strings.Join(selectorsJSON, "\n")
-	if err := WriteToConfig(configPath, content, parserConfig); err != nil {
+	historyPolicy := parserConfig.ParserConfig.Parser.HistoryPolicy()
+	EnsureHistoryBaseline(configPath, historyPolicy)
+
+	if err := WriteToConfig(configPath, content, parserConfig, opts.Validate); err != nil {
 		if progressCallback != nil {
 			progressCallback(-1, fmt.Sprintf("Write error: %v", err))
 		}
+		if errors.Is(err, ErrConfigCheckFailed) {
+			report.RolledBack = true
+			log.Printf("Parser: New config rejected by sing-box check, previous config kept: %v", err)
+		}
 		return fmt.Errorf("failed to write to config: %w", err)
 	}
 
+	if _, err := RecordConfigVersion(configPath, HistoryTriggerUpdate, historyPolicy); err != nil {
+		log.Printf("Parser: Warning: Failed to record config snapshot: %v", err)
+	}
+
+	// Track node churn only for written configs, so a failed update doesn't shift the baseline
+	updateNodeHistory(configPath, report)
+
 	log.Printf("Parser: Done! File %s successfully updated.", configPath)
 	log.Printf("Parser: Successfully updated last_updated timestamp")
 
@@ -89,8 +151,9 @@ func UpdateConfigFromSubscriptions(
 }
 
 // WriteToConfig writes content between @ParserSTART and @ParserEND markers
-// Also updates @ParserConfig block with last_updated timestamp in a single file write
-func WriteToConfig(configPath string, content string, parserConfig *ParserConfig) error {
+// Also updates @ParserConfig block with last_updated timestamp in a single file write.
+// The file is replaced atomically; if validate rejects the candidate, config.json is left unchanged.
+func WriteToConfig(configPath string, content string, parserConfig *ParserConfig, validate ConfigValidator) error {
 	// Read config file
 	data, err := os.ReadFile(configPath)
 	if err != nil {
@@ -100,22 +163,13 @@ func WriteToConfig(configPath string, content string, parserConfig *ParserConfig
 	configStr := string(data)
 
 	// Find markers
-	startMarker := "/** @ParserSTART */"
-	endMarker := "/** @ParserEND */"
-
-	startIdx := strings.Index(configStr, startMarker)
-	endIdx := strings.Index(configStr, endMarker)
-
-	if startIdx == -1 || endIdx == -1 {
-		return fmt.Errorf("markers @ParserSTART or @ParserEND not found in config.json")
-	}
-
-	if endIdx <= startIdx {
-		return fmt.Errorf("invalid marker positions")
+	startIdx, endIdx, err := findParserBlock(configStr)
+	if err != nil {
+		return err
 	}
 
 	// Build new content with updated @ParserSTART/@ParserEND section
-	newContent := configStr[:startIdx+len(startMarker)] + "\n" + content + "\n" + configStr[endIdx:]
+	newContent := configStr[:startIdx+len(parserStartMarker)] + "\n" + content + "\n" + configStr[endIdx:]
 
 	// Also update @ParserConfig block if parserConfig is provided
 	if parserConfig != nil {
@@ -160,10 +214,6 @@ func WriteToConfig(configPath string, content string, parserConfig *ParserConfig
 		}
 	}
 
-	// Write to file (single write operation)
-	if err := os.WriteFile(configPath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
-	}
-
-	return nil
+	// Write to temp file, check it and atomically replace config.json
+	return WriteFileAtomic(configPath, []byte(newContent), validate)
 }
@@ -3,11 +3,17 @@
 package core
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2"
 
 	"singbox-launcher/core/config"
 	"singbox-launcher/core/config/parser"
 	"singbox-launcher/core/config/subscription"
+	"singbox-launcher/internal/constants"
 	"singbox-launcher/internal/debuglog"
 	"singbox-launcher/internal/dialogs"
 )
@@ -29,6 +35,21 @@ func NewConfigService(ac *AppController) *ConfigService {
 // RunParserProcess starts the internal configuration update process.
 // Logic migrated from controller-level function without behavior changes.
 func (svc *ConfigService) RunParserProcess() {
+	_ = svc.runParserProcess(false)
+}
+
+// RunParserPreviewProcess starts a configuration update that shows the diff
+// of the generated outbounds and writes config.json only after user approval.
+func (svc *ConfigService) RunParserPreviewProcess() {
+	_ = svc.runParserProcess(true)
+}
+
+// ErrParserRunning is returned when a configuration update is requested while another one is in progress
+var ErrParserRunning = errors.New("configuration update is already in progress")
+
+// runParserProcess runs the update and reports the result to the user; the error is also returned
+// to callers that need it (control API)
+func (svc *ConfigService) runParserProcess(preview bool) error {
 	ac := svc.ac
 	// Проверяем, не запущен ли уже парсинг
 	ac.ParserMutex.Lock()
@@ -37,7 +58,7 @@ func (svc *ConfigService) RunParserProcess() {
 		if ac.UIService != nil && ac.UIService.Application != nil && ac.UIService.MainWindow != nil {
 			dialogs.ShowAutoHideInfo(ac.UIService.Application, ac.UIService.MainWindow, "Parser Info", "Configuration update is already in progress.")
 		}
-		return
+		return ErrParserRunning
 	}
 	ac.ParserRunning = true
 	ac.ParserMutex.Unlock()
@@ -51,10 +72,16 @@ func (svc *ConfigService) RunParserProcess() {
 	}()
 
 	// Call internal parser to update configuration
-	err := svc.UpdateConfigFromSubscriptions()
+	var approve config.ApprovalFunc
+	if preview {
+		approve = svc.requestApproval
+	}
+	err := svc.updateConfigFromSubscriptions(approve, false)
 
 	// Обрабатываем результат
-	if err != nil {
+	if errors.Is(err, config.ErrUpdateCancelled) {
+		debuglog.InfoLog("RunParser: Update cancelled by user, config not changed.")
+	} else if err != nil {
 		debuglog.ErrorLog("RunParser: Failed to update config: %v", err)
 		// Progress already updated in UpdateConfigFromSubscriptions with error status
 		ac.ShowParserError(fmt.Errorf("failed to update config: %w", err))
@@ -65,6 +92,38 @@ func (svc *ConfigService) RunParserProcess() {
 			dialogs.ShowAutoHideInfo(ac.UIService.Application, ac.UIService.MainWindow, "Parser", "Config updated successfully!")
 		}
 	}
+	return err
+}
+
+// RestoreConfigSnapshot восстанавливает config.json из истории версий (с проверкой sing-box check)
+// и перезапускает sing-box, если он был запущен.
+func (svc *ConfigService) RestoreConfigSnapshot(id string) error {
+	ac := svc.ac
+	configPath := ac.FileService.ConfigPath()
+
+	policy := config.HistoryPolicy{}
+	if parserConfig, err := parser.ExtractParserConfig(configPath); err == nil {
+		policy = parserConfig.ParserConfig.Parser.HistoryPolicy()
+	}
+	validate := func(candidatePath string) error {
+		return config.ValidateConfigWithSingBox(candidatePath, ac.FileService.SingboxPath)
+	}
+	if err := config.RestoreConfigSnapshot(configPath, id, policy, validate); err != nil {
+		return fmt.Errorf("failed to restore config %s: %w", id, err)
+	}
+	debuglog.InfoLog("RestoreConfigSnapshot: config.json restored from snapshot %s", id)
+
+	if ac.UIService != nil && ac.UIService.UpdateConfigStatusFunc != nil {
+		ac.UIService.UpdateConfigStatusFunc()
+	}
+
+	if ac.RunningState.IsRunning() {
+		debuglog.InfoLog("RestoreConfigSnapshot: sing-box is running, restarting with restored config")
+		if err := ac.ProcessService.Restart(); err != nil {
+			return fmt.Errorf("config restored, but restart failed: %w", err)
+		}
+	}
+	return nil
 }
 
 // updateParserProgress safely calls UpdateParserProgressFunc if it's not nil
@@ -74,9 +133,25 @@ func updateParserProgress(ac *AppController, progress float64, status string) {
 	}
 }
 
-// ProcessProxySource delegates to subscription.LoadNodesFromSource
-func (svc *ConfigService) ProcessProxySource(proxySource config.ProxySource, tagCounts map[string]int, progressCallback func(float64, string), subscriptionIndex, totalSubscriptions int) ([]*config.ParsedNode, error) {
-	return subscription.LoadNodesFromSource(proxySource, tagCounts, progressCallback, subscriptionIndex, totalSubscriptions)
+// ProcessProxySource delegates to subscription.LoadNodesFromSource.
+// Downloaded subscriptions are stored in the subscription cache for partial auto-updates.
+func (svc *ConfigService) ProcessProxySource(proxySource config.ProxySource, tagCounts map[string]int, progressCallback func(float64, string), subscriptionIndex, totalSubscriptions int, report *config.SourceReport) ([]*config.ParsedNode, error) {
+	return subscription.LoadNodesFromSourceWithFetcher(svc.subscriptionCache().FetchLines, proxySource, tagCounts, progressCallback, subscriptionIndex, totalSubscriptions, report)
+}
+
+// processCachedProxySource builds nodes of a source that is not due for reload from the subscription cache
+func (svc *ConfigService) processCachedProxySource(proxySource config.ProxySource, tagCounts map[string]int, progressCallback func(float64, string), subscriptionIndex, totalSubscriptions int, report *config.SourceReport) ([]*config.ParsedNode, error) {
+	nodes, err := subscription.LoadNodesFromSourceWithFetcher(svc.subscriptionCache().ReadLines, proxySource, tagCounts, progressCallback, subscriptionIndex, totalSubscriptions, report)
+	if report != nil {
+		report.FromCache = true
+		report.HTTPStatus = 0
+	}
+	return nodes, err
+}
+
+// subscriptionCache returns the cache of downloaded subscriptions stored next to config.json
+func (svc *ConfigService) subscriptionCache() *subscription.SubscriptionCache {
+	return subscription.NewSubscriptionCache(filepath.Join(filepath.Dir(svc.ac.FileService.ConfigPath()), constants.SubscriptionCacheDirName))
 }
 
 // GenerateSelector delegates to config.GenerateSelector
@@ -96,18 +171,78 @@ func (svc *ConfigService) GenerateOutboundsFromParserConfig(
 	progressCallback func(float64, string),
 ) (*config.OutboundGenerationResult, error) {
 	// Create a wrapper function that matches the signature expected by config.GenerateOutboundsFromParserConfig
-	loadNodesFunc := func(ps config.ProxySource, tc map[string]int, pc func(float64, string), idx, total int) ([]*config.ParsedNode, error) {
-		return svc.ProcessProxySource(ps, tc, pc, idx, total)
+	loadNodesFunc := func(ps config.ProxySource, tc map[string]int, pc func(float64, string), idx, total int, report *config.SourceReport) ([]*config.ParsedNode, error) {
+		return svc.ProcessProxySource(ps, tc, pc, idx, total, report)
 	}
-	return config.GenerateOutboundsFromParserConfig(parserConfig, tagCounts, progressCallback, loadNodesFunc)
+	return config.GenerateOutboundsFromParserConfig(parserConfig, tagCounts, progressCallback, loadNodesFunc, nil)
 }
 
 // UpdateConfigFromSubscriptions delegates to config.UpdateConfigFromSubscriptions
 func (svc *ConfigService) UpdateConfigFromSubscriptions() error {
+	return svc.updateConfigFromSubscriptions(nil, false)
+}
+
+// autoUpdateConfigFromSubscriptions runs a scheduled update. Only subscriptions that are due for reload
+// are downloaded, the others are rebuilt from the subscription cache. If parser.approval_threshold is set
+// and the update would remove more than that percentage of nodes, the user must approve it.
+func (svc *ConfigService) autoUpdateConfigFromSubscriptions() error {
+	return svc.updateConfigFromSubscriptions(func(diff *config.ConfigDiff) bool {
+		threshold := svc.approvalThreshold()
+		if threshold <= 0 || diff.RemovedNodesPercent() <= float64(threshold) {
+			return true
+		}
+		debuglog.WarnLog("Auto-update: update removes %.0f%% of nodes (threshold %d%%), approval required",
+			diff.RemovedNodesPercent(), threshold)
+		ac := svc.ac
+		if ac.UIService != nil && ac.UIService.Application != nil {
+			ac.UIService.Application.SendNotification(&fyne.Notification{
+				Title:   "Auto-update",
+				Content: fmt.Sprintf("Update removes %d of %d nodes. Review it in the launcher.", diff.NodesRemoved, diff.NodesBefore),
+			})
+		}
+		return svc.requestApproval(diff)
+	}, true)
+}
+
+// approvalThreshold reads parser.approval_threshold from the current config
+func (svc *ConfigService) approvalThreshold() int {
+	parserConfig, err := parser.ExtractParserConfig(svc.ac.FileService.ConfigPath())
+	if err != nil {
+		return 0
+	}
+	return parserConfig.ParserConfig.Parser.ApprovalThreshold
+}
+
+// requestApproval показывает превью изменений через UI и ждёт решения пользователя.
+// Без UI обновление не подтверждается.
+func (svc *ConfigService) requestApproval(diff *config.ConfigDiff) bool {
+	ac := svc.ac
+	if ac.UIService == nil || ac.UIService.ConfirmConfigDiffFunc == nil {
+		debuglog.WarnLog("requestApproval: ConfirmConfigDiffFunc callback not set, update is not approved")
+		return false
+	}
+
+	decision := make(chan bool, 1)
+	ac.UIService.ConfirmConfigDiffFunc(diff, func(approved bool) {
+		decision <- approved
+	})
+
+	select {
+	case approved := <-decision:
+		debuglog.InfoLog("requestApproval: update approved: %v", approved)
+		return approved
+	case <-ac.ctx.Done():
+		return false
+	}
+}
+
+// updateConfigFromSubscriptions runs the update; approve (may be nil) confirms the diff before writing.
+// If dueOnly is set, subscriptions that are not due for reload are taken from the subscription cache.
+func (svc *ConfigService) updateConfigFromSubscriptions(approve config.ApprovalFunc, dueOnly bool) error {
 	ac := svc.ac
 
 	// Step 1: Extract configuration
-	parserConfig, err := parser.ExtractParserConfig(ac.FileService.ConfigPath)
+	parserConfig, err := parser.ExtractParserConfig(ac.FileService.ConfigPath())
 	if err != nil {
 		updateParserProgress(ac, -1, fmt.Sprintf("Error: %v", err))
 		return fmt.Errorf("failed to extract parser config: %w", err)
@@ -120,15 +255,58 @@ func (svc *ConfigService) UpdateConfigFromSubscriptions() error {
 		updateParserProgress(ac, p, s)
 	}
 
+	var due map[string]bool
+	if dueOnly {
+		due, _ = sourceReloadPlan(parserConfig, svc.subscriptionCache().FetchedAt, time.Now())
+		debuglog.InfoLog("Auto-update: %d subscription(s) due for reload, others are taken from cache", len(due))
+	}
+
 	// Create a wrapper function that matches the signature expected by config.UpdateConfigFromSubscriptions
-	loadNodesFunc := func(ps config.ProxySource, tc map[string]int, pc func(float64, string), idx, total int) ([]*config.ParsedNode, error) {
-		return svc.ProcessProxySource(ps, tc, pc, idx, total)
+	loadNodesFunc := func(ps config.ProxySource, tc map[string]int, pc func(float64, string), idx, total int, report *config.SourceReport) ([]*config.ParsedNode, error) {
+		if dueOnly && subscription.IsSubscriptionURL(ps.Source) && !due[ps.Source] {
+			return svc.processCachedProxySource(ps, tc, pc, idx, total, report)
+		}
+		return svc.ProcessProxySource(ps, tc, pc, idx, total, report)
+	}
+
+	opts := config.UpdateOptions{
+		Approve: approve,
+		// Проверяем новый конфиг через sing-box check до замены config.json
+		Validate: func(candidatePath string) error {
+			return config.ValidateConfigWithSingBox(candidatePath, ac.FileService.SingboxPath)
+		},
 	}
+	// parser.apply_on_update: запоминаем текущие outbounds, чтобы применить обновление к запущенному sing-box
+	hotApply := svc.prepareHotApply(parserConfig)
 
-	err = config.UpdateConfigFromSubscriptions(ac.FileService.ConfigPath, parserConfig, progressCallback, loadNodesFunc)
+	err = config.UpdateConfigFromSubscriptions(ac.FileService.ConfigPath(), parserConfig, progressCallback, loadNodesFunc, opts)
 	if err == nil {
 		// Resume auto-update after successful update
 		ac.resumeAutoUpdate()
+		svc.notifyNodeChurn()
+		if hotApply != nil {
+			// Ошибка применения не отменяет обновление: config.json уже записан или откатан
+			svc.reportHotApply(svc.applyUpdatedConfig(hotApply))
+		}
 	}
 	return err
 }
+
+// notifyNodeChurn отправляет системное уведомление, если в последнем обновлении изменился состав узлов
+func (svc *ConfigService) notifyNodeChurn() {
+	ac := svc.ac
+	report, err := config.LoadParseReport(config.ParseReportPath(ac.FileService.ConfigPath()))
+	if err != nil {
+		debuglog.WarnLog("notifyNodeChurn: failed to load parse report: %v", err)
+		return
+	}
+	added, removed, changed := report.TotalChurn()
+	if added == 0 && removed == 0 && changed == 0 {
+		return
+	}
+	message := fmt.Sprintf("Nodes changed: %d added, %d removed, %d changed", added, removed, changed)
+	debuglog.InfoLog("notifyNodeChurn: %s", message)
+	if ac.UIService != nil && ac.UIService.Application != nil {
+		ac.UIService.Application.SendNotification(&fyne.Notification{Title: "Subscriptions", Content: message})
+	}
+}
@@ -0,0 +1,489 @@
+package core
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"singbox-launcher/api"
+	"singbox-launcher/core/config"
+	"singbox-launcher/internal/constants"
+	"singbox-launcher/internal/debuglog"
+)
+
+const (
+	// controlAPIShutdownTimeout is how long in-flight control API requests may take on shutdown
+	controlAPIShutdownTimeout = 3 * time.Second
+
+	// controlAPIStopTimeout is how long "stop" waits for sing-box to exit
+	controlAPIStopTimeout = gracefulShutdownTimeout + 3*time.Second
+
+	// controlAPIMaxBodySize limits request bodies (only small JSON objects are accepted)
+	controlAPIMaxBodySize = 64 * 1024
+)
+
+// controlAPIServer is the running local control API
+type controlAPIServer struct {
+	server  *http.Server
+	network string
+	address string
+}
+
+// ControlAPISettings returns the control API settings from launcher_state.json
+func (ac *AppController) ControlAPISettings() config.ControlAPISettings {
+	state, err := config.LoadLauncherState(ac.launcherStatePath())
+	if err != nil {
+		debuglog.WarnLog("ControlAPI: failed to load launcher state: %v", err)
+	}
+	if state.ControlAPI == nil {
+		return config.ControlAPISettings{}
+	}
+	return *state.ControlAPI
+}
+
+// SetControlAPIEnabled persists the opt-in flag and starts or stops the control API accordingly.
+func (ac *AppController) SetControlAPIEnabled(enabled bool) error {
+	err := config.UpdateLauncherState(ac.launcherStatePath(), func(state *config.LauncherState) {
+		if state.ControlAPI == nil {
+			state.ControlAPI = &config.ControlAPISettings{}
+		}
+		state.ControlAPI.Enabled = enabled
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save control API settings: %w", err)
+	}
+	ac.StopControlAPI()
+	if enabled {
+		return ac.StartControlAPI()
+	}
+	return nil
+}
+
+// StartControlAPI starts the local control API if it is enabled in launcher_state.json.
+// A token is generated and saved on first start. Does nothing if the API is disabled or already running.
+func (ac *AppController) StartControlAPI() error {
+	settings := ac.ControlAPISettings()
+	if !settings.Enabled {
+		debuglog.DebugLog("ControlAPI: disabled")
+		return nil
+	}
+	network, address, err := settings.Endpoint()
+	if err != nil {
+		return err
+	}
+
+	ac.controlAPIMutex.Lock()
+	defer ac.controlAPIMutex.Unlock()
+	if ac.controlAPI != nil {
+		return nil
+	}
+
+	if settings.Token == "" {
+		token, err := config.GenerateControlAPIToken()
+		if err != nil {
+			return err
+		}
+		err = config.UpdateLauncherState(ac.launcherStatePath(), func(state *config.LauncherState) {
+			if state.ControlAPI == nil {
+				state.ControlAPI = &settings
+			}
+			state.ControlAPI.Token = token
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save control API token: %w", err)
+		}
+		settings.Token = token
+		debuglog.InfoLog("ControlAPI: generated a new token (launcher_state.json)")
+	}
+
+	if network == "unix" {
+		// Сокет мог остаться от предыдущего запуска, завершившегося аварийно
+		if info, err := os.Lstat(address); err == nil && info.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(address)
+		}
+	}
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return fmt.Errorf("control API: cannot listen on %s: %w", address, err)
+	}
+	if network == "unix" {
+		if err := os.Chmod(address, 0600); err != nil {
+			debuglog.WarnLog("ControlAPI: failed to restrict socket permissions: %v", err)
+		}
+	} else {
+		address = listener.Addr().String() // Actual port when listening on ":0"
+	}
+
+	server := &http.Server{
+		Handler:           newControlAPIHandler(ac, settings.Token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ac.controlAPI = &controlAPIServer{server: server, network: network, address: address}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			debuglog.ErrorLog("ControlAPI: server stopped: %v", err)
+		}
+	}()
+	debuglog.InfoLog("ControlAPI: listening on %s %s", network, address)
+	return nil
+}
+
+// StopControlAPI stops the control API server if it is running.
+func (ac *AppController) StopControlAPI() {
+	ac.controlAPIMutex.Lock()
+	srv := ac.controlAPI
+	ac.controlAPI = nil
+	ac.controlAPIMutex.Unlock()
+	if srv == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), controlAPIShutdownTimeout)
+	defer cancel()
+	if err := srv.server.Shutdown(ctx); err != nil {
+		debuglog.WarnLog("ControlAPI: shutdown: %v", err)
+	}
+	debuglog.InfoLog("ControlAPI: stopped")
+}
+
+// ControlAPIAddress returns the address the control API listens on ("" if it is not running).
+// Unix sockets are returned with the "unix:" prefix, as in control_api.listen.
+func (ac *AppController) ControlAPIAddress() string {
+	ac.controlAPIMutex.Lock()
+	defer ac.controlAPIMutex.Unlock()
+	if ac.controlAPI == nil {
+		return ""
+	}
+	if ac.controlAPI.network == "unix" {
+		return "unix:" + ac.controlAPI.address
+	}
+	return ac.controlAPI.address
+}
+
+// controlAPIHandler serves the control API: a token check in front of the routes
+type controlAPIHandler struct {
+	ac    *AppController
+	token string
+	mux   *http.ServeMux
+}
+
+func newControlAPIHandler(ac *AppController, token string) http.Handler {
+	h := &controlAPIHandler{ac: ac, token: token, mux: http.NewServeMux()}
+	h.mux.HandleFunc("GET /v1/status", h.handleStatus)
+	h.mux.HandleFunc("POST /v1/start", h.handleStart)
+	h.mux.HandleFunc("POST /v1/stop", h.handleStop)
+	h.mux.HandleFunc("POST /v1/restart", h.handleRestart)
+	h.mux.HandleFunc("POST /v1/update", h.handleUpdate)
+	h.mux.HandleFunc("GET /v1/report", h.handleReport)
+	h.mux.HandleFunc("GET /v1/groups", h.handleGroups)
+	h.mux.HandleFunc("GET /v1/groups/{group}", h.handleGroup)
+	h.mux.HandleFunc("PUT /v1/groups/{group}", h.handleSwitchProxy)
+	return h
+}
+
+func (h *controlAPIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		writeControlAPIError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, controlAPIMaxBodySize)
+	h.mux.ServeHTTP(w, r)
+}
+
+// authorized checks "Authorization: Bearer <token>"
+func (h *controlAPIHandler) authorized(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") || h.token == "" {
+		return false
+	}
+	token := strings.TrimPrefix(auth, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+// controlAPIStatus is the response of GET /v1/status and of the process commands
+type controlAPIStatus struct {
+	Running               bool                  `json:"running"`
+	PID                   int                   `json:"pid,omitempty"`
+	LauncherVersion       string                `json:"launcher_version"`
+	Profile               string                `json:"profile"`
+	ConfigPath            string                `json:"config_path"`
+	UpdateInProgress      bool                  `json:"update_in_progress"`
+	AutoUpdate            bool                  `json:"auto_update"`
+	AutoUpdatePausedUntil *time.Time            `json:"auto_update_paused_until,omitempty"`
+	ClashAPI              bool                  `json:"clash_api"`
+	SelectedGroup         string                `json:"selected_group,omitempty"`
+	ActiveProxy           string                `json:"active_proxy,omitempty"`
+	LastUpdate            *controlAPILastUpdate `json:"last_update,omitempty"`
+	RestartAt             *time.Time            `json:"restart_at,omitempty"` // Pending crash restart
+	CrashAttempts         int                   `json:"crash_attempts,omitempty"`
+}
+
+// controlAPILastUpdate summarizes parse_report.json
+type controlAPILastUpdate struct {
+	FinishedAt time.Time `json:"finished_at"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	NodesCount int       `json:"nodes_count"`
+}
+
+func (h *controlAPIHandler) status() *controlAPIStatus {
+	ac := h.ac
+	st := &controlAPIStatus{
+		Running:         ac.RunningState.IsRunning(),
+		LauncherVersion: constants.AppVersion,
+		Profile:         ac.ActiveProfile(),
+		ConfigPath:      ac.FileService.ConfigPath(),
+		AutoUpdate:      ac.StateService.IsAutoUpdateEnabled(),
+	}
+	if st.Running {
+		if pid := ac.ProcessService.getTrackedPID(); pid > 0 {
+			st.PID = pid
+		}
+	}
+	if backoff, pending := ac.ProcessService.PendingRestart(); pending {
+		st.RestartAt = &backoff.RestartAt
+		st.CrashAttempts = backoff.Attempt
+	}
+	ac.ParserMutex.Lock()
+	st.UpdateInProgress = ac.ParserRunning
+	ac.ParserMutex.Unlock()
+	if until := ac.StateService.GetAutoUpdatePausedUntil(); !until.IsZero() {
+		st.AutoUpdatePausedUntil = &until
+	}
+	if ac.APIService != nil {
+		_, _, st.ClashAPI = ac.APIService.GetClashAPIConfig()
+		st.SelectedGroup = ac.APIService.GetSelectedClashGroup()
+		st.ActiveProxy = ac.APIService.GetActiveProxyName()
+	}
+	if report, err := config.LoadParseReport(config.ParseReportPath(ac.FileService.ConfigPath())); err == nil {
+		st.LastUpdate = &controlAPILastUpdate{
+			FinishedAt: report.FinishedAt,
+			Success:    report.Success,
+			Error:      report.Error,
+			NodesCount: report.NodesCount,
+		}
+	}
+	return st
+}
+
+func (h *controlAPIHandler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeControlAPIJSON(w, http.StatusOK, h.status())
+}
+
+func (h *controlAPIHandler) handleStart(w http.ResponseWriter, r *http.Request) {
+	ac := h.ac
+	if ac.RunningState.IsRunning() {
+		writeControlAPIError(w, http.StatusConflict, errors.New("sing-box is already running"))
+		return
+	}
+	if found, pid := ac.ProcessService.FindRunningProcess(); found {
+		writeControlAPIError(w, http.StatusConflict, fmt.Errorf("sing-box is already running outside of the launcher (PID %d)", pid))
+		return
+	}
+	debuglog.InfoLog("ControlAPI: start requested")
+	ac.ProcessService.Start()
+	if !ac.RunningState.IsRunning() {
+		writeControlAPIError(w, http.StatusInternalServerError, errors.New("failed to start sing-box, see sing-box.log"))
+		return
+	}
+	writeControlAPIJSON(w, http.StatusOK, h.status())
+}
+
+func (h *controlAPIHandler) handleStop(w http.ResponseWriter, r *http.Request) {
+	ac := h.ac
+	debuglog.InfoLog("ControlAPI: stop requested")
+	ac.ProcessService.Stop()
+	deadline := time.Now().Add(controlAPIStopTimeout)
+	for ac.RunningState.IsRunning() {
+		if time.Now().After(deadline) {
+			writeControlAPIError(w, http.StatusInternalServerError, errors.New("sing-box did not stop in time"))
+			return
+		}
+		<-time.After(100 * time.Millisecond)
+	}
+	writeControlAPIJSON(w, http.StatusOK, h.status())
+}
+
+func (h *controlAPIHandler) handleRestart(w http.ResponseWriter, r *http.Request) {
+	ac := h.ac
+	if !ac.RunningState.IsRunning() {
+		h.handleStart(w, r)
+		return
+	}
+	debuglog.InfoLog("ControlAPI: restart requested")
+	if err := ac.ProcessService.Restart(); err != nil {
+		writeControlAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeControlAPIJSON(w, http.StatusOK, h.status())
+}
+
+// handleUpdate starts a subscription update (like "Update" in the Core Dashboard).
+// By default it returns 202 at once; with ?wait=1 it responds when the update is finished.
+func (h *controlAPIHandler) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	ac := h.ac
+	if _, err := os.Stat(ac.FileService.ConfigPath()); err != nil {
+		writeControlAPIError(w, http.StatusConflict, errors.New("config.json not found"))
+		return
+	}
+	ac.ParserMutex.Lock()
+	running := ac.ParserRunning
+	ac.ParserMutex.Unlock()
+	if running {
+		writeControlAPIError(w, http.StatusConflict, ErrParserRunning)
+		return
+	}
+
+	debuglog.InfoLog("ControlAPI: subscription update requested")
+	if !isTruthy(r.URL.Query().Get("wait")) {
+		go ac.ConfigService.RunParserProcess()
+		writeControlAPIJSON(w, http.StatusAccepted, map[string]string{"status": "started"})
+		return
+	}
+
+	err := ac.ConfigService.runParserProcess(false)
+	switch {
+	case errors.Is(err, ErrParserRunning):
+		writeControlAPIError(w, http.StatusConflict, err)
+	case err != nil:
+		writeControlAPIError(w, http.StatusInternalServerError, err)
+	default:
+		h.handleReport(w, r)
+	}
+}
+
+func isTruthy(value string) bool {
+	switch strings.ToLower(value) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
+
+// handleReport returns parse_report.json of the last subscription update
+func (h *controlAPIHandler) handleReport(w http.ResponseWriter, r *http.Request) {
+	report, err := config.LoadParseReport(config.ParseReportPath(h.ac.FileService.ConfigPath()))
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeControlAPIError(w, http.StatusNotFound, errors.New("no update report yet"))
+			return
+		}
+		writeControlAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeControlAPIJSON(w, http.StatusOK, report)
+}
+
+// clashAPI returns Clash API credentials, or writes an error if proxies cannot be queried now
+func (h *controlAPIHandler) clashAPI(w http.ResponseWriter) (baseURL, token string, ok bool) {
+	if h.ac.APIService == nil {
+		writeControlAPIError(w, http.StatusServiceUnavailable, errors.New("Clash API is not available"))
+		return "", "", false
+	}
+	baseURL, token, enabled := h.ac.APIService.GetClashAPIConfig()
+	if !enabled {
+		writeControlAPIError(w, http.StatusServiceUnavailable, errors.New("Clash API is disabled in config.json"))
+		return "", "", false
+	}
+	if !h.ac.RunningState.IsRunning() {
+		writeControlAPIError(w, http.StatusServiceUnavailable, errors.New("sing-box is not running"))
+		return "", "", false
+	}
+	return baseURL, token, true
+}
+
+// controlAPIGroup is a selector group in GET /v1/groups
+type controlAPIGroup struct {
+	Name    string   `json:"name"`
+	Now     string   `json:"now"`
+	Proxies []string `json:"proxies"`
+}
+
+func (h *controlAPIHandler) handleGroups(w http.ResponseWriter, r *http.Request) {
+	baseURL, token, ok := h.clashAPI(w)
+	if !ok {
+		return
+	}
+	groups, err := api.GetSelectorGroups(baseURL, token, h.ac.APIService.ApiLogFile)
+	if err != nil {
+		writeControlAPIError(w, http.StatusBadGateway, err)
+		return
+	}
+	result := make([]controlAPIGroup, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, controlAPIGroup{Name: g.Name, Now: g.Now, Proxies: g.All})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	writeControlAPIJSON(w, http.StatusOK, result)
+}
+
+// controlAPIProxy is a group member in GET /v1/groups/{group}
+type controlAPIProxy struct {
+	Name  string `json:"name"`
+	Delay int64  `json:"delay,omitempty"` // Last known delay, ms
+	Up    int64  `json:"up"`
+	Down  int64  `json:"down"`
+}
+
+func (h *controlAPIHandler) handleGroup(w http.ResponseWriter, r *http.Request) {
+	baseURL, token, ok := h.clashAPI(w)
+	if !ok {
+		return
+	}
+	group := r.PathValue("group")
+	proxies, now, err := api.GetProxiesInGroup(baseURL, token, group, h.ac.APIService.ApiLogFile)
+	if err != nil {
+		writeControlAPIError(w, http.StatusBadGateway, err)
+		return
+	}
+	result := struct {
+		Name    string            `json:"name"`
+		Now     string            `json:"now"`
+		Proxies []controlAPIProxy `json:"proxies"`
+	}{Name: group, Now: now, Proxies: make([]controlAPIProxy, 0, len(proxies))}
+	for _, p := range proxies {
+		result.Proxies = append(result.Proxies, controlAPIProxy{Name: p.Name, Delay: p.Delay, Up: p.Traffic[0], Down: p.Traffic[1]})
+	}
+	writeControlAPIJSON(w, http.StatusOK, result)
+}
+
+// handleSwitchProxy switches the proxy of a selector group: PUT /v1/groups/{group} {"proxy": "name"}
+func (h *controlAPIHandler) handleSwitchProxy(w http.ResponseWriter, r *http.Request) {
+	if _, _, ok := h.clashAPI(w); !ok {
+		return
+	}
+	var req struct {
+		Proxy string `json:"proxy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Proxy == "" {
+		writeControlAPIError(w, http.StatusBadRequest, errors.New(`request body must be {"proxy": "<name>"}`))
+		return
+	}
+	group := r.PathValue("group")
+	debuglog.InfoLog("ControlAPI: switch %s -> %s", group, req.Proxy)
+	if err := h.ac.APIService.SwitchProxy(group, req.Proxy); err != nil {
+		writeControlAPIError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeControlAPIJSON(w, http.StatusOK, map[string]string{"name": group, "now": req.Proxy})
+}
+
+func writeControlAPIJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		debuglog.WarnLog("ControlAPI: failed to write response: %v", err)
+	}
+}
+
+func writeControlAPIError(w http.ResponseWriter, status int, err error) {
+	writeControlAPIJSON(w, status, map[string]string{"error": err.Error()})
+}
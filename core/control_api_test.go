@@ -0,0 +1,152 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"singbox-launcher/core/config"
+	"singbox-launcher/core/services"
+)
+
+// newTestFileService returns a FileService with configPath as the default profile's config
+func newTestFileService(configPath string) *services.FileService {
+	fs := &services.FileService{BaseConfigPath: configPath}
+	fs.SetActiveProfile("", configPath)
+	return fs
+}
+
+func newControlAPITestController(t *testing.T) *AppController {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	ac := &AppController{
+		FileService:  newTestFileService(configPath),
+		StateService: services.NewStateService(),
+	}
+	ac.SingboxInstance = newSingboxInstance(ac, "")
+	ac.ConfigService = NewConfigService(ac)
+	return ac
+}
+
+func TestControlAPIHandler_RequiresToken(t *testing.T) {
+	handler := newControlAPIHandler(newControlAPITestController(t), "secret")
+
+	for _, auth := range []string{"", "Bearer wrong", "secret", "Basic secret"} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/status", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status %d, want 401", auth, rec.Code)
+		}
+	}
+
+	// An empty token must never authorize requests
+	req := httptest.NewRequest(http.MethodGet, "/v1/status", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	newControlAPIHandler(newControlAPITestController(t), "").ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("empty token: status %d, want 401", rec.Code)
+	}
+}
+
+func TestControlAPIHandler_Routes(t *testing.T) {
+	ac := newControlAPITestController(t)
+	handler := newControlAPIHandler(ac, "secret")
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/v1/status", "")
+	var status controlAPIStatus
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &status) != nil {
+		t.Fatalf("status: %d %s", rec.Code, rec.Body.String())
+	}
+	if status.Running || status.ConfigPath != ac.FileService.ConfigPath() || !status.AutoUpdate {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	if rec := do(http.MethodGet, "/v1/report", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("report without parse_report.json: status %d, want 404", rec.Code)
+	}
+	report := &config.ParseReport{Success: true, NodesCount: 7}
+	if err := config.SaveParseReport(config.ParseReportPath(ac.FileService.ConfigPath()), report); err != nil {
+		t.Fatal(err)
+	}
+	if rec := do(http.MethodGet, "/v1/report", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"nodes_count":7`) {
+		t.Errorf("report: %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := do(http.MethodGet, "/v1/groups", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("groups without Clash API: status %d, want 503", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/v1/update", ""); rec.Code != http.StatusConflict {
+		t.Errorf("update without config.json: status %d, want 409", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/v1/stop", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /v1/stop: status %d, want 405", rec.Code)
+	}
+}
+
+func TestStartControlAPI_GeneratesTokenAndServes(t *testing.T) {
+	ac := newControlAPITestController(t)
+	statePath := config.LauncherStatePath(ac.FileService.ConfigPath())
+
+	// Disabled by default
+	if err := ac.StartControlAPI(); err != nil || ac.ControlAPIAddress() != "" {
+		t.Fatalf("control API must be opt-in: err=%v address=%q", err, ac.ControlAPIAddress())
+	}
+
+	state := &config.LauncherState{
+		Selections: map[string]config.SelectorChoice{"proxy-out": {Tag: "de-1"}},
+		ControlAPI: &config.ControlAPISettings{Enabled: true, Listen: "127.0.0.1:0"},
+	}
+	if err := config.SaveLauncherState(statePath, state); err != nil {
+		t.Fatal(err)
+	}
+	if err := ac.StartControlAPI(); err != nil {
+		t.Fatalf("StartControlAPI: %v", err)
+	}
+	defer ac.StopControlAPI()
+
+	saved, err := config.LoadLauncherState(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.ControlAPI == nil || len(saved.ControlAPI.Token) < 32 {
+		t.Fatalf("token was not generated and saved: %+v", saved.ControlAPI)
+	}
+	if saved.Selections["proxy-out"].Tag != "de-1" {
+		t.Errorf("selector choices were lost when saving the token")
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://"+ac.ControlAPIAddress()+"/v1/status", nil)
+	req.Header.Set("Authorization", "Bearer "+saved.ControlAPI.Token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request to control API failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status: %d, want 200", resp.StatusCode)
+	}
+
+	ac.StopControlAPI()
+	if ac.ControlAPIAddress() != "" {
+		t.Errorf("address must be empty after StopControlAPI")
+	}
+	if _, err := os.Stat(statePath); err != nil {
+		t.Errorf("launcher state removed: %v", err)
+	}
+}
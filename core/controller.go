@@ -7,7 +7,6 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"strings"
 	"sync"
 	"time"
 
@@ -20,7 +19,7 @@ import (
 	"singbox-launcher/internal/constants"
 	"singbox-launcher/internal/dialogs"
 	"singbox-launcher/internal/platform"
-	"singbox-launcher/internal/process"
+	"singbox-launcher/internal/singleinstance"
 )
 
 // Constants for log file names
@@ -47,26 +46,33 @@ type AppController struct {
 	StateService *services.StateService
 	// FileService manages file paths and log file handles
 	FileService *services.FileService
-	// ProcessService manages sing-box process lifecycle (start, stop, monitor, auto-restart)
-	ProcessService *ProcessService
 	// ConfigService handles configuration parsing, subscription fetching, and JSON generation
 	ConfigService *ConfigService
 
-	// --- Process State ---
-	SingboxCmd               *exec.Cmd
-	CmdMutex                 sync.Mutex
-	ParserMutex              sync.Mutex // Mutex for ParserRunning
-	ParserRunning            bool
-	StoppedByUser            bool
-	ConsecutiveCrashAttempts int
+	// --- Primary sing-box instance (active profile) ---
+	// ProcessService, SingboxCmd, CmdMutex, StoppedByUser, ConsecutiveCrashAttempts and RunningState
+	// of the sing-box process controlled by the main window and the tray
+	*SingboxInstance
 
-	// --- VPN Operation State ---
-	RunningState *RunningState
+	// --- Additional sing-box instances (other profiles running side by side) ---
+	instances      map[string]*SingboxInstance
+	instancesMutex sync.Mutex
+
+	// --- Parser State ---
+	ParserMutex   sync.Mutex // Mutex for ParserRunning
+	ParserRunning bool
 
 	// --- Context for goroutine cancellation ---
 	ctx        context.Context    // Context for cancellation
 	cancelFunc context.CancelFunc // Cancel function for stopping goroutines
 
+	// --- Local control API (opt-in, launcher_state.json) ---
+	controlAPI      *controlAPIServer
+	controlAPIMutex sync.Mutex
+
+	// --- Single-instance lock and IPC for forwarded launches ---
+	singleInstance *singleinstance.Instance
+
 	// --- Update popup state ---
 	updatePopupShown bool         // Флаг, что попап обновления уже был показан в этой сессии
 	updatePopupMutex sync.RWMutex // Мьютекс для защиты updatePopupShown
@@ -103,8 +109,7 @@ func GetController() *AppController {
 			instance = &AppController{
 				FileService: fileService,
 			}
-			instance.RunningState = &RunningState{controller: instance}
-			instance.ProcessService = NewProcessService(instance)
+			instance.SingboxInstance = newSingboxInstance(instance, "")
 			instance.ConfigService = NewConfigService(instance)
 			instance.ctx, instance.cancelFunc = context.WithCancel(context.Background())
 			instance.StateService = services.NewStateService()
@@ -139,15 +144,16 @@ func NewAppController(appIconData, greyIconData, greenIconData, redIconData []by
 		return nil, fmt.Errorf("NewAppController: cannot create FileService: %w", err)
 	}
 	ac.FileService = fileService
+	// Active profile (launcher_state.json) decides which config.json the other services use
+	ac.loadActiveProfile()
 
 	// Open log files with rotation support
 	if err := ac.FileService.OpenLogFiles(logFileName, childLogFileName, apiLogFileName); err != nil {
 		return nil, fmt.Errorf("NewAppController: cannot open log files: %w", err)
 	}
 
-	// Initialize RunningState before UIService (needed for callback)
-	ac.RunningState = &RunningState{controller: ac}
-	ac.RunningState.Set(false)
+	// Initialize the primary instance (RunningState) before UIService (needed for callback)
+	ac.SingboxInstance = newSingboxInstance(ac, "")
 
 	// Initialize UIService
 	uiService, err := services.NewUIService(
@@ -160,8 +166,7 @@ func NewAppController(appIconData, greyIconData, greenIconData, redIconData []by
 		return nil, fmt.Errorf("NewAppController: cannot create UIService: %w", err)
 	}
 	ac.UIService = uiService
-	ac.ConsecutiveCrashAttempts = 0
-	ac.ProcessService = NewProcessService(ac)
+	ac.UIService.RestartPendingFunc = ac.restartPending
 	ac.ConfigService = NewConfigService(ac)
 
 	// Устанавливаем callback для проверки обновлений при открытии окна
@@ -171,7 +176,7 @@ func NewAppController(appIconData, greyIconData, greenIconData, redIconData []by
 
 	// Initialize APIService
 	apiService, err := services.NewAPIService(
-		ac.FileService.ConfigPath,
+		ac.FileService.ConfigPath(),
 		ac.FileService.ApiLogFile,
 		func() bool { return ac.RunningState.IsRunning() },
 		func() {
@@ -227,20 +232,75 @@ func NewAppController(appIconData, greyIconData, greenIconData, redIconData []by
 	ac.StateService = services.NewStateService()
 
 	// Check if config file exists before starting auto-update
-	if _, err := os.Stat(ac.FileService.ConfigPath); os.IsNotExist(err) {
-		debuglog.InfoLog("Auto-update: Config file does not exist (%s), auto-update disabled", ac.FileService.ConfigPath)
-		ac.StateService.SetAutoUpdateEnabled(false)
-	}
-	go ac.startAutoUpdateLoop()
+	ac.StartAutoUpdate()
 
 	// Set global singleton instance
 	instanceOnce.Do(func() {
 		instance = ac
 	})
 
+	// Local control API is opt-in (launcher_state.json); a failure must not prevent the launcher from starting
+	if err := ac.StartControlAPI(); err != nil {
+		debuglog.ErrorLog("NewAppController: failed to start control API: %v", err)
+	}
+
+	// Keep the sing-box:// / clash:// handler registration pointing to this executable (Linux, if registered)
+	go ac.refreshDeepLinkHandler()
+
 	return ac, nil
 }
 
+// NewHeadlessAppController creates an AppController without UIService for the command-line mode.
+// Fyne is never initialized: all dialogs and notifications are skipped (hasUI() is false),
+// results are reported by the caller. The auto-update loop is not started, see StartAutoUpdate.
+func NewHeadlessAppController() (*AppController, error) {
+	ac := &AppController{}
+
+	fileService, err := services.NewFileService()
+	if err != nil {
+		return nil, fmt.Errorf("NewHeadlessAppController: cannot create FileService: %w", err)
+	}
+	ac.FileService = fileService
+	ac.loadActiveProfile()
+
+	if err := ac.FileService.OpenLogFiles(logFileName, childLogFileName, apiLogFileName); err != nil {
+		return nil, fmt.Errorf("NewHeadlessAppController: cannot open log files: %w", err)
+	}
+
+	ac.SingboxInstance = newSingboxInstance(ac, "")
+	ac.ConfigService = NewConfigService(ac)
+
+	apiService, err := services.NewAPIService(
+		ac.FileService.ConfigPath(),
+		ac.FileService.ApiLogFile,
+		func() bool { return ac.RunningState.IsRunning() },
+		nil, nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("NewHeadlessAppController: cannot create APIService: %w", err)
+	}
+	ac.APIService = apiService
+
+	ac.ctx, ac.cancelFunc = context.WithCancel(context.Background())
+	ac.StateService = services.NewStateService()
+
+	instanceOnce.Do(func() {
+		instance = ac
+	})
+
+	return ac, nil
+}
+
+// StartAutoUpdate starts the auto-update loop (NewAppController does it itself; the headless
+// controller only when sing-box is supervised in the foreground).
+func (ac *AppController) StartAutoUpdate() {
+	if _, err := os.Stat(ac.FileService.ConfigPath()); os.IsNotExist(err) {
+		debuglog.InfoLog("Auto-update: Config file does not exist (%s), auto-update disabled", ac.FileService.ConfigPath())
+		ac.StateService.SetAutoUpdateEnabled(false)
+	}
+	go ac.startAutoUpdateLoop()
+}
+
 // UpdateUI updates all UI elements based on the current application state.
 func (ac *AppController) UpdateUI() {
 	if ac.hasUI() {
@@ -287,7 +347,12 @@ func (ac *AppController) GracefulExit() {
 		ac.UIService.StopTrayMenuUpdateTimer()
 	}
 
+	ac.StopControlAPI()
+	if ac.singleInstance != nil {
+		_ = ac.singleInstance.Close()
+	}
 	StopSingBoxProcess()
+	ac.stopInstances()
 
 	debuglog.InfoLog("GracefulExit: Waiting for sing-box to stop...")
 	// Use ProcessService constant for timeout
@@ -455,9 +520,9 @@ func StartSingBoxProcess(skipRunningCheck ...bool) {
 	if ac == nil {
 		return
 	}
-	if ac.ProcessService == nil {
+	if ac.SingboxInstance == nil {
 		debuglog.WarnLog("StartSingBoxProcess: ProcessService is nil, this should not happen. Initializing...")
-		ac.ProcessService = NewProcessService(ac)
+		ac.SingboxInstance = newSingboxInstance(ac, "")
 	}
 	ac.ProcessService.Start(skipRunningCheck...)
 }
@@ -469,9 +534,9 @@ func StopSingBoxProcess() {
 	if ac == nil {
 		return
 	}
-	if ac.ProcessService == nil {
+	if ac.SingboxInstance == nil {
 		debuglog.WarnLog("StopSingBoxProcess: ProcessService is nil, this should not happen. Initializing...")
-		ac.ProcessService = NewProcessService(ac)
+		ac.SingboxInstance = newSingboxInstance(ac, "")
 	}
 	ac.ProcessService.Stop()
 }
@@ -490,6 +555,19 @@ func RunParserProcess() {
 	ac.ConfigService.RunParserProcess()
 }
 
+// RunParserPreviewProcess starts a configuration update with diff preview and user approval.
+func RunParserPreviewProcess() {
+	ac := GetController()
+	if ac == nil {
+		return
+	}
+	if ac.ConfigService == nil {
+		debuglog.WarnLog("RunParserPreviewProcess: ConfigService is nil, this should not happen. Initializing...")
+		ac.ConfigService = NewConfigService(ac)
+	}
+	ac.ConfigService.RunParserPreviewProcess()
+}
+
 // CheckIfSingBoxRunningAtStartUtil checks if sing-box is already running at application start.
 // Note: ProcessService must be initialized in NewAppController. This is a wrapper for backward compatibility.
 func CheckIfSingBoxRunningAtStartUtil() {
@@ -497,9 +575,9 @@ func CheckIfSingBoxRunningAtStartUtil() {
 	if ac == nil {
 		return
 	}
-	if ac.ProcessService == nil {
+	if ac.SingboxInstance == nil {
 		debuglog.WarnLog("CheckIfSingBoxRunningAtStartUtil: ProcessService is nil, this should not happen. Initializing...")
-		ac.ProcessService = NewProcessService(ac)
+		ac.SingboxInstance = newSingboxInstance(ac, "")
 	}
 	ac.ProcessService.CheckIfRunningAtStart()
 }
@@ -510,8 +588,8 @@ func CheckConfigFileExists() {
 	if ac == nil {
 		return
 	}
-	if _, err := os.Stat(ac.FileService.ConfigPath); os.IsNotExist(err) {
-		debuglog.WarnLog("CheckConfigFileExists: config.json not found at %s", ac.FileService.ConfigPath)
+	if _, err := os.Stat(ac.FileService.ConfigPath()); os.IsNotExist(err) {
+		debuglog.WarnLog("CheckConfigFileExists: config.json not found at %s", ac.FileService.ConfigPath())
 
 		message := fmt.Sprintf(
 			"⚠️ Configuration file not found!\n\n"+
@@ -529,38 +607,6 @@ func CheckConfigFileExists() {
 	}
 }
 
-func CheckIfLauncherAlreadyRunningUtil() {
-	ac := GetController()
-	if ac == nil {
-		return
-	}
-	execPath, err := os.Executable()
-	if err != nil {
-		debuglog.ErrorLog("CheckIfLauncherAlreadyRunning: cannot detect executable path: %v", err)
-		return
-	}
-	execName := strings.ToLower(filepath.Base(execPath))
-	currentPID := os.Getpid()
-
-	processes, err := process.GetProcesses()
-	if err != nil {
-		debuglog.ErrorLog("CheckIfLauncherAlreadyRunning: error listing processes: %v", err)
-		return
-	}
-
-	for _, p := range processes {
-		if p.PID == currentPID {
-			continue
-		}
-		if strings.EqualFold(p.Name, execName) {
-			if ac.hasUI() {
-				dialogs.ShowInfo(ac.UIService.MainWindow, "Information", "The application is already running. Use the existing instance or close it before starting a new one.")
-			}
-			return
-		}
-	}
-}
-
 // AutoLoadProxies attempts to load proxies with retry intervals (1, 3, 7, 13, 17 seconds).
 func (ac *AppController) AutoLoadProxies() {
 	if ac.APIService != nil {
@@ -584,7 +630,7 @@ func (ac *AppController) GetVPNButtonState() VPNButtonState {
 
 	// Check if config.json exists
 	configExists := false
-	if _, err := os.Stat(ac.FileService.ConfigPath); err == nil {
+	if _, err := os.Stat(ac.FileService.ConfigPath()); err == nil {
 		configExists = true
 	}
 
@@ -623,8 +669,9 @@ func (ac *AppController) GetVPNButtonState() VPNButtonState {
 			state.StopEnabled = true
 		} else {
 			// VPN is not running and all requirements met - Start enabled, Stop disabled
+			// (Stop stays enabled while a crash restart is pending: it cancels the restart)
 			state.StartEnabled = true
-			state.StopEnabled = false
+			state.StopEnabled = ac.restartPending()
 		}
 	} else {
 		// Requirements not met - both buttons disabled
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"fmt"
+	"os"
+
+	"singbox-launcher/core/config/subscription"
+	"singbox-launcher/internal/debuglog"
+	"singbox-launcher/internal/platform"
+)
+
+// DeepLinkHandlerStatus describes the registration of the launcher as the handler of sing-box:// and clash:// links
+type DeepLinkHandlerStatus struct {
+	Supported  bool   // Registration is implemented on this platform (Linux)
+	Registered bool   // A .desktop entry of the launcher exists
+	Current    bool   // The entry points to the running executable
+	ExecPath   string // Executable set in the entry
+}
+
+// DeepLinkHandler returns whether the launcher is registered as the handler of deep links
+func (ac *AppController) DeepLinkHandler() DeepLinkHandlerStatus {
+	status := DeepLinkHandlerStatus{Supported: platform.URLSchemeRegistrationSupported()}
+	status.ExecPath, status.Registered = platform.RegisteredURLSchemeHandler()
+	if execPath, err := os.Executable(); err == nil && status.Registered {
+		status.Current = status.ExecPath == execPath
+	}
+	return status
+}
+
+// RegisterDeepLinkHandler registers the running executable as the handler of sing-box://, clash:// and clashmeta:// links.
+// On Linux a .desktop entry is generated; returns its path.
+func (ac *AppController) RegisterDeepLinkHandler() (string, error) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("cannot detect executable path: %w", err)
+	}
+	return platform.RegisterURLSchemes(execPath, subscription.DeepLinkSchemes())
+}
+
+// refreshDeepLinkHandler updates an existing registration when the launcher was moved to another folder.
+// The handler is never registered implicitly: the user enables it on the Diagnostics tab.
+func (ac *AppController) refreshDeepLinkHandler() {
+	status := ac.DeepLinkHandler()
+	if !status.Registered || status.Current {
+		return
+	}
+	debuglog.InfoLog("Deep links: handler points to %s, updating registration", status.ExecPath)
+	if _, err := ac.RegisterDeepLinkHandler(); err != nil {
+		debuglog.WarnLog("Deep links: failed to update registration: %v", err)
+	}
+}
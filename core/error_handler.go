@@ -1,8 +1,10 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 
+	"singbox-launcher/internal/corelog"
 	"singbox-launcher/internal/debuglog"
 	"singbox-launcher/internal/dialogs"
 )
@@ -17,8 +19,19 @@ func (ac *AppController) showErrorUI(category string, err error) {
 }
 
 // ShowStartupError shows an error when sing-box fails to start.
+// A *corelog.Diagnosis is shown with its explanation and remediation hint.
 func (ac *AppController) ShowStartupError(err error) {
-	ac.showErrorUI("StartupError", fmt.Errorf("Failed to start sing-box:\n\n%s\n\nPlease check:\n1. config.json is valid\n2. sing-box executable exists\n3. Check logs for details", err.Error()))
+	ac.showStartupError("sing-box", err)
+}
+
+// showStartupError shows a startup error of the named sing-box instance
+func (ac *AppController) showStartupError(name string, err error) {
+	var diagnosis *corelog.Diagnosis
+	if errors.As(err, &diagnosis) {
+		ac.showErrorUI("StartupError", fmt.Errorf("Failed to start %s:\n\n%s\n\n%s\n\nsing-box: %s", name, diagnosis.Explanation, diagnosis.Hint, diagnosis.Line))
+		return
+	}
+	ac.showErrorUI("StartupError", fmt.Errorf("Failed to start %s:\n\n%s\n\nPlease check:\n1. config.json is valid\n2. sing-box executable exists\n3. Check logs for details", name, err.Error()))
 }
 
 // ShowParserError shows an error when parser fails.
@@ -0,0 +1,235 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"singbox-launcher/api"
+	"singbox-launcher/core/config"
+	"singbox-launcher/internal/debuglog"
+)
+
+// healthFailure is a failed round of health probes
+type healthFailure struct {
+	Time time.Time
+	Err  error
+}
+
+// healthWatchdog probes a running sing-box every check.Interval and calls unhealthy after
+// check.FailureThreshold consecutive failed liveness probes. A failed connectivity probe only
+// means the proxies cannot reach the URL, not that the core hangs, so it is logged but never
+// leads to a restart. Dependencies are injected, so the watchdog can be tested without
+// sing-box and the Clash API.
+type healthWatchdog struct {
+	check        config.HealthCheck
+	probe        func(ctx context.Context) error // Liveness probe, bounded by check.Timeout
+	connectivity func(ctx context.Context) error // Optional connectivity probe (check.URL), bounded by check.Timeout
+	alive        func() bool                     // Whether the watched process is still the running one
+	unhealthy    func(failures []healthFailure)
+}
+
+// run probes until ctx is cancelled, the watched process is gone or it is reported unhealthy
+func (w *healthWatchdog) run(ctx context.Context) {
+	ticker := time.NewTicker(w.check.Interval)
+	defer ticker.Stop()
+
+	var failures []healthFailure
+	connectivityFailures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !w.alive() {
+			return
+		}
+
+		err := w.runProbe(ctx, w.probe)
+		if ctx.Err() != nil || !w.alive() {
+			return
+		}
+		if err == nil {
+			if len(failures) > 0 {
+				debuglog.InfoLog("healthWatchdog: Sing-Box is healthy again after %d failed probe(s).", len(failures))
+			}
+			failures = nil
+			if w.connectivity != nil {
+				connectivityFailures = w.checkConnectivity(ctx, connectivityFailures)
+			}
+			continue
+		}
+
+		failures = append(failures, healthFailure{Time: time.Now(), Err: err})
+		debuglog.WarnLog("healthWatchdog: Health probe failed (%d/%d): %v", len(failures), w.check.FailureThreshold, err)
+		if len(failures) >= w.check.FailureThreshold {
+			w.unhealthy(failures)
+			return
+		}
+	}
+}
+
+// runProbe runs one probe bounded by check.Timeout
+func (w *healthWatchdog) runProbe(ctx context.Context, probe func(ctx context.Context) error) error {
+	probeCtx, cancel := context.WithTimeout(ctx, w.check.Timeout)
+	defer cancel()
+	return probe(probeCtx)
+}
+
+// checkConnectivity runs the connectivity probe and logs when it starts and stops failing.
+// Returns the new number of consecutive connectivity failures.
+func (w *healthWatchdog) checkConnectivity(ctx context.Context, failures int) int {
+	err := w.runProbe(ctx, w.connectivity)
+	if ctx.Err() != nil {
+		return failures
+	}
+	if err == nil {
+		if failures > 0 {
+			debuglog.InfoLog("healthWatchdog: %s is reachable again after %d failed probe(s).", w.check.URL, failures)
+		}
+		return 0
+	}
+	failures++
+	if failures == 1 || failures%w.check.FailureThreshold == 0 {
+		debuglog.WarnLog("healthWatchdog: %s is not reachable through the proxy (%d failed probe(s)), not restarting: %v", w.check.URL, failures, err)
+	}
+	return failures
+}
+
+// HealthCheck returns the health watchdog configuration from launcher_state.json.
+// Invalid settings are logged and replaced by the defaults.
+func (ac *AppController) HealthCheck() config.HealthCheck {
+	state, err := config.LoadLauncherState(ac.launcherStatePath())
+	if err != nil {
+		debuglog.WarnLog("HealthCheck: failed to load launcher state: %v", err)
+	}
+	if state.HealthCheck == nil {
+		return config.DefaultHealthCheck()
+	}
+	check, err := state.HealthCheck.Resolve()
+	if err != nil {
+		debuglog.WarnLog("HealthCheck: %v, using defaults", err)
+		return config.DefaultHealthCheck()
+	}
+	return check
+}
+
+// watchHealth runs the health watchdog for the sing-box process pid until it exits.
+// A process whose Clash API stops responding is killed by restartUnhealthy; Monitor then
+// restarts it according to the restart policy.
+func (svc *ProcessService) watchHealth(pid int) {
+	ac, inst := svc.ac, svc.inst
+	check := ac.HealthCheck()
+	if !check.Enabled {
+		debuglog.DebugLog("healthWatchdog: disabled")
+		return
+	}
+	clashAPI := inst.ClashAPI()
+	if clashAPI == nil {
+		return
+	}
+	if _, _, enabled := clashAPI.GetClashAPIConfig(); !enabled {
+		debuglog.InfoLog("healthWatchdog: Clash API is not configured, health checks are disabled for PID=%d", pid)
+		return
+	}
+	debuglog.DebugLog("healthWatchdog: Watching PID=%d (every %v, restart after %d failures)", pid, check.Interval, check.FailureThreshold)
+
+	w := &healthWatchdog{
+		check: check,
+		probe: svc.probeHealth,
+		alive: func() bool {
+			return inst.RunningState.IsRunning() && svc.getTrackedPID() == pid
+		},
+		unhealthy: func(failures []healthFailure) { svc.restartUnhealthy(pid, check, failures) },
+	}
+	if check.URL != "" {
+		w.connectivity = func(ctx context.Context) error { return svc.probeConnectivity(ctx, check) }
+	}
+	w.run(ac.ctx)
+}
+
+// probeHealth checks that the Clash API of the core answers
+func (svc *ProcessService) probeHealth(ctx context.Context) error {
+	baseURL, token, enabled := svc.inst.ClashAPI().GetClashAPIConfig()
+	if !enabled {
+		return nil
+	}
+	return api.ProbeVersion(ctx, baseURL, token)
+}
+
+// probeConnectivity checks that check.URL is reachable through the selected group
+func (svc *ProcessService) probeConnectivity(ctx context.Context, check config.HealthCheck) error {
+	clashAPI := svc.inst.ClashAPI()
+	baseURL, token, enabled := clashAPI.GetClashAPIConfig()
+	if !enabled {
+		return nil
+	}
+	group := clashAPI.GetSelectedClashGroup()
+	if group == "" {
+		return nil
+	}
+	if _, err := api.ProbeDelay(ctx, baseURL, token, group, check.URL, check.Timeout); err != nil {
+		return err
+	}
+	return nil
+}
+
+// restartUnhealthy logs a diagnostic snapshot and kills the unresponsive process.
+// The exit is recorded in the crash history and handled by the restart policy in Monitor,
+// but does not count towards max_attempts.
+func (svc *ProcessService) restartUnhealthy(pid int, check config.HealthCheck, failures []healthFailure) {
+	inst := svc.inst
+	inst.CmdMutex.Lock()
+	defer inst.CmdMutex.Unlock()
+
+	if inst.StoppedByUser || !inst.RunningState.IsRunning() || inst.SingboxCmd == nil || inst.SingboxCmd.Process == nil || inst.SingboxCmd.Process.Pid != pid {
+		return
+	}
+
+	debuglog.WarnLog("healthWatchdog: Sing-Box is not responding, restarting.\n%s", svc.healthSnapshot(pid, check, failures))
+	svc.unhealthyPID = pid
+	svc.unhealthyReason = fmt.Sprintf("not responding: %d health checks failed, last: %v", len(failures), failures[len(failures)-1].Err)
+	if err := inst.SingboxCmd.Process.Kill(); err != nil {
+		debuglog.ErrorLog("healthWatchdog: Failed to kill PID=%d: %v", pid, err)
+		svc.unhealthyPID = 0
+		svc.unhealthyReason = ""
+	}
+}
+
+// takeUnhealthyReason returns why the watchdog killed pid ("" if it did not) and clears it.
+// Must be called with inst.CmdMutex held.
+func (svc *ProcessService) takeUnhealthyReason(pid int) string {
+	if svc.unhealthyPID != pid {
+		return ""
+	}
+	reason := svc.unhealthyReason
+	svc.unhealthyPID = 0
+	svc.unhealthyReason = ""
+	return reason
+}
+
+// healthSnapshot describes the state of an unresponsive sing-box for the log.
+// Must be called with inst.CmdMutex held.
+func (svc *ProcessService) healthSnapshot(pid int, check config.HealthCheck, failures []healthFailure) string {
+	ac, inst := svc.ac, svc.inst
+	var b strings.Builder
+	fmt.Fprintf(&b, "  PID: %d, uptime: %v\n", pid, time.Since(svc.startedAt).Round(time.Second))
+	fmt.Fprintf(&b, "  Health check: every %v, timeout %v, threshold %d, url %q\n", check.Interval, check.Timeout, check.FailureThreshold, check.URL)
+	if clashAPI := inst.ClashAPI(); clashAPI != nil {
+		baseURL, _, _ := clashAPI.GetClashAPIConfig()
+		fmt.Fprintf(&b, "  Clash API: %s, group %q, active proxy %q\n", baseURL, clashAPI.GetSelectedClashGroup(), clashAPI.GetActiveProxyName())
+	}
+	fmt.Fprintf(&b, "  Restart policy: %s, consecutive crashes: %d\n", ac.RestartPolicy(), inst.ConsecutiveCrashAttempts)
+	for _, failure := range failures {
+		fmt.Fprintf(&b, "  Probe failed at %s: %v\n", failure.Time.Format("15:04:05"), failure.Err)
+	}
+	if svc.output != nil {
+		texts := svc.output.Texts()
+		for _, line := range texts[max(0, len(texts)-crashOutputLines):] {
+			fmt.Fprintf(&b, "  | %s\n", line)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
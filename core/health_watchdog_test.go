@@ -0,0 +1,127 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"singbox-launcher/core/config"
+)
+
+func testHealthCheck() config.HealthCheck {
+	return config.HealthCheck{Enabled: true, Interval: time.Millisecond, Timeout: time.Millisecond, FailureThreshold: 3}
+}
+
+func TestHealthWatchdog_RestartsAfterConsecutiveFailures(t *testing.T) {
+	// A success in between resets the count: fail, fail, ok, fail, fail, fail -> unhealthy after round 6
+	results := []error{errors.New("timeout"), errors.New("timeout"), nil, errors.New("a"), errors.New("b"), errors.New("c"), nil}
+	rounds := 0
+	var reported []healthFailure
+	w := &healthWatchdog{
+		check: testHealthCheck(),
+		probe: func(ctx context.Context) error {
+			err := results[rounds]
+			rounds++
+			return err
+		},
+		alive:     func() bool { return true },
+		unhealthy: func(failures []healthFailure) { reported = failures },
+	}
+	w.run(context.Background())
+
+	if rounds != 6 {
+		t.Errorf("probe rounds = %d, want 6", rounds)
+	}
+	if len(reported) != 3 || reported[0].Err.Error() != "a" || reported[2].Err.Error() != "c" {
+		t.Errorf("reported failures = %v", reported)
+	}
+}
+
+func TestHealthWatchdog_ConnectivityFailuresDoNotRestart(t *testing.T) {
+	check := testHealthCheck()
+	check.URL = "https://www.gstatic.com/generate_204"
+	rounds := 0
+	w := &healthWatchdog{
+		check: check,
+		probe: func(ctx context.Context) error {
+			rounds++
+			return nil
+		},
+		connectivity: func(ctx context.Context) error { return errors.New("timeout") },
+		alive:        func() bool { return rounds < 10 },
+		unhealthy:    func([]healthFailure) { t.Error("an unreachable URL must not restart a responding core") },
+	}
+	w.run(context.Background())
+
+	if rounds != 10 {
+		t.Errorf("probe rounds = %d, want 10", rounds)
+	}
+}
+
+func TestHealthWatchdog_StopsWhenProcessIsGone(t *testing.T) {
+	rounds := 0
+	w := &healthWatchdog{
+		check: testHealthCheck(),
+		probe: func(ctx context.Context) error {
+			rounds++
+			return errors.New("connection refused")
+		},
+		alive:     func() bool { return rounds < 2 },
+		unhealthy: func([]healthFailure) { t.Error("a stopped process must not be reported unhealthy") },
+	}
+
+	done := make(chan struct{})
+	go func() {
+		w.run(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchdog did not stop")
+	}
+}
+
+func TestMonitor_RecordsUnhealthyReason(t *testing.T) {
+	ac := newControlAPITestController(t)
+	ac.ctx = context.Background()
+	setTestRestartPolicy(t, ac, config.RestartPolicySettings{Mode: config.RestartModeNever})
+
+	cmd := startCrashingProcess(t, ac, "FATAL start service: bind: address already in use")
+	ac.ProcessService.unhealthyPID = cmd.Process.Pid
+	ac.ProcessService.unhealthyReason = "not responding: 3 health checks failed"
+	ac.ProcessService.Monitor(cmd)
+
+	crashes := ac.ProcessService.CrashHistory()
+	if len(crashes) != 1 || crashes[0].Error != "not responding: 3 health checks failed" || crashes[0].Action != "not restarted" {
+		t.Errorf("crash history = %+v", crashes)
+	}
+	if ac.ProcessService.unhealthyPID != 0 {
+		t.Errorf("unhealthy PID is not cleared")
+	}
+}
+
+func TestMonitor_UnhealthyRestartDoesNotCountAttempts(t *testing.T) {
+	ac := newControlAPITestController(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // The restart is cancelled right away
+	ac.ctx = ctx
+	setTestRestartPolicy(t, ac, config.RestartPolicySettings{Mode: config.RestartModeOnFailure})
+
+	// All attempts are already used up by crashes, yet a hung core is still restarted
+	ac.ConsecutiveCrashAttempts = config.DefaultRestartMaxAttempts
+	cmd := startCrashingProcess(t, ac, "")
+	ac.ProcessService.unhealthyPID = cmd.Process.Pid
+	ac.ProcessService.unhealthyReason = "not responding: 3 health checks failed"
+	ac.ProcessService.Monitor(cmd)
+
+	crashes := ac.ProcessService.CrashHistory()
+	if len(crashes) != 1 || !strings.HasPrefix(crashes[0].Action, "restart in") {
+		t.Errorf("crash history = %+v", crashes)
+	}
+	if ac.ConsecutiveCrashAttempts != config.DefaultRestartMaxAttempts {
+		t.Errorf("attempts = %d, a watchdog kill must not count", ac.ConsecutiveCrashAttempts)
+	}
+}
@@ -0,0 +1,283 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"singbox-launcher/api"
+	"singbox-launcher/core/config"
+	"singbox-launcher/internal/debuglog"
+)
+
+const (
+	// hotApplyAPITimeout is how long to wait for the Clash API after restart/reload
+	hotApplyAPITimeout = 20 * time.Second
+
+	// hotApplyPollInterval is the interval between Clash API availability checks
+	hotApplyPollInterval = 500 * time.Millisecond
+
+	// hotApplyNoAPIGrace is how long the process must stay alive when Clash API is disabled
+	hotApplyNoAPIGrace = 3 * time.Second
+)
+
+// hotApplyState хранит то, что нужно для применения обновления к запущенному sing-box:
+// политику и блок outbounds до обновления (для отката)
+type hotApplyState struct {
+	policy        string
+	historyPolicy config.HistoryPolicy
+	previousBlock string
+}
+
+// hotApplyResult описывает результат восстановления выбора в селекторах
+type hotApplyResult struct {
+	Restored     int      // Сколько селекторов переключено обратно на прежний прокси
+	Missing      []string // Селекторы, в которых прежнего прокси больше нет
+	SwitchErrors []string // Ошибки переключения через Clash API
+}
+
+// prepareHotApply запоминает текущий блок outbounds перед обновлением, если включён parser.apply_on_update
+// и sing-box запущен. Возвращает nil, если применять обновление к процессу не нужно
+// (или без возможности отката нельзя).
+func (svc *ConfigService) prepareHotApply(parserConfig *config.ParserConfig) *hotApplyState {
+	ac := svc.ac
+	policy := parserConfig.ParserConfig.Parser.ApplyPolicy()
+	if policy == config.ApplyOnUpdateOff || !ac.RunningState.IsRunning() {
+		return nil
+	}
+
+	previousBlock, err := config.ReadParserBlock(ac.FileService.ConfigPath())
+	if err != nil {
+		debuglog.WarnLog("prepareHotApply: failed to read current outbounds block, update will not be applied to sing-box: %v", err)
+		return nil
+	}
+	return &hotApplyState{
+		policy:        policy,
+		historyPolicy: parserConfig.ParserConfig.Parser.HistoryPolicy(),
+		previousBlock: previousBlock,
+	}
+}
+
+// applyUpdatedConfig применяет только что записанный config.json к запущенному sing-box
+// (перезапуск или перезагрузка) и восстанавливает выбор прокси в селекторах.
+// Если sing-box не поднялся с новым конфигом, возвращается предыдущий config.json,
+// чтобы пользователь не остался без соединения.
+func (svc *ConfigService) applyUpdatedConfig(state *hotApplyState) (*hotApplyResult, error) {
+	ac := svc.ac
+	configPath := ac.FileService.ConfigPath()
+
+	if newBlock, err := config.ReadParserBlock(configPath); err == nil && newBlock == state.previousBlock {
+		debuglog.InfoLog("applyUpdatedConfig: outbounds did not change, sing-box is left running as is")
+		return nil, nil
+	}
+	if !ac.RunningState.IsRunning() {
+		debuglog.InfoLog("applyUpdatedConfig: sing-box is no longer running, nothing to apply")
+		return nil, nil
+	}
+
+	selections := svc.captureSelectorChoices()
+
+	debuglog.InfoLog("applyUpdatedConfig: applying updated config (%s)", state.policy)
+	var err error
+	if state.policy == config.ApplyOnUpdateReload {
+		err = ac.ProcessService.Reload()
+		if err == nil && ac.APIService != nil {
+			if reloadErr := ac.APIService.ReloadClashAPIConfig(); reloadErr != nil {
+				debuglog.WarnLog("applyUpdatedConfig: failed to reload Clash API config: %v", reloadErr)
+			}
+		}
+	} else {
+		err = ac.ProcessService.Restart()
+	}
+	if err == nil {
+		err = svc.waitForSingBox(hotApplyAPITimeout)
+	}
+	if err != nil {
+		return nil, svc.rollbackHotApply(state, err)
+	}
+
+	result := svc.restoreSelectorChoices(selections)
+	if state.policy == config.ApplyOnUpdateReload {
+		// Start() не вызывался - обновляем список прокси в UI сами
+		go ac.AutoLoadProxies()
+	}
+	return result, nil
+}
+
+// captureSelectorChoices запоминает текущий выбор во всех селекторах запущенного sing-box
+func (svc *ConfigService) captureSelectorChoices() map[string]string {
+	ac := svc.ac
+	if ac.APIService == nil {
+		return nil
+	}
+	baseURL, token, enabled := ac.APIService.GetClashAPIConfig()
+	if !enabled {
+		return nil
+	}
+	groups, err := api.GetSelectorGroups(baseURL, token, ac.APIService.ApiLogFile)
+	if err != nil {
+		debuglog.WarnLog("captureSelectorChoices: failed to read selector groups, choices will not be restored: %v", err)
+		return nil
+	}
+	selections := make(map[string]string, len(groups))
+	for name, group := range groups {
+		if group.Now != "" {
+			selections[name] = group.Now
+		}
+	}
+	debuglog.DebugLog("captureSelectorChoices: remembered choice in %d selectors", len(selections))
+	return selections
+}
+
+// waitForSingBox ждёт, пока sing-box с новым конфигом ответит на Clash API.
+// Если Clash API выключен, проверяется только, что процесс не завершился.
+func (svc *ConfigService) waitForSingBox(timeout time.Duration) error {
+	ac := svc.ac
+	enabled := false
+	var baseURL, token string
+	if ac.APIService != nil {
+		baseURL, token, enabled = ac.APIService.GetClashAPIConfig()
+	}
+
+	started := time.Now()
+	var lastErr error
+	for {
+		<-time.After(hotApplyPollInterval)
+		if !ac.RunningState.IsRunning() {
+			return fmt.Errorf("sing-box exited after applying the new config, see sing-box.log")
+		}
+		if !enabled {
+			if time.Since(started) >= hotApplyNoAPIGrace {
+				return nil
+			}
+			continue
+		}
+		if lastErr = api.TestAPIConnection(baseURL, token, ac.APIService.ApiLogFile); lastErr == nil {
+			return nil
+		}
+		if time.Since(started) >= timeout {
+			return fmt.Errorf("Clash API did not respond within %s: %w", timeout, lastErr)
+		}
+	}
+}
+
+// rollbackHotApply возвращает предыдущий блок outbounds и запускает sing-box с ним.
+// @ParserConfig (и last_updated) не откатывается, чтобы автообновление не повторяло попытку сразу же.
+func (svc *ConfigService) rollbackHotApply(state *hotApplyState, cause error) error {
+	ac := svc.ac
+	configPath := ac.FileService.ConfigPath()
+	debuglog.ErrorLog("applyUpdatedConfig: sing-box failed with the updated config: %v. Restoring previous config.", cause)
+
+	if err := config.WriteParserBlock(configPath, state.previousBlock); err != nil {
+		return fmt.Errorf("sing-box failed with the updated config (%v) and the previous config could not be restored: %w", cause, err)
+	}
+	if _, err := config.RecordConfigVersion(configPath, config.HistoryTriggerRestore, state.historyPolicy); err != nil {
+		debuglog.WarnLog("rollbackHotApply: failed to record config history: %v", err)
+	}
+	if ac.UIService != nil && ac.UIService.UpdateConfigStatusFunc != nil {
+		ac.UIService.UpdateConfigStatusFunc()
+	}
+
+	if err := ac.ProcessService.Restart(); err != nil {
+		return fmt.Errorf("sing-box failed with the updated config (%v); previous config restored, but restart failed: %w", cause, err)
+	}
+	return fmt.Errorf("sing-box failed with the updated config (%v); previous config restored and sing-box restarted", cause)
+}
+
+// restoreSelectorChoices переключает селекторы на прокси, выбранные до перезапуска, если они ещё существуют
+func (svc *ConfigService) restoreSelectorChoices(selections map[string]string) *hotApplyResult {
+	ac := svc.ac
+	result := &hotApplyResult{}
+	if len(selections) == 0 || ac.APIService == nil {
+		return result
+	}
+	baseURL, token, enabled := ac.APIService.GetClashAPIConfig()
+	if !enabled {
+		return result
+	}
+	groups, err := api.GetSelectorGroups(baseURL, token, ac.APIService.ApiLogFile)
+	if err != nil {
+		result.SwitchErrors = append(result.SwitchErrors, fmt.Sprintf("failed to read selector groups: %v", err))
+		return result
+	}
+
+	switches, missing := planSelectorRestore(selections, groups)
+	result.Missing = missing
+	groupNames := make([]string, 0, len(switches))
+	for group := range switches {
+		groupNames = append(groupNames, group)
+	}
+	sort.Strings(groupNames)
+	for _, group := range groupNames {
+		proxy := switches[group]
+		if err := api.SwitchProxy(baseURL, token, group, proxy, ac.APIService.ApiLogFile); err != nil {
+			debuglog.WarnLog("restoreSelectorChoices: failed to switch %s to %s: %v", group, proxy, err)
+			result.SwitchErrors = append(result.SwitchErrors, fmt.Sprintf("%s: %v", group, err))
+			continue
+		}
+		ac.APIService.SetLastSelectedProxyForGroup(group, proxy)
+		result.Restored++
+	}
+	debuglog.InfoLog("restoreSelectorChoices: restored %d selectors, %d choices no longer exist, %d errors",
+		result.Restored, len(result.Missing), len(result.SwitchErrors))
+	return result
+}
+
+// planSelectorRestore определяет, какие селекторы нужно переключить обратно.
+// Возвращает group -> proxy для переключения и отсортированный список селекторов,
+// в которых прежнего прокси больше нет (или исчез сам селектор).
+func planSelectorRestore(selections map[string]string, groups map[string]api.SelectorGroup) (map[string]string, []string) {
+	switches := make(map[string]string)
+	var missing []string
+	for groupName, proxy := range selections {
+		group, ok := groups[groupName]
+		if !ok {
+			missing = append(missing, groupName)
+			continue
+		}
+		if group.Now == proxy {
+			continue
+		}
+		found := false
+		for _, member := range group.All {
+			if member == proxy {
+				found = true
+				break
+			}
+		}
+		if found {
+			switches[groupName] = proxy
+		} else {
+			missing = append(missing, groupName)
+		}
+	}
+	sort.Strings(missing)
+	return switches, missing
+}
+
+// reportHotApply сообщает пользователю о результате применения обновления к запущенному sing-box
+func (svc *ConfigService) reportHotApply(result *hotApplyResult, err error) {
+	ac := svc.ac
+	if err != nil {
+		ac.showErrorUI("ApplyError", fmt.Errorf("Config was updated, but applying it failed:\n\n%s", err.Error()))
+		return
+	}
+	if result == nil {
+		return
+	}
+
+	message := fmt.Sprintf("sing-box is running with the new config, %d selector choice(s) restored", result.Restored)
+	if len(result.Missing) > 0 {
+		message += fmt.Sprintf("; previous proxy no longer exists in: %s", strings.Join(result.Missing, ", "))
+	}
+	if len(result.SwitchErrors) > 0 {
+		message += fmt.Sprintf("; failed to restore: %s", strings.Join(result.SwitchErrors, "; "))
+	}
+	debuglog.InfoLog("reportHotApply: %s", message)
+	if ac.hasUI() && ac.UIService.Application != nil {
+		ac.UIService.Application.SendNotification(&fyne.Notification{Title: "Config applied", Content: message})
+	}
+}
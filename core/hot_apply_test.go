@@ -0,0 +1,31 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"singbox-launcher/api"
+)
+
+func TestPlanSelectorRestore(t *testing.T) {
+	selections := map[string]string{
+		"proxy-out": "de-1",   // still exists -> switch back
+		"streaming": "nl-2",   // node removed -> missing
+		"gaming":    "auto",   // already selected -> nothing to do
+		"old-group": "direct", // group removed -> missing
+	}
+	groups := map[string]api.SelectorGroup{
+		"proxy-out": {Name: "proxy-out", Now: "auto", All: []string{"auto", "de-1", "nl-1"}},
+		"streaming": {Name: "streaming", Now: "nl-1", All: []string{"nl-1", "de-1"}},
+		"gaming":    {Name: "gaming", Now: "auto", All: []string{"auto", "de-1"}},
+	}
+
+	switches, missing := planSelectorRestore(selections, groups)
+
+	if want := map[string]string{"proxy-out": "de-1"}; !reflect.DeepEqual(switches, want) {
+		t.Errorf("switches = %v, want %v", switches, want)
+	}
+	if want := []string{"old-group", "streaming"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("missing = %v, want %v", missing, want)
+	}
+}
@@ -0,0 +1,294 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"singbox-launcher/core/config"
+	"singbox-launcher/core/services"
+	"singbox-launcher/internal/constants"
+	"singbox-launcher/internal/debuglog"
+)
+
+// SingboxInstance is a managed sing-box process with its own config, log file, Clash API endpoint
+// and ProcessService (start, monitor, crash restarts, health watchdog). The primary instance runs
+// the active profile and is embedded in AppController; additional instances run other profiles
+// side by side (StartInstance), e.g. a TUN profile next to a SOCKS-only one.
+type SingboxInstance struct {
+	ac      *AppController
+	profile string // Profile of an additional instance; "" for the primary instance (active profile)
+
+	// ProcessService manages the sing-box process lifecycle (start, stop, monitor, auto-restart)
+	ProcessService *ProcessService
+
+	// --- Process State ---
+	SingboxCmd               *exec.Cmd
+	CmdMutex                 sync.Mutex
+	StoppedByUser            bool
+	ConsecutiveCrashAttempts int
+	RunningState             *RunningState
+	starting                 bool // StartInstance has launched ProcessService.Start, guarded by ac.instancesMutex
+
+	// Clash API client and log file of an additional instance; the primary instance
+	// uses AppController.APIService and sing-box.log
+	clashAPI *services.APIService
+	logFile  *os.File
+}
+
+// newSingboxInstance creates the primary instance (profile "") or an additional instance of a profile
+func newSingboxInstance(ac *AppController, profile string) *SingboxInstance {
+	inst := &SingboxInstance{ac: ac, profile: profile}
+	inst.RunningState = &RunningState{controller: ac}
+	inst.ProcessService = NewProcessService(inst)
+	return inst
+}
+
+// IsPrimary reports whether this is the instance of the active profile
+func (inst *SingboxInstance) IsPrimary() bool {
+	return inst.profile == ""
+}
+
+// Profile returns the profile the instance runs
+func (inst *SingboxInstance) Profile() string {
+	if inst.IsPrimary() {
+		return inst.ac.ActiveProfile()
+	}
+	return inst.profile
+}
+
+// ConfigPath returns config.json of the instance
+func (inst *SingboxInstance) ConfigPath() string {
+	if inst.IsPrimary() {
+		return inst.ac.FileService.ConfigPath()
+	}
+	return config.ProfileConfigPath(inst.ac.FileService.BaseConfigPath, inst.profile)
+}
+
+// LogPath returns the sing-box log of the instance: sing-box.log or sing-box-<profile>.log
+func (inst *SingboxInstance) LogPath() string {
+	if inst.IsPrimary() {
+		return filepath.Join(inst.ac.FileService.ExecDir, childLogFileName)
+	}
+	name := strings.TrimSuffix(constants.ChildLogFileName, ".log") + "-" + inst.profile + ".log"
+	return filepath.Join(inst.ac.FileService.ExecDir, "logs", name)
+}
+
+// ClashAPI returns the Clash API client of the instance (nil if there is none)
+func (inst *SingboxInstance) ClashAPI() *services.APIService {
+	if inst.IsPrimary() {
+		return inst.ac.APIService
+	}
+	return inst.clashAPI
+}
+
+// PID returns the PID of the running process, -1 if it is not running
+func (inst *SingboxInstance) PID() int {
+	return inst.ProcessService.getTrackedPID()
+}
+
+// label names the instance in messages: "Sing-Box" or "Sing-Box (office)"
+func (inst *SingboxInstance) label() string {
+	if inst.IsPrimary() {
+		return "Sing-Box"
+	}
+	return fmt.Sprintf("Sing-Box (%s)", inst.profile)
+}
+
+// showStartupError shows why the instance failed to start
+func (svc *ProcessService) showStartupError(err error) {
+	svc.ac.showStartupError(strings.ToLower(svc.inst.label()), err)
+}
+
+// outputLog returns the log file the sing-box output is written to, opening it for an additional instance
+func (inst *SingboxInstance) outputLog() *os.File {
+	if inst.IsPrimary() {
+		return inst.ac.FileService.ChildLogFile
+	}
+	if inst.logFile == nil {
+		logFile, err := inst.ac.FileService.OpenLogFileWithRotation(inst.LogPath())
+		if err != nil {
+			debuglog.WarnLog("startSingBox: Failed to open %s: %v", inst.LogPath(), err)
+			return nil
+		}
+		inst.logFile = logFile
+	}
+	return inst.logFile
+}
+
+// InstanceConflictError reports resources that an instance would share with another running instance
+type InstanceConflictError struct {
+	Profile   string   // Profile being started
+	Other     string   // Profile of the running instance
+	Conflicts []string // Shared ports and TUN interfaces
+}
+
+func (e *InstanceConflictError) Error() string {
+	return fmt.Sprintf("profile %q cannot run next to profile %q, both use:\n• %s\n\nChange the ports (listen_port, clash_api.external_controller) or interface_name in one of the configs.",
+		e.Profile, e.Other, strings.Join(e.Conflicts, "\n• "))
+}
+
+// checkConflicts compares the ports and TUN interfaces of the instance's config with the other
+// running instances. An unreadable config is left to sing-box.
+func (inst *SingboxInstance) checkConflicts() error {
+	resources, err := config.GetInstanceResources(inst.ConfigPath())
+	if err != nil {
+		debuglog.WarnLog("startSingBox: Failed to read resources of %s: %v", inst.ConfigPath(), err)
+		return nil
+	}
+	for _, other := range inst.ac.Instances() {
+		if other == inst || !other.RunningState.IsRunning() {
+			continue
+		}
+		otherResources, err := config.GetInstanceResources(other.ConfigPath())
+		if err != nil {
+			continue
+		}
+		if conflicts := config.ResourceConflicts(resources, otherResources); len(conflicts) > 0 {
+			return &InstanceConflictError{Profile: inst.Profile(), Other: other.Profile(), Conflicts: conflicts}
+		}
+	}
+	return nil
+}
+
+// Instances returns the primary instance followed by the additional instances sorted by profile
+func (ac *AppController) Instances() []*SingboxInstance {
+	ac.instancesMutex.Lock()
+	defer ac.instancesMutex.Unlock()
+	instances := []*SingboxInstance{ac.SingboxInstance}
+	for _, inst := range ac.instances {
+		instances = append(instances, inst)
+	}
+	sort.Slice(instances[1:], func(i, j int) bool {
+		return instances[1+i].profile < instances[1+j].profile
+	})
+	return instances
+}
+
+// instanceRunning reports whether the profile runs as an additional instance
+func (ac *AppController) instanceRunning(profile string) bool {
+	ac.instancesMutex.Lock()
+	inst, ok := ac.instances[profile]
+	ac.instancesMutex.Unlock()
+	return ok && inst.RunningState.IsRunning()
+}
+
+// StartInstance starts sing-box with the config of another profile next to the active one.
+// Its ports and TUN interfaces must not overlap with the running instances.
+func (ac *AppController) StartInstance(profile string) error {
+	if profile == ac.ActiveProfile() {
+		return fmt.Errorf("profile %q is the active profile, start it with Start", profile)
+	}
+	if !config.ProfileExists(ac.FileService.BaseConfigPath, profile) {
+		return fmt.Errorf("profile %q does not exist", profile)
+	}
+	configPath := config.ProfileConfigPath(ac.FileService.BaseConfigPath, profile)
+	if _, err := os.Stat(configPath); err != nil {
+		return fmt.Errorf("profile %q has no config.json yet, run the Config Wizard to create it", profile)
+	}
+
+	ac.instancesMutex.Lock()
+	if ac.instances == nil {
+		ac.instances = make(map[string]*SingboxInstance)
+	}
+	inst, ok := ac.instances[profile]
+	if !ok {
+		inst = newSingboxInstance(ac, profile)
+		apiService, err := services.NewAPIService(configPath, ac.FileService.ApiLogFile, inst.RunningState.IsRunning, nil, nil)
+		if err != nil {
+			ac.instancesMutex.Unlock()
+			return fmt.Errorf("failed to create Clash API client of profile %q: %w", profile, err)
+		}
+		inst.clashAPI = apiService
+		ac.instances[profile] = inst
+	}
+	// The flag is held until Start has set the running state, so a second call cannot launch another process
+	if inst.starting || inst.RunningState.IsRunning() {
+		ac.instancesMutex.Unlock()
+		return fmt.Errorf("profile %q is already running", profile)
+	}
+	inst.starting = true
+	ac.instancesMutex.Unlock()
+
+	if err := inst.checkConflicts(); err != nil {
+		ac.setInstanceStarting(inst, false)
+		return err
+	}
+	debuglog.InfoLog("StartInstance: Starting profile %q (%s)", profile, configPath)
+	go func() {
+		inst.ProcessService.Start()
+		ac.setInstanceStarting(inst, false)
+	}()
+	return nil
+}
+
+// setInstanceStarting sets the starting flag of an additional instance
+func (ac *AppController) setInstanceStarting(inst *SingboxInstance, starting bool) {
+	ac.instancesMutex.Lock()
+	inst.starting = starting
+	ac.instancesMutex.Unlock()
+}
+
+// StopInstance stops an additional instance and forgets it
+func (ac *AppController) StopInstance(profile string) error {
+	ac.instancesMutex.Lock()
+	inst, ok := ac.instances[profile]
+	ac.instancesMutex.Unlock()
+	if !ok {
+		return fmt.Errorf("profile %q is not running", profile)
+	}
+
+	debuglog.InfoLog("StopInstance: Stopping profile %q", profile)
+	err := inst.ProcessService.stopAndWait()
+	ac.forgetInstance(profile)
+	ac.UpdateUI()
+	if ac.UIService != nil && ac.UIService.UpdateCoreStatusFunc != nil {
+		ac.UIService.UpdateCoreStatusFunc()
+	}
+	return err
+}
+
+// forgetInstance removes a stopped additional instance and closes its log file
+func (ac *AppController) forgetInstance(profile string) {
+	ac.instancesMutex.Lock()
+	inst, ok := ac.instances[profile]
+	delete(ac.instances, profile)
+	ac.instancesMutex.Unlock()
+	if ok && inst.logFile != nil {
+		debuglog.RunAndLog("forgetInstance: close "+inst.LogPath(), inst.logFile.Close)
+	}
+}
+
+// stopInstances stops all additional instances (on exit)
+func (ac *AppController) stopInstances() {
+	for _, inst := range ac.Instances()[1:] {
+		inst.ProcessService.Stop()
+	}
+	deadline := time.Now().Add(gracefulShutdownTimeout)
+	for _, inst := range ac.Instances()[1:] {
+		for inst.RunningState.IsRunning() && time.Now().Before(deadline) {
+			<-time.After(100 * time.Millisecond)
+		}
+		inst.CmdMutex.Lock()
+		if inst.RunningState.IsRunning() && inst.SingboxCmd != nil && inst.SingboxCmd.Process != nil {
+			_ = inst.SingboxCmd.Process.Kill()
+		}
+		inst.CmdMutex.Unlock()
+	}
+}
+
+// managedPIDs returns the PIDs of all running instances
+func (ac *AppController) managedPIDs() map[int]bool {
+	pids := make(map[int]bool)
+	for _, inst := range ac.Instances() {
+		if pid := inst.PID(); pid > 0 {
+			pids[pid] = true
+		}
+	}
+	return pids
+}
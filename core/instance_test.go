@@ -0,0 +1,150 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"singbox-launcher/core/config"
+	"singbox-launcher/internal/platform"
+)
+
+func TestStartInstance_RefusesConflictsWithRunningInstance(t *testing.T) {
+	ac := newControlAPITestController(t)
+	base := ac.FileService.BaseConfigPath
+	tunConfig := `{
+  "inbounds": [
+    { "type": "tun", "tag": "tun-in", "interface_name": "singbox-tun0" },
+    { "type": "mixed", "tag": "mixed-in", "listen": "127.0.0.1", "listen_port": 2080 }
+  ],
+  "outbounds": []
+}`
+	if err := os.WriteFile(base, []byte(tunConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ac.CreateProfile("socks", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := ac.StartInstance("socks"); err == nil {
+		t.Errorf("a profile without config.json must not start")
+	}
+	socksConfig := `{
+  "inbounds": [ { "type": "socks", "tag": "socks-in", "listen_port": 2080 } ],
+  "outbounds": []
+}`
+	if err := os.WriteFile(config.ProfileConfigPath(base, "socks"), []byte(socksConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The active profile runs, the SOCKS profile listens on all interfaces on the same port
+	ac.RunningState.Set(true)
+	err := ac.StartInstance("socks")
+	var conflict *InstanceConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("StartInstance: %v, want InstanceConflictError", err)
+	}
+	if conflict.Other != config.DefaultProfileName || len(conflict.Conflicts) != 1 {
+		t.Errorf("conflict = %+v", conflict)
+	}
+	if instances := ac.Instances(); len(instances) != 2 || instances[1].Profile() != "socks" || instances[1].RunningState.IsRunning() {
+		t.Errorf("instances after a refused start: %d", len(instances))
+	}
+
+	if err := ac.StartInstance(config.DefaultProfileName); err == nil {
+		t.Errorf("the active profile must not start as an additional instance")
+	}
+	ac.RunningState.Set(false)
+	if err := ac.SwitchProfile("socks"); err != nil {
+		t.Errorf("SwitchProfile to a stopped instance's profile: %v", err)
+	}
+	if instances := ac.Instances(); len(instances) != 1 {
+		t.Errorf("the stopped instance of the new active profile is still listed (%d instances)", len(instances))
+	}
+}
+
+func TestStartInstance_ConcurrentCallsStartOneProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	ac := newControlAPITestController(t)
+	ac.ctx = context.Background()
+	base := ac.FileService.BaseConfigPath
+	if err := os.WriteFile(base, []byte(`{"inbounds": [], "outbounds": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ac.CreateProfile("socks", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config.ProfileConfigPath(base, "socks"), []byte(`{"inbounds": [], "outbounds": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The fake core records each start and runs until it is stopped
+	ac.FileService.ExecDir = t.TempDir()
+	binDir := platform.GetBinDir(ac.FileService.ExecDir)
+	for _, dir := range []string{binDir, filepath.Join(ac.FileService.ExecDir, "logs")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	starts := filepath.Join(ac.FileService.ExecDir, "starts")
+	core := filepath.Join(binDir, "fake-core")
+	script := "#!/bin/sh\necho started >> '" + starts + "'\nexec sleep 30\n"
+	if err := os.WriteFile(core, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ac.FileService.SingboxPath = core
+	defer func(check func(string) string) { checkCapabilities = check }(checkCapabilities)
+	checkCapabilities = func(string) string { return "" }
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = ac.StartInstance("socks")
+		}(i)
+	}
+	wg.Wait()
+	if (errs[0] == nil) == (errs[1] == nil) {
+		t.Errorf("StartInstance errors = %v, %v, want exactly one refused call", errs[0], errs[1])
+	}
+
+	// Wait until the process runs, has recorded its start and no Start call is pending
+	started := func() bool {
+		ac.instancesMutex.Lock()
+		inst, ok := ac.instances["socks"]
+		pending := ok && inst.starting
+		ac.instancesMutex.Unlock()
+		data, _ := os.ReadFile(starts)
+		return ok && !pending && inst.RunningState.IsRunning() && len(data) > 0
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !started() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !started() {
+		t.Fatal("the instance did not start")
+	}
+	if err := ac.StartInstance("socks"); err == nil {
+		t.Errorf("a running instance must not start again")
+	}
+	if err := ac.StopInstance("socks"); err != nil {
+		t.Errorf("StopInstance: %v", err)
+	}
+
+	data, err := os.ReadFile(starts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "started"); n != 1 {
+		t.Errorf("the core was started %d times, want 1", n)
+	}
+}
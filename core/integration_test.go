@@ -72,7 +72,7 @@ func TestIntegration_RealWorldSubscription(t *testing.T) {
 	t.Run("Process through ConfigService", func(t *testing.T) {
 		fileService, _ := services.NewFileService()
 		if fileService != nil {
-			fileService.ConfigPath = "/tmp/test-config.json"
+			fileService.SetActiveProfile("", "/tmp/test-config.json")
 		}
 		ac := &AppController{
 			FileService: fileService,
@@ -84,7 +84,7 @@ func TestIntegration_RealWorldSubscription(t *testing.T) {
 			Connections: realWorldLinks,
 		}
 		tagCounts := make(map[string]int)
-		nodes, err := svc.ProcessProxySource(proxySource, tagCounts, nil, 0, 1)
+		nodes, err := svc.ProcessProxySource(proxySource, tagCounts, nil, 0, 1, nil)
 		if err != nil {
 			t.Fatalf("Failed to process real-world links: %v", err)
 		}
@@ -219,10 +219,7 @@ func TestIntegration_ParserConfigFlow(t *testing.T) {
 				Version   int                     `json:"version,omitempty"`
 				Proxies   []config.ProxySource    `json:"proxies"`
 				Outbounds []config.OutboundConfig `json:"outbounds"`
-				Parser    struct {
-					Reload      string `json:"reload,omitempty"`
-					LastUpdated string `json:"last_updated,omitempty"`
-				} `json:"parser,omitempty"`
+				Parser    config.ParserSettings   `json:"parser,omitempty"`
 			}{
 				Version: 3,
 				Proxies: []config.ProxySource{
@@ -254,7 +251,7 @@ func TestIntegration_ParserConfigFlow(t *testing.T) {
 		// Process through ConfigService
 		fileService, _ := services.NewFileService()
 		if fileService != nil {
-			fileService.ConfigPath = "/tmp/test-config.json"
+			fileService.SetActiveProfile("", "/tmp/test-config.json")
 		}
 		ac := &AppController{
 			FileService: fileService,
@@ -262,7 +259,7 @@ func TestIntegration_ParserConfigFlow(t *testing.T) {
 		svc := NewConfigService(ac)
 
 		tagCounts := make(map[string]int)
-		nodes, err := svc.ProcessProxySource(parserConfig.ParserConfig.Proxies[0], tagCounts, nil, 0, 1)
+		nodes, err := svc.ProcessProxySource(parserConfig.ParserConfig.Proxies[0], tagCounts, nil, 0, 1, nil)
 		if err != nil {
 			t.Fatalf("Failed to process proxy source: %v", err)
 		}
@@ -0,0 +1,232 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+
+	"singbox-launcher/core/config"
+	"singbox-launcher/core/config/parser"
+	"singbox-launcher/internal/debuglog"
+	"singbox-launcher/internal/process"
+)
+
+const (
+	// wsaeaddrinuse is WSAEADDRINUSE, returned on Windows instead of EADDRINUSE
+	wsaeaddrinuse = syscall.Errno(10048)
+
+	// freePortSearchRange is how many ports after a taken one are tried before asking the OS for any free port
+	freePortSearchRange = 100
+)
+
+// FindPortConflicts probes the listen ports of the inbounds in config.json. Ports taken by
+// another program are returned with their owner (if it can be identified) and a free port
+// the inbound can be moved to.
+func (svc *ProcessService) FindPortConflicts() ([]config.PortConflict, error) {
+	inbounds, err := config.GetInboundListens(svc.inst.ConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	return findPortConflicts(inbounds), nil
+}
+
+// findPortConflicts probes every inbound, see FindPortConflicts
+func findPortConflicts(inbounds []config.InboundListen) []config.PortConflict {
+	used := make(map[int]bool)
+	for _, inbound := range inbounds {
+		used[inbound.Port] = true
+	}
+
+	var conflicts []config.PortConflict
+	for _, inbound := range inbounds {
+		if !isAddrInUse(probeListen(inbound.Network(), inbound.Address())) {
+			continue
+		}
+		conflict := config.PortConflict{Inbound: inbound}
+		if inbound.Network() == "tcp" {
+			owner, found, err := process.FindPortOwner(inbound.Port)
+			if err != nil {
+				debuglog.WarnLog("FindPortConflicts: Failed to find the owner of port %d: %v", inbound.Port, err)
+			} else if found && owner.PID != os.Getpid() {
+				conflict.OwnerPID = owner.PID
+				conflict.OwnerName = owner.Name
+			}
+		}
+		conflict.FreePort = findFreePort(inbound, used)
+		used[conflict.FreePort] = true
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
+
+// probeListen tries to listen on address and closes the listener at once
+func probeListen(network, address string) error {
+	if network == "udp" {
+		conn, err := net.ListenPacket(network, address)
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+	ln, err := net.Listen(network, address)
+	if err == nil {
+		ln.Close()
+	}
+	return err
+}
+
+// isAddrInUse reports whether a listen error means the port is taken.
+// Other errors (no such address, no permission for ports below 1024) are left to sing-box.
+func isAddrInUse(err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	return errno == syscall.EADDRINUSE || errno == wsaeaddrinuse
+}
+
+// findFreePort returns a free port for the inbound, preferring the ports right after the taken one.
+// Ports in used (taken by other inbounds of the config) are skipped. Returns 0 if none is found.
+func findFreePort(inbound config.InboundListen, used map[int]bool) int {
+	candidate := inbound
+	for port := inbound.Port + 1; port <= inbound.Port+freePortSearchRange && port <= 65535; port++ {
+		if used[port] {
+			continue
+		}
+		candidate.Port = port
+		if probeListen(candidate.Network(), candidate.Address()) == nil {
+			return port
+		}
+	}
+
+	// Any free port chosen by the OS
+	candidate.Port = 0
+	if candidate.Network() == "udp" {
+		conn, err := net.ListenPacket("udp", candidate.Address())
+		if err != nil {
+			return 0
+		}
+		defer conn.Close()
+		return conn.LocalAddr().(*net.UDPAddr).Port
+	}
+	ln, err := net.Listen("tcp", candidate.Address())
+	if err != nil {
+		return 0
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+// PortConflictError reports inbound ports taken by other programs
+type PortConflictError struct {
+	Conflicts []config.PortConflict
+}
+
+func (e *PortConflictError) Error() string {
+	lines := make([]string, len(e.Conflicts))
+	for i, conflict := range e.Conflicts {
+		lines[i] = conflict.String()
+	}
+	return strings.Join(lines, "\n") + "\n\nStop the program that uses the port, or change listen_port of the inbound in config.json."
+}
+
+// checkPortConflicts checks the inbound ports before sing-box is started. If some are taken,
+// the user decides (UIService.ResolvePortConflictsFunc) whether to stop their owners or to move
+// the inbounds to free ports, and sing-box is started again. Returns true if the start must not go on.
+func (svc *ProcessService) checkPortConflicts() bool {
+	ac := svc.ac
+	conflicts, err := svc.FindPortConflicts()
+	if err != nil {
+		// An invalid config is reported by sing-box itself
+		debuglog.WarnLog("startSingBox: Failed to check inbound ports: %v", err)
+		return false
+	}
+	if len(conflicts) == 0 {
+		return false
+	}
+	for _, conflict := range conflicts {
+		debuglog.WarnLog("startSingBox: Port conflict: %s", conflict)
+	}
+
+	// Ports are moved in the config of the active profile only; an additional instance reports the conflict
+	if !svc.inst.IsPrimary() || ac.UIService == nil || ac.UIService.ResolvePortConflictsFunc == nil {
+		svc.showStartupError(&PortConflictError{Conflicts: conflicts})
+		return true
+	}
+	ac.UIService.ResolvePortConflictsFunc(conflicts, func(action config.PortConflictAction) {
+		go svc.resolvePortConflicts(conflicts, action)
+	})
+	return true
+}
+
+// resolvePortConflicts carries out the user's decision and starts sing-box again
+func (svc *ProcessService) resolvePortConflicts(conflicts []config.PortConflict, action config.PortConflictAction) {
+	ac := svc.ac
+	switch action {
+	case config.PortConflictKillOwner:
+		for _, conflict := range conflicts {
+			if conflict.OwnerPID == 0 {
+				continue
+			}
+			debuglog.InfoLog("resolvePortConflicts: Stopping %s (PID %d) that uses port %d", conflict.OwnerName, conflict.OwnerPID, conflict.Inbound.Port)
+			if err := svc.StopPID(conflict.OwnerPID); err != nil {
+				svc.showStartupError(fmt.Errorf("failed to stop %s (PID %d): %w", conflict.OwnerName, conflict.OwnerPID, err))
+				return
+			}
+		}
+	case config.PortConflictChangePort:
+		if err := ac.ConfigService.MoveInboundPorts(conflicts); err != nil {
+			svc.showStartupError(err)
+			return
+		}
+	default:
+		debuglog.InfoLog("resolvePortConflicts: Start cancelled because of port conflicts")
+		return
+	}
+	svc.Start()
+}
+
+// MoveInboundPorts changes listen_port of the conflicting inbounds in config.json to their FreePort.
+// The new config is checked with `sing-box check` and recorded in the config history.
+func (svc *ConfigService) MoveInboundPorts(conflicts []config.PortConflict) error {
+	ac := svc.ac
+	configPath := ac.FileService.ConfigPath()
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	for _, conflict := range conflicts {
+		if conflict.FreePort == 0 {
+			return fmt.Errorf("no free port found for inbound %s", conflict.Inbound.Name())
+		}
+		if data, err = config.SetInboundListenPort(data, conflict.Inbound.Index, conflict.FreePort); err != nil {
+			return fmt.Errorf("failed to change the port of inbound %s: %w", conflict.Inbound.Name(), err)
+		}
+	}
+
+	policy := config.HistoryPolicy{}
+	if parserConfig, err := parser.ExtractParserConfig(configPath); err == nil {
+		policy = parserConfig.ParserConfig.Parser.HistoryPolicy()
+	}
+	validate := func(candidatePath string) error {
+		return config.ValidateConfigWithSingBox(candidatePath, ac.FileService.SingboxPath)
+	}
+	config.EnsureHistoryBaseline(configPath, policy)
+	if err := config.WriteFileAtomic(configPath, data, validate); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	if _, err := config.RecordConfigVersion(configPath, config.HistoryTriggerPort, policy); err != nil {
+		debuglog.WarnLog("MoveInboundPorts: Failed to record config snapshot: %v", err)
+	}
+	for _, conflict := range conflicts {
+		debuglog.InfoLog("MoveInboundPorts: Inbound %s moved from port %d to %d", conflict.Inbound.Name(), conflict.Inbound.Port, conflict.FreePort)
+	}
+
+	if ac.UIService != nil && ac.UIService.UpdateConfigStatusFunc != nil {
+		ac.UIService.UpdateConfigStatusFunc()
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"singbox-launcher/core/config"
+	"singbox-launcher/core/services"
+)
+
+func TestPortConflicts_MoveInboundToFreePort(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	ac := newControlAPITestController(t)
+	configJSON := fmt.Sprintf(`{
+  "inbounds": [
+    { "type": "mixed", "tag": "mixed-in", "listen": "127.0.0.1", "listen_port": %d } // system proxy
+  ]
+}`, port)
+	if err := os.WriteFile(ac.FileService.ConfigPath(), []byte(configJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conflicts, err := ac.ProcessService.FindPortConflicts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 {
+		t.Fatalf("conflicts = %+v, want one", conflicts)
+	}
+	conflict := conflicts[0]
+	// The port is held by the test itself: the launcher never offers to kill its own process
+	if conflict.Inbound.Tag != "mixed-in" || conflict.OwnerPID != 0 || conflict.FreePort == 0 || conflict.FreePort == port {
+		t.Errorf("conflict = %+v", conflict)
+	}
+
+	if err := ac.ConfigService.MoveInboundPorts(conflicts); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(ac.FileService.ConfigPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf(`"listen_port": %d } // system proxy`, conflict.FreePort); !strings.Contains(string(data), want) {
+		t.Errorf("config after MoveInboundPorts:\n%s\nwant it to contain %s", data, want)
+	}
+	if conflicts, err := ac.ProcessService.FindPortConflicts(); err != nil || len(conflicts) != 0 {
+		t.Errorf("conflicts after MoveInboundPorts = %+v, %v", conflicts, err)
+	}
+}
+
+func TestStart_PortConflictAsksUser(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	ac := newControlAPITestController(t)
+	configJSON := fmt.Sprintf(`{"inbounds": [{"type": "socks", "listen": "127.0.0.1", "listen_port": %d}]}`, ln.Addr().(*net.TCPAddr).Port)
+	if err := os.WriteFile(ac.FileService.ConfigPath(), []byte(configJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var asked []config.PortConflict
+	ac.UIService = &services.UIService{
+		ResolvePortConflictsFunc: func(conflicts []config.PortConflict, decide func(action config.PortConflictAction)) {
+			asked = conflicts
+		},
+	}
+
+	if !ac.ProcessService.checkPortConflicts() {
+		t.Fatal("checkPortConflicts let sing-box start on a taken port")
+	}
+	if len(asked) != 1 || asked[0].Inbound.Name() != "socks[0]" {
+		t.Errorf("conflicts shown to the user = %+v", asked)
+	}
+}
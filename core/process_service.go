@@ -2,15 +2,18 @@ package core
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"singbox-launcher/core/config"
+	"singbox-launcher/internal/corelog"
 	"singbox-launcher/internal/debuglog"
 	"singbox-launcher/internal/dialogs"
 	"singbox-launcher/internal/platform"
@@ -18,37 +21,50 @@ import (
 )
 
 const (
-	// restartAttempts is the maximum number of consecutive crash restart attempts
-	restartAttempts = 3
-
-	// stabilityThreshold is the duration a process must run without crashing
-	// before the crash counter is reset
-	stabilityThreshold = 180 * time.Second
-
 	// gracefulShutdownTimeout is the maximum time to wait for graceful shutdown
 	// before forcing kill
 	gracefulShutdownTimeout = 2 * time.Second
+
+	// outputBufferLines is how many recent lines of sing-box output are kept in memory
+	outputBufferLines = 200
+
+	// startupFailureWindow: a process that exits this soon after the start with a recognized
+	// fatal error has failed to start; restarting it would fail the same way
+	startupFailureWindow = 15 * time.Second
 )
 
 // ProcessService encapsulates sing-box process lifecycle management.
 // It handles starting, stopping, monitoring, and auto-restarting the sing-box process.
 // The service ensures proper cleanup of TUN interfaces, log rotation, and process state management.
+// Crash restarts follow the restart policy from launcher_state.json (see RestartPolicy).
+// A health watchdog restarts a process that is running but no longer responds (see watchHealth).
 type ProcessService struct {
-	ac *AppController
+	ac        *AppController
+	inst      *SingboxInstance // The instance whose process is managed
+	output    *corelog.Buffer  // Recent output lines of the current process, guarded by inst.CmdMutex
+	startedAt time.Time        // Start time of the current process, guarded by inst.CmdMutex
+	restart   restartState
+
+	// Process killed by the health watchdog and why, guarded by inst.CmdMutex
+	unhealthyPID    int
+	unhealthyReason string
 }
 
-// NewProcessService constructs a ProcessService bound to the controller.
-func NewProcessService(ac *AppController) *ProcessService {
-	return &ProcessService{ac: ac}
+// checkCapabilities checks the Linux capabilities of the sing-box binary; replaced in tests
+var checkCapabilities = platform.CheckAndSuggestCapabilities
+
+// NewProcessService constructs a ProcessService bound to a sing-box instance.
+func NewProcessService(inst *SingboxInstance) *ProcessService {
+	return &ProcessService{ac: inst.ac, inst: inst}
 }
 
 // Start launches the sing-box process. Behavior is identical to the previous StartSingBoxProcess.
 // skipRunningCheck: если true, пропускает проверку на уже запущенный процесс (для автоперезапуска).
 func (svc *ProcessService) Start(skipRunningCheck ...bool) {
-	ac := svc.ac
-	if ac.RunningState.IsRunning() {
+	ac, inst := svc.ac, svc.inst
+	if inst.RunningState.IsRunning() {
 		if ac.UIService != nil && ac.UIService.Application != nil && ac.UIService.MainWindow != nil {
-			dialogs.ShowAutoHideInfo(ac.UIService.Application, ac.UIService.MainWindow, "Info", "Sing-Box already running (according to internal state).")
+			dialogs.ShowAutoHideInfo(ac.UIService.Application, ac.UIService.MainWindow, "Info", inst.label()+" already running (according to internal state).")
 		}
 		return
 	}
@@ -56,16 +72,36 @@ func (svc *ProcessService) Start(skipRunningCheck ...bool) {
 	// Проверяем, не запущен ли уже процесс на уровне ОС (пропускаем при автоперезапуске)
 	skipCheck := len(skipRunningCheck) > 0 && skipRunningCheck[0]
 	if !skipCheck {
+		// Запуск пользователем отменяет ожидающий перезапуск после падения
+		svc.cancelPendingRestart()
 		if svc.checkAndShowSingBoxRunningWarning("startSingBox") {
 			return
 		}
 	}
 
-	ac.CmdMutex.Lock()
-	defer ac.CmdMutex.Unlock()
+	// Порты и TUN-интерфейсы, занятые другими запущенными экземплярами лаунчера
+	if err := inst.checkConflicts(); err != nil {
+		debuglog.WarnLog("startSingBox: %v", err)
+		svc.showStartupError(err)
+		return
+	}
+
+	// Порты inbound'ов, занятые другими программами: sing-box сразу завершился бы с "address already in use"
+	if svc.checkPortConflicts() {
+		return
+	}
+
+	inst.CmdMutex.Lock()
+	defer inst.CmdMutex.Unlock()
+
+	// Параллельный вызов Start мог запустить процесс, пока выполнялись проверки выше
+	if inst.RunningState.IsRunning() {
+		debuglog.InfoLog("startSingBox: %s was started by a concurrent call", inst.label())
+		return
+	}
 
 	// Check capabilities on Linux before starting
-	if suggestion := platform.CheckAndSuggestCapabilities(ac.FileService.SingboxPath); suggestion != "" {
+	if suggestion := checkCapabilities(ac.FileService.SingboxPath); suggestion != "" {
 		debuglog.WarnLog("startSingBox: Capabilities check failed: %s", suggestion)
 		if ac.UIService != nil && ac.UIService.MainWindow != nil {
 			dialogs.ShowError(ac.UIService.MainWindow, fmt.Errorf("Linux capabilities required\n\n%s", suggestion))
@@ -75,9 +111,9 @@ func (svc *ProcessService) Start(skipRunningCheck ...bool) {
 
 	// Reload Clash API configuration from config.json before starting
 	// This ensures we pick up any changes made via wizard or manual config edits
-	if ac.APIService != nil {
+	if clashAPI := inst.ClashAPI(); clashAPI != nil {
 		debuglog.InfoLog("startSingBox: Reloading Clash API configuration...")
-		if err := ac.APIService.ReloadClashAPIConfig(); err != nil {
+		if err := clashAPI.ReloadClashAPIConfig(); err != nil {
 			debuglog.WarnLog("startSingBox: Warning: Failed to reload Clash API config: %v", err)
 			// Continue anyway - API might not be configured or config might be invalid
 		}
@@ -85,7 +121,7 @@ func (svc *ProcessService) Start(skipRunningCheck ...bool) {
 
 	// Check and remove existing TUN interface before starting (prevents "file already exists" error)
 	if runtime.GOOS == "windows" {
-		interfaceName, err := config.GetTunInterfaceName(ac.FileService.ConfigPath)
+		interfaceName, err := config.GetTunInterfaceName(inst.ConfigPath())
 		if err != nil {
 			debuglog.WarnLog("startSingBox: Failed to get TUN interface name from config: %v", err)
 			// Continue anyway - maybe config doesn't have TUN
@@ -98,51 +134,66 @@ func (svc *ProcessService) Start(skipRunningCheck ...bool) {
 		}
 	}
 
-	// Reset API cache before starting
-	if ac.UIService != nil && ac.UIService.ResetAPIStateFunc != nil {
+	// Reset API cache before starting (the Clash API tab shows the primary instance)
+	if inst.IsPrimary() && ac.UIService != nil && ac.UIService.ResetAPIStateFunc != nil {
 		debuglog.InfoLog("startSingBox: Resetting API state cache...")
 		ac.UIService.ResetAPIStateFunc()
 	}
 
-	debuglog.InfoLog("startSingBox: Starting Sing-Box...")
-	ac.SingboxCmd = exec.Command(ac.FileService.SingboxPath, "run", "-c", filepath.Base(ac.FileService.ConfigPath))
-	platform.PrepareCommand(ac.SingboxCmd)
-	ac.SingboxCmd.Dir = platform.GetBinDir(ac.FileService.ExecDir)
-	if ac.FileService.ChildLogFile != nil {
+	debuglog.InfoLog("startSingBox: Starting %s...", inst.label())
+	// sing-box runs in bin/ (relative paths of the config point there)
+	binDir := platform.GetBinDir(ac.FileService.ExecDir)
+	inst.SingboxCmd = exec.Command(ac.FileService.SingboxPath, "run", "-c", singboxConfigArg(binDir, inst.ConfigPath()))
+	platform.PrepareCommand(inst.SingboxCmd)
+	inst.SingboxCmd.Dir = binDir
+	if logFile := inst.outputLog(); logFile != nil {
 		// Check and rotate log file before starting new process to prevent unbounded growth
-		ac.FileService.CheckAndRotateLogFile(filepath.Join(ac.FileService.ExecDir, childLogFileName))
-
-		// Write directly to file - no buffering in memory
-		// This prevents memory leaks from accumulating log output
-		// Logs are written immediately to disk, not stored in memory
-		ac.SingboxCmd.Stdout = ac.FileService.ChildLogFile
-		ac.SingboxCmd.Stderr = ac.FileService.ChildLogFile
+		ac.FileService.CheckAndRotateLogFile(inst.LogPath())
+
+		// Output is written to the file as it comes; only the last outputBufferLines lines
+		// are kept in memory (ring buffer) to explain startup failures and crashes
+		svc.output = corelog.NewBuffer(outputBufferLines)
+		output := io.MultiWriter(logFile, svc.output)
+		// The same writer for both streams: exec then copies them in one goroutine
+		inst.SingboxCmd.Stdout = output
+		inst.SingboxCmd.Stderr = output
 	} else {
 		debuglog.WarnLog("startSingBox: Warning: sing-box log file not available, output will not be logged.")
+		svc.output = corelog.NewBuffer(outputBufferLines)
+		inst.SingboxCmd.Stdout = svc.output
+		inst.SingboxCmd.Stderr = svc.output
 	}
-	if err := ac.SingboxCmd.Start(); err != nil {
-		ac.ShowStartupError(fmt.Errorf("failed to start Sing-Box process: %w", err))
-		debuglog.ErrorLog("startSingBox: Failed to start Sing-Box: %v", err)
+	// Output goes through pipes: do not let a leftover child holding them block Wait after sing-box exits
+	inst.SingboxCmd.WaitDelay = gracefulShutdownTimeout
+	if err := inst.SingboxCmd.Start(); err != nil {
+		svc.showStartupError(fmt.Errorf("failed to start Sing-Box process: %w", err))
+		debuglog.ErrorLog("startSingBox: Failed to start %s: %v", inst.label(), err)
 		return
 	}
-	ac.RunningState.Set(true)
-	ac.StoppedByUser = false
+	inst.RunningState.Set(true)
+	inst.StoppedByUser = false
+	svc.startedAt = time.Now()
 	// Add log with PID
-	debuglog.DebugLog("startSingBox: Sing-Box started. PID=%d", ac.SingboxCmd.Process.Pid)
+	debuglog.DebugLog("startSingBox: %s started. PID=%d", inst.label(), inst.SingboxCmd.Process.Pid)
 
 	// Start auto-loading proxies after sing-box is running
 	go func() {
 		// Small delay to ensure API is ready
 		<-time.After(2 * time.Second)
-		ac.AutoLoadProxies()
+		if clashAPI := inst.ClashAPI(); clashAPI != nil {
+			clashAPI.AutoLoadProxies(ac.ctx)
+		}
 	}()
 
-	go svc.Monitor(ac.SingboxCmd)
+	go svc.Monitor(inst.SingboxCmd)
+	go svc.watchHealth(inst.SingboxCmd.Process.Pid)
 }
 
-// Monitor tracks the sing-box process and auto-restarts on crash (same logic as before).
+// Monitor tracks the sing-box process and restarts it according to the restart policy:
+// exponential backoff between attempts, the attempt counter is reset after the process
+// has run for policy.ResetAfter. Every unexpected exit is recorded in the crash history.
 func (svc *ProcessService) Monitor(cmdToMonitor *exec.Cmd) {
-	ac := svc.ac
+	ac, inst := svc.ac, svc.inst
 	// Store the PID we're monitoring to avoid conflicts with restarted processes
 	monitoredPID := cmdToMonitor.Process.Pid
 
@@ -150,117 +201,191 @@ func (svc *ProcessService) Monitor(cmdToMonitor *exec.Cmd) {
 	// The process should run until it exits or is stopped by user
 	err := cmdToMonitor.Wait()
 
-	ac.CmdMutex.Lock()
-	defer ac.CmdMutex.Unlock()
+	inst.CmdMutex.Lock()
+	defer inst.CmdMutex.Unlock()
 
 	// GOLDEN STANDARD: Check order to prevent all race conditions
 	// 1. First PID (is this my process?)
-	if ac.SingboxCmd == nil || ac.SingboxCmd.Process == nil || ac.SingboxCmd.Process.Pid != monitoredPID {
+	if inst.SingboxCmd == nil || inst.SingboxCmd.Process == nil || inst.SingboxCmd.Process.Pid != monitoredPID {
 		debuglog.DebugLog("monitorSingBox: Process was restarted (PID changed from %d). This monitor is obsolete. Exiting.", monitoredPID)
 		return
 	}
 
 	// 2. Then StoppedByUser (did user stop it?)
-	if ac.StoppedByUser {
+	if inst.StoppedByUser {
 		debuglog.InfoLog("monitorSingBox: Sing-Box exited as requested by user.")
-		ac.ConsecutiveCrashAttempts = 0
-		ac.RunningState.Set(false)
-		ac.StoppedByUser = false // Reset flag for next start
+		inst.ConsecutiveCrashAttempts = 0
+		inst.RunningState.Set(false)
+		inst.StoppedByUser = false // Reset flag for next start
 		return
 	}
 
-	// 3. Then err == nil (exited normally?)
-	if err == nil {
+	// 3. Then err == nil (exited normally?) — restarted only with the "always" policy
+	unhealthyReason := svc.takeUnhealthyReason(monitoredPID)
+	policy := ac.RestartPolicy()
+	if err == nil && !policy.RestartsAfter(true) {
 		debuglog.InfoLog("monitorSingBox: Sing-Box exited gracefully (exit code 0).")
-		ac.ConsecutiveCrashAttempts = 0
-		ac.RunningState.Set(false)
+		inst.ConsecutiveCrashAttempts = 0
+		inst.RunningState.Set(false)
 		return
 	}
 
-	// 4. Only then — crash → restart
-	// Процесс завершился с ошибкой - проверяем лимит попыток
-	ac.RunningState.Set(false)
-	ac.ConsecutiveCrashAttempts++
+	// 4. Only then — crash → restart according to the policy
+	inst.RunningState.Set(false)
+	attempt := inst.ConsecutiveCrashAttempts + 1
+	if unhealthyReason == "" {
+		// A hung core killed by the health watchdog did not crash: it is restarted
+		// without using up max_attempts
+		inst.ConsecutiveCrashAttempts = attempt
+	}
+	record := CrashRecord{
+		Time:     time.Now(),
+		PID:      monitoredPID,
+		ExitCode: exitCode(err),
+		Attempt:  attempt,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if unhealthyReason != "" {
+		record.Error = unhealthyReason
+	}
+	var diagnosis *corelog.Diagnosis
+	if svc.output != nil {
+		lines := svc.output.Lines()
+		diagnosis = corelog.ClassifyExit(lines)
+		for _, line := range lines[max(0, len(lines)-crashOutputLines):] {
+			record.Output = append(record.Output, line.Text)
+		}
+	}
+	if diagnosis != nil {
+		record.Diagnosis = diagnosis.Explanation
+	}
+	details := formatCrashDetails(diagnosis, record.Output)
+
+	// A recognized fatal error right after the start (port in use, bad config, no TUN permission)
+	// is a startup failure: restarting would fail the same way, so the user gets the explanation
+	if unhealthyReason == "" && diagnosis != nil && diagnosis.Known() && time.Since(svc.startedAt) < startupFailureWindow {
+		debuglog.WarnLog("monitorSingBox: Sing-Box failed to start (%s): %s", diagnosis.Kind, diagnosis.Line)
+		record.Action = "not restarted: startup failed"
+		svc.recordCrash(record)
+		inst.ConsecutiveCrashAttempts = 0
+		svc.showStartupError(diagnosis)
+		return
+	}
 
-	if ac.ConsecutiveCrashAttempts > restartAttempts {
-		debuglog.DebugLog("monitorSingBox: Maximum restart attempts (%d) reached. Stopping auto-restart.", restartAttempts)
-		if ac.UIService != nil && ac.UIService.MainWindow != nil {
-			dialogs.ShowError(ac.UIService.MainWindow, fmt.Errorf("Sing-Box failed to restart after %d attempts. Check sing-box.log for details.", restartAttempts))
+	if !policy.RestartsAfter(err == nil) {
+		debuglog.WarnLog("monitorSingBox: Sing-Box crashed: %v. Restart policy is %q, not restarting.", err, policy.Mode)
+		record.Action = "not restarted"
+		svc.recordCrash(record)
+		inst.ConsecutiveCrashAttempts = 0
+		if ac.hasUI() {
+			dialogs.ShowError(ac.UIService.MainWindow, fmt.Errorf("%s exited with code %d and was not restarted (restart policy: never).%s", inst.label(), record.ExitCode, details))
 		}
-		ac.ConsecutiveCrashAttempts = 0
 		return
 	}
 
+	if unhealthyReason == "" && policy.AttemptsExhausted(attempt) {
+		debuglog.DebugLog("monitorSingBox: Maximum restart attempts (%d) reached. Stopping auto-restart.", policy.MaxAttempts)
+		record.Action = "gave up"
+		svc.recordCrash(record)
+		if ac.hasUI() {
+			dialogs.ShowError(ac.UIService.MainWindow, fmt.Errorf("%s failed to restart after %d attempts. Check %s for details.%s", inst.label(), policy.MaxAttempts, filepath.Base(inst.LogPath()), details))
+		}
+		inst.ConsecutiveCrashAttempts = 0
+		return
+	}
+
+	delay := restartBackoffPolicy(policy).Delay(attempt, nil)
+	record.Action = fmt.Sprintf("restart in %v", delay)
+	svc.recordCrash(record)
+	backoff := RestartBackoff{Attempt: attempt, MaxAttempts: policy.MaxAttempts, RestartAt: time.Now().Add(delay)}
+	if unhealthyReason != "" {
+		backoff.MaxAttempts = 0
+	}
+
 	// Try to restart
-	debuglog.WarnLog("monitorSingBox: Sing-Box crashed: %v, attempting auto-restart (attempt %d/%d)", err, ac.ConsecutiveCrashAttempts, restartAttempts)
-	if ac.UIService != nil && ac.UIService.Application != nil && ac.UIService.MainWindow != nil {
-		dialogs.ShowAutoHideInfo(ac.UIService.Application, ac.UIService.MainWindow, "Crash", fmt.Sprintf("Sing-Box crashed, restarting... (attempt %d/%d)", ac.ConsecutiveCrashAttempts, restartAttempts))
+	debuglog.WarnLog("monitorSingBox: Sing-Box exited unexpectedly (code %d, %v), %s", record.ExitCode, err, backoff)
+	if ac.hasUIWithApp() {
+		what := inst.label() + " crashed, "
+		if unhealthyReason != "" {
+			what = inst.label() + " stopped responding, "
+		}
+		dialogs.ShowAutoHideInfo(ac.UIService.Application, ac.UIService.MainWindow, "Crash", what+backoff.String())
 	}
 
-	// Wait 2 seconds before restart
-	ac.CmdMutex.Unlock()
-	<-time.After(2 * time.Second)
-	svc.Start(true) // skipRunningCheck = true для автоперезапуска
-	ac.CmdMutex.Lock()
+	// Wait with backoff before restart; Start/Stop by the user cancel the wait
+	inst.CmdMutex.Unlock()
+	restart := svc.waitRestartBackoff(backoff, delay)
+	if restart {
+		svc.Start(true) // skipRunningCheck = true для автоперезапуска
+	}
+	inst.CmdMutex.Lock()
+	if !restart {
+		debuglog.InfoLog("monitorSingBox: Restart attempt %d cancelled.", attempt)
+		return
+	}
 
-	if ac.RunningState.IsRunning() {
+	if inst.RunningState.IsRunning() {
 		debuglog.InfoLog("monitorSingBox: Sing-Box restarted successfully.")
-		currentAttemptCount := ac.ConsecutiveCrashAttempts
+		currentAttemptCount := inst.ConsecutiveCrashAttempts
 		go func() {
 			select {
 			case <-ac.ctx.Done():
 				debuglog.InfoLog("monitorSingBox: Stability check cancelled (context cancelled)")
 				return
-			case <-time.After(stabilityThreshold):
-				ac.CmdMutex.Lock()
-				defer ac.CmdMutex.Unlock()
+			case <-time.After(policy.ResetAfter):
+				inst.CmdMutex.Lock()
+				defer inst.CmdMutex.Unlock()
 
-				if ac.RunningState.IsRunning() && ac.ConsecutiveCrashAttempts == currentAttemptCount {
-					debuglog.DebugLog("monitorSingBox: Process has been stable for %v. Resetting crash counter from %d to 0.", stabilityThreshold, ac.ConsecutiveCrashAttempts)
-					ac.ConsecutiveCrashAttempts = 0
+				if inst.RunningState.IsRunning() && inst.ConsecutiveCrashAttempts == currentAttemptCount {
+					debuglog.DebugLog("monitorSingBox: Process has been stable for %v. Resetting crash counter from %d to 0.", policy.ResetAfter, inst.ConsecutiveCrashAttempts)
+					inst.ConsecutiveCrashAttempts = 0
 					// Обновляем UI, чтобы счетчик исчез из статуса на вкладке Core
 					if ac.UIService != nil && ac.UIService.UpdateCoreStatusFunc != nil {
 						ac.UIService.UpdateCoreStatusFunc()
 					}
 				} else {
-					debuglog.DebugLog("monitorSingBox: Stability timer expired, but conditions for reset not met (running: %v, current attempts: %d, attempts at timer start: %d).", ac.RunningState.IsRunning(), ac.ConsecutiveCrashAttempts, currentAttemptCount)
+					debuglog.DebugLog("monitorSingBox: Stability timer expired, but conditions for reset not met (running: %v, current attempts: %d, attempts at timer start: %d).", inst.RunningState.IsRunning(), inst.ConsecutiveCrashAttempts, currentAttemptCount)
 				}
 			}
 		}()
 	} else {
-		debuglog.DebugLog("monitorSingBox: Restart attempt %d failed.", ac.ConsecutiveCrashAttempts)
+		debuglog.DebugLog("monitorSingBox: Restart attempt %d failed.", inst.ConsecutiveCrashAttempts)
 	}
 }
 
 // Stop attempts graceful shutdown, mirroring previous StopSingBoxProcess.
 func (svc *ProcessService) Stop() {
-	ac := svc.ac
-	ac.CmdMutex.Lock()
+	inst := svc.inst
+	inst.CmdMutex.Lock()
 
 	// CRITICAL: Set flag BEFORE sending signal
 	// This ensures the monitor sees the flag even if the process exits very quickly
-	ac.StoppedByUser = true
-	ac.ConsecutiveCrashAttempts = 0
-
-	if !ac.RunningState.IsRunning() {
-		ac.StoppedByUser = false
-		ac.CmdMutex.Unlock()
+	inst.StoppedByUser = true
+	inst.ConsecutiveCrashAttempts = 0
+	// Stop также отменяет ожидающий перезапуск после падения
+	svc.cancelPendingRestart()
+
+	if !inst.RunningState.IsRunning() {
+		inst.StoppedByUser = false
+		inst.CmdMutex.Unlock()
 		return
 	}
 
-	if ac.SingboxCmd == nil || ac.SingboxCmd.Process == nil {
+	if inst.SingboxCmd == nil || inst.SingboxCmd.Process == nil {
 		debuglog.InfoLog("StopSingBoxProcess: Inconsistent state detected. Correcting state.")
-		ac.RunningState.Set(false)
-		ac.StoppedByUser = false
-		ac.CmdMutex.Unlock()
+		inst.RunningState.Set(false)
+		inst.StoppedByUser = false
+		inst.CmdMutex.Unlock()
 		return
 	}
 
 	debuglog.InfoLog("stopSingBox: Attempting graceful shutdown...")
-	processToStop := ac.SingboxCmd.Process
+	processToStop := inst.SingboxCmd.Process
 
 	// Разблокируем мьютекс перед отправкой сигнала, чтобы не блокировать
-	ac.CmdMutex.Unlock()
+	inst.CmdMutex.Unlock()
 
 	var err error
 	if runtime.GOOS == "windows" {
@@ -292,6 +417,58 @@ func (svc *ProcessService) Stop() {
 	}
 }
 
+// Restart stops sing-box, waits until the process has exited and starts it again.
+// Used after the config was replaced (e.g. restored from history).
+func (svc *ProcessService) Restart() error {
+	inst := svc.inst
+	if err := svc.stopAndWait(); err != nil {
+		return err
+	}
+
+	svc.Start(true)
+	if !inst.RunningState.IsRunning() {
+		return fmt.Errorf("sing-box failed to start, see sing-box.log")
+	}
+	return nil
+}
+
+// stopAndWait stops sing-box and waits until the process has exited
+func (svc *ProcessService) stopAndWait() error {
+	svc.Stop()
+
+	deadline := time.Now().Add(gracefulShutdownTimeout + 3*time.Second)
+	for svc.inst.RunningState.IsRunning() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("sing-box did not stop in time")
+		}
+		<-time.After(100 * time.Millisecond)
+	}
+	return nil
+}
+
+// Reload asks the running sing-box to re-read its config (SIGHUP) without restarting the process.
+// On Windows signals are not supported, so the process is restarted instead.
+func (svc *ProcessService) Reload() error {
+	inst := svc.inst
+	if runtime.GOOS == "windows" {
+		return svc.Restart()
+	}
+
+	inst.CmdMutex.Lock()
+	if !inst.RunningState.IsRunning() || inst.SingboxCmd == nil || inst.SingboxCmd.Process == nil {
+		inst.CmdMutex.Unlock()
+		return fmt.Errorf("sing-box is not running")
+	}
+	processToReload := inst.SingboxCmd.Process
+	inst.CmdMutex.Unlock()
+
+	debuglog.InfoLog("reloadSingBox: Sending SIGHUP to PID=%d", processToReload.Pid)
+	if err := processToReload.Signal(syscall.SIGHUP); err != nil {
+		return fmt.Errorf("failed to send reload signal: %w", err)
+	}
+	return nil
+}
+
 // CheckIfRunningAtStart checks if sing-box is already running at application start.
 // Shows a warning dialog if a running instance is detected.
 func (svc *ProcessService) CheckIfRunningAtStart() {
@@ -300,15 +477,22 @@ func (svc *ProcessService) CheckIfRunningAtStart() {
 
 // checkAndShowSingBoxRunningWarning checks if sing-box is running and shows warning dialog if found.
 // Returns true if process was found and warning was shown, false otherwise.
+// Processes of the other instances of this launcher are not foreign and are skipped.
 func (svc *ProcessService) checkAndShowSingBoxRunningWarning(ctx string) bool {
-	found, foundPID := svc.isSingBoxProcessRunning()
+	managed := svc.ac.managedPIDs()
+	found, foundPID := svc.isSingBoxProcessRunning(managed)
 	if found {
 		debuglog.DebugLog("%s: Found sing-box process already running (PID=%d). Showing warning dialog.", ctx, foundPID)
 		if svc.ac.hasUI() {
 			dialogs.ShowProcessKillConfirmation(svc.ac.UIService.MainWindow, func() {
-				processName := platform.GetProcessNameForCheck()
-				_ = platform.KillProcess(processName)
-				svc.ac.RunningState.Set(false)
+				if len(managed) > 0 {
+					// Killing by name would also kill the other running instances
+					_ = platform.KillProcessByPID(foundPID)
+				} else {
+					processName := platform.GetProcessNameForCheck()
+					_ = platform.KillProcess(processName)
+				}
+				svc.inst.RunningState.Set(false)
 			})
 		}
 		return true
@@ -317,16 +501,60 @@ func (svc *ProcessService) checkAndShowSingBoxRunningWarning(ctx string) bool {
 	return false
 }
 
+// FindRunningProcess reports whether a sing-box process is running on the system (started by
+// this launcher or by anyone else) and returns its PID.
+func (svc *ProcessService) FindRunningProcess() (bool, int) {
+	return svc.isSingBoxProcessRunning(nil)
+}
+
+// StopPID stops a sing-box process that is not tracked by this launcher instance
+// (e.g. started by another launcher or by "start" in the command-line mode):
+// graceful signal first, kill after gracefulShutdownTimeout.
+func (svc *ProcessService) StopPID(pid int) error {
+	var err error
+	if runtime.GOOS == "windows" {
+		err = platform.SendCtrlBreak(pid)
+	} else {
+		var p *os.Process
+		if p, err = os.FindProcess(pid); err == nil {
+			err = p.Signal(os.Interrupt)
+		}
+	}
+	if err != nil {
+		debuglog.WarnLog("StopPID: Graceful signal to PID=%d failed: %v. Forcing kill.", pid, err)
+		return platform.KillProcessByPID(pid)
+	}
+
+	deadline := time.Now().Add(gracefulShutdownTimeout)
+	for time.Now().Before(deadline) {
+		if _, found, err := process.FindProcess(pid); err == nil && !found {
+			return nil
+		}
+		<-time.After(100 * time.Millisecond)
+	}
+	debuglog.DebugLog("StopPID: Process %d still running after timeout. Forcing kill.", pid)
+	return platform.KillProcessByPID(pid)
+}
+
 // getTrackedPID safely gets the PID of the tracked sing-box process.
 func (svc *ProcessService) getTrackedPID() int {
-	svc.ac.CmdMutex.Lock()
-	defer svc.ac.CmdMutex.Unlock()
-	if svc.ac.SingboxCmd != nil && svc.ac.SingboxCmd.Process != nil {
-		return svc.ac.SingboxCmd.Process.Pid
+	svc.inst.CmdMutex.Lock()
+	defer svc.inst.CmdMutex.Unlock()
+	if svc.inst.SingboxCmd != nil && svc.inst.SingboxCmd.Process != nil {
+		return svc.inst.SingboxCmd.Process.Pid
 	}
 	return -1
 }
 
+// singboxConfigArg returns the -c argument for sing-box started in binDir: config.json relative
+// to binDir (a profile's config is profiles/<name>/config.json), or the absolute path if it is elsewhere.
+func singboxConfigArg(binDir, configPath string) string {
+	if rel, err := filepath.Rel(binDir, configPath); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return configPath
+}
+
 // parseCSVLine parses a CSV line, handling quoted fields.
 func parseCSVLine(line string) []string {
 	var parts []string
@@ -355,8 +583,8 @@ func parseCSVLine(line string) []string {
 }
 
 // isSingBoxProcessRunning checks if sing-box process is running on the system.
-// Returns (isRunning, pid) tuple.
-func (svc *ProcessService) isSingBoxProcessRunning() (bool, int) {
+// Processes with PIDs in skip are ignored. Returns (isRunning, pid) tuple.
+func (svc *ProcessService) isSingBoxProcessRunning(skip map[int]bool) (bool, int) {
 	ourPID := svc.getTrackedPID()
 
 	if runtime.GOOS == "windows" {
@@ -368,7 +596,7 @@ func (svc *ProcessService) isSingBoxProcessRunning() (bool, int) {
 		if err != nil {
 			debuglog.WarnLog("isSingBoxProcessRunning: tasklist failed: %v", err)
 			// Fallback to ps library
-			return svc.isSingBoxProcessRunningWithPS(ourPID)
+			return svc.isSingBoxProcessRunningWithPS(ourPID, skip)
 		}
 
 		lines := strings.Split(string(output), "\n")
@@ -384,6 +612,9 @@ func (svc *ProcessService) isSingBoxProcessRunning() (bool, int) {
 				// CRITICAL: Check that the process name matches sing-box.exe
 				if strings.EqualFold(name, processName) {
 					if pid, err := strconv.Atoi(pidStr); err == nil {
+						if skip[pid] {
+							continue
+						}
 						isOurProcess := (ourPID != -1 && pid == ourPID)
 						debuglog.DebugLog("isSingBoxProcessRunning: Found process: PID=%d, name='%s' (our tracked PID=%d, isOurProcess=%v)",
 							pid, name, ourPID, isOurProcess)
@@ -399,11 +630,11 @@ func (svc *ProcessService) isSingBoxProcessRunning() (bool, int) {
 	}
 
 	// For other OS use ps library
-	return svc.isSingBoxProcessRunningWithPS(ourPID)
+	return svc.isSingBoxProcessRunningWithPS(ourPID, skip)
 }
 
 // isSingBoxProcessRunningWithPS uses ps library to check for running process
-func (svc *ProcessService) isSingBoxProcessRunningWithPS(ourPID int) (bool, int) {
+func (svc *ProcessService) isSingBoxProcessRunningWithPS(ourPID int, skip map[int]bool) (bool, int) {
 	processes, err := process.GetProcesses()
 	if err != nil {
 		debuglog.WarnLog("isSingBoxProcessRunningWithPS: error listing processes: %v", err)
@@ -413,7 +644,7 @@ func (svc *ProcessService) isSingBoxProcessRunningWithPS(ourPID int) (bool, int)
 
 	for _, p := range processes {
 		execName := p.Name
-		if strings.EqualFold(execName, processName) {
+		if strings.EqualFold(execName, processName) && !skip[p.PID] {
 			foundPID := p.PID
 			isOurProcess := (ourPID != -1 && foundPID == ourPID)
 			debuglog.DebugLog("isSingBoxProcessRunningWithPS: Found process: PID=%d, name='%s' (our tracked PID=%d, isOurProcess=%v)", foundPID, execName, ourPID, isOurProcess)
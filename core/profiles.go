@@ -0,0 +1,164 @@
+package core
+
+import (
+	"fmt"
+	"os"
+
+	"singbox-launcher/core/config"
+	"singbox-launcher/internal/debuglog"
+)
+
+// launcherStatePath returns bin/launcher_state.json with the launcher-wide settings: active profile,
+// control API, restart policy and health check. Selector choices are kept per profile next to its config.json.
+func (ac *AppController) launcherStatePath() string {
+	return config.LauncherStatePath(ac.FileService.BaseConfigPath)
+}
+
+// loadActiveProfile points FileService at the config of the profile saved in launcher_state.json.
+// Called once at startup, before the services that read config.json are created.
+func (ac *AppController) loadActiveProfile() {
+	state, err := config.LoadLauncherState(ac.launcherStatePath())
+	if err != nil {
+		debuglog.WarnLog("loadActiveProfile: failed to load launcher state: %v", err)
+	}
+	if state.Profile == "" || state.Profile == config.DefaultProfileName {
+		return
+	}
+	if !config.ProfileExists(ac.FileService.BaseConfigPath, state.Profile) {
+		debuglog.WarnLog("loadActiveProfile: profile %q does not exist, using the default profile", state.Profile)
+		return
+	}
+	ac.FileService.SetActiveProfile(state.Profile, config.ProfileConfigPath(ac.FileService.BaseConfigPath, state.Profile))
+	debuglog.InfoLog("loadActiveProfile: using profile %q (%s)", state.Profile, ac.FileService.ConfigPath())
+}
+
+// Profiles returns the configuration profiles, the default one first
+func (ac *AppController) Profiles() []string {
+	profiles, err := config.ListProfiles(ac.FileService.BaseConfigPath)
+	if err != nil {
+		debuglog.WarnLog("Profiles: %v", err)
+	}
+	return profiles
+}
+
+// ActiveProfile returns the name of the current configuration profile
+func (ac *AppController) ActiveProfile() string {
+	if ac.FileService.Profile() == "" {
+		return config.DefaultProfileName
+	}
+	return ac.FileService.Profile()
+}
+
+// SwitchProfile makes another profile active. A running sing-box is stopped, the launcher switches
+// to the profile's config.json (Clash API, selector choices, auto-update settings from its ParserConfig)
+// and sing-box is started again with the new config.
+func (ac *AppController) SwitchProfile(name string) error {
+	if name == ac.ActiveProfile() {
+		return nil
+	}
+	if !config.ProfileExists(ac.FileService.BaseConfigPath, name) {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	if ac.UIService != nil && ac.UIService.WizardWindow != nil {
+		return fmt.Errorf("close the configuration wizard before switching profiles")
+	}
+	if ac.instanceRunning(name) {
+		return fmt.Errorf("profile %q is running alongside the active one, stop it before switching to it", name)
+	}
+	// A stopped additional instance of the profile becomes the primary one
+	ac.forgetInstance(name)
+
+	// The switch blocks configuration updates: an update must not write the previous profile's config
+	ac.ParserMutex.Lock()
+	if ac.ParserRunning {
+		ac.ParserMutex.Unlock()
+		return ErrParserRunning
+	}
+	ac.ParserRunning = true
+	ac.ParserMutex.Unlock()
+
+	wasRunning, err := ac.switchProfile(name)
+
+	ac.ParserMutex.Lock()
+	ac.ParserRunning = false
+	ac.ParserMutex.Unlock()
+
+	if ac.hasUI() && ac.UIService.UpdateConfigStatusFunc != nil {
+		ac.UIService.UpdateConfigStatusFunc()
+	}
+	ac.UpdateUI()
+	if err != nil || !wasRunning {
+		return err
+	}
+
+	if _, err := os.Stat(ac.FileService.ConfigPath()); err != nil {
+		return fmt.Errorf("profile %q has no config.json yet, run the Config Wizard to create it", name)
+	}
+	go ac.ProcessService.Start()
+	return nil
+}
+
+// switchProfile stops sing-box and points the services at the profile's config.json.
+// wasRunning reports whether sing-box was running (or waiting for a crash restart) before the switch.
+func (ac *AppController) switchProfile(name string) (wasRunning bool, err error) {
+	_, restartPending := ac.ProcessService.PendingRestart()
+	wasRunning = ac.RunningState.IsRunning() || restartPending
+	if wasRunning {
+		debuglog.InfoLog("SwitchProfile: stopping sing-box before switching to profile %q", name)
+		if err := ac.ProcessService.stopAndWait(); err != nil {
+			return false, fmt.Errorf("failed to switch profile: %w", err)
+		}
+	}
+
+	configPath := config.ProfileConfigPath(ac.FileService.BaseConfigPath, name)
+	ac.FileService.SetActiveProfile(name, configPath)
+	if ac.APIService != nil {
+		if err := ac.APIService.SetConfigPath(configPath); err != nil {
+			debuglog.WarnLog("SwitchProfile: %v", err)
+		}
+	}
+	// Auto-update follows the new config: failures of the previous profile's subscriptions do not pause it
+	if ac.StateService != nil {
+		ac.StateService.ResumeAutoUpdate()
+		if _, err := os.Stat(configPath); err != nil {
+			ac.StateService.SetAutoUpdateEnabled(false)
+		}
+	}
+	if err := config.UpdateLauncherState(ac.launcherStatePath(), func(state *config.LauncherState) {
+		state.Profile = name
+	}); err != nil {
+		debuglog.WarnLog("SwitchProfile: failed to save the active profile: %v", err)
+	}
+	debuglog.InfoLog("SwitchProfile: switched to profile %q (%s)", name, configPath)
+	return wasRunning, nil
+}
+
+// CreateProfile creates a named profile, optionally starting with a copy of the active profile's config
+func (ac *AppController) CreateProfile(name string, copyActive bool) error {
+	copyFrom := ""
+	if copyActive {
+		copyFrom = ac.ActiveProfile()
+	}
+	if err := config.CreateProfile(ac.FileService.BaseConfigPath, name, copyFrom); err != nil {
+		return err
+	}
+	debuglog.InfoLog("CreateProfile: created profile %q (copy of %q)", name, copyFrom)
+	ac.UpdateUI()
+	return nil
+}
+
+// DeleteProfile removes a named profile that is not active
+func (ac *AppController) DeleteProfile(name string) error {
+	if name == ac.ActiveProfile() {
+		return fmt.Errorf("the active profile cannot be deleted, switch to another profile first")
+	}
+	if ac.instanceRunning(name) {
+		return fmt.Errorf("profile %q is running, stop it before deleting", name)
+	}
+	if err := config.DeleteProfile(ac.FileService.BaseConfigPath, name); err != nil {
+		return err
+	}
+	debuglog.InfoLog("DeleteProfile: deleted profile %q", name)
+	ac.UpdateUI()
+	return nil
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"singbox-launcher/core/config"
+)
+
+func TestSwitchProfile_SwitchesConfigAndPersists(t *testing.T) {
+	ac := newControlAPITestController(t)
+	base := ac.FileService.BaseConfigPath
+	if err := os.WriteFile(base, []byte(`{"outbounds": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ac.CreateProfile("travel", true); err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+
+	if err := ac.SwitchProfile("travel"); err != nil {
+		t.Fatalf("SwitchProfile: %v", err)
+	}
+	want := config.ProfileConfigPath(base, "travel")
+	if ac.FileService.ConfigPath() != want || ac.ActiveProfile() != "travel" {
+		t.Fatalf("active config = %s (%s), want %s (travel)", ac.FileService.ConfigPath(), ac.ActiveProfile(), want)
+	}
+	if !ac.StateService.IsAutoUpdateEnabled() {
+		t.Errorf("auto-update should be enabled for a profile with config.json")
+	}
+	state, err := config.LoadLauncherState(config.LauncherStatePath(base))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Profile != "travel" {
+		t.Errorf("saved profile = %q, want travel", state.Profile)
+	}
+
+	// The next start of the launcher opens the saved profile
+	restarted := &AppController{FileService: newTestFileService(base)}
+	restarted.loadActiveProfile()
+	if restarted.FileService.ConfigPath() != want {
+		t.Errorf("loadActiveProfile: config = %s, want %s", restarted.FileService.ConfigPath(), want)
+	}
+
+	if err := ac.DeleteProfile("travel"); err == nil {
+		t.Errorf("the active profile must not be deleted")
+	}
+	if err := ac.SwitchProfile(config.DefaultProfileName); err != nil {
+		t.Fatalf("SwitchProfile(default): %v", err)
+	}
+	if ac.FileService.ConfigPath() != base {
+		t.Errorf("default profile config = %s, want %s", ac.FileService.ConfigPath(), base)
+	}
+}
+
+func TestSwitchProfile_RefusedDuringConfigUpdate(t *testing.T) {
+	ac := newControlAPITestController(t)
+	if err := ac.CreateProfile("home", false); err != nil {
+		t.Fatal(err)
+	}
+	ac.ParserRunning = true
+	if err := ac.SwitchProfile("home"); err != ErrParserRunning {
+		t.Fatalf("SwitchProfile during an update: %v, want ErrParserRunning", err)
+	}
+	if ac.ActiveProfile() != config.DefaultProfileName {
+		t.Errorf("profile switched during an update")
+	}
+}
+
+func TestSingboxConfigArg_ProfileConfig(t *testing.T) {
+	binDir := t.TempDir()
+	base := filepath.Join(binDir, "config.json")
+	tests := []struct {
+		configPath string
+		want       string
+	}{
+		{base, "config.json"},
+		{config.ProfileConfigPath(base, "office"), filepath.Join("profiles", "office", "config.json")},
+		// A config outside bin/ is passed by its absolute path
+		{filepath.Join(filepath.Dir(binDir), "config.json"), filepath.Join(filepath.Dir(binDir), "config.json")},
+	}
+	for _, tt := range tests {
+		if got := singboxConfigArg(binDir, tt.configPath); got != tt.want {
+			t.Errorf("singboxConfigArg(%s) = %q, want %q", tt.configPath, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"singbox-launcher/core/config"
+	"singbox-launcher/internal/corelog"
+	"singbox-launcher/internal/debuglog"
+)
+
+const (
+	// crashHistorySize is how many crashes are kept in the crash history
+	crashHistorySize = 20
+
+	// crashOutputLines is how many last lines of sing-box output are kept for a crash record
+	crashOutputLines = 20
+)
+
+// CrashRecord describes an unexpected exit of sing-box
+type CrashRecord struct {
+	Time      time.Time
+	PID       int
+	ExitCode  int      // -1 if the process was killed by a signal
+	Error     string   // Wait error, empty for exit code 0
+	Attempt   int      // Consecutive crash number
+	Action    string   // What the restart policy did: "restart in 4s", "gave up", "not restarted"
+	Diagnosis string   // Explanation of the error found in the output (empty if none)
+	Output    []string // Last lines of sing-box output (sing-box logs to stderr)
+}
+
+// RestartBackoff is a pending crash restart of sing-box
+type RestartBackoff struct {
+	Attempt     int
+	MaxAttempts int // 0 = unlimited
+	RestartAt   time.Time
+}
+
+// String describes the pending restart for the UI, e.g. "restarting at 15:04:05 (attempt 2/3)"
+func (b RestartBackoff) String() string {
+	if b.MaxAttempts > 0 {
+		return fmt.Sprintf("restarting at %s (attempt %d/%d)", b.RestartAt.Format("15:04:05"), b.Attempt, b.MaxAttempts)
+	}
+	return fmt.Sprintf("restarting at %s (attempt %d)", b.RestartAt.Format("15:04:05"), b.Attempt)
+}
+
+// restartState is the crash history and the pending restart of ProcessService
+type restartState struct {
+	mu      sync.Mutex
+	crashes []CrashRecord
+	backoff *RestartBackoff
+	cancel  chan struct{} // Closed to cancel the pending restart
+}
+
+// RestartPolicy returns the crash restart policy from launcher_state.json.
+// Invalid settings are logged and replaced by the defaults.
+func (ac *AppController) RestartPolicy() config.RestartPolicy {
+	state, err := config.LoadLauncherState(ac.launcherStatePath())
+	if err != nil {
+		debuglog.WarnLog("RestartPolicy: failed to load launcher state: %v", err)
+	}
+	if state.RestartPolicy == nil {
+		return config.DefaultRestartPolicy()
+	}
+	policy, err := state.RestartPolicy.Resolve()
+	if err != nil {
+		debuglog.WarnLog("RestartPolicy: %v, using defaults", err)
+		return config.DefaultRestartPolicy()
+	}
+	return policy
+}
+
+// SetRestartMode persists the restart mode ("on-failure", "always" or "never"), keeping the other policy settings
+func (ac *AppController) SetRestartMode(mode string) error {
+	if _, err := (config.RestartPolicySettings{Mode: mode}).Resolve(); err != nil {
+		return err
+	}
+	err := config.UpdateLauncherState(ac.launcherStatePath(), func(state *config.LauncherState) {
+		if state.RestartPolicy == nil {
+			state.RestartPolicy = &config.RestartPolicySettings{}
+		}
+		state.RestartPolicy.Mode = mode
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save restart policy: %w", err)
+	}
+	debuglog.InfoLog("RestartPolicy: mode set to %s", mode)
+	return nil
+}
+
+// CrashHistory returns the recent crashes of sing-box, oldest first
+func (svc *ProcessService) CrashHistory() []CrashRecord {
+	svc.restart.mu.Lock()
+	defer svc.restart.mu.Unlock()
+	return append([]CrashRecord(nil), svc.restart.crashes...)
+}
+
+// PendingRestart returns the pending crash restart, ok = false if sing-box is not in back-off
+func (svc *ProcessService) PendingRestart() (RestartBackoff, bool) {
+	svc.restart.mu.Lock()
+	defer svc.restart.mu.Unlock()
+	if svc.restart.backoff == nil {
+		return RestartBackoff{}, false
+	}
+	return *svc.restart.backoff, true
+}
+
+// restartPending reports whether a crash restart of sing-box is pending (tray and button state)
+func (ac *AppController) restartPending() bool {
+	if ac.ProcessService == nil {
+		return false
+	}
+	_, pending := ac.ProcessService.PendingRestart()
+	return pending
+}
+
+// recordCrash appends a crash to the history, dropping the oldest ones
+func (svc *ProcessService) recordCrash(record CrashRecord) {
+	svc.restart.mu.Lock()
+	defer svc.restart.mu.Unlock()
+	svc.restart.crashes = append(svc.restart.crashes, record)
+	if len(svc.restart.crashes) > crashHistorySize {
+		svc.restart.crashes = svc.restart.crashes[len(svc.restart.crashes)-crashHistorySize:]
+	}
+}
+
+// cancelPendingRestart cancels the pending crash restart (sing-box is started or stopped by the user).
+// Returns false if there was none.
+func (svc *ProcessService) cancelPendingRestart() bool {
+	svc.restart.mu.Lock()
+	defer svc.restart.mu.Unlock()
+	if svc.restart.cancel == nil {
+		return false
+	}
+	close(svc.restart.cancel)
+	svc.restart.cancel = nil
+	svc.restart.backoff = nil
+	debuglog.InfoLog("monitorSingBox: Pending restart cancelled.")
+	return true
+}
+
+// waitRestartBackoff waits delay before a crash restart. The tray and the Core Dashboard show
+// the pending restart meanwhile. Returns false if the restart was cancelled by Start, Stop or exit.
+func (svc *ProcessService) waitRestartBackoff(backoff RestartBackoff, delay time.Duration) bool {
+	cancel := make(chan struct{})
+	svc.restart.mu.Lock()
+	svc.restart.backoff = &backoff
+	svc.restart.cancel = cancel
+	svc.restart.mu.Unlock()
+	svc.ac.UpdateUI()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	restart := false
+	select {
+	case <-timer.C:
+		restart = true
+	case <-cancel:
+	case <-svc.ac.ctx.Done():
+	}
+
+	svc.restart.mu.Lock()
+	if svc.restart.cancel == cancel {
+		svc.restart.backoff = nil
+		svc.restart.cancel = nil
+	} else {
+		restart = false // Cancelled right when the timer fired
+	}
+	svc.restart.mu.Unlock()
+	svc.ac.UpdateUI()
+	return restart
+}
+
+// restartBackoffPolicy turns the restart policy into the exponential backoff of the restart delay
+func restartBackoffPolicy(policy config.RestartPolicy) backoffPolicy {
+	return backoffPolicy{
+		Initial:    policy.InitialDelay,
+		Max:        policy.MaxDelay,
+		Multiplier: 2,
+	}
+}
+
+// exitCode returns the exit code of a Wait error: 0 for nil, -1 if unknown (killed by a signal)
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// formatCrashDetails returns the explanation of a crash for an error dialog:
+// the diagnosis if an error was found in the output, else its last lines ("" if there are none)
+func formatCrashDetails(diagnosis *corelog.Diagnosis, output []string) string {
+	if diagnosis != nil {
+		return "\n\n" + diagnosis.Explanation + "\n" + diagnosis.Hint
+	}
+	if len(output) > 5 {
+		output = output[len(output)-5:]
+	}
+	if len(output) == 0 {
+		return ""
+	}
+	return "\n\nLast output:\n" + strings.Join(output, "\n")
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"singbox-launcher/core/config"
+	"singbox-launcher/internal/corelog"
+)
+
+// startCrashingProcess starts a shell that prints output and exits with code 3,
+// set up like ProcessService.Start does
+func startCrashingProcess(t *testing.T, ac *AppController, output string) *exec.Cmd {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	cmd := exec.Command("sh", "-c", "echo 'INFO router: started'; echo \"$0\" >&2; exit 3", output)
+	ac.ProcessService.output = corelog.NewBuffer(outputBufferLines)
+	cmd.Stdout = ac.ProcessService.output
+	cmd.Stderr = ac.ProcessService.output
+	ac.ProcessService.startedAt = time.Now()
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	ac.SingboxCmd = cmd
+	ac.RunningState.Set(true)
+	return cmd
+}
+
+func setTestRestartPolicy(t *testing.T, ac *AppController, settings config.RestartPolicySettings) {
+	t.Helper()
+	err := config.UpdateLauncherState(ac.launcherStatePath(), func(state *config.LauncherState) {
+		state.RestartPolicy = &settings
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMonitor_NeverPolicyRecordsCrash(t *testing.T) {
+	ac := newControlAPITestController(t)
+	ac.ctx = context.Background()
+	setTestRestartPolicy(t, ac, config.RestartPolicySettings{Mode: config.RestartModeNever})
+
+	ac.ProcessService.Monitor(startCrashingProcess(t, ac, "FATAL start service: something new"))
+
+	if ac.RunningState.IsRunning() || ac.ConsecutiveCrashAttempts != 0 {
+		t.Errorf("running = %v, attempts = %d after a crash with the never policy", ac.RunningState.IsRunning(), ac.ConsecutiveCrashAttempts)
+	}
+	crashes := ac.ProcessService.CrashHistory()
+	if len(crashes) != 1 {
+		t.Fatalf("crash history has %d records, want 1", len(crashes))
+	}
+	crash := crashes[0]
+	if crash.ExitCode != 3 || crash.Attempt != 1 || crash.Action != "not restarted" {
+		t.Errorf("crash record = %+v", crash)
+	}
+	if want := []string{"INFO router: started", "FATAL start service: something new"}; !reflect.DeepEqual(crash.Output, want) {
+		t.Errorf("Output = %q, want %q", crash.Output, want)
+	}
+	if crash.Diagnosis != "start service: something new" {
+		t.Errorf("Diagnosis = %q", crash.Diagnosis)
+	}
+}
+
+func TestMonitor_RecognizedStartupFailureIsNotRestarted(t *testing.T) {
+	ac := newControlAPITestController(t)
+	ac.ctx = context.Background()
+	setTestRestartPolicy(t, ac, config.RestartPolicySettings{Mode: config.RestartModeAlways})
+
+	ac.ProcessService.Monitor(startCrashingProcess(t, ac, "FATAL[0000] start service: start inbound/mixed[mixed-in]: listen tcp 127.0.0.1:2080: bind: address already in use"))
+
+	if _, pending := ac.ProcessService.PendingRestart(); pending || ac.RunningState.IsRunning() {
+		t.Error("sing-box is restarted after a recognized startup failure")
+	}
+	crashes := ac.ProcessService.CrashHistory()
+	if len(crashes) != 1 || crashes[0].Action != "not restarted: startup failed" || !strings.Contains(crashes[0].Diagnosis, "127.0.0.1:2080") {
+		t.Errorf("crash history = %+v", crashes)
+	}
+}
+
+func TestMonitor_EarlierErrorIsNotStartupFailure(t *testing.T) {
+	ac := newControlAPITestController(t)
+	ac.ctx = context.Background()
+	setTestRestartPolicy(t, ac, config.RestartPolicySettings{Mode: config.RestartModeNever})
+
+	output := "ERROR inbound/mixed[mixed-in]: listen tcp 127.0.0.1:2080: bind: address already in use\n" +
+		"INFO router: started\n" +
+		"panic: runtime error: invalid memory address or nil pointer dereference\n" +
+		"goroutine 1 [running]:"
+	ac.ProcessService.Monitor(startCrashingProcess(t, ac, output))
+
+	crashes := ac.ProcessService.CrashHistory()
+	if len(crashes) != 1 || crashes[0].Action != "not restarted" || !strings.HasPrefix(crashes[0].Diagnosis, "panic: runtime error") {
+		t.Errorf("crash history = %+v", crashes)
+	}
+}
+
+func TestMonitor_StopCancelsPendingRestart(t *testing.T) {
+	ac := newControlAPITestController(t)
+	ac.ctx = context.Background()
+	setTestRestartPolicy(t, ac, config.RestartPolicySettings{InitialDelay: "1h"})
+
+	done := make(chan struct{})
+	go func() {
+		ac.ProcessService.Monitor(startCrashingProcess(t, ac, "ERROR dns: exchange failed"))
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if backoff, pending := ac.ProcessService.PendingRestart(); pending {
+			if backoff.Attempt != 1 || backoff.MaxAttempts != config.DefaultRestartMaxAttempts || time.Until(backoff.RestartAt) < 59*time.Minute {
+				t.Errorf("pending restart = %+v", backoff)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no pending restart after the crash")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ac.ProcessService.Stop()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Monitor did not return after Stop")
+	}
+	if _, pending := ac.ProcessService.PendingRestart(); pending || ac.RunningState.IsRunning() {
+		t.Errorf("pending restart or running state left after Stop")
+	}
+	if crashes := ac.ProcessService.CrashHistory(); len(crashes) != 1 || crashes[0].Action != "restart in 1h0m0s" {
+		t.Errorf("crash history = %+v", crashes)
+	}
+}
@@ -27,6 +27,15 @@ type APIService struct {
 	AutoLoadInProgress bool
 	AutoLoadMutex      sync.Mutex
 
+	// saveChoicesMutex serializes writes of launcher_state.json
+	saveChoicesMutex sync.Mutex
+
+	// Кэш идентичностей узлов из истории узлов; перечитывается только при изменении файла истории
+	identitiesMutex   sync.Mutex
+	identities        map[string]config.NodeIdentity
+	identitiesPath    string
+	identitiesModTime time.Time
+
 	// Proxy list state (protected by StateMutex)
 	StateMutex      sync.RWMutex
 	ProxiesList     []api.ProxyInfo
@@ -35,6 +44,8 @@ type APIService struct {
 	// LastSelectedProxyByGroup maps selector group -> last selected proxy name.
 	// This allows remembering the last proxy per selector (group) independently.
 	LastSelectedProxyByGroup map[string]string
+	// savedChoices - выбор в селекторах с идентичностью узла, сохраняется в launcher_state.json
+	savedChoices map[string]config.SelectorChoice
 
 	// Dependencies (passed from AppController)
 	ConfigPath            string
@@ -85,6 +96,7 @@ func NewAPIService(configPath string, apiLogFile *os.File,
 	apiSvc.SetSelectedIndex(-1)
 	apiSvc.SetActiveProxyName("")
 	apiSvc.LastSelectedProxyByGroup = make(map[string]string)
+	apiSvc.loadSavedChoices()
 
 	return apiSvc, nil
 }
@@ -135,13 +147,147 @@ func (apiSvc *APIService) GetSelectedIndex() int {
 }
 
 // SetLastSelectedProxyForGroup safely sets the last selected proxy name for a selector group with mutex protection.
+// The choice is persisted in launcher state together with the node identity; launcher_state.json
+// is written only when the choice differs from the saved one.
 func (apiSvc *APIService) SetLastSelectedProxyForGroup(group, name string) {
+	choice := config.NewSelectorChoice(name, apiSvc.nodeIdentities(), time.Now())
+
+	// Снимок выбора и запись под одним мьютексом, чтобы более старый снимок не перезаписал новый
+	apiSvc.saveChoicesMutex.Lock()
+	defer apiSvc.saveChoicesMutex.Unlock()
+
 	apiSvc.StateMutex.Lock()
-	defer apiSvc.StateMutex.Unlock()
 	if apiSvc.LastSelectedProxyByGroup == nil {
 		apiSvc.LastSelectedProxyByGroup = make(map[string]string)
 	}
 	apiSvc.LastSelectedProxyByGroup[group] = name
+	if saved, ok := apiSvc.savedChoices[group]; ok && sameSelectorChoice(saved, choice) {
+		apiSvc.StateMutex.Unlock()
+		return
+	}
+	if apiSvc.savedChoices == nil {
+		apiSvc.savedChoices = make(map[string]config.SelectorChoice)
+	}
+	apiSvc.savedChoices[group] = choice
+	selections := make(map[string]config.SelectorChoice, len(apiSvc.savedChoices))
+	for g, c := range apiSvc.savedChoices {
+		selections[g] = c
+	}
+	configPath := apiSvc.ConfigPath
+	apiSvc.StateMutex.Unlock()
+
+	// Остальные секции launcher_state.json (например, control_api) сохраняются как есть
+	if err := config.UpdateLauncherState(config.LauncherStatePath(configPath), func(state *config.LauncherState) {
+		state.Selections = selections
+	}); err != nil {
+		debuglog.WarnLog("SetLastSelectedProxyForGroup: failed to save selector choice: %v", err)
+	}
+}
+
+// sameSelectorChoice reports whether two choices select the same node (the time is ignored)
+func sameSelectorChoice(a, b config.SelectorChoice) bool {
+	if a.Tag != b.Tag || (a.Identity == nil) != (b.Identity == nil) {
+		return false
+	}
+	return a.Identity == nil || *a.Identity == *b.Identity
+}
+
+// configPath returns the current config.json path (it changes on a profile switch)
+func (apiSvc *APIService) configPath() string {
+	apiSvc.StateMutex.RLock()
+	defer apiSvc.StateMutex.RUnlock()
+	return apiSvc.ConfigPath
+}
+
+// nodeIdentities возвращает идентичности узлов из истории узлов текущего конфига.
+// История перечитывается только если файл изменился (его перезаписывает обновление подписок).
+func (apiSvc *APIService) nodeIdentities() map[string]config.NodeIdentity {
+	configPath := apiSvc.configPath()
+	path := config.NodeHistoryPath(configPath)
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime()
+	}
+
+	apiSvc.identitiesMutex.Lock()
+	defer apiSvc.identitiesMutex.Unlock()
+	if apiSvc.identities == nil || apiSvc.identitiesPath != path || !apiSvc.identitiesModTime.Equal(modTime) {
+		apiSvc.identities = config.LoadNodeIdentities(configPath)
+		apiSvc.identitiesPath = path
+		apiSvc.identitiesModTime = modTime
+	}
+	return apiSvc.identities
+}
+
+// loadSavedChoices загружает сохранённый выбор в селекторах из launcher_state.json
+func (apiSvc *APIService) loadSavedChoices() {
+	state, err := config.LoadLauncherState(config.LauncherStatePath(apiSvc.configPath()))
+	if err != nil {
+		debuglog.WarnLog("loadSavedChoices: failed to load launcher state: %v", err)
+	}
+
+	apiSvc.StateMutex.Lock()
+	defer apiSvc.StateMutex.Unlock()
+	apiSvc.savedChoices = state.Selections
+	for group, choice := range state.Selections {
+		apiSvc.LastSelectedProxyByGroup[group] = choice.Tag
+	}
+	debuglog.DebugLog("loadSavedChoices: loaded %d saved selector choices", len(state.Selections))
+}
+
+// restoreSavedChoices переключает все селекторы на сохранённый выбор через Clash API.
+// Узел ищется по идентичности (адрес и учётные данные), поэтому переименованный узел находится под новым тегом;
+// если узел удалён, в селекторе остаётся выбор по умолчанию.
+func (apiSvc *APIService) restoreSavedChoices(baseURL, token, selectedGroup string) {
+	apiSvc.StateMutex.RLock()
+	choices := make(map[string]config.SelectorChoice, len(apiSvc.savedChoices))
+	for group, choice := range apiSvc.savedChoices {
+		choices[group] = choice
+	}
+	apiSvc.StateMutex.RUnlock()
+	if len(choices) == 0 {
+		return
+	}
+
+	groups, err := api.GetSelectorGroups(baseURL, token, apiSvc.ApiLogFile)
+	if err != nil {
+		debuglog.WarnLog("restoreSavedChoices: failed to read selector groups: %v", err)
+		return
+	}
+	identities := apiSvc.nodeIdentities()
+
+	for groupName, choice := range choices {
+		group, ok := groups[groupName]
+		if !ok {
+			debuglog.DebugLog("restoreSavedChoices: group '%s' no longer exists, skipping", groupName)
+			continue
+		}
+		proxy, found := config.ResolveSelectorChoice(choice, group.All, identities)
+		if !found {
+			debuglog.InfoLog("restoreSavedChoices: saved proxy '%s' for group '%s' no longer exists, keeping '%s'", choice.Tag, groupName, group.Now)
+			continue
+		}
+		if proxy != choice.Tag {
+			debuglog.InfoLog("restoreSavedChoices: saved proxy '%s' for group '%s' was renamed to '%s'", choice.Tag, groupName, proxy)
+		}
+		if proxy == group.Now {
+			if proxy != choice.Tag {
+				apiSvc.SetLastSelectedProxyForGroup(groupName, proxy)
+			}
+			continue
+		}
+
+		debuglog.DebugLog("restoreSavedChoices: Switching group '%s' to saved proxy '%s' (current: '%s')", groupName, proxy, group.Now)
+		if groupName == selectedGroup {
+			err = apiSvc.SwitchProxy(groupName, proxy)
+		} else if err = api.SwitchProxy(baseURL, token, groupName, proxy, apiSvc.ApiLogFile); err == nil {
+			apiSvc.SetLastSelectedProxyForGroup(groupName, proxy)
+		}
+		if err != nil {
+			debuglog.WarnLog("restoreSavedChoices: Failed to switch group '%s' to saved proxy '%s': %v", groupName, proxy, err)
+			// Не критично, продолжаем с текущим прокси
+		}
+	}
 }
 
 // GetLastSelectedProxyForGroup safely gets the last selected proxy name for a selector group with mutex protection.
@@ -175,6 +321,23 @@ func (apiSvc *APIService) GetClashAPIConfig() (baseURL, token string, enabled bo
 	return apiSvc.BaseURL, apiSvc.Token, apiSvc.Enabled
 }
 
+// SetConfigPath switches the service to another config.json (profile switch): the proxy list
+// and the selector choices of the previous config are dropped, the Clash API settings and
+// the saved choices are loaded for the new one.
+func (apiSvc *APIService) SetConfigPath(configPath string) error {
+	apiSvc.StateMutex.Lock()
+	apiSvc.ConfigPath = configPath
+	apiSvc.ProxiesList = []api.ProxyInfo{}
+	apiSvc.ActiveProxyName = ""
+	apiSvc.SelectedIndex = -1
+	apiSvc.LastSelectedProxyByGroup = make(map[string]string)
+	apiSvc.savedChoices = nil
+	apiSvc.StateMutex.Unlock()
+
+	apiSvc.loadSavedChoices()
+	return apiSvc.ReloadClashAPIConfig()
+}
+
 // ReloadClashAPIConfig reloads Clash API configuration from config.json file.
 // This should be called when config might have changed (e.g., after wizard updates).
 func (apiSvc *APIService) ReloadClashAPIConfig() error {
@@ -312,27 +475,8 @@ func (apiSvc *APIService) AutoLoadProxies(ctx context.Context) {
 				}
 			})
 
-			// Проверяем, есть ли сохраненный прокси для текущей группы, и переключаемся на него, если он отличается от текущего
-			// Делаем это после обновления UI, чтобы не блокировать
-			lastSelected := apiSvc.GetLastSelectedProxyForGroup(currentGroup)
-			if lastSelected != "" && lastSelected != now {
-				// Проверяем, что сохраненный прокси существует в списке
-				proxyExists := false
-				for _, proxy := range proxies {
-					if proxy.Name == lastSelected {
-						proxyExists = true
-						break
-					}
-				}
-				if proxyExists {
-					debuglog.DebugLog("AutoLoadProxies: Switching to saved proxy '%s' (current: '%s') for group '%s'", lastSelected, now, currentGroup)
-					// Переключаемся на сохраненный прокси (мы уже в goroutine, дополнительная не нужна)
-					if err := apiSvc.SwitchProxy(currentGroup, lastSelected); err != nil {
-						debuglog.WarnLog("AutoLoadProxies: Failed to switch to saved proxy '%s' for group '%s': %v", lastSelected, currentGroup, err)
-						// Не критично, продолжаем с текущим прокси
-					}
-				}
-			}
+			// Восстанавливаем сохранённый выбор во всех селекторах (после обновления UI, чтобы не блокировать)
+			apiSvc.restoreSavedChoices(baseURL, token, currentGroup)
 
 			debuglog.InfoLog("AutoLoadProxies: Successfully loaded %d proxies for group '%s' on attempt %d", len(proxies), currentGroup, attempt+1)
 
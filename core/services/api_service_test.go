@@ -0,0 +1,39 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"singbox-launcher/core/config"
+)
+
+func TestSetLastSelectedProxyForGroup_WritesOnlyChanges(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	statePath := config.LauncherStatePath(configPath)
+	apiSvc := &APIService{ConfigPath: configPath}
+
+	apiSvc.SetLastSelectedProxyForGroup("proxy-out", "A")
+	state, err := config.LoadLauncherState(statePath)
+	if err != nil || state.Selections["proxy-out"].Tag != "A" {
+		t.Fatalf("saved selections = %+v (%v)", state.Selections, err)
+	}
+
+	// The same choice again must not touch launcher_state.json
+	if err := os.Remove(statePath); err != nil {
+		t.Fatal(err)
+	}
+	apiSvc.SetLastSelectedProxyForGroup("proxy-out", "A")
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Errorf("unchanged choice was written again (%v)", err)
+	}
+
+	apiSvc.SetLastSelectedProxyForGroup("proxy-out", "B")
+	state, err = config.LoadLauncherState(statePath)
+	if err != nil || state.Selections["proxy-out"].Tag != "B" {
+		t.Errorf("saved selections = %+v (%v)", state.Selections, err)
+	}
+	if apiSvc.GetLastSelectedProxyForGroup("proxy-out") != "B" {
+		t.Errorf("last selected proxy = %q", apiSvc.GetLastSelectedProxyForGroup("proxy-out"))
+	}
+}
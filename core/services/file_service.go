@@ -4,10 +4,10 @@
 //
 // Ответственности:
 //   - Определение путей к исполняемым файлам и конфигурации (ExecDir, ConfigPath, SingboxPath)
+//   - Путь к config.json активного профиля (ConfigPath) и профиля по умолчанию (BaseConfigPath)
 //   - Создание необходимых директорий (logs/, bin/) при старте
 //   - Управление жизненным циклом лог-файлов (открытие, закрытие)
 //   - Ротация логов при превышении размера (максимум 1 старый файл на каждый лог)
-//   - Создание резервных копий файлов (BackupFile, BackupPath)
 //
 // Ротация логов:
 //   - Порог: 2 MB (maxLogFileSize)
@@ -27,8 +27,9 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
+	"sync"
 
+	"singbox-launcher/core/config"
 	"singbox-launcher/internal/debuglog"
 	"singbox-launcher/internal/platform"
 )
@@ -44,8 +45,17 @@ type FileService struct {
 	// Все относительные пути (bin/, logs/, config.json) строятся от неё.
 	ExecDir string
 
-	// ConfigPath — полный путь к config.json (платформозависимый).
-	ConfigPath string
+	// configPath — полный путь к config.json активного профиля, profile — имя профиля.
+	// Меняются при переключении профиля (AppController.SwitchProfile), пока их читают
+	// автообновление, API управления и сторожевой таймер, поэтому доступны только через
+	// ConfigPath(), Profile() и SetActiveProfile() под activeMutex.
+	configPath  string
+	profile     string
+	activeMutex sync.RWMutex
+
+	// BaseConfigPath — путь к config.json профиля по умолчанию (bin/config.json).
+	// Рядом с ним лежат общие настройки лаунчера (launcher_state.json) и папка профилей.
+	BaseConfigPath string
 
 	// SingboxPath — полный путь к исполняемому файлу sing-box (bin/sing-box или bin/sing-box.exe).
 	SingboxPath string
@@ -65,6 +75,28 @@ type FileService struct {
 	ApiLogFile *os.File
 }
 
+// ConfigPath возвращает путь к config.json активного профиля
+func (fs *FileService) ConfigPath() string {
+	fs.activeMutex.RLock()
+	defer fs.activeMutex.RUnlock()
+	return fs.configPath
+}
+
+// Profile возвращает имя активного профиля ("" до выбора профиля)
+func (fs *FileService) Profile() string {
+	fs.activeMutex.RLock()
+	defer fs.activeMutex.RUnlock()
+	return fs.profile
+}
+
+// SetActiveProfile делает активным профиль profile с конфигом configPath
+func (fs *FileService) SetActiveProfile(profile, configPath string) {
+	fs.activeMutex.Lock()
+	defer fs.activeMutex.Unlock()
+	fs.profile = profile
+	fs.configPath = configPath
+}
+
 // NewFileService создаёт и инициализирует FileService.
 // Определяет все пути и создаёт необходимые директории (logs/, bin/).
 // Вызывается один раз при создании AppController.
@@ -81,7 +113,8 @@ func NewFileService() (*FileService, error) {
 		return nil, fmt.Errorf("NewFileService: cannot create directories: %w", err)
 	}
 
-	fs.ConfigPath = platform.GetConfigPath(fs.ExecDir)
+	fs.BaseConfigPath = platform.GetConfigPath(fs.ExecDir)
+	fs.SetActiveProfile(config.DefaultProfileName, fs.BaseConfigPath)
 	singboxName := platform.GetExecutableNames()
 	fs.SingboxPath = filepath.Join(fs.ExecDir, "bin", singboxName)
 	fs.WintunPath = platform.GetWintunPath(fs.ExecDir)
@@ -165,36 +198,3 @@ func (fs *FileService) CheckAndRotateLogFile(logPath string) {
 		}
 	}
 }
-
-// BackupPath генерирует путь для резервной копии файла.
-// Формат: file.ext → file-old.ext (например, config.json → config-old.json).
-func BackupPath(path string) string {
-	dir := filepath.Dir(path)
-	ext := filepath.Ext(path)
-	base := strings.TrimSuffix(filepath.Base(path), ext)
-	return filepath.Join(dir, fmt.Sprintf("%s-old%s", base, ext))
-}
-
-// BackupFile создаёт резервную копию файла (file.ext → file-old.ext).
-// Предыдущая резервная копия удаляется — хранится максимум 1 бэкап.
-// Если файл не существует или является директорией — ничего не делает.
-func BackupFile(path string) error {
-	info, err := os.Stat(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil // Nothing to backup
-		}
-		return fmt.Errorf("BackupFile: cannot stat %s: %w", path, err)
-	}
-	if info.IsDir() {
-		return nil
-	}
-
-	backup := BackupPath(path)
-	_ = os.Remove(backup) // Remove old backup if exists
-	if err := os.Rename(path, backup); err != nil {
-		return fmt.Errorf("BackupFile: cannot rename %s to %s: %w", path, backup, err)
-	}
-	debuglog.DebugLog("BackupFile: %s → %s", path, backup)
-	return nil
-}
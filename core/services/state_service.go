@@ -23,6 +23,7 @@ type StateService struct {
 	// Auto-update configuration
 	AutoUpdateEnabled        bool
 	AutoUpdateFailedAttempts int
+	AutoUpdatePausedUntil    time.Time // Auto-update is paused after repeated failures (zero = not paused)
 	AutoUpdateMutex          sync.Mutex
 }
 
@@ -141,12 +142,27 @@ func (s *StateService) ResetAutoUpdateFailedAttempts() {
 	s.AutoUpdateFailedAttempts = 0
 }
 
-// ResumeAutoUpdate resumes automatic updates after successful manual update.
+// ResumeAutoUpdate resumes automatic updates after successful manual update or after the cool-down.
 func (s *StateService) ResumeAutoUpdate() {
 	s.AutoUpdateMutex.Lock()
 	defer s.AutoUpdateMutex.Unlock()
 	s.AutoUpdateFailedAttempts = 0
+	s.AutoUpdatePausedUntil = time.Time{}
 	if !s.AutoUpdateEnabled {
 		s.AutoUpdateEnabled = true
 	}
 }
+
+// PauseAutoUpdate pauses automatic updates until the given time (cool-down after repeated failures).
+func (s *StateService) PauseAutoUpdate(until time.Time) {
+	s.AutoUpdateMutex.Lock()
+	defer s.AutoUpdateMutex.Unlock()
+	s.AutoUpdatePausedUntil = until
+}
+
+// GetAutoUpdatePausedUntil safely gets the end of the auto-update pause (zero if not paused).
+func (s *StateService) GetAutoUpdatePausedUntil() time.Time {
+	s.AutoUpdateMutex.Lock()
+	defer s.AutoUpdateMutex.Unlock()
+	return s.AutoUpdatePausedUntil
+}
@@ -11,6 +11,7 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"singbox-launcher/core/config"
 	"singbox-launcher/internal/constants"
 	"singbox-launcher/internal/debuglog"
 )
@@ -60,6 +61,13 @@ type UIService struct {
 	UpdateParserProgressFunc func(progress float64, status string)
 	FocusOpenRuleDialogs     func()
 	ShowUpdatePopupFunc      func(currentVersion, latestVersion string) // Called to show update popup
+	// ConfirmConfigDiffFunc показывает превью обновления конфигурации; decide вызывается ровно один раз
+	ConfirmConfigDiffFunc func(diff *config.ConfigDiff, decide func(approved bool))
+	// ImportSourceFunc открывает визард и добавляет в него источник (URL подписки или ссылку на ноду);
+	// name — имя профиля из deep link (может быть пустым)
+	ImportSourceFunc func(source, name string)
+	// AddWizardSourceFunc добавляет источник в открытый визард; устанавливается визардом, пока его окно открыто
+	AddWizardSourceFunc func(source, name string)
 
 	// Dependencies (passed from AppController)
 	RunningStateIsRunning func() bool
@@ -72,6 +80,12 @@ type UIService struct {
 	// OnWindowShown — опциональный callback, который вызывается после открытия главного окна
 	// Используется для проверки обновлений при первом открытии окна после запуска с -tray
 	OnWindowShown func() // Called after main window is shown
+
+	// RestartPendingFunc сообщает, ожидается ли перезапуск sing-box после падения (иконка трея)
+	RestartPendingFunc func() bool
+	// ResolvePortConflictsFunc показывает порты inbound'ов, занятые другими программами, перед запуском sing-box;
+	// decide вызывается ровно один раз
+	ResolvePortConflictsFunc func(conflicts []config.PortConflict, decide func(action config.PortConflictAction))
 }
 
 // NewUIService creates and initializes a new UIService instance.
@@ -163,6 +177,9 @@ func (ui *UIService) UpdateUI() {
 			if ui.RunningStateIsRunning() {
 				// Green icon - if running
 				iconToSet = ui.GreenIconData
+			} else if ui.RestartPendingFunc != nil && ui.RestartPendingFunc() {
+				// Red icon - crashed, waiting for the restart (back-off)
+				iconToSet = ui.RedIconData
 			} else {
 				// Check for binary to determine error state
 				if _, err := os.Stat(ui.SingboxPath); os.IsNotExist(err) {
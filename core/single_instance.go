@@ -0,0 +1,149 @@
+package core
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+
+	"singbox-launcher/core/config/subscription"
+	"singbox-launcher/internal/debuglog"
+	"singbox-launcher/internal/singleinstance"
+)
+
+// LauncherArgs is the command line of a GUI launch.
+// A second launch forwards the same command line to the running instance.
+type LauncherArgs struct {
+	Start   bool           // -start: start VPN
+	Tray    bool           // -tray: do not show the main window
+	Sources []ImportSource // Sources to import via the Config Wizard
+}
+
+// ImportSource is a source passed on the command line: a subscription URL or a node link.
+// Deep links (sing-box://, clash://) are resolved to their subscription URL and profile name.
+type ImportSource struct {
+	Source string
+	Name   string // Profile name from a deep link (empty for plain URLs and node links)
+}
+
+// ParseLauncherArgs parses GUI flags and positional sources. Usage and flag errors are written to output.
+func ParseLauncherArgs(args []string, output io.Writer) (LauncherArgs, error) {
+	var la LauncherArgs
+	fs := flag.NewFlagSet("singbox-launcher", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.BoolVar(&la.Start, "start", false, "Automatically start VPN on launch")
+	fs.BoolVar(&la.Tray, "tray", false, "Start minimized to system tray (hide window on launch)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: singbox-launcher [-start] [-tray] [subscription URL, node link or sing-box:// / clash:// link...]\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return la, err
+	}
+
+	for _, arg := range fs.Args() {
+		source, err := parseImportSource(arg)
+		if err != nil {
+			fmt.Fprintln(output, err)
+			return la, err
+		}
+		la.Sources = append(la.Sources, source)
+	}
+	return la, nil
+}
+
+// parseImportSource accepts a subscription URL, a node link or a deep link
+func parseImportSource(arg string) (ImportSource, error) {
+	source := strings.TrimSpace(arg)
+	switch {
+	case subscription.IsDeepLink(source):
+		link, err := subscription.ParseDeepLink(source)
+		if err != nil {
+			return ImportSource{}, err
+		}
+		return ImportSource{Source: link.URL, Name: link.Name}, nil
+	case subscription.IsSubscriptionURL(source), subscription.IsDirectLink(source):
+		return ImportSource{Source: source}, nil
+	}
+	return ImportSource{}, fmt.Errorf("unsupported argument %q: expected a subscription URL, a node link or a sing-box:// / clash:// link", arg)
+}
+
+// AcquireSingleInstance makes this process the only launcher of its installation directory.
+// If another launcher is already running, args are forwarded to it and forwarded is true:
+// the caller must exit. Other lock errors are returned with forwarded = false; the launcher
+// may still start without single-instance protection (e.g. from a read-only directory).
+func AcquireSingleInstance(args []string) (inst *singleinstance.Instance, forwarded bool, err error) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return nil, false, fmt.Errorf("cannot detect executable path: %w", err)
+	}
+	dir := filepath.Dir(execPath)
+
+	inst, err = singleinstance.Acquire(dir)
+	if err == nil {
+		return inst, false, nil
+	}
+	if !errors.Is(err, singleinstance.ErrAlreadyRunning) {
+		return nil, false, err
+	}
+	if err := singleinstance.Forward(dir, args); err != nil {
+		return nil, true, err
+	}
+	return nil, true, nil
+}
+
+// SetSingleInstance attaches the single-instance lock to the controller:
+// command lines forwarded by later launches are performed by HandleForwardedArgs,
+// and the lock is released in GracefulExit.
+func (ac *AppController) SetSingleInstance(inst *singleinstance.Instance) {
+	if inst == nil {
+		return
+	}
+	ac.singleInstance = inst
+	inst.SetHandler(ac.HandleForwardedArgs)
+}
+
+// HandleForwardedArgs performs the command line of a second launch in this instance:
+// focuses the main window (unless -tray), starts VPN for -start and imports sources.
+func (ac *AppController) HandleForwardedArgs(args []string) error {
+	la, err := ParseLauncherArgs(args, io.Discard)
+	if err != nil {
+		return err
+	}
+	if !ac.hasUIWithApp() {
+		return fmt.Errorf("the launcher has no UI")
+	}
+	debuglog.InfoLog("Single instance: second launch forwarded start=%v tray=%v sources=%d", la.Start, la.Tray, len(la.Sources))
+
+	if !la.Tray && len(la.Sources) == 0 {
+		ac.UIService.ShowMainWindowOrFocusWizard()
+	}
+	if la.Start && !ac.RunningState.IsRunning() {
+		go StartSingBoxProcess()
+	}
+	ac.ImportSources(la.Sources)
+	return nil
+}
+
+// ImportSources opens the Config Wizard with the given subscription URLs and node links added to its sources.
+// The user reviews the result and saves it in the wizard as usual.
+func (ac *AppController) ImportSources(sources []ImportSource) {
+	if len(sources) == 0 || !ac.hasUIWithApp() {
+		return
+	}
+	fyne.Do(func() {
+		if ac.UIService.ImportSourceFunc == nil {
+			debuglog.WarnLog("ImportSources: ImportSourceFunc handler is not set, %d source(s) ignored", len(sources))
+			return
+		}
+		ac.UIService.ShowMainWindowOrFocusWizard()
+		for _, source := range sources {
+			ac.UIService.ImportSourceFunc(source.Source, source.Name)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLauncherArgs(t *testing.T) {
+	var out bytes.Buffer
+	la, err := ParseLauncherArgs([]string{"-start", "-tray", "https://example.com/sub", " vless://uuid@host:443#node "}, &out)
+	if err != nil {
+		t.Fatalf("ParseLauncherArgs: %v", err)
+	}
+	want := LauncherArgs{Start: true, Tray: true, Sources: []ImportSource{{Source: "https://example.com/sub"}, {Source: "vless://uuid@host:443#node"}}}
+	if !reflect.DeepEqual(la, want) {
+		t.Errorf("ParseLauncherArgs = %+v, want %+v", la, want)
+	}
+
+	// Deep links are resolved to the subscription URL and the profile name
+	la, err = ParseLauncherArgs([]string{"sing-box://import-remote-profile?url=https%3A%2F%2Fexample.com%2Fsub#Provider"}, &out)
+	if err != nil || len(la.Sources) != 1 || la.Sources[0] != (ImportSource{Source: "https://example.com/sub", Name: "Provider"}) {
+		t.Errorf("deep link: %+v, err = %v", la, err)
+	}
+
+	if _, err := ParseLauncherArgs([]string{"-h"}, &out); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("-h: err = %v, want flag.ErrHelp", err)
+	}
+	for _, args := range [][]string{{"-unknown"}, {"config.json"}, {"-start", "ftp://example.com"}, {"clash://install-config?name=no-url"}} {
+		if _, err := ParseLauncherArgs(args, &out); err == nil {
+			t.Errorf("ParseLauncherArgs(%v) must fail", args)
+		}
+	}
+}
+
+func TestHandleForwardedArgs_RejectsInvalidCommandLine(t *testing.T) {
+	ac := newControlAPITestController(t)
+	err := ac.HandleForwardedArgs([]string{"-bogus"})
+	if err == nil || !strings.Contains(err.Error(), "-bogus") {
+		t.Errorf("unknown flag: err = %v", err)
+	}
+	// Without a window there is nothing to focus or import into
+	if err := ac.HandleForwardedArgs(nil); err == nil {
+		t.Errorf("expected an error for a controller without UI")
+	}
+}
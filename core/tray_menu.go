@@ -30,6 +30,13 @@ func (ac *AppController) CreateTrayMenu() *fyne.Menu {
 		fyne.NewMenuItemSeparator(),
 	)
 
+	// Profile submenu (only when there is more than one profile)
+	if profiles := ac.Profiles(); len(profiles) > 1 {
+		profileItem := fyne.NewMenuItem("Profile: "+ac.ActiveProfile(), nil)
+		profileItem.ChildMenu = ac.buildProfileSubmenu(profiles)
+		menuItems = append(menuItems, profileItem, fyne.NewMenuItemSeparator())
+	}
+
 	// VPN controls + proxy submenu (only when APIService is available)
 	if ac.APIService != nil {
 		menuItems = ac.addVPNAndProxyMenuItems(menuItems)
@@ -51,6 +58,13 @@ func (ac *AppController) addVPNAndProxyMenuItems(menuItems []*fyne.MenuItem) []*
 	// Trigger auto-load if proxies list is empty and API is enabled
 	ac.triggerProxyAutoLoadIfNeeded()
 
+	// Pending crash restart (back-off)
+	if backoff, pending := ac.ProcessService.PendingRestart(); pending {
+		statusItem := fyne.NewMenuItem("Sing-Box crashed, "+backoff.String(), nil)
+		statusItem.Disabled = true
+		menuItems = append(menuItems, statusItem)
+	}
+
 	// Start/Stop VPN buttons
 	buttonState := ac.GetVPNButtonState()
 
@@ -123,6 +137,35 @@ func (ac *AppController) buildProxySubmenu(selectedGroup string) *fyne.Menu {
 	return fyne.NewMenu("Select Proxy", items...)
 }
 
+// buildProfileSubmenu creates the profile selection submenu.
+func (ac *AppController) buildProfileSubmenu(profiles []string) *fyne.Menu {
+	activeProfile := ac.ActiveProfile()
+
+	items := make([]*fyne.MenuItem, 0, len(profiles))
+	for _, profile := range profiles {
+		name := profile
+		menuItem := fyne.NewMenuItem(name, func() {
+			go func() {
+				err := ac.SwitchProfile(name)
+				fyne.Do(func() {
+					if err != nil {
+						debuglog.ErrorLog("CreateTrayMenu: Failed to switch profile: %v", err)
+						if ac.hasUI() {
+							dialogs.ShowError(ac.UIService.MainWindow, err)
+						}
+					}
+				})
+			}()
+		})
+		if name == activeProfile {
+			menuItem.Label = "✓ " + name
+		}
+		items = append(items, menuItem)
+	}
+
+	return fyne.NewMenu("Profile", items...)
+}
+
 // triggerProxyAutoLoadIfNeeded starts background proxy loading if conditions are met.
 func (ac *AppController) triggerProxyAutoLoadIfNeeded() {
 	_, _, clashAPIEnabled := ac.APIService.GetClashAPIConfig()
@@ -22,6 +22,33 @@ const (
 	APILogFileName    = "api.log"
 )
 
+// Report file names (stored next to config.json)
+const (
+	ParseReportFileName = "parse_report.json"
+	NodeHistoryFileName = "node_history.json"
+)
+
+// LauncherStateFileName is the launcher state (e.g. selector choices) stored next to config.json
+const LauncherStateFileName = "launcher_state.json"
+
+// Config history (snapshots of config.json stored next to it)
+const (
+	ConfigHistoryDirName      = "config_history"
+	ConfigHistoryIndexName    = "index.json"
+	DefaultConfigHistoryLimit = 20
+)
+
+// SubscriptionCacheDirName is the directory next to config.json with downloaded subscriptions
+// (used to rebuild sources that are not due for reload)
+const SubscriptionCacheDirName = "subscription_cache"
+
+// ProfilesDirName is the directory next to config.json with named configuration profiles,
+// one subdirectory per profile
+const ProfilesDirName = "profiles"
+
+// MaxNodeHistoryEvents limits the node churn timeline stored in node_history.json
+const MaxNodeHistoryEvents = 100
+
 // Process names for checking
 const (
 	SingBoxProcessNameWindows = "sing-box.exe"
@@ -0,0 +1,172 @@
+package corelog
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Kind is a class of sing-box fatal errors
+type Kind string
+
+const (
+	KindPortInUse      Kind = "port_in_use"
+	KindUnknownField   Kind = "unknown_field"
+	KindMissingRuleSet Kind = "missing_rule_set"
+	KindTUNPermission  Kind = "tun_permission"
+	KindOther          Kind = "other" // A fatal error that is not recognized
+)
+
+// Diagnosis explains why sing-box failed. It is an error, so it can be passed to ShowStartupError.
+type Diagnosis struct {
+	Kind        Kind
+	Subject     string // Port, field or rule set the error is about (may be empty)
+	Line        string // The sing-box output line the diagnosis is based on
+	Explanation string // What happened, in plain words
+	Hint        string // What to do about it
+}
+
+func (d *Diagnosis) Error() string {
+	return d.Explanation
+}
+
+// Known reports whether the error was recognized (not KindOther)
+func (d *Diagnosis) Known() bool {
+	return d.Kind != KindOther
+}
+
+var (
+	// "listen tcp 127.0.0.1:2080: bind: address already in use",
+	// Windows: "bind: Only one usage of each socket address (protocol/network address/port) is normally permitted."
+	portInUsePattern   = regexp.MustCompile(`(?i)address already in use|only one usage of each socket address`)
+	listenAddrPattern  = regexp.MustCompile(`listen (?:tcp|udp)[46]? (\S+?):\s`)
+	unknownFieldRegexp = regexp.MustCompile(`unknown field "([^"]+)"`)
+	// "rule-set not found: geosite-ru",
+	// "initialize rule-set[2]: open /path/geoip-ru.srs: no such file or directory"
+	ruleSetNotFound = regexp.MustCompile(`rule-set not found: (\S+)`)
+	ruleSetNoFile   = regexp.MustCompile(`rule-set.*open (\S+?): (?:no such file or directory|The system cannot find the (?:file|path) specified)`)
+	// "start inbound/tun[tun-in]: configure tun interface: operation not permitted"
+	tunPattern        = regexp.MustCompile(`(?i)\btun\b|wintun`)
+	permissionPattern = regexp.MustCompile(`(?i)operation not permitted|permission denied|access is denied`)
+)
+
+// Classify looks for the reason of a sing-box failure in its last output lines.
+// Returns nil if the output contains no error (e.g. the process was killed).
+func Classify(lines []Line) *Diagnosis {
+	// The newest recognized error wins: earlier errors may be unrelated warnings
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		if line.Level != LevelUnknown && line.Level < LevelError {
+			continue
+		}
+		if d := classifyLine(line); d != nil {
+			return d
+		}
+	}
+
+	// Not recognized: report the last fatal error, else the last error or Go panic
+	for _, minLevel := range []Level{LevelFatal, LevelError} {
+		for i := len(lines) - 1; i >= 0; i-- {
+			if lines[i].Level >= minLevel {
+				return otherDiagnosis(lines[i].Message, lines[i].Text)
+			}
+		}
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.HasPrefix(lines[i].Text, "panic: ") {
+			return otherDiagnosis(lines[i].Text, lines[i].Text)
+		}
+	}
+	return nil
+}
+
+// exitLines is how many last output lines before an exit may explain it
+const exitLines = 5
+
+// ClassifyExit looks for the reason of a sing-box exit. Only FATAL and PANIC lines and Go
+// panics are considered; without them only the last exitLines lines are. An earlier ERROR
+// line (a failed DNS query, a refused connection) is not mistaken for the reason of an
+// unrelated crash.
+func ClassifyExit(lines []Line) *Diagnosis {
+	var fatal []Line
+	for _, line := range lines {
+		if line.Level >= LevelFatal || strings.HasPrefix(line.Text, "panic: ") {
+			fatal = append(fatal, line)
+		}
+	}
+	if len(fatal) > 0 {
+		return Classify(fatal)
+	}
+	return Classify(lines[max(0, len(lines)-exitLines):])
+}
+
+// classifyLine recognizes a known fatal error in one line
+func classifyLine(line Line) *Diagnosis {
+	text := line.Text
+	switch {
+	case portInUsePattern.MatchString(text):
+		d := &Diagnosis{Kind: KindPortInUse, Line: text}
+		if m := listenAddrPattern.FindStringSubmatch(text); m != nil {
+			d.Subject = m[1]
+			d.Explanation = fmt.Sprintf("The address %s is already in use by another program, so sing-box cannot listen on it.", m[1])
+		} else {
+			d.Explanation = "A port sing-box listens on is already in use by another program."
+		}
+		d.Hint = "Stop the program that uses the port (often another sing-box or a proxy client), or change listen_port of the inbound in config.json."
+		return d
+
+	case unknownFieldRegexp.MatchString(text):
+		field := unknownFieldRegexp.FindStringSubmatch(text)[1]
+		return &Diagnosis{
+			Kind:        KindUnknownField,
+			Subject:     field,
+			Line:        text,
+			Explanation: fmt.Sprintf("config.json contains the field %q that this version of sing-box does not know.", field),
+			Hint:        "The config was written for another sing-box version. Update sing-box on the Core tab, or remove the field from config.json (the Config Wizard regenerates the config for the installed version).",
+		}
+
+	case ruleSetNotFound.MatchString(text), ruleSetNoFile.MatchString(text):
+		m := ruleSetNotFound.FindStringSubmatch(text)
+		if m == nil {
+			m = ruleSetNoFile.FindStringSubmatch(text)
+		}
+		return &Diagnosis{
+			Kind:        KindMissingRuleSet,
+			Subject:     m[1],
+			Line:        text,
+			Explanation: fmt.Sprintf("The rule set %s is missing: it is used in route rules but is not defined in route.rule_set or its file does not exist.", m[1]),
+			Hint:        "Add the rule set to route.rule_set or remove the rules that use it. For a local rule set, check the file path; remote rule sets are downloaded on start and need network access.",
+		}
+
+	case tunPattern.MatchString(text) && permissionPattern.MatchString(text):
+		return &Diagnosis{
+			Kind:        KindTUNPermission,
+			Line:        text,
+			Explanation: "sing-box has no permission to create the TUN interface.",
+			Hint:        tunPermissionHint(),
+		}
+	}
+	return nil
+}
+
+// tunPermissionHint returns the remediation of a TUN permission error for this platform
+func tunPermissionHint() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "Run the launcher as Administrator, or remove the tun inbound from config.json to use the system proxy only."
+	case "darwin":
+		return "Start the launcher with administrator privileges, or remove the tun inbound from config.json to use the system proxy only."
+	}
+	return "Grant sing-box the network capabilities: sudo setcap 'cap_net_admin,cap_net_bind_service=+ep' bin/sing-box (or run it as root), or remove the tun inbound from config.json."
+}
+
+// otherDiagnosis describes an unrecognized error by the sing-box message itself
+func otherDiagnosis(message, text string) *Diagnosis {
+	return &Diagnosis{
+		Kind:        KindOther,
+		Line:        text,
+		Explanation: message,
+		Hint:        "Check config.json and logs/sing-box.log for details.",
+	}
+}
@@ -0,0 +1,151 @@
+// Package corelog captures the output of the sing-box core: a ring buffer of recent lines
+// with their log levels, and classification of common fatal errors into a human explanation
+// with a remediation hint.
+package corelog
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Level is a sing-box log level
+type Level int
+
+// Log levels in increasing severity. LevelUnknown is output without a level (e.g. a Go panic).
+const (
+	LevelUnknown Level = iota
+	LevelTrace
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+	LevelPanic
+)
+
+var levelNames = map[string]Level{
+	"TRACE": LevelTrace,
+	"DEBUG": LevelDebug,
+	"INFO":  LevelInfo,
+	"WARN":  LevelWarn,
+	"ERROR": LevelError,
+	"FATAL": LevelFatal,
+	"PANIC": LevelPanic,
+}
+
+func (l Level) String() string {
+	for name, level := range levelNames {
+		if level == l {
+			return name
+		}
+	}
+	return "UNKNOWN"
+}
+
+// maxPartialLine limits an unterminated line kept by Buffer
+const maxPartialLine = 4096
+
+var (
+	// ansiEscape matches color codes (sing-box colors its log unless log.disable_color is set)
+	ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+	// levelPattern matches the level of a sing-box log line:
+	// "+0300 2024-05-01 12:00:00 ERROR [3425 0ms] router: ..." or "FATAL[0000] start service: ..."
+	levelPattern = regexp.MustCompile(`(?:^|\s)(TRACE|DEBUG|INFO|WARN|ERROR|FATAL|PANIC)(?:\[[^\]]*\])?(?:\s+\[[^\]]*\])?\s+`)
+)
+
+// Line is a line of sing-box output
+type Line struct {
+	Level   Level
+	Text    string // The whole line without color codes
+	Message string // Text after the timestamp and the level
+}
+
+// ParseLine recognizes the log level of a line of sing-box output
+func ParseLine(raw string) Line {
+	text := strings.TrimRight(ansiEscape.ReplaceAllString(raw, ""), "\r")
+	line := Line{Text: text, Message: strings.TrimSpace(text)}
+	if m := levelPattern.FindStringSubmatchIndex(text); m != nil {
+		line.Level = levelNames[text[m[2]:m[3]]]
+		line.Message = strings.TrimSpace(text[m[1]:])
+	}
+	return line
+}
+
+// Buffer is an io.Writer that keeps the last lines written to it. It is safe for concurrent use,
+// so the same Buffer can receive stdout and stderr of the process.
+type Buffer struct {
+	mu      sync.Mutex
+	size    int
+	lines   []Line
+	next    int // Position of the oldest line once the ring is full
+	partial []byte
+}
+
+// NewBuffer returns a Buffer keeping up to size lines
+func NewBuffer(size int) *Buffer {
+	return &Buffer{size: size, lines: make([]Line, 0, size)}
+}
+
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data := append(b.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		b.add(string(data[:i]))
+		data = data[i+1:]
+	}
+	if len(data) > maxPartialLine {
+		data = data[len(data)-maxPartialLine:]
+	}
+	b.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// add appends a complete line, overwriting the oldest one when the ring is full
+func (b *Buffer) add(raw string) {
+	line := ParseLine(raw)
+	if strings.TrimSpace(line.Text) == "" {
+		return
+	}
+	if len(b.lines) < b.size {
+		b.lines = append(b.lines, line)
+		return
+	}
+	b.lines[b.next] = line
+	b.next = (b.next + 1) % b.size
+}
+
+// Lines returns the kept lines, oldest first, including an unterminated last line
+func (b *Buffer) Lines() []Line {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	lines := make([]Line, 0, len(b.lines)+1)
+	lines = append(lines, b.lines[b.next:]...)
+	lines = append(lines, b.lines[:b.next]...)
+	if len(b.partial) > 0 {
+		if line := ParseLine(string(b.partial)); strings.TrimSpace(line.Text) != "" {
+			lines = append(lines, line)
+		}
+		if len(lines) > b.size {
+			lines = lines[len(lines)-b.size:]
+		}
+	}
+	return lines
+}
+
+// Texts returns the text of the kept lines, oldest first
+func (b *Buffer) Texts() []string {
+	lines := b.Lines()
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		texts[i] = line.Text
+	}
+	return texts
+}
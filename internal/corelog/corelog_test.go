@@ -0,0 +1,159 @@
+package corelog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		raw     string
+		level   Level
+		message string
+	}{
+		{"+0300 2024-05-01 12:00:00 INFO [3425 0ms] inbound/mixed[mixed-in]: inbound connection", LevelInfo, "inbound/mixed[mixed-in]: inbound connection"},
+		{"FATAL[0000] start service: bind: address already in use", LevelFatal, "start service: bind: address already in use"},
+		{"\x1b[31mERROR\x1b[0m [1 5ms] router: rule-set not found: geosite-ru", LevelError, "router: rule-set not found: geosite-ru"},
+		{"WARN dns: lookup failed", LevelWarn, "dns: lookup failed"},
+		{"panic: runtime error: index out of range", LevelUnknown, "panic: runtime error: index out of range"},
+		{"INFORMATION is not a level", LevelUnknown, "INFORMATION is not a level"},
+	}
+	for _, tt := range tests {
+		line := ParseLine(tt.raw)
+		if line.Level != tt.level || line.Message != tt.message {
+			t.Errorf("ParseLine(%q) = %v %q, want %v %q", tt.raw, line.Level, line.Message, tt.level, tt.message)
+		}
+	}
+}
+
+func TestBuffer_KeepsLastLines(t *testing.T) {
+	b := NewBuffer(3)
+	for _, chunk := range []string{"INFO one\nINFO tw", "o\r\n\nWARN three\nERROR four\n", "FATAL fi"} {
+		if _, err := b.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := b.Texts(), []string{"WARN three", "ERROR four", "FATAL fi"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Texts() = %q, want %q", got, want)
+	}
+	if lines := b.Lines(); lines[2].Level != LevelFatal {
+		t.Errorf("unterminated line level = %v, want FATAL", lines[2].Level)
+	}
+
+	// The ring wraps around more than once
+	for i := 0; i < 10; i++ {
+		_, _ = b.Write([]byte("INFO line\n"))
+	}
+	if n := len(b.Lines()); n != 3 {
+		t.Errorf("len(Lines()) = %d, want 3", n)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  []string
+		kind    Kind
+		subject string
+	}{
+		{
+			name:    "port in use",
+			output:  []string{"INFO router: started", "FATAL[0000] start service: start inbound/mixed[mixed-in]: listen tcp 127.0.0.1:2080: bind: address already in use"},
+			kind:    KindPortInUse,
+			subject: "127.0.0.1:2080",
+		},
+		{
+			name:    "port in use on Windows",
+			output:  []string{"FATAL[0000] start service: start inbound/mixed[mixed-in]: listen tcp 127.0.0.1:2080: bind: Only one usage of each socket address (protocol/network address/port) is normally permitted."},
+			kind:    KindPortInUse,
+			subject: "127.0.0.1:2080",
+		},
+		{
+			name:    "unknown field",
+			output:  []string{`FATAL[0000] decode config at ./config.json: outbounds[3].tls: json: unknown field "utls_fingerprint"`},
+			kind:    KindUnknownField,
+			subject: "utls_fingerprint",
+		},
+		{
+			name:    "rule set not defined",
+			output:  []string{"FATAL[0000] start service: initialize router: rule-set not found: geosite-ru"},
+			kind:    KindMissingRuleSet,
+			subject: "geosite-ru",
+		},
+		{
+			name:    "rule set file missing",
+			output:  []string{"FATAL[0000] start service: initialize rule-set[1]: open /opt/bin/geoip-ru.srs: no such file or directory"},
+			kind:    KindMissingRuleSet,
+			subject: "/opt/bin/geoip-ru.srs",
+		},
+		{
+			name:   "tun permission",
+			output: []string{"FATAL[0000] start service: start inbound/tun[tun-in]: configure tun interface: operation not permitted"},
+			kind:   KindTUNPermission,
+		},
+		{
+			name:   "unrecognized fatal wins over an earlier error",
+			output: []string{"ERROR dns: exchange failed", "FATAL[0000] start service: something new"},
+			kind:   KindOther,
+		},
+		{
+			name:   "go panic",
+			output: []string{"panic: runtime error: invalid memory address", "goroutine 1 [running]:"},
+			kind:   KindOther,
+		},
+	}
+	for _, tt := range tests {
+		var lines []Line
+		for _, raw := range tt.output {
+			lines = append(lines, ParseLine(raw))
+		}
+		d := Classify(lines)
+		if d == nil {
+			t.Errorf("%s: Classify returned nil", tt.name)
+			continue
+		}
+		if d.Kind != tt.kind || d.Subject != tt.subject || d.Explanation == "" || d.Hint == "" {
+			t.Errorf("%s: Classify = %+v, want kind %s subject %q", tt.name, d, tt.kind, tt.subject)
+		}
+	}
+
+	if d := Classify([]Line{ParseLine("INFO router: started"), ParseLine("WARN dns: slow")}); d != nil {
+		t.Errorf("Classify of output without errors = %+v, want nil", d)
+	}
+	if d := Classify([]Line{ParseLine("FATAL start service: something new")}); d.Explanation != "start service: something new" || d.Known() {
+		t.Errorf("unrecognized fatal error: %+v", d)
+	}
+}
+
+func TestClassifyExit_IgnoresEarlierErrors(t *testing.T) {
+	parse := func(output ...string) []Line {
+		var lines []Line
+		for _, raw := range output {
+			lines = append(lines, ParseLine(raw))
+		}
+		return lines
+	}
+
+	// A recognized ERROR long before an unrelated panic must not explain the exit
+	lines := parse("ERROR inbound/mixed[mixed-in]: listen tcp 127.0.0.1:2080: bind: address already in use",
+		"INFO router: started", "INFO outbound/vless[proxy]: connected",
+		"panic: runtime error: invalid memory address", "goroutine 1 [running]:")
+	if d := ClassifyExit(lines); d == nil || d.Known() || !strings.HasPrefix(d.Line, "panic: ") {
+		t.Errorf("ClassifyExit = %+v, want the panic", d)
+	}
+	if d := Classify(lines); d == nil || d.Kind != KindPortInUse {
+		t.Errorf("Classify = %+v, want port_in_use", d)
+	}
+
+	// Without FATAL lines only the last lines count
+	lines = parse("ERROR dns: exchange failed: rule-set not found: geosite-ru",
+		"INFO a", "INFO b", "INFO c", "INFO d", "INFO e", "signal: killed")
+	if d := ClassifyExit(lines); d != nil {
+		t.Errorf("ClassifyExit = %+v, want nil", d)
+	}
+	lines = parse("INFO router: started", "ERROR start inbound/tun[tun-in]: configure tun interface: operation not permitted")
+	if d := ClassifyExit(lines); d == nil || d.Kind != KindTUNPermission {
+		t.Errorf("ClassifyExit of the last error = %+v, want tun_permission", d)
+	}
+}
@@ -0,0 +1,137 @@
+//go:build linux
+// +build linux
+
+package platform
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"singbox-launcher/internal/debuglog"
+)
+
+// desktopEntryName is the file name of the generated .desktop entry
+const desktopEntryName = "singbox-launcher.desktop"
+
+// URLSchemeRegistrationSupported reports whether RegisterURLSchemes is implemented on this platform
+func URLSchemeRegistrationSupported() bool {
+	return true
+}
+
+// desktopEntryPath returns $XDG_DATA_HOME/applications/singbox-launcher.desktop (~/.local/share by default)
+func desktopEntryPath() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot detect home directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "applications", desktopEntryName), nil
+}
+
+// RegisterURLSchemes makes the launcher the handler of the given URL schemes:
+// writes a .desktop entry with x-scheme-handler MIME types and sets it as default via xdg-mime.
+// Returns the path of the .desktop file. A missing xdg-mime or update-desktop-database is logged, not an error.
+func RegisterURLSchemes(execPath string, schemes []string) (string, error) {
+	path, err := desktopEntryPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("cannot create %s: %w", filepath.Dir(path), err)
+	}
+
+	mimeTypes := make([]string, 0, len(schemes))
+	for _, scheme := range schemes {
+		mimeTypes = append(mimeTypes, "x-scheme-handler/"+scheme)
+	}
+	if err := os.WriteFile(path, []byte(desktopEntry(execPath, mimeTypes)), 0644); err != nil {
+		return "", fmt.Errorf("cannot write %s: %w", path, err)
+	}
+	debuglog.InfoLog("platform: desktop entry written to %s", path)
+
+	if out, err := exec.Command("xdg-mime", append([]string{"default", desktopEntryName}, mimeTypes...)...).CombinedOutput(); err != nil {
+		debuglog.WarnLog("platform: xdg-mime default failed: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	if out, err := exec.Command("update-desktop-database", filepath.Dir(path)).CombinedOutput(); err != nil {
+		debuglog.DebugLog("platform: update-desktop-database failed: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	return path, nil
+}
+
+// RegisteredURLSchemeHandler returns the executable set in the generated .desktop entry (ok = false if there is none)
+func RegisteredURLSchemeHandler() (execPath string, ok bool) {
+	path, err := desktopEntryPath()
+	if err != nil {
+		return "", false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if value, found := strings.CutPrefix(line, "Exec="); found {
+			return unquoteDesktopExec(strings.TrimSuffix(value, " %u")), true
+		}
+	}
+	return "", false
+}
+
+// desktopEntry builds the content of the .desktop file
+func desktopEntry(execPath string, mimeTypes []string) string {
+	var b strings.Builder
+	b.WriteString("[Desktop Entry]\n")
+	b.WriteString("Type=Application\n")
+	b.WriteString("Name=Singbox Launcher\n")
+	b.WriteString("Comment=Import sing-box and Clash subscription links\n")
+	b.WriteString("Exec=" + quoteDesktopExec(execPath) + " %u\n")
+	b.WriteString("Terminal=false\n")
+	b.WriteString("NoDisplay=true\n")
+	b.WriteString("Categories=Network;\n")
+	b.WriteString("MimeType=" + strings.Join(mimeTypes, ";") + ";\n")
+	return b.String()
+}
+
+// quoteDesktopExec quotes a path for the Exec key (Desktop Entry Specification, "The Exec key"):
+// inside double quotes ", `, $ and \ are escaped with a backslash, and every backslash is doubled
+// once more because Exec is a string value
+func quoteDesktopExec(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		switch r {
+		case '"', '`', '$':
+			b.WriteString(`\\`)
+		case '\\':
+			b.WriteString(`\\\`)
+		case '%':
+			b.WriteRune('%')
+		}
+		b.WriteRune(r)
+	}
+	return `"` + b.String() + `"`
+}
+
+// unquoteDesktopExec reverses quoteDesktopExec
+func unquoteDesktopExec(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return value
+	}
+	value = value[1 : len(value)-1]
+	value = strings.ReplaceAll(value, "%%", "%")
+	value = strings.ReplaceAll(value, `\\\\`, `\`)
+	for _, c := range []string{`"`, "`", "$"} {
+		value = strings.ReplaceAll(value, `\\`+c, c)
+	}
+	return value
+}
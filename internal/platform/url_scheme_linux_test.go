@@ -0,0 +1,45 @@
+//go:build linux
+// +build linux
+
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegisterURLSchemes(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	t.Setenv("PATH", "") // xdg-mime and update-desktop-database are optional
+
+	execPath := `/opt/sing box/$HOME "launcher"\bin%/singbox-launcher`
+	path, err := RegisterURLSchemes(execPath, []string{"clash", "sing-box"})
+	if err != nil {
+		t.Fatalf("RegisterURLSchemes: %v", err)
+	}
+	if want := filepath.Join(dataHome, "applications", desktopEntryName); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	for _, line := range []string{
+		"[Desktop Entry]",
+		`Exec="/opt/sing box/\\$HOME \\"launcher\\"\\\\bin%%/singbox-launcher" %u`,
+		"MimeType=x-scheme-handler/clash;x-scheme-handler/sing-box;",
+	} {
+		if !strings.Contains(content, line+"\n") {
+			t.Errorf("desktop entry has no line %q:\n%s", line, content)
+		}
+	}
+
+	if got, ok := RegisteredURLSchemeHandler(); !ok || got != execPath {
+		t.Errorf("RegisteredURLSchemeHandler() = %q, %v; want %q", got, ok, execPath)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+// +build !linux
+
+package platform
+
+import "fmt"
+
+// URLSchemeRegistrationSupported reports whether RegisterURLSchemes is implemented on this platform
+func URLSchemeRegistrationSupported() bool {
+	return false
+}
+
+// RegisterURLSchemes is implemented only on Linux (.desktop entry)
+func RegisterURLSchemes(execPath string, schemes []string) (string, error) {
+	return "", fmt.Errorf("registering URL schemes is not supported on this platform")
+}
+
+// RegisteredURLSchemeHandler is implemented only on Linux
+func RegisteredURLSchemeHandler() (execPath string, ok bool) {
+	return "", false
+}
@@ -0,0 +1,34 @@
+//go:build darwin
+// +build darwin
+
+package process
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// FindPortOwner returns the process listening on a TCP port (from lsof)
+func FindPortOwner(port int) (ProcessInfo, bool, error) {
+	out, err := exec.Command("lsof", "-nP", "-iTCP:"+strconv.Itoa(port), "-sTCP:LISTEN", "-Fpc").Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return ProcessInfo{}, false, nil // lsof exits with 1 when nothing is found
+		}
+		return ProcessInfo{}, false, err
+	}
+
+	// Field output: "p<pid>" followed by "c<command>"
+	var info ProcessInfo
+	for _, line := range strings.Split(string(out), "\n") {
+		switch {
+		case strings.HasPrefix(line, "p") && info.PID == 0:
+			info.PID, _ = strconv.Atoi(line[1:])
+		case strings.HasPrefix(line, "c") && info.PID != 0:
+			info.Name = line[1:]
+			return info, true, nil
+		}
+	}
+	return info, info.PID != 0, nil
+}
@@ -0,0 +1,65 @@
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// tcpListenState is the LISTEN state in /proc/net/tcp
+const tcpListenState = "0A"
+
+// FindPortOwner returns the process listening on a TCP port.
+// Sockets of processes of other users are not visible without root, so found may be false
+// even if the port is taken.
+func FindPortOwner(port int) (ProcessInfo, bool, error) {
+	sockets := make(map[string]bool)
+	for _, file := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n")[1:] {
+			// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+			fields := strings.Fields(line)
+			if len(fields) < 10 || fields[3] != tcpListenState {
+				continue
+			}
+			i := strings.LastIndexByte(fields[1], ':')
+			if p, err := strconv.ParseUint(fields[1][i+1:], 16, 16); err != nil || int(p) != port {
+				continue
+			}
+			sockets["socket:["+fields[9]+"]"] = true
+		}
+	}
+	if len(sockets) == 0 {
+		return ProcessInfo{}, false, nil
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return ProcessInfo{}, false, err
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join("/proc", entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue // Process of another user or already gone
+		}
+		for _, fd := range fds {
+			if link, err := os.Readlink(filepath.Join(fdDir, fd.Name())); err == nil && sockets[link] {
+				name, _ := os.ReadFile(filepath.Join("/proc", entry.Name(), "comm"))
+				return ProcessInfo{PID: pid, Name: strings.TrimSpace(string(name))}, true, nil
+			}
+		}
+	}
+	return ProcessInfo{}, false, nil
+}
@@ -0,0 +1,29 @@
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"net"
+	"os"
+	"testing"
+)
+
+func TestFindPortOwner(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	info, found, err := FindPortOwner(port)
+	if err != nil || !found || info.PID != os.Getpid() {
+		t.Errorf("FindPortOwner(%d) = %+v, %v, %v, want PID %d", port, info, found, err, os.Getpid())
+	}
+
+	ln.Close()
+	if _, found, _ := FindPortOwner(port); found {
+		t.Errorf("FindPortOwner(%d) found an owner of a closed port", port)
+	}
+}
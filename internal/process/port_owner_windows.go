@@ -0,0 +1,50 @@
+//go:build windows
+// +build windows
+
+package process
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"singbox-launcher/internal/platform"
+)
+
+// FindPortOwner returns the process listening on a TCP port (from `netstat -ano`)
+func FindPortOwner(port int) (ProcessInfo, bool, error) {
+	cmd := exec.Command("netstat", "-ano", "-p", "TCP")
+	platform.PrepareCommand(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return ProcessInfo{}, false, err
+	}
+	cmd6 := exec.Command("netstat", "-ano", "-p", "TCPv6")
+	platform.PrepareCommand(cmd6)
+	if out6, err := cmd6.Output(); err == nil {
+		out = append(out, out6...)
+	}
+
+	suffix := ":" + strconv.Itoa(port)
+	for _, line := range strings.Split(string(out), "\n") {
+		// Proto  Local Address  Foreign Address  State  PID
+		fields := strings.Fields(line)
+		if len(fields) != 5 || fields[0] != "TCP" || !strings.HasSuffix(fields[1], suffix) {
+			continue
+		}
+		// The state name is localized; a listening socket has no foreign address
+		if fields[2] != "0.0.0.0:0" && fields[2] != "[::]:0" {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[4])
+		if err != nil || pid == 0 {
+			continue
+		}
+		info, found, err := FindProcess(pid)
+		if err != nil || !found {
+			return ProcessInfo{PID: pid}, true, nil
+		}
+		return info, true, nil
+	}
+	return ProcessInfo{}, false, nil
+}
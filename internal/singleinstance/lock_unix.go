@@ -0,0 +1,28 @@
+//go:build !windows
+// +build !windows
+
+package singleinstance
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile opens path and takes a non-blocking exclusive flock on it.
+// The lock is released by the kernel when the process exits, so a crash leaves no stale lock.
+func lockFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("singleinstance: cannot open %s: %w", path, err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrAlreadyRunning
+		}
+		return nil, fmt.Errorf("singleinstance: cannot lock %s: %w", path, err)
+	}
+	return f, nil
+}
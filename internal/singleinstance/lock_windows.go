@@ -0,0 +1,38 @@
+//go:build windows
+// +build windows
+
+package singleinstance
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// errorSharingViolation is ERROR_SHARING_VIOLATION: the file is open for writing by another process
+const errorSharingViolation syscall.Errno = 32
+
+// lockFile opens path for writing and shares it for reading only, so a second instance
+// cannot open it for writing while the first one runs but can still read the address.
+// Windows closes the handle when the process exits, so a crash leaves no stale lock.
+func lockFile(path string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, fmt.Errorf("singleinstance: invalid path %s: %w", path, err)
+	}
+	handle, err := syscall.CreateFile(name,
+		syscall.GENERIC_READ|syscall.GENERIC_WRITE,
+		syscall.FILE_SHARE_READ,
+		nil,
+		syscall.OPEN_ALWAYS,
+		syscall.FILE_ATTRIBUTE_NORMAL,
+		0)
+	if err != nil {
+		if errors.Is(err, errorSharingViolation) {
+			return nil, ErrAlreadyRunning
+		}
+		return nil, fmt.Errorf("singleinstance: cannot open %s: %w", path, err)
+	}
+	return os.NewFile(uintptr(handle), path), nil
+}
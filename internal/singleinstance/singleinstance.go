@@ -0,0 +1,239 @@
+// Package singleinstance keeps a single launcher running per installation directory.
+//
+// The first instance takes an exclusive lock on a lock file and listens on a loopback
+// address; the address and a random token are written into the lock file. A second
+// invocation fails to take the lock, reads the address and forwards its command line
+// to the running instance instead of starting another GUI.
+package singleinstance
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// LockFileName is the name of the lock file inside the launcher directory
+const LockFileName = "launcher.lock"
+
+const (
+	ioTimeout      = 5 * time.Second        // Deadline for a forwarded request and its response
+	maxRequestSize = 64 * 1024              // Upper bound for a forwarded command line
+	readRetryDelay = 100 * time.Millisecond // Delay between attempts to read a lock file that is still being written
+	readAttempts   = 20
+)
+
+// ErrAlreadyRunning is returned by Acquire when another instance holds the lock
+var ErrAlreadyRunning = errors.New("another launcher instance is already running")
+
+// Handler performs a command line forwarded by another invocation.
+// It is called from the IPC goroutine and must not block for long.
+type Handler func(args []string) error
+
+// errNotReady is reported to a second invocation that arrives before SetHandler is called
+var errNotReady = errors.New("the launcher is still starting, try again")
+
+// lockInfo is the content of the lock file
+type lockInfo struct {
+	PID     int    `json:"pid"`
+	Address string `json:"address"`
+	Token   string `json:"token"`
+}
+
+type request struct {
+	Token string   `json:"token"`
+	Args  []string `json:"args"`
+}
+
+type response struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Instance is the lock and the IPC listener of the running launcher
+type Instance struct {
+	lockFile *os.File
+	listener net.Listener
+	token    string
+	wg       sync.WaitGroup
+
+	handlerMutex sync.Mutex
+	handler      Handler
+}
+
+// Acquire takes the single-instance lock in dir and starts accepting forwarded command lines.
+// Requests are refused until SetHandler is called. Returns ErrAlreadyRunning if another instance holds the lock.
+func Acquire(dir string) (*Instance, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("singleinstance: cannot create %s: %w", dir, err)
+	}
+	f, err := lockFile(filepath.Join(dir, LockFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("singleinstance: cannot listen: %w", err)
+	}
+	token, err := newToken()
+	if err != nil {
+		_ = listener.Close()
+		_ = f.Close()
+		return nil, err
+	}
+
+	info := lockInfo{PID: os.Getpid(), Address: listener.Addr().String(), Token: token}
+	if err := writeLockInfo(f, info); err != nil {
+		_ = listener.Close()
+		_ = f.Close()
+		return nil, err
+	}
+
+	inst := &Instance{lockFile: f, listener: listener, token: token}
+	inst.wg.Add(1)
+	go inst.serve()
+	return inst, nil
+}
+
+// SetHandler sets the function that performs forwarded command lines
+func (i *Instance) SetHandler(handler Handler) {
+	i.handlerMutex.Lock()
+	defer i.handlerMutex.Unlock()
+	i.handler = handler
+}
+
+// Close stops accepting forwarded command lines and releases the lock
+func (i *Instance) Close() error {
+	if i == nil {
+		return nil
+	}
+	err := i.listener.Close()
+	i.wg.Wait()
+	// The lock file stays on disk; only its content is cleared so stale addresses are not used
+	_ = i.lockFile.Truncate(0)
+	if cerr := i.lockFile.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (i *Instance) serve() {
+	defer i.wg.Done()
+	for {
+		conn, err := i.listener.Accept()
+		if err != nil {
+			return
+		}
+		i.handle(conn)
+	}
+}
+
+func (i *Instance) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(ioTimeout))
+
+	var req request
+	resp := response{OK: true}
+	if err := json.NewDecoder(io.LimitReader(conn, maxRequestSize)).Decode(&req); err != nil {
+		resp = response{Error: fmt.Sprintf("invalid request: %v", err)}
+	} else if subtle.ConstantTimeCompare([]byte(req.Token), []byte(i.token)) != 1 {
+		resp = response{Error: "invalid token"}
+	} else if err := i.runHandler(req.Args); err != nil {
+		resp = response{Error: err.Error()}
+	}
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+func (i *Instance) runHandler(args []string) error {
+	i.handlerMutex.Lock()
+	handler := i.handler
+	i.handlerMutex.Unlock()
+	if handler == nil {
+		return errNotReady
+	}
+	return handler(args)
+}
+
+// Forward sends args to the instance running in dir and returns the error reported by it
+func Forward(dir string, args []string) error {
+	info, err := readLockInfo(filepath.Join(dir, LockFileName))
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.DialTimeout("tcp", info.Address, ioTimeout)
+	if err != nil {
+		return fmt.Errorf("singleinstance: cannot connect to the running instance (pid %d): %w", info.PID, err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(ioTimeout))
+
+	if args == nil {
+		args = []string{}
+	}
+	if err := json.NewEncoder(conn).Encode(request{Token: info.Token, Args: args}); err != nil {
+		return fmt.Errorf("singleinstance: cannot send request: %w", err)
+	}
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return fmt.Errorf("singleinstance: no response from the running instance: %w", err)
+	}
+	if !resp.OK {
+		return fmt.Errorf("running instance: %s", resp.Error)
+	}
+	return nil
+}
+
+// readLockInfo reads the lock file, waiting briefly if the owner has not written it yet
+func readLockInfo(path string) (lockInfo, error) {
+	var info lockInfo
+	var lastErr error
+	for attempt := 0; attempt < readAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(readRetryDelay)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if err := json.Unmarshal(data, &info); err != nil || info.Address == "" {
+			lastErr = fmt.Errorf("lock file is empty or incomplete")
+			continue
+		}
+		return info, nil
+	}
+	return info, fmt.Errorf("singleinstance: cannot read %s: %w", path, lastErr)
+}
+
+func writeLockInfo(f *os.File, info lockInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return fmt.Errorf("singleinstance: cannot write lock file: %w", err)
+	}
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("singleinstance: cannot write lock file: %w", err)
+	}
+	return f.Sync()
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("singleinstance: cannot generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
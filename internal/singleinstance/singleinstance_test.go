@@ -0,0 +1,87 @@
+package singleinstance
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAcquire_SecondInstanceForwardsArgs(t *testing.T) {
+	dir := t.TempDir()
+	received := make(chan []string, 1)
+	first, err := Acquire(dir)
+	if err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+
+	if _, err := Acquire(dir); !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("second Acquire: err = %v, want ErrAlreadyRunning", err)
+	}
+
+	// Until the handler is set the running instance answers that it is still starting
+	if err := Forward(dir, []string{"-start"}); err == nil || !strings.Contains(err.Error(), "still starting") {
+		t.Errorf("Forward before SetHandler: err = %v", err)
+	}
+	first.SetHandler(func(args []string) error {
+		if len(args) > 0 && args[0] == "-bad" {
+			return errors.New("flag provided but not defined: -bad")
+		}
+		received <- args
+		return nil
+	})
+
+	args := []string{"-start", "https://example.com/sub"}
+	if err := Forward(dir, args); err != nil {
+		t.Fatalf("Forward: %v", err)
+	}
+	if got := <-received; !reflect.DeepEqual(got, args) {
+		t.Errorf("handler received %v, want %v", got, args)
+	}
+	if err := Forward(dir, []string{"-bad"}); err == nil || !strings.Contains(err.Error(), "-bad") {
+		t.Errorf("Forward must return the handler error, got %v", err)
+	}
+
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	// After the first instance exits the lock can be taken again
+	next, err := Acquire(dir)
+	if err != nil {
+		t.Fatalf("Acquire after Close: %v", err)
+	}
+	_ = next.Close()
+}
+
+func TestInstance_RejectsWrongToken(t *testing.T) {
+	dir := t.TempDir()
+	called := false
+	inst, err := Acquire(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inst.Close()
+	inst.SetHandler(func(args []string) error {
+		called = true
+		return nil
+	})
+
+	// Another local program that guesses the port but cannot read the lock file is refused
+	info, err := readLockInfo(filepath.Join(dir, LockFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := filepath.Join(t.TempDir(), LockFileName)
+	data := []byte(`{"pid":1,"address":"` + info.Address + `","token":"guessed"}`)
+	if err := os.WriteFile(forged, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Forward(filepath.Dir(forged), []string{"-start"}); err == nil || !strings.Contains(err.Error(), "invalid token") {
+		t.Errorf("Forward with a wrong token: err = %v", err)
+	}
+	if called {
+		t.Errorf("handler was called for a request with a wrong token")
+	}
+}
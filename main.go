@@ -2,14 +2,17 @@ package main
 
 import (
 	_ "embed" // For embedding resource files (icons)
+	"errors"
 	"flag"
 	"log"
+	"os"
 	"runtime"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/driver/desktop"
 
+	"singbox-launcher/cli"
 	"singbox-launcher/core"
 	"singbox-launcher/core/config/parser"
 	"singbox-launcher/internal/debuglog"
@@ -35,10 +38,33 @@ const (
 
 // main is the application's entry point. It simply creates and runs the AppController.
 func main() {
+	// Headless subcommands (update, check, start, ...) run without Fyne and exit with their own code
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	// Parse command line arguments
-	autoStart := flag.Bool("start", false, "Automatically start VPN on launch")
-	startInTray := flag.Bool("tray", false, "Start minimized to system tray (hide window on launch)")
-	flag.Parse()
+	launchArgs, err := core.ParseLauncherArgs(os.Args[1:], os.Stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	}
+
+	// Only one launcher runs per installation: a second launch hands its command line
+	// (-start, -tray, sources to import) to the running instance and exits.
+	singleInstance, forwarded, err := core.AcquireSingleInstance(os.Args[1:])
+	if forwarded {
+		if err != nil {
+			log.Fatalf("Launcher is already running, failed to pass the command line to it: %v", err)
+		}
+		log.Printf("Launcher is already running, command line passed to it")
+		return
+	}
+	if err != nil {
+		log.Printf("Single-instance lock is not available, continuing without it: %v", err)
+	}
 
 	// Create the application controller. If an error occurs, print it and exit the program.
 	// Use greyIconData for red icon (no separate red icon yet)
@@ -137,7 +163,7 @@ func main() {
 			// Read config once at application startup
 			go func() {
 				debuglog.InfoLog("Application startup: Reading config...")
-				config, err := parser.ExtractParserConfig(controller.FileService.ConfigPath)
+				config, err := parser.ExtractParserConfig(controller.FileService.ConfigPath())
 				if err != nil {
 					debuglog.WarnLog("Application startup: Failed to read config: %v", err)
 					return
@@ -148,8 +174,11 @@ func main() {
 					len(config.ParserConfig.Outbounds))
 			}()
 
+			// Sources passed on the command line are added via the Config Wizard
+			controller.ImportSources(launchArgs.Sources)
+
 			// Auto-start VPN if -start flag is provided
-			if *autoStart {
+			if launchArgs.Start {
 				go func() {
 					// Wait a bit for everything to initialize
 					<-time.After(autoStartDelay)
@@ -159,7 +188,7 @@ func main() {
 			}
 
 			// Hide window if -tray flag is provided
-			if *startInTray {
+			if launchArgs.Tray {
 				go func() {
 					// Wait a bit for window to be fully initialized
 					<-time.After(500 * time.Millisecond)
@@ -183,7 +212,7 @@ func main() {
 	controller.UIService.MainWindow.Resize(fyne.NewSize(350, 450)) // initial window size
 	controller.UIService.MainWindow.CenterOnScreen()               // Center the window on the screen
 
-	core.CheckIfLauncherAlreadyRunningUtil()
+	controller.SetSingleInstance(singleInstance)
 
 	// Intercept the window close event (clicking "X") to hide it instead of exiting completely.
 	if controller.UIService.MainWindow != nil {
@@ -230,7 +259,7 @@ func main() {
 	// This allows the app to keep running even when window is closed/hidden
 	// On macOS, this enables standard Dock behavior (applicationShouldHandleReopen)
 	// See: https://github.com/fyne-io/fyne/issues/3845
-	if !*startInTray {
+	if !launchArgs.Tray {
 		// Show window on startup if not starting in tray
 		if controller.UIService != nil {
 			controller.UIService.ShowMainWindowOrFocusWizard()
@@ -25,7 +25,7 @@ func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
 	status := widget.NewLabel("Click 'Load Proxies'")
 	ac.UIService.ListStatusLabel = status
 
-	selectorOptions, defaultSelector, err := config.GetSelectorGroupsFromConfig(ac.FileService.ConfigPath)
+	selectorOptions, defaultSelector, err := config.GetSelectorGroupsFromConfig(ac.FileService.ConfigPath())
 	if err != nil {
 		debuglog.ErrorLog("clash_api_tab: failed to get selector groups: %v", err)
 	}
@@ -117,7 +117,7 @@ func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
 
 	// Функция для обновления списка селекторов из конфига (вызывается когда sing-box запущен и конфиг загружен)
 	updateSelectorList := func() {
-		updatedSelectorOptions, updatedDefaultSelector, err := config.GetSelectorGroupsFromConfig(ac.FileService.ConfigPath)
+		updatedSelectorOptions, updatedDefaultSelector, err := config.GetSelectorGroupsFromConfig(ac.FileService.ConfigPath())
 		if err == nil && len(updatedSelectorOptions) > 0 && groupSelect != nil {
 			// Обновляем и переменную selectorOptions, и виджет groupSelect
 			selectorOptions = updatedSelectorOptions
@@ -546,7 +546,7 @@ func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
 				currentSelectorOptions = groupSelect.Options
 			} else {
 				// Fallback: перечитываем из конфига, если groupSelect еще не инициализирован
-				updatedOptions, _, err := config.GetSelectorGroupsFromConfig(ac.FileService.ConfigPath)
+				updatedOptions, _, err := config.GetSelectorGroupsFromConfig(ac.FileService.ConfigPath())
 				if err != nil {
 					debuglog.ErrorLog("clash_api_tab: failed to get selector groups for popup: %v", err)
 					currentSelectorOptions = selectorOptions // Используем старый список как fallback
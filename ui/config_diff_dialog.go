@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"singbox-launcher/core/config"
+	"singbox-launcher/ui/components"
+)
+
+// showConfigDiffDialog показывает превью обновления конфигурации с кнопками Apply/Cancel.
+// decide вызывается ровно один раз: true при подтверждении, false при отмене или закрытии диалога.
+func showConfigDiffDialog(window fyne.Window, diff *config.ConfigDiff, decide func(approved bool)) {
+	decided := false
+	finish := func(approved bool) {
+		if decided {
+			return
+		}
+		decided = true
+		decide(approved)
+	}
+
+	summary := widget.NewLabel(formatConfigDiffSummary(diff))
+	summary.Wrapping = fyne.TextWrapWord
+
+	details := widget.NewLabel(formatConfigDiffDetails(diff))
+	details.TextStyle = fyne.TextStyle{Monospace: true}
+	detailsScroll := container.NewVScroll(details)
+	detailsScroll.SetMinSize(fyne.NewSize(560, 320))
+
+	var d dialog.Dialog
+	applyButton := widget.NewButton("✅ Apply", func() {
+		finish(true)
+		d.Hide()
+	})
+	applyButton.Importance = widget.HighImportance
+
+	content := container.NewBorder(summary, nil, nil, nil, detailsScroll)
+	d = components.NewCustom("Update preview", content, applyButton, "Cancel", window)
+	d.SetOnClosed(func() { finish(false) })
+	d.Show()
+}
+
+// formatConfigDiffSummary формирует краткую сводку изменений
+func formatConfigDiffSummary(diff *config.ConfigDiff) string {
+	if diff.IsEmpty() {
+		return "No changes: the generated outbounds are identical to the current configuration."
+	}
+	return fmt.Sprintf("Nodes: %d → %d (%d removed, %.0f%%)\nOutbounds: +%d / -%d, selectors changed: %d",
+		diff.NodesBefore, diff.NodesAfter, diff.NodesRemoved, diff.RemovedNodesPercent(),
+		len(diff.OutboundsAdded), len(diff.OutboundsRemoved), len(diff.SelectorsChanged))
+}
+
+// formatConfigDiffDetails формирует подробный список изменений
+func formatConfigDiffDetails(diff *config.ConfigDiff) string {
+	var b strings.Builder
+	if len(diff.OutboundsAdded) > 0 {
+		b.WriteString("Added outbounds\n")
+		for _, tag := range diff.OutboundsAdded {
+			fmt.Fprintf(&b, "   + %s\n", tag)
+		}
+	}
+	if len(diff.OutboundsRemoved) > 0 {
+		b.WriteString("Removed outbounds\n")
+		for _, tag := range diff.OutboundsRemoved {
+			fmt.Fprintf(&b, "   - %s\n", tag)
+		}
+	}
+	if len(diff.SelectorsChanged) > 0 {
+		b.WriteString("Selector membership\n")
+		for _, change := range diff.SelectorsChanged {
+			fmt.Fprintf(&b, "   %s\n", change.Tag)
+			for _, tag := range change.Added {
+				fmt.Fprintf(&b, "      + %s\n", tag)
+			}
+			for _, tag := range change.Removed {
+				fmt.Fprintf(&b, "      - %s\n", tag)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,207 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"singbox-launcher/core"
+	"singbox-launcher/core/config"
+	"singbox-launcher/internal/debuglog"
+	"singbox-launcher/internal/dialogs"
+	"singbox-launcher/ui/components"
+)
+
+// currentConfigOption - пункт выбора для сравнения с текущим config.json (может отличаться от снимков после ручных правок)
+const currentConfigOption = "Current config.json"
+
+// diffContextLines - сколько неизменённых строк показывать вокруг изменений
+const diffContextLines = 2
+
+// showConfigHistoryDialog показывает историю версий config.json: сравнение двух версий и восстановление
+func showConfigHistoryDialog(ac *core.AppController) {
+	window := ac.GetMainWindow()
+	configPath := ac.FileService.ConfigPath()
+
+	entries, err := config.ListConfigHistory(configPath)
+	if err != nil {
+		debuglog.WarnLog("showConfigHistoryDialog: failed to load history: %v", err)
+		dialogs.ShowError(window, fmt.Errorf("failed to load config history: %w", err))
+		return
+	}
+	if len(entries) == 0 {
+		dialogs.ShowInfo(window, "Config history", "No config versions recorded yet. A version is saved on every wizard save and subscription update.")
+		return
+	}
+
+	labels := make([]string, len(entries))
+	byLabel := make(map[string]config.HistoryEntry, len(entries))
+	for i, entry := range entries {
+		labels[i] = formatHistoryEntry(entry)
+		byLabel[labels[i]] = entry
+	}
+
+	selected := -1
+	list := widget.NewList(
+		func() int { return len(entries) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.TextStyle = fyne.TextStyle{Monospace: true}
+			return label
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(labels[id])
+		},
+	)
+	listScroll := container.NewVScroll(list)
+	listScroll.SetMinSize(fyne.NewSize(600, 260))
+
+	fromSelect := widget.NewSelect(labels, nil)
+	toSelect := widget.NewSelect(append([]string{currentConfigOption}, labels...), nil)
+	toSelect.SetSelected(currentConfigOption)
+	if len(labels) > 0 {
+		fromSelect.SetSelected(labels[0])
+	}
+
+	readVersion := func(option string) ([]byte, error) {
+		if option == currentConfigOption {
+			return os.ReadFile(configPath)
+		}
+		entry, ok := byLabel[option]
+		if !ok {
+			return nil, fmt.Errorf("select a version")
+		}
+		return config.ReadConfigSnapshot(configPath, entry.ID)
+	}
+
+	diffButton := widget.NewButton("Show diff", func() {
+		from, err := readVersion(fromSelect.Selected)
+		if err != nil {
+			dialogs.ShowError(window, err)
+			return
+		}
+		to, err := readVersion(toSelect.Selected)
+		if err != nil {
+			dialogs.ShowError(window, err)
+			return
+		}
+		showTextDiffDialog(window, fromSelect.Selected, toSelect.Selected, config.DiffLines(string(from), string(to)))
+	})
+
+	var d dialog.Dialog
+	restoreButton := widget.NewButton("⏪ Restore selected", func() {
+		if selected < 0 {
+			dialogs.ShowInfo(window, "Config history", "Select a version in the list first.")
+			return
+		}
+		entry := entries[selected]
+		message := fmt.Sprintf("Restore config.json from %s?", entry.Time.Local().Format("2006-01-02 15:04:05"))
+		if ac.RunningState.IsRunning() {
+			message += "\nSing-box is running and will be restarted."
+		}
+		dialogs.ShowConfirm(window, "Restore config", message, func(ok bool) {
+			if !ok {
+				return
+			}
+			d.Hide()
+			go func() {
+				if err := ac.ConfigService.RestoreConfigSnapshot(entry.ID); err != nil {
+					debuglog.ErrorLog("showConfigHistoryDialog: restore failed: %v", err)
+					dialogs.ShowError(window, err)
+					return
+				}
+				dialogs.ShowAutoHideInfo(ac.UIService.Application, window, "Config history", "Config restored.")
+			}()
+		})
+	})
+	restoreButton.Importance = widget.HighImportance
+
+	list.OnSelected = func(id widget.ListItemID) { selected = id }
+
+	compareRow := container.NewBorder(nil, nil, nil, diffButton,
+		container.NewGridWithColumns(2,
+			container.NewBorder(nil, nil, widget.NewLabel("From"), nil, fromSelect),
+			container.NewBorder(nil, nil, widget.NewLabel("To"), nil, toSelect),
+		))
+	content := container.NewBorder(nil, compareRow, nil, nil, listScroll)
+
+	d = components.NewCustom("Config history", content, restoreButton, "Close", window)
+	d.Show()
+}
+
+// formatHistoryEntry формирует строку версии для списка
+func formatHistoryEntry(entry config.HistoryEntry) string {
+	return fmt.Sprintf("%s  %-8s %5d nodes  %s  %s",
+		entry.Time.Local().Format("2006-01-02 15:04:05"), entry.Trigger, entry.NodesCount,
+		shortSHA(entry.SHA256), formatSize(entry.Size))
+}
+
+// shortSHA возвращает первые 8 символов хэша
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
+
+// formatSize форматирует размер файла
+func formatSize(size int64) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f KB", float64(size)/1024)
+}
+
+// showTextDiffDialog показывает построчный diff двух версий
+func showTextDiffDialog(window fyne.Window, fromTitle, toTitle string, lines []config.DiffLine) {
+	text := formatLineDiff(lines, diffContextLines)
+	if text == "" {
+		text = "Versions are identical."
+	}
+	label := widget.NewLabel(text)
+	label.TextStyle = fyne.TextStyle{Monospace: true}
+	scroll := container.NewScroll(label)
+	scroll.SetMinSize(fyne.NewSize(700, 420))
+
+	header := widget.NewLabel(fmt.Sprintf("- %s\n+ %s", fromTitle, toTitle))
+	content := container.NewBorder(header, nil, nil, nil, scroll)
+	components.NewCustom("Config diff", content, nil, "Close", window).Show()
+}
+
+// formatLineDiff оставляет только изменённые строки с context строками вокруг; пропуски отмечаются "..."
+func formatLineDiff(lines []config.DiffLine, context int) string {
+	keep := make([]bool, len(lines))
+	for i, line := range lines {
+		if line.Op == ' ' {
+			continue
+		}
+		for j := i - context; j <= i+context; j++ {
+			if j >= 0 && j < len(lines) {
+				keep[j] = true
+			}
+		}
+	}
+
+	var b strings.Builder
+	skipped := false
+	for i, line := range lines {
+		if !keep[i] {
+			skipped = true
+			continue
+		}
+		if skipped && b.Len() > 0 {
+			b.WriteString("...\n")
+		}
+		skipped = false
+		b.WriteByte(line.Op)
+		b.WriteString(" ")
+		b.WriteString(line.Text)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
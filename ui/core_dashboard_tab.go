@@ -20,6 +20,7 @@ import (
 	"fyne.io/fyne/v2/widget"
 
 	"singbox-launcher/core"
+	"singbox-launcher/core/config"
 	"singbox-launcher/core/config/parser"
 	"singbox-launcher/internal/debuglog"
 	"singbox-launcher/internal/dialogs"
@@ -49,10 +50,16 @@ type CoreDashboardTab struct {
 	wintunDownloadProgress    *widget.ProgressBar // Progress bar for wintun.dll download
 	wintunDownloadContainer   fyne.CanvasObject   // Container for wintun button/progress bar
 	wintunDownloadPlaceholder *canvas.Rectangle   // keeps width when button hidden
+	profileSelect             *widget.Select      // Активный профиль конфигурации
+	deleteProfileButton       *widget.Button      // Удаление выбранного (неактивного) профиля
+	instancesBox              *fyne.Container     // Профили, запущенные рядом с активным
 	configStatusLabel         *widget.Button      // Используем Button для возможности клика
 	templateDownloadButton    *widget.Button
 	wizardButton              *widget.Button
 	updateConfigButton        *widget.Button
+	previewUpdateButton       *widget.Button      // Обновление с превью изменений
+	lastUpdateButton          *widget.Button      // Отчёт последнего обновления подписок
+	historyButton             *widget.Button      // История версий config.json
 	parserProgressBar         *widget.ProgressBar // Progress bar for parser
 	parserStatusLabel         *widget.Label       // Status label for parser
 
@@ -180,6 +187,20 @@ func CreateCoreDashboardTab(ac *core.AppController) fyne.CanvasObject {
 
 	// Регистрируем callback для показа попапа обновления
 	tab.controller.UIService.ShowUpdatePopupFunc = tab.showUpdatePopup
+	tab.controller.UIService.ConfirmConfigDiffFunc = func(diff *config.ConfigDiff, decide func(approved bool)) {
+		fyne.Do(func() {
+			showConfigDiffDialog(tab.controller.GetMainWindow(), diff, decide)
+		})
+	}
+	tab.controller.UIService.ResolvePortConflictsFunc = func(conflicts []config.PortConflict, decide func(action config.PortConflictAction)) {
+		fyne.Do(func() {
+			showPortConflictDialog(tab.controller.GetMainWindow(), conflicts, decide)
+		})
+	}
+	// Источники из командной строки (в том числе переданные вторым запуском) добавляются через визард
+	tab.controller.UIService.ImportSourceFunc = func(source, name string) {
+		wizard.ImportSource(tab.controller.GetMainWindow(), source, name)
+	}
 
 	return content
 }
@@ -265,6 +286,12 @@ func (tab *CoreDashboardTab) createConfigBlock() fyne.CanvasObject {
 	})
 	tab.updateConfigButton.Importance = widget.MediumImportance
 
+	// Кнопка Preview: обновление с просмотром изменений и подтверждением перед записью
+	tab.previewUpdateButton = widget.NewButton("🔍 Preview", func() {
+		go core.RunParserPreviewProcess()
+	})
+	tab.previewUpdateButton.Importance = widget.LowImportance
+
 	tab.wizardButton = widget.NewButton("⚙️ Wizard", func() {
 		// Get parent window from AppController
 		ac := core.GetController()
@@ -273,6 +300,16 @@ func (tab *CoreDashboardTab) createConfigBlock() fyne.CanvasObject {
 	})
 	tab.wizardButton.Importance = widget.MediumImportance
 
+	tab.lastUpdateButton = widget.NewButton("📋 Last update", func() {
+		showParseReportDialog(tab.controller.GetMainWindow(), tab.controller.FileService.ConfigPath())
+	})
+	tab.lastUpdateButton.Importance = widget.LowImportance
+
+	tab.historyButton = widget.NewButton("🗂 History", func() {
+		showConfigHistoryDialog(tab.controller)
+	})
+	tab.historyButton.Importance = widget.LowImportance
+
 	tab.templateDownloadButton = widget.NewButton("Download Config Template", func() {
 		tab.downloadConfigTemplate()
 	})
@@ -282,6 +319,9 @@ func (tab *CoreDashboardTab) createConfigBlock() fyne.CanvasObject {
 	tab.wizardButton.Hide()
 	tab.templateDownloadButton.Hide()
 
+	profileRow := tab.createProfileRow()
+	tab.instancesBox = container.NewVBox()
+
 	// Строка со статусом
 	statusRow := container.NewHBox(
 		title,
@@ -293,6 +333,9 @@ func (tab *CoreDashboardTab) createConfigBlock() fyne.CanvasObject {
 	buttonsRow := container.NewCenter(
 		container.NewHBox(
 			tab.updateConfigButton, // Кнопка Update
+			tab.previewUpdateButton,
+			tab.lastUpdateButton,
+			tab.historyButton,
 			tab.wizardButton,
 			tab.templateDownloadButton,
 		),
@@ -305,12 +348,157 @@ func (tab *CoreDashboardTab) createConfigBlock() fyne.CanvasObject {
 	)
 
 	return container.NewVBox(
+		profileRow,
+		tab.instancesBox, // Дополнительные экземпляры sing-box
 		statusRow,
 		buttonsRow,
 		parserProgressRow, // Прогрессбар и статус парсера в отдельной строке
 	)
 }
 
+// createProfileRow создает строку выбора профиля конфигурации (свой config.json, ParserConfig и состояние визарда).
+// Переключение останавливает запущенный sing-box и запускает его снова с конфигом выбранного профиля.
+func (tab *CoreDashboardTab) createProfileRow() fyne.CanvasObject {
+	title := widget.NewLabel("Profile")
+
+	tab.profileSelect = widget.NewSelect(tab.controller.Profiles(), func(name string) {
+		// SetSelected из updateConfigInfo тоже вызывает OnChanged, активный профиль пропускаем
+		if name == "" || name == tab.controller.ActiveProfile() {
+			return
+		}
+		tab.switchProfile(name)
+	})
+	tab.profileSelect.SetSelected(tab.controller.ActiveProfile())
+
+	newButton := widget.NewButton("➕", func() {
+		showNewProfileDialog(tab.controller, func(name string) {
+			tab.profileSelect.SetOptions(tab.controller.Profiles())
+			tab.switchProfile(name)
+		})
+	})
+	newButton.Importance = widget.LowImportance
+
+	// Запуск другого профиля рядом с активным (например, SOCKS-профиль рядом с TUN)
+	alongsideButton := widget.NewButton("▶➕", func() {
+		showRunAlongsideDialog(tab.controller, tab.updateInstancesRow)
+	})
+	alongsideButton.Importance = widget.LowImportance
+
+	tab.deleteProfileButton = widget.NewButton("🗑", func() {
+		tab.confirmDeleteProfile()
+	})
+	tab.deleteProfileButton.Importance = widget.LowImportance
+	tab.deleteProfileButton.Hide()
+
+	return container.NewHBox(
+		title,
+		layout.NewSpacer(),
+		tab.profileSelect,
+		newButton,
+		alongsideButton,
+		tab.deleteProfileButton,
+	)
+}
+
+// switchProfile переключает профиль в фоне; при ошибке выбор возвращается к активному профилю
+func (tab *CoreDashboardTab) switchProfile(name string) {
+	tab.profileSelect.Disable()
+	go func() {
+		err := tab.controller.SwitchProfile(name)
+		fyne.Do(func() {
+			tab.profileSelect.Enable()
+			if err != nil {
+				debuglog.ErrorLog("CoreDashboard: Failed to switch profile: %v", err)
+				dialogs.ShowError(tab.controller.GetMainWindow(), err)
+			}
+			tab.updateConfigInfo()
+		})
+	}()
+}
+
+// confirmDeleteProfile удаляет профили, кроме активного и профиля по умолчанию, после подтверждения.
+// Удаляется весь каталог профиля: config.json, история версий и состояние визарда.
+func (tab *CoreDashboardTab) confirmDeleteProfile() {
+	var deletable []string
+	for _, name := range tab.controller.Profiles() {
+		if name != config.DefaultProfileName && name != tab.controller.ActiveProfile() {
+			deletable = append(deletable, name)
+		}
+	}
+	if len(deletable) == 0 {
+		return
+	}
+
+	profileSelect := widget.NewSelect(deletable, nil)
+	profileSelect.SetSelected(deletable[0])
+	message := widget.NewLabel("The profile's config.json, config history and wizard state are deleted.")
+	message.Wrapping = fyne.TextWrapWord
+
+	var d dialog.Dialog
+	deleteButton := widget.NewButton("Delete", func() {
+		name := profileSelect.Selected
+		d.Hide()
+		if err := tab.controller.DeleteProfile(name); err != nil {
+			dialogs.ShowError(tab.controller.GetMainWindow(), err)
+		}
+		tab.updateConfigInfo()
+	})
+	deleteButton.Importance = widget.DangerImportance
+
+	d = components.NewCustom("Delete profile", container.NewVBox(profileSelect, message), deleteButton, "Cancel", tab.controller.GetMainWindow())
+	d.Resize(fyne.NewSize(360, 0))
+	d.Show()
+}
+
+// updateProfileRow обновляет список профилей и активный профиль
+func (tab *CoreDashboardTab) updateProfileRow() {
+	if tab.profileSelect == nil {
+		return
+	}
+	profiles := tab.controller.Profiles()
+	tab.profileSelect.SetOptions(profiles)
+	tab.profileSelect.SetSelected(tab.controller.ActiveProfile())
+	// Удалить можно только неактивный именованный профиль
+	if len(profiles) > 2 || (len(profiles) == 2 && tab.controller.ActiveProfile() == config.DefaultProfileName) {
+		tab.deleteProfileButton.Show()
+	} else {
+		tab.deleteProfileButton.Hide()
+	}
+}
+
+// updateInstancesRow показывает профили, запущенные рядом с активным, со статусом и кнопкой остановки
+func (tab *CoreDashboardTab) updateInstancesRow() {
+	if tab.instancesBox == nil {
+		return
+	}
+	tab.instancesBox.RemoveAll()
+	for _, inst := range tab.controller.Instances()[1:] {
+		profile := inst.Profile()
+		status := "⏸️ Stopped"
+		if inst.RunningState.IsRunning() {
+			status = "✅ Running"
+		} else if backoff, pending := inst.ProcessService.PendingRestart(); pending {
+			status = "⏳ Crashed, " + backoff.String()
+		}
+		stopButton := widget.NewButton("⏹", func() {
+			go func() {
+				if err := tab.controller.StopInstance(profile); err != nil {
+					debuglog.ErrorLog("CoreDashboard: Failed to stop profile %q: %v", profile, err)
+					fyne.Do(func() { dialogs.ShowError(tab.controller.GetMainWindow(), err) })
+				}
+			}()
+		})
+		stopButton.Importance = widget.LowImportance
+		tab.instancesBox.Add(container.NewHBox(
+			widget.NewLabel("Alongside: "+profile),
+			layout.NewSpacer(),
+			widget.NewLabel(status),
+			stopButton,
+		))
+	}
+	tab.instancesBox.Refresh()
+}
+
 // createVersionBlock creates a block with version (similar to wintun)
 func (tab *CoreDashboardTab) createVersionBlock() fyne.CanvasObject {
 	title := widget.NewLabel("Sing-box")
@@ -469,8 +657,14 @@ func (tab *CoreDashboardTab) updateRunningStatus() {
 	// Update status label based on state
 	restartInfo := ""
 	if tab.controller.ConsecutiveCrashAttempts > 0 {
-		restartInfo = fmt.Sprintf(" [restart %d/%d]", tab.controller.ConsecutiveCrashAttempts, 3)
+		if maxAttempts := tab.controller.RestartPolicy().MaxAttempts; maxAttempts > 0 {
+			restartInfo = fmt.Sprintf(" [restart %d/%d]", tab.controller.ConsecutiveCrashAttempts, maxAttempts)
+		} else {
+			restartInfo = fmt.Sprintf(" [restart %d]", tab.controller.ConsecutiveCrashAttempts)
+		}
 	}
+	backoff, restartPending := tab.controller.ProcessService.PendingRestart()
+	tab.updateInstancesRow()
 
 	if !buttonState.BinaryExists {
 		tab.statusLabel.SetText("Core Status ❌ Error: sing-box not found" + restartInfo)
@@ -478,6 +672,9 @@ func (tab *CoreDashboardTab) updateRunningStatus() {
 	} else if buttonState.IsRunning {
 		tab.statusLabel.SetText("Core Status ✅ Running" + restartInfo)
 		tab.statusLabel.Importance = widget.MediumImportance // Текст всегда черный
+	} else if restartPending {
+		tab.statusLabel.SetText("Core Status ⏳ Crashed, " + backoff.String())
+		tab.statusLabel.Importance = widget.MediumImportance // Текст всегда черный
 	} else {
 		tab.statusLabel.SetText("Core Status ⏸️ Stopped" + restartInfo)
 		tab.statusLabel.Importance = widget.MediumImportance // Текст всегда черный
@@ -516,7 +713,7 @@ func (tab *CoreDashboardTab) readConfigOnDemand() {
 	}
 
 	// Читаем конфиг
-	config, err := parser.ExtractParserConfig(tab.controller.FileService.ConfigPath)
+	config, err := parser.ExtractParserConfig(tab.controller.FileService.ConfigPath())
 	if err != nil {
 		debuglog.ErrorLog("CoreDashboard: Failed to read config on demand: %v", err)
 		// Можно показать сообщение пользователю через dialog
@@ -536,10 +733,12 @@ func (tab *CoreDashboardTab) updateConfigInfo() {
 		tab.updateWintunStatus()
 	}
 
+	tab.updateProfileRow()
+
 	if tab.configStatusLabel == nil {
 		return
 	}
-	configPath := tab.controller.FileService.ConfigPath
+	configPath := tab.controller.FileService.ConfigPath()
 	configExists := false
 	if info, err := os.Stat(configPath); err == nil {
 		modTime := info.ModTime().Format("2006-01-02")
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"singbox-launcher/core"
+	"singbox-launcher/internal/dialogs"
+	"singbox-launcher/ui/components"
+)
+
+// showCrashHistoryDialog показывает падения sing-box за время работы лаунчера: код выхода,
+// решение политики перезапуска, объяснение ошибки и последние строки вывода
+func showCrashHistoryDialog(ac *core.AppController) {
+	window := ac.GetMainWindow()
+	crashes := ac.ProcessService.CrashHistory()
+	if len(crashes) == 0 {
+		dialogs.ShowInfo(window, "Crash history", "Sing-Box has not crashed since the launcher was started.")
+		return
+	}
+
+	text := formatCrashHistory(crashes)
+	label := widget.NewLabel(text)
+	label.TextStyle = fyne.TextStyle{Monospace: true}
+	scroll := container.NewVScroll(label)
+	scroll.SetMinSize(fyne.NewSize(700, 360))
+
+	copyButton := widget.NewButton("Copy", func() {
+		window.Clipboard().SetContent(text)
+		dialogs.ShowAutoHideInfo(ac.UIService.Application, window, "Copied", "Crash history copied to clipboard.")
+	})
+
+	components.NewCustom("Crash history", scroll, copyButton, "Close", window).Show()
+}
+
+// formatCrashHistory формирует текст истории падений, последние сверху
+func formatCrashHistory(crashes []core.CrashRecord) string {
+	var b strings.Builder
+	for i := len(crashes) - 1; i >= 0; i-- {
+		crash := crashes[i]
+		fmt.Fprintf(&b, "%s  PID %d  exit code %d  attempt %d  %s\n",
+			crash.Time.Local().Format("2006-01-02 15:04:05"), crash.PID, crash.ExitCode, crash.Attempt, crash.Action)
+		if crash.Error != "" {
+			fmt.Fprintf(&b, "  %s\n", crash.Error)
+		}
+		if crash.Diagnosis != "" {
+			fmt.Fprintf(&b, "  %s\n", crash.Diagnosis)
+		}
+		for _, line := range crash.Output {
+			fmt.Fprintf(&b, "  | %s\n", line)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
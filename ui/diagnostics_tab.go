@@ -14,6 +14,7 @@ import (
 	"github.com/txthinking/socks5"
 
 	"singbox-launcher/core"
+	"singbox-launcher/core/config"
 	"singbox-launcher/internal/constants"
 	"singbox-launcher/internal/debuglog"
 	"singbox-launcher/internal/platform"
@@ -170,5 +171,119 @@ func CreateDiagnosticsTab(ac *core.AppController) fyne.CanvasObject {
 		openBrowserButton("Yandex Internet", "https://yandex.ru/internet/"),
 		openBrowserButton("SpeedTest", "https://www.speedtest.net/"),
 		openBrowserButton("WhatIsMyIPAddress", "https://whatismyipaddress.com"),
+		widget.NewSeparator(),
+		createRestartPolicySection(ac),
+		widget.NewSeparator(),
+		createControlAPISection(ac),
+		createDeepLinkSection(ac),
+	)
+}
+
+// createRestartPolicySection creates the crash restart mode switch and the crash history button.
+// Attempts, delays and the reset window are set in launcher_state.json ("restart_policy").
+func createRestartPolicySection(ac *core.AppController) fyne.CanvasObject {
+	policyLabel := widget.NewLabel("")
+	policyLabel.Wrapping = fyne.TextWrapWord
+	refresh := func() {
+		policy := ac.RestartPolicy()
+		policyLabel.SetText(fmt.Sprintf("Policy: %s (reset after %v of stable run)", policy, policy.ResetAfter))
+	}
+
+	modeSelect := widget.NewSelect([]string{config.RestartModeOnFailure, config.RestartModeAlways, config.RestartModeNever}, nil)
+	modeSelect.SetSelected(ac.RestartPolicy().Mode)
+	modeSelect.OnChanged = func(mode string) {
+		if err := ac.SetRestartMode(mode); err != nil {
+			debuglog.ErrorLog("diagnosticsTab: Failed to save restart policy: %v", err)
+			ShowError(ac.UIService.MainWindow, err)
+		}
+		refresh()
+	}
+	refresh()
+
+	return container.NewVBox(
+		widget.NewLabel("Crash Restart:"),
+		container.NewBorder(nil, nil, widget.NewLabel("Mode"), nil, modeSelect),
+		policyLabel,
+		widget.NewButton("Crash history", func() { showCrashHistoryDialog(ac) }),
+	)
+}
+
+// createDeepLinkSection creates the registration of the launcher as the handler of sing-box:// and clash:// links.
+// Registration is implemented only on Linux; on other platforms the section is empty.
+func createDeepLinkSection(ac *core.AppController) fyne.CanvasObject {
+	if !ac.DeepLinkHandler().Supported {
+		return container.NewVBox()
+	}
+
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+	refresh := func() {
+		status := ac.DeepLinkHandler()
+		switch {
+		case status.Registered && status.Current:
+			statusLabel.SetText("Registered: sing-box:// and clash:// links open the Config Wizard with the subscription added.")
+		case status.Registered:
+			statusLabel.SetText(fmt.Sprintf("Registered for another copy of the launcher: %s", status.ExecPath))
+		default:
+			statusLabel.SetText("Not registered. Register to import subscriptions from sing-box:// and clash:// links in the browser.")
+		}
+	}
+
+	registerButton := widget.NewButton("Register link handler", func() {
+		path, err := ac.RegisterDeepLinkHandler()
+		if err != nil {
+			debuglog.ErrorLog("diagnosticsTab: Failed to register link handler: %v", err)
+			ShowError(ac.UIService.MainWindow, err)
+			return
+		}
+		refresh()
+		ShowAutoHideInfo(ac.UIService.Application, ac.UIService.MainWindow, "Link Handler", fmt.Sprintf("Desktop entry written to %s", path))
+	})
+	refresh()
+
+	return container.NewVBox(
+		widget.NewSeparator(),
+		widget.NewLabel("Subscription Links:"),
+		statusLabel,
+		registerButton,
+	)
+}
+
+// createControlAPISection creates the opt-in switch of the local control API with its address and token.
+func createControlAPISection(ac *core.AppController) fyne.CanvasObject {
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+
+	copyTokenButton := widget.NewButton("Copy token", func() {
+		ac.UIService.MainWindow.Clipboard().SetContent(ac.ControlAPISettings().Token)
+		ShowAutoHideInfo(ac.UIService.Application, ac.UIService.MainWindow, "Copied", "Control API token copied to clipboard.")
+	})
+
+	refresh := func() {
+		if address := ac.ControlAPIAddress(); address != "" {
+			statusLabel.SetText(fmt.Sprintf("Listening on %s (Authorization: Bearer <token>)", address))
+			copyTokenButton.Enable()
+		} else {
+			statusLabel.SetText("Disabled. Lets scripts query status, start/stop sing-box, update subscriptions and switch proxies.")
+			copyTokenButton.Disable()
+		}
+	}
+
+	enableCheck := widget.NewCheck("Enable local control API", nil)
+	enableCheck.SetChecked(ac.ControlAPISettings().Enabled)
+	enableCheck.OnChanged = func(enabled bool) {
+		if err := ac.SetControlAPIEnabled(enabled); err != nil {
+			debuglog.ErrorLog("diagnosticsTab: Failed to switch control API: %v", err)
+			ShowError(ac.UIService.MainWindow, err)
+		}
+		refresh()
+	}
+	refresh()
+
+	return container.NewVBox(
+		widget.NewLabel("Local Control API:"),
+		enableCheck,
+		statusLabel,
+		copyTokenButton,
 	)
 }
@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"singbox-launcher/core/config"
+	"singbox-launcher/internal/debuglog"
+	"singbox-launcher/internal/dialogs"
+	"singbox-launcher/ui/components"
+)
+
+// showParseReportDialog показывает диалог "Last update" с отчётом последнего обновления подписок
+func showParseReportDialog(window fyne.Window, configPath string) {
+	reportPath := config.ParseReportPath(configPath)
+	report, err := config.LoadParseReport(reportPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			dialogs.ShowInfo(window, "Last update", "No update report yet. Press \"Update\" to refresh subscriptions.")
+			return
+		}
+		debuglog.WarnLog("showParseReportDialog: failed to load report %s: %v", reportPath, err)
+		dialogs.ShowError(window, fmt.Errorf("failed to load update report: %w", err))
+		return
+	}
+
+	summary := widget.NewLabel(formatParseReportSummary(report))
+	summary.Wrapping = fyne.TextWrapWord
+
+	details := widget.NewLabel(formatParseReportDetails(report))
+	details.TextStyle = fyne.TextStyle{Monospace: true}
+	detailsScroll := container.NewVScroll(details)
+	detailsScroll.SetMinSize(fyne.NewSize(560, 320))
+
+	historyButton := widget.NewButton("🕘 Node history", func() {
+		showNodeHistoryDialog(window, configPath)
+	})
+
+	content := container.NewBorder(summary, nil, nil, nil, detailsScroll)
+	d := components.NewCustom("Last update", content, historyButton, "Close", window)
+	d.Show()
+}
+
+// showNodeHistoryDialog показывает хронологию изменений узлов (добавленные/удалённые/изменённые) по источникам
+func showNodeHistoryDialog(window fyne.Window, configPath string) {
+	historyPath := config.NodeHistoryPath(configPath)
+	history, err := config.LoadNodeHistory(historyPath)
+	if err != nil && !os.IsNotExist(err) {
+		debuglog.WarnLog("showNodeHistoryDialog: failed to load history %s: %v", historyPath, err)
+		dialogs.ShowError(window, fmt.Errorf("failed to load node history: %w", err))
+		return
+	}
+	if history == nil || len(history.Timeline) == 0 {
+		dialogs.ShowInfo(window, "Node history", "No node changes recorded yet.")
+		return
+	}
+
+	timeline := widget.NewLabel(formatNodeTimeline(history.Timeline))
+	timeline.TextStyle = fyne.TextStyle{Monospace: true}
+	scroll := container.NewVScroll(timeline)
+	scroll.SetMinSize(fyne.NewSize(560, 360))
+
+	d := components.NewCustom("Node history", scroll, nil, "Close", window)
+	d.Show()
+}
+
+// formatNodeTimeline формирует текст хронологии изменений узлов (новые события сверху)
+func formatNodeTimeline(timeline []config.ChurnEvent) string {
+	var b strings.Builder
+	for i := len(timeline) - 1; i >= 0; i-- {
+		event := timeline[i]
+		source := event.URLHash
+		if event.Name != "" {
+			source = event.Name
+		}
+		fmt.Fprintf(&b, "%s  source #%d %s\n", event.Time.Local().Format("2006-01-02 15:04"), event.Source, source)
+		writeNodeChurn(&b, &event.NodeChurn)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// writeNodeChurn выводит изменения узлов источника построчно
+func writeNodeChurn(b *strings.Builder, churn *config.NodeChurn) {
+	for _, tag := range churn.Added {
+		fmt.Fprintf(b, "   + %s\n", tag)
+	}
+	for _, tag := range churn.Removed {
+		fmt.Fprintf(b, "   - %s\n", tag)
+	}
+	for _, change := range churn.Changed {
+		if change.NewEndpoint != "" {
+			fmt.Fprintf(b, "   ~ %s (%s: %s -> %s)\n", change.Tag, strings.Join(change.Fields, ", "), change.OldEndpoint, change.NewEndpoint)
+		} else {
+			fmt.Fprintf(b, "   ~ %s (%s)\n", change.Tag, strings.Join(change.Fields, ", "))
+		}
+	}
+}
+
+// formatParseReportSummary формирует краткую сводку отчёта
+func formatParseReportSummary(report *config.ParseReport) string {
+	status := "✅ Success"
+	if !report.Success {
+		status = "❌ Failed: " + report.Error
+	}
+	if report.RolledBack {
+		status += "\nThe new config did not pass sing-box check, the previous config.json was kept."
+	}
+	validSelectors := 0
+	for _, sel := range report.Selectors {
+		if sel.Valid {
+			validSelectors++
+		}
+	}
+	summary := fmt.Sprintf("%s\n%s (took %s)\nNodes: %d, sources: %d, selectors: %d/%d valid",
+		status,
+		report.FinishedAt.Local().Format("2006-01-02 15:04:05"),
+		(time.Duration(report.DurationMs) * time.Millisecond).String(),
+		report.NodesCount, len(report.Sources), validSelectors, len(report.Selectors))
+	if added, removed, changed := report.TotalChurn(); added+removed+changed > 0 {
+		summary += fmt.Sprintf("\nNode changes: %d added, %d removed, %d changed", added, removed, changed)
+	}
+	return summary
+}
+
+// formatParseReportDetails формирует подробный текст отчёта по источникам и селекторам
+func formatParseReportDetails(report *config.ParseReport) string {
+	var b strings.Builder
+
+	b.WriteString("Sources\n")
+	for _, src := range report.Sources {
+		name := src.URLHash
+		if name == "" {
+			name = fmt.Sprintf("%d connection(s)", src.Connections)
+		}
+		if src.Name != "" {
+			name = fmt.Sprintf("%s (%s)", src.Name, name)
+		}
+		fmt.Fprintf(&b, "#%d %s\n", src.Index, name)
+		if src.Disabled {
+			b.WriteString("   disabled, not loaded\n")
+			continue
+		}
+		if src.FromCache {
+			fmt.Fprintf(&b, "   not due for reload, taken from cache (%d lines)\n", src.Lines)
+		} else if src.HTTPStatus != 0 || src.Bytes > 0 {
+			fmt.Fprintf(&b, "   HTTP %d, %d bytes, %d lines, %s\n",
+				src.HTTPStatus, src.Bytes, src.Lines, (time.Duration(src.DurationMs) * time.Millisecond).String())
+		}
+		fmt.Fprintf(&b, "   parsed %d, skipped %d, over limit %d, failed %d, renamed duplicates %d\n",
+			src.Parsed, src.Skipped, src.SkippedByLimit, src.Failed, src.Dedups)
+		if src.Error != "" {
+			fmt.Fprintf(&b, "   error: %s\n", src.Error)
+		}
+		for _, problem := range src.OverrideErrors {
+			fmt.Fprintf(&b, "   ignored %s\n", problem)
+		}
+		if src.Churn != nil {
+			fmt.Fprintf(&b, "   changes since previous update: %d added, %d removed, %d changed\n",
+				len(src.Churn.Added), len(src.Churn.Removed), len(src.Churn.Changed))
+			writeNodeChurn(&b, src.Churn)
+		}
+		reasons := make([]string, 0, len(src.FailReasons))
+		for reason := range src.FailReasons {
+			reasons = append(reasons, reason)
+		}
+		sort.Strings(reasons)
+		for _, reason := range reasons {
+			fmt.Fprintf(&b, "   ✗ %s: %d\n", reason, src.FailReasons[reason])
+		}
+	}
+
+	b.WriteString("\nSelectors\n")
+	for _, sel := range report.Selectors {
+		mark := "✓"
+		if !sel.Valid {
+			mark = "✗ empty, skipped"
+		}
+		scope := "global"
+		if sel.Local {
+			scope = fmt.Sprintf("source #%d", sel.Source)
+		}
+		fmt.Fprintf(&b, "%s (%s, %s): %d nodes, %d outbounds %s\n",
+			sel.Tag, sel.Type, scope, sel.Nodes, sel.Outbounds, mark)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"singbox-launcher/core/config"
+	"singbox-launcher/ui/components"
+)
+
+// showPortConflictDialog показывает занятые порты inbound'ов перед запуском sing-box и предлагает
+// остановить программы, занявшие порты, или перенести inbound'ы на свободные порты.
+// decide вызывается ровно один раз; закрытие диалога — отмена запуска.
+func showPortConflictDialog(window fyne.Window, conflicts []config.PortConflict, decide func(action config.PortConflictAction)) {
+	decided := false
+	finish := func(action config.PortConflictAction) {
+		if decided {
+			return
+		}
+		decided = true
+		decide(action)
+	}
+
+	message := widget.NewLabel(formatPortConflicts(conflicts))
+	message.Wrapping = fyne.TextWrapWord
+
+	var d dialog.Dialog
+	killButton := widget.NewButton(killOwnersLabel(conflicts), func() {
+		finish(config.PortConflictKillOwner)
+		d.Hide()
+	})
+	// Остановить можно только известные программы: иначе sing-box всё равно не запустится
+	for _, conflict := range conflicts {
+		if conflict.OwnerPID == 0 {
+			killButton.Disable()
+		}
+	}
+
+	moveButton := widget.NewButton(movePortsLabel(conflicts), func() {
+		finish(config.PortConflictChangePort)
+		d.Hide()
+	})
+	moveButton.Importance = widget.HighImportance
+	for _, conflict := range conflicts {
+		if conflict.FreePort == 0 {
+			moveButton.Disable()
+		}
+	}
+
+	content := container.NewVBox(message)
+	d = components.NewCustom("Port in use", content, container.NewHBox(killButton, moveButton), "Cancel", window)
+	d.SetOnClosed(func() { finish(config.PortConflictCancel) })
+	d.Show()
+}
+
+// formatPortConflicts описывает занятые порты и последствия переноса
+func formatPortConflicts(conflicts []config.PortConflict) string {
+	var b strings.Builder
+	b.WriteString("Sing-Box cannot start: ")
+	if len(conflicts) == 1 {
+		b.WriteString("a port it listens on is already in use.\n\n")
+	} else {
+		b.WriteString("ports it listens on are already in use.\n\n")
+	}
+	for _, conflict := range conflicts {
+		fmt.Fprintf(&b, "• %s\n", conflict)
+	}
+	b.WriteString("\nStop the program, or move the inbound to a free port (config.json is changed; " +
+		"programs using the old port as a proxy need the new one).")
+	return b.String()
+}
+
+// killOwnersLabel возвращает подпись кнопки остановки программ, занявших порты
+func killOwnersLabel(conflicts []config.PortConflict) string {
+	if len(conflicts) == 1 && conflicts[0].OwnerPID != 0 {
+		return fmt.Sprintf("Stop %s", conflicts[0].OwnerName)
+	}
+	return "Stop programs"
+}
+
+// movePortsLabel возвращает подпись кнопки переноса inbound'ов на свободные порты
+func movePortsLabel(conflicts []config.PortConflict) string {
+	if len(conflicts) == 1 && conflicts[0].FreePort != 0 {
+		return fmt.Sprintf("Use port %d", conflicts[0].FreePort)
+	}
+	return "Use free ports"
+}
@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"singbox-launcher/core"
+	"singbox-launcher/internal/dialogs"
+	"singbox-launcher/ui/components"
+)
+
+// showNewProfileDialog запрашивает имя нового профиля. Профиль может начинаться с копии config.json
+// активного профиля, иначе конфиг создаётся визардом после переключения.
+// onCreated вызывается с именем созданного профиля.
+func showNewProfileDialog(ac *core.AppController, onCreated func(name string)) {
+	window := ac.GetMainWindow()
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("office, home, travel...")
+	copyCheck := widget.NewCheck(fmt.Sprintf("Copy config of profile %q", ac.ActiveProfile()), nil)
+	copyCheck.SetChecked(true)
+	errorLabel := widget.NewLabel("")
+	errorLabel.Importance = widget.DangerImportance
+	errorLabel.Hide()
+
+	var d dialog.Dialog
+	createButton := widget.NewButton("Create", func() {
+		name := strings.TrimSpace(nameEntry.Text)
+		// Ошибка (недопустимое имя, профиль уже есть) показывается в диалоге, чтобы можно было исправить имя
+		if err := ac.CreateProfile(name, copyCheck.Checked); err != nil {
+			errorLabel.SetText(err.Error())
+			errorLabel.Show()
+			return
+		}
+		d.Hide()
+		onCreated(name)
+	})
+	createButton.Importance = widget.HighImportance
+
+	content := container.NewVBox(
+		widget.NewLabel("Profile name:"),
+		nameEntry,
+		copyCheck,
+		errorLabel,
+	)
+	d = components.NewCustom("New profile", content, createButton, "Cancel", window)
+	d.Resize(fyne.NewSize(360, 0))
+	d.Show()
+	window.Canvas().Focus(nameEntry)
+}
+
+// showRunAlongsideDialog запускает другой профиль рядом с активным: свой sing-box со своим config.json,
+// логом и Clash API. Порты и TUN-интерфейсы профилей не должны совпадать, иначе запуск отклоняется.
+// onStarted вызывается после запуска.
+func showRunAlongsideDialog(ac *core.AppController, onStarted func()) {
+	window := ac.GetMainWindow()
+
+	running := make(map[string]bool)
+	for _, inst := range ac.Instances() {
+		if inst.RunningState.IsRunning() {
+			running[inst.Profile()] = true
+		}
+	}
+	var candidates []string
+	for _, name := range ac.Profiles() {
+		if !running[name] && name != ac.ActiveProfile() {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 0 {
+		dialogs.ShowInfo(window, "Run alongside", "There is no other profile to run. Create one with ➕ first.")
+		return
+	}
+
+	profileSelect := widget.NewSelect(candidates, nil)
+	profileSelect.SetSelected(candidates[0])
+	message := widget.NewLabel("The profile runs its own sing-box next to the active one. Its ports and TUN interface must differ from the running profiles.")
+	message.Wrapping = fyne.TextWrapWord
+	errorLabel := widget.NewLabel("")
+	errorLabel.Importance = widget.DangerImportance
+	errorLabel.Wrapping = fyne.TextWrapWord
+	errorLabel.Hide()
+
+	var d dialog.Dialog
+	startButton := widget.NewButton("Start", func() {
+		// Конфликт портов или TUN показывается в диалоге, чтобы можно было выбрать другой профиль
+		if err := ac.StartInstance(profileSelect.Selected); err != nil {
+			errorLabel.SetText(err.Error())
+			errorLabel.Show()
+			return
+		}
+		d.Hide()
+		onStarted()
+	})
+	startButton.Importance = widget.HighImportance
+
+	d = components.NewCustom("Run alongside", container.NewVBox(profileSelect, message, errorLabel), startButton, "Cancel", window)
+	d.Resize(fyne.NewSize(420, 0))
+	d.Show()
+}
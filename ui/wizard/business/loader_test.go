@@ -26,10 +26,7 @@ func TestSerializeParserConfig(t *testing.T) {
 					Version   int                     `json:"version,omitempty"`
 					Proxies   []config.ProxySource    `json:"proxies"`
 					Outbounds []config.OutboundConfig `json:"outbounds"`
-					Parser    struct {
-						Reload      string `json:"reload,omitempty"`
-						LastUpdated string `json:"last_updated,omitempty"`
-					} `json:"parser,omitempty"`
+					Parser    config.ParserSettings   `json:"parser,omitempty"`
 				}{
 					Version: 2,
 					Proxies: []config.ProxySource{
@@ -179,10 +176,7 @@ func TestEnsureRequiredOutbounds(t *testing.T) {
 			Version   int                     `json:"version,omitempty"`
 			Proxies   []config.ProxySource    `json:"proxies"`
 			Outbounds []config.OutboundConfig `json:"outbounds"`
-			Parser    struct {
-				Reload      string `json:"reload,omitempty"`
-				LastUpdated string `json:"last_updated,omitempty"`
-			} `json:"parser,omitempty"`
+			Parser    config.ParserSettings   `json:"parser,omitempty"`
 		}{
 			Version:   2,
 			Proxies:   []config.ProxySource{},
@@ -256,10 +250,7 @@ func TestEnsureRequiredOutbounds_Overwrite(t *testing.T) {
 			Version   int                     `json:"version,omitempty"`
 			Proxies   []config.ProxySource    `json:"proxies"`
 			Outbounds []config.OutboundConfig `json:"outbounds"`
-			Parser    struct {
-				Reload      string `json:"reload,omitempty"`
-				LastUpdated string `json:"last_updated,omitempty"`
-			} `json:"parser,omitempty"`
+			Parser    config.ParserSettings   `json:"parser,omitempty"`
 		}{
 			Version: 2,
 			Proxies: []config.ProxySource{},
@@ -313,10 +304,7 @@ func TestEnsureRequiredOutbounds_Preserve(t *testing.T) {
 			Version   int                     `json:"version,omitempty"`
 			Proxies   []config.ProxySource    `json:"proxies"`
 			Outbounds []config.OutboundConfig `json:"outbounds"`
-			Parser    struct {
-				Reload      string `json:"reload,omitempty"`
-				LastUpdated string `json:"last_updated,omitempty"`
-			} `json:"parser,omitempty"`
+			Parser    config.ParserSettings   `json:"parser,omitempty"`
 		}{
 			Version: 2,
 			Proxies: []config.ProxySource{},
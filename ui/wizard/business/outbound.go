@@ -54,8 +54,11 @@ func GetAvailableOutbounds(model *wizardmodels.WizardModel) []string {
 				tags[extra] = struct{}{}
 			}
 		}
-		// Add local outbounds from all ProxySource
+		// Add local outbounds from all enabled ProxySource
 		for _, proxySource := range parserCfg.ParserConfig.Proxies {
+			if !proxySource.IsEnabled() {
+				continue // Local outbounds of a disabled source are not generated
+			}
 			for _, outbound := range proxySource.Outbounds {
 				if outbound.IsWizardHidden() {
 					continue
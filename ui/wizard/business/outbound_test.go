@@ -23,10 +23,7 @@ func TestGetAvailableOutbounds(t *testing.T) {
 						Version   int                     `json:"version,omitempty"`
 						Proxies   []config.ProxySource    `json:"proxies"`
 						Outbounds []config.OutboundConfig `json:"outbounds"`
-						Parser    struct {
-							Reload      string `json:"reload,omitempty"`
-							LastUpdated string `json:"last_updated,omitempty"`
-						} `json:"parser,omitempty"`
+						Parser    config.ParserSettings   `json:"parser,omitempty"`
 					}{
 						Outbounds: []config.OutboundConfig{
 							{Tag: "selector-1", Type: "selector"},
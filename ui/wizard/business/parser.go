@@ -5,6 +5,7 @@
 //   - CheckURL - координирует проверку URL через subscription.FetchSubscription, subscription.ParseNode
 //   - ParseAndPreview - координирует генерацию outbounds через ConfigService.GenerateOutboundsFromParserConfig
 //   - ApplyURLToParserConfig - применяет URL к ParserConfig (работает со структурами config.ParserConfig)
+//   - AddSource - добавляет источник из командной строки или deep link и применяет его через ApplyURLToParserConfig
 //   - SerializeParserConfig - сериализует через config.NormalizeParserConfig
 //
 // Файл работает в контексте визарда (использует WizardModel и UIUpdater для обновления GUI).
@@ -458,7 +459,7 @@ func ParseAndPreview(model *wizardmodels.WizardModel, updater UIUpdater, configS
 }
 
 // ApplyURLToParserConfig applies URL input to ParserConfig, correctly separating subscriptions and connections.
-// It preserves existing local outbounds, tag_prefix, and tag_postfix for each source.
+// It preserves all settings of existing sources (local outbounds, tag_prefix, skip, detour, etc.).
 func ApplyURLToParserConfig(model *wizardmodels.WizardModel, updater UIUpdater, input string) error {
 	timing := debuglog.StartTiming("applyURLToParserConfig")
 	defer timing.EndWithDefer()
@@ -496,6 +497,106 @@ func ApplyURLToParserConfig(model *wizardmodels.WizardModel, updater UIUpdater,
 	return updateAndSerializeParserConfig(parserConfig, newProxies, subscriptions, connections, model, updater, timing)
 }
 
+// ListProxySources возвращает источники из текста ParserConfig с назначенными id (для списка источников в визарде).
+func ListProxySources(parserConfigJSON string) ([]config.ProxySource, error) {
+	var parserConfig config.ParserConfig
+	if err := json.Unmarshal([]byte(strings.TrimSpace(parserConfigJSON)), &parserConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse ParserConfig: %w", err)
+	}
+	config.AssignSourceIDs(parserConfig.ParserConfig.Proxies)
+	return parserConfig.ParserConfig.Proxies, nil
+}
+
+// SetProxySourceEnabled включает или отключает источник с заданным id.
+// Отключённый источник остаётся в ParserConfig со всеми настройками, но не используется при генерации.
+func SetProxySourceEnabled(model *wizardmodels.WizardModel, updater UIUpdater, id string, enabled bool) error {
+	return updateProxySource(model, updater, id, func(proxySource *config.ProxySource) {
+		proxySource.SetEnabled(enabled)
+	})
+}
+
+// SetProxySourceName задаёт имя источника с заданным id (пустое имя удаляет поле).
+func SetProxySourceName(model *wizardmodels.WizardModel, updater UIUpdater, id string, name string) error {
+	return updateProxySource(model, updater, id, func(proxySource *config.ProxySource) {
+		proxySource.Name = strings.TrimSpace(name)
+	})
+}
+
+// AddSource добавляет источник (URL подписки или ссылку на ноду) в конец SourceURLs и применяет
+// список к ParserConfig через ApplyURLToParserConfig. name (имя профиля из deep link) задаётся
+// новому источнику-подписке, если у него ещё нет имени. Возвращает false, если источник уже есть.
+func AddSource(model *wizardmodels.WizardModel, updater UIUpdater, source, name string) (bool, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return false, fmt.Errorf("source is empty")
+	}
+	for _, line := range strings.Split(model.SourceURLs, "\n") {
+		if strings.TrimSpace(line) == source {
+			return false, nil
+		}
+	}
+	if strings.TrimSpace(model.SourceURLs) == "" {
+		model.SourceURLs = source
+	} else {
+		model.SourceURLs = strings.TrimRight(model.SourceURLs, "\n") + "\n" + source
+	}
+
+	if err := ApplyURLToParserConfig(model, updater, strings.TrimSpace(model.SourceURLs)); err != nil {
+		return true, err
+	}
+	if name = strings.TrimSpace(name); name != "" && model.ParserConfig != nil {
+		proxies := model.ParserConfig.ParserConfig.Proxies
+		for i := range proxies {
+			if proxies[i].Source == source && proxies[i].Name == "" {
+				proxies[i].Name = name
+			}
+		}
+	}
+	// ApplyURLToParserConfig обновляет только model.ParserConfig; текст синхронизируем здесь,
+	// чтобы повторное применение (OnChanged поля источников) сохранило имя источника
+	serialized, err := SerializeParserConfig(model.ParserConfig)
+	if err != nil {
+		return true, fmt.Errorf("failed to serialize ParserConfig: %w", err)
+	}
+	updater.UpdateParserConfig(serialized)
+	model.ParserConfigJSON = serialized
+	return true, nil
+}
+
+// updateProxySource применяет изменение к источнику с заданным id и записывает ParserConfig обратно в модель и GUI.
+func updateProxySource(model *wizardmodels.WizardModel, updater UIUpdater, id string, apply func(*config.ProxySource)) error {
+	timing := debuglog.StartTiming("updateProxySource")
+	defer timing.EndWithDefer()
+
+	parserConfig, err := parseParserConfigForApply(model.ParserConfigJSON, timing)
+	if err != nil {
+		return err
+	}
+	config.AssignSourceIDs(parserConfig.ParserConfig.Proxies)
+
+	found := false
+	for i := range parserConfig.ParserConfig.Proxies {
+		if parserConfig.ParserConfig.Proxies[i].ID == id {
+			apply(&parserConfig.ParserConfig.Proxies[i])
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("proxy source %q not found", id)
+	}
+
+	serialized, err := SerializeParserConfig(parserConfig)
+	if err != nil {
+		return fmt.Errorf("failed to serialize ParserConfig: %w", err)
+	}
+	updater.UpdateParserConfig(serialized)
+	model.ParserConfigJSON = serialized
+	model.ParserConfig = parserConfig
+	model.PreviewNeedsParse = true
+	return nil
+}
+
 // validateApplyURLInput проверяет входные данные перед применением URL.
 func validateApplyURLInput(input, parserConfigJSON string) error {
 	if input == "" {
@@ -553,32 +654,22 @@ func classifyInputLines(input string, timing interface{ LogTiming(string, time.D
 	return subscriptions, connections
 }
 
-// existingProperties содержит сохраненные свойства существующих ProxySource.
+// existingProperties содержит сохраненные существующие ProxySource.
 type existingProperties struct {
-	OutboundsMap         map[string][]config.OutboundConfig
-	TagPrefixMap         map[string]string
-	TagPostfixMap        map[string]string
+	SourcesMap           map[string]config.ProxySource // источники с подпиской целиком, по URL подписки
 	ConnectionsProxies   []config.ProxySource
 }
 
-// preserveExistingProperties сохраняет существующие свойства из текущего ParserConfig.
+// preserveExistingProperties сохраняет существующие источники из текущего ParserConfig.
 func preserveExistingProperties(parserConfig *config.ParserConfig) *existingProperties {
 	props := &existingProperties{
-		OutboundsMap:       make(map[string][]config.OutboundConfig),
-		TagPrefixMap:       make(map[string]string),
-		TagPostfixMap:      make(map[string]string),
+		SourcesMap:         make(map[string]config.ProxySource),
 		ConnectionsProxies: make([]config.ProxySource, 0),
 	}
 
 	for _, existingProxy := range parserConfig.ParserConfig.Proxies {
 		if existingProxy.Source != "" {
-			props.OutboundsMap[existingProxy.Source] = existingProxy.Outbounds
-			if existingProxy.TagPrefix != "" {
-				props.TagPrefixMap[existingProxy.Source] = existingProxy.TagPrefix
-			}
-			if existingProxy.TagPostfix != "" {
-				props.TagPostfixMap[existingProxy.Source] = existingProxy.TagPostfix
-			}
+			props.SourcesMap[existingProxy.Source] = existingProxy
 		} else if len(existingProxy.Connections) > 0 {
 			// Preserve all ProxySource entries with connections but no source
 			props.ConnectionsProxies = append(props.ConnectionsProxies, existingProxy)
@@ -589,6 +680,7 @@ func preserveExistingProperties(parserConfig *config.ParserConfig) *existingProp
 	}
 
 // createSubscriptionProxies создает ProxySource для каждой подписки.
+// Для уже известной подписки сохраняются все ее настройки, меняется только Source.
 func createSubscriptionProxies(subscriptions []string, existingProps *existingProperties) []config.ProxySource {
 	newProxies := make([]config.ProxySource, 0, len(subscriptions))
 
@@ -596,18 +688,12 @@ func createSubscriptionProxies(subscriptions []string, existingProps *existingPr
 	autoAddPrefix := len(subscriptions) > 1
 
 	for idx, sub := range subscriptions {
-		proxySource := config.ProxySource{
-			Source: sub,
-		}
-
-		// Restore local outbounds if they existed for this source
-		if existingOutbounds, ok := existingProps.OutboundsMap[sub]; ok {
-			proxySource.Outbounds = existingOutbounds
-			debuglog.DebugLog("applyURLToParserConfig: Restored %d local outbounds for subscription: %s", len(existingOutbounds), sub)
+		proxySource, ok := existingProps.SourcesMap[sub]
+		if ok {
+			debuglog.DebugLog("applyURLToParserConfig: Restored existing settings (tag_prefix '%s', tag_postfix '%s', %d local outbounds) for subscription: %s",
+				proxySource.TagPrefix, proxySource.TagPostfix, len(proxySource.Outbounds), sub)
 		}
-
-		// Restore tag_prefix and tag_postfix
-		restoreTagPrefixAndPostfix(&proxySource, sub, existingProps, fmt.Sprintf("subscription: %s", sub))
+		proxySource.Source = sub
 
 		// Automatically add tag_prefix if not restored and auto-add is enabled
 		if proxySource.TagPrefix == "" && autoAddPrefix {
@@ -621,18 +707,6 @@ func createSubscriptionProxies(subscriptions []string, existingProps *existingPr
 	return newProxies
 }
 
-// restoreTagPrefixAndPostfix восстанавливает tag_prefix и tag_postfix из сохраненных свойств.
-func restoreTagPrefixAndPostfix(proxySource *config.ProxySource, lookupKey string, existingProps *existingProperties, logContext string) {
-	if existingTagPrefix, ok := existingProps.TagPrefixMap[lookupKey]; ok {
-		proxySource.TagPrefix = existingTagPrefix
-		debuglog.DebugLog("applyURLToParserConfig: Restored tag_prefix '%s' for %s", existingTagPrefix, logContext)
-	}
-	if existingTagPostfix, ok := existingProps.TagPostfixMap[lookupKey]; ok {
-		proxySource.TagPostfix = existingTagPostfix
-		debuglog.DebugLog("applyURLToParserConfig: Restored tag_postfix '%s' for %s", existingTagPostfix, logContext)
-	}
-}
-
 // connectionsMatch проверяет, совпадают ли два массива connections (порядок не важен).
 func connectionsMatch(conn1, conn2 []string) bool {
 		if len(conn1) != len(conn2) {
@@ -670,14 +744,8 @@ func matchOrCreateConnectionProxy(connections []string, existingProps *existingP
 	for _, existingConnectionsProxy := range existingProps.ConnectionsProxies {
 			if connectionsMatch(existingConnectionsProxy.Connections, connections) {
 				// Matched existing proxy - update connections but preserve all other properties
-				matchedProxy := config.ProxySource{
-					Connections: connections, // Update with potentially reordered connections
-					Outbounds:   existingConnectionsProxy.Outbounds,
-					TagPrefix:   existingConnectionsProxy.TagPrefix,
-					TagPostfix:  existingConnectionsProxy.TagPostfix,
-					TagMask:     existingConnectionsProxy.TagMask,
-					Skip:        existingConnectionsProxy.Skip,
-				}
+				matchedProxy := existingConnectionsProxy
+				matchedProxy.Connections = connections // Update with potentially reordered connections
 				newProxies = append(newProxies, matchedProxy)
 				debuglog.DebugLog("applyURLToParserConfig: Matched existing connections proxy, preserved tag_prefix '%s', tag_postfix '%s', tag_mask '%s'",
 					matchedProxy.TagPrefix, matchedProxy.TagPostfix, matchedProxy.TagMask)
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"singbox-launcher/core/config"
+	wizardmodels "singbox-launcher/ui/wizard/models"
 )
 
 // TestSerializeParserConfig_Standalone tests SerializeParserConfig without UI dependencies
@@ -23,10 +24,7 @@ func TestSerializeParserConfig_Standalone(t *testing.T) {
 					Version   int                     `json:"version,omitempty"`
 					Proxies   []config.ProxySource    `json:"proxies"`
 					Outbounds []config.OutboundConfig `json:"outbounds"`
-					Parser    struct {
-						Reload      string `json:"reload,omitempty"`
-						LastUpdated string `json:"last_updated,omitempty"`
-					} `json:"parser,omitempty"`
+					Parser    config.ParserSettings   `json:"parser,omitempty"`
 				}{
 					Version: 2,
 					Proxies: []config.ProxySource{
@@ -114,3 +112,145 @@ vmess://base64`
 		t.Errorf("Expected 2 connections, got %d", len(connections))
 	}
 }
+
+// parserConfigRecorder is a UIUpdater that records ParserConfig updates
+type parserConfigRecorder struct {
+	parserConfig string
+}
+
+func (r *parserConfigRecorder) UpdateURLStatus(string)          {}
+func (r *parserConfigRecorder) UpdateCheckURLProgress(float64)  {}
+func (r *parserConfigRecorder) UpdateCheckURLButtonText(string) {}
+func (r *parserConfigRecorder) UpdateOutboundsPreview(string)   {}
+func (r *parserConfigRecorder) UpdateParserConfig(text string)  { r.parserConfig = text }
+func (r *parserConfigRecorder) UpdateTemplatePreview(string)    {}
+func (r *parserConfigRecorder) UpdateSaveProgress(float64)      {}
+func (r *parserConfigRecorder) UpdateSaveButtonText(string)     {}
+
+func TestSetProxySourceEnabledAndName(t *testing.T) {
+	model := &wizardmodels.WizardModel{ParserConfigJSON: `{
+  "ParserConfig": {
+    "version": 5,
+    "proxies": [
+      {"source": "https://a.example.com/sub", "tag_prefix": "A:", "outbounds": [{"tag": "a-select", "type": "selector"}]},
+      {"source": "https://b.example.com/sub"}
+    ],
+    "outbounds": []
+  }
+}`}
+	recorder := &parserConfigRecorder{}
+
+	sources, err := ListProxySources(model.ParserConfigJSON)
+	if err != nil || len(sources) != 2 || sources[0].ID == "" {
+		t.Fatalf("ListProxySources: %v, %+v", err, sources)
+	}
+	id := sources[0].ID
+
+	if err := SetProxySourceEnabled(model, recorder, id, false); err != nil {
+		t.Fatalf("SetProxySourceEnabled: %v", err)
+	}
+	if err := SetProxySourceName(model, recorder, id, "  Main  "); err != nil {
+		t.Fatalf("SetProxySourceName: %v", err)
+	}
+	if recorder.parserConfig != model.ParserConfigJSON {
+		t.Error("ParserConfig text was not updated")
+	}
+
+	sources, _ = ListProxySources(model.ParserConfigJSON)
+	if sources[0].IsEnabled() || sources[0].Name != "Main" || sources[0].TagPrefix != "A:" || len(sources[0].Outbounds) != 1 {
+		t.Errorf("source settings lost or not applied: %+v", sources[0])
+	}
+	if !sources[1].IsEnabled() {
+		t.Error("other source must stay enabled")
+	}
+	for _, tag := range GetAvailableOutbounds(model) {
+		if tag == "a-select" {
+			t.Error("local outbound of a disabled source must not be offered")
+		}
+	}
+
+	if err := SetProxySourceEnabled(model, recorder, "missing", true); err == nil {
+		t.Error("expected error for unknown source id")
+	}
+}
+
+func TestAddSource(t *testing.T) {
+	model := &wizardmodels.WizardModel{
+		SourceURLs: "https://a.example.com/sub\n",
+		ParserConfigJSON: `{
+  "ParserConfig": {
+    "version": 5,
+    "proxies": [{"source": "https://a.example.com/sub", "tag_prefix": "A:"}],
+    "outbounds": []
+  }
+}`,
+	}
+	recorder := &parserConfigRecorder{}
+
+	added, err := AddSource(model, recorder, " https://b.example.com/sub ", "Provider B")
+	if err != nil || !added {
+		t.Fatalf("AddSource: added=%v err=%v", added, err)
+	}
+	if model.SourceURLs != "https://a.example.com/sub\nhttps://b.example.com/sub" {
+		t.Errorf("SourceURLs = %q", model.SourceURLs)
+	}
+	if recorder.parserConfig != model.ParserConfigJSON {
+		t.Error("ParserConfig text was not updated")
+	}
+	sources, err := ListProxySources(model.ParserConfigJSON)
+	if err != nil || len(sources) != 2 {
+		t.Fatalf("ListProxySources: %v, %+v", err, sources)
+	}
+	if sources[0].TagPrefix != "A:" || sources[0].Name != "" {
+		t.Errorf("existing source changed: %+v", sources[0])
+	}
+	if sources[1].Source != "https://b.example.com/sub" || sources[1].Name != "Provider B" {
+		t.Errorf("imported source: %+v", sources[1])
+	}
+
+	// The same source is not added twice
+	if added, err := AddSource(model, recorder, "https://b.example.com/sub", "Other"); added || err != nil {
+		t.Errorf("duplicate source: added=%v err=%v", added, err)
+	}
+}
+
+func TestApplyURLToParserConfig_PreservesSourceSettings(t *testing.T) {
+	model := &wizardmodels.WizardModel{ParserConfigJSON: `{
+  "ParserConfig": {
+    "version": 5,
+    "proxies": [
+      {
+        "id": "src-a", "name": "Main", "enabled": false,
+        "source": "https://a.example.com/sub",
+        "skip": [{"tag": "/RU/"}], "tag_mask": "A-{$tag}",
+        "detour": "chain", "override": {"tcp_fast_open": true}, "limit": 20, "reload": "12h",
+        "outbounds": [{"tag": "a-select", "type": "selector"}]
+      },
+      {"id": "conn", "connections": ["vless://uuid@one:443#One"], "skip": [{"tag": "/x/"}], "tag_prefix": "C:"}
+    ],
+    "outbounds": []
+  }
+}`}
+	recorder := &parserConfigRecorder{}
+
+	input := "https://a.example.com/sub\nhttps://b.example.com/sub\nvless://uuid@one:443#One"
+	if err := ApplyURLToParserConfig(model, recorder, input); err != nil {
+		t.Fatalf("ApplyURLToParserConfig: %v", err)
+	}
+	sources, err := ListProxySources(recorder.parserConfig)
+	if err != nil || len(sources) != 3 {
+		t.Fatalf("ListProxySources: %v, %+v", err, sources)
+	}
+
+	a := sources[0]
+	if a.ID != "src-a" || a.Name != "Main" || a.IsEnabled() || len(a.Skip) != 1 || a.TagMask != "A-{$tag}" ||
+		a.Detour != "chain" || len(a.Override) != 1 || a.Limit == nil || *a.Limit != 20 || a.Reload != "12h" || len(a.Outbounds) != 1 {
+		t.Errorf("existing subscription settings lost: %+v", a)
+	}
+	if sources[1].Source != "https://b.example.com/sub" || sources[1].TagPrefix != GenerateTagPrefix(2) {
+		t.Errorf("new subscription: %+v", sources[1])
+	}
+	if c := sources[2]; c.ID != "conn" || len(c.Skip) != 1 || c.TagPrefix != "C:" || len(c.Connections) != 1 {
+		t.Errorf("existing connections settings lost: %+v", c)
+	}
+}
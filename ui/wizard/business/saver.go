@@ -3,20 +3,20 @@
 // Package business содержит бизнес-логику визарда конфигурации.
 //
 // Файл saver.go содержит функции для сохранения конфигурации:
-//   - SaveConfigWithBackup - сохранение конфигурации с созданием бэкапа и генерацией случайного secret для Clash API
+//   - SaveConfigWithBackup - сохранение конфигурации со снимком в истории версий и генерацией случайного secret для Clash API
 //   - FileServiceAdapter - адаптер для services.FileService, предоставляющий доступ к путям и файловым операциям
 //
 // SaveConfigWithBackup выполняет:
 //  1. Валидацию JSON конфигурации (включая поддержку JSONC с комментариями)
 //  2. Генерацию случайного secret для experimental.clash_api.secret (если отсутствует)
-//  3. Создание бэкапа существующего файла конфигурации
-//  4. Сохранение новой конфигурации в файл
+//  3. Сохранение снимка существующего файла в историю версий (если история пуста)
+//  4. Атомарное сохранение новой конфигурации в файл и запись снимка в историю версий
 //
 // Эти функции работают только с данными (текст конфигурации, путь к файлу),
 // без зависимостей от GUI и WizardState, что делает их тестируемыми и переиспользуемыми.
 //
 // Сохранение конфигурации - это отдельная ответственность от парсинга и генерации.
-// Содержит логику работы с файловой системой и бэкапами.
+// Содержит логику работы с файловой системой и историей версий.
 // Используется презентером (presenter_save.go) для финального сохранения конфигурации.
 //
 // Используется в:
@@ -24,13 +24,11 @@
 package business
 
 import (
-	"bytes"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -38,9 +36,10 @@ import (
 
 	"github.com/muhammadmuzzammil1998/jsonc"
 
+	"singbox-launcher/core/config"
+	"singbox-launcher/core/config/parser"
 	"singbox-launcher/core/services"
 	"singbox-launcher/internal/debuglog"
-	"singbox-launcher/internal/platform"
 )
 
 // FileServiceAdapter адаптирует services.FileService для использования в бизнес-логике.
@@ -50,14 +49,14 @@ type FileServiceAdapter struct {
 }
 
 func (a *FileServiceAdapter) ConfigPath() string {
-	return a.FileService.ConfigPath
+	return a.FileService.ConfigPath()
 }
 
 func (a *FileServiceAdapter) ExecDir() string {
 	return a.FileService.ExecDir
 }
 
-// SaveConfigWithBackup сохраняет конфигурацию с созданием бэкапа.
+// SaveConfigWithBackup сохраняет конфигурацию и записывает её снимок в историю версий (config_history/).
 func SaveConfigWithBackup(fileService FileServiceInterface, configText string) (string, error) {
 	jsonBytes := jsonc.ToJSON([]byte(configText))
 	var configJSON map[string]interface{}
@@ -106,15 +105,26 @@ func SaveConfigWithBackup(fileService FileServiceInterface, configText string) (
 	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
 		return "", err
 	}
-	if err := services.BackupFile(configPath); err != nil {
+	// Снимок текущего config.json попадает в историю до первой перезаписи
+	config.EnsureHistoryBaseline(configPath, historyPolicyOf(configPath))
+	if err := config.WriteFileAtomic(configPath, []byte(finalText), nil); err != nil {
 		return "", err
 	}
-	if err := os.WriteFile(configPath, []byte(finalText), 0o644); err != nil {
-		return "", err
+	if _, err := config.RecordConfigVersion(configPath, config.HistoryTriggerWizard, historyPolicyOf(configPath)); err != nil {
+		debuglog.WarnLog("SaveConfigWithBackup: failed to record config snapshot: %v", err)
 	}
 	return configPath, nil
 }
 
+// historyPolicyOf читает настройки истории версий из @ParserConfig файла (по умолчанию, если блока нет)
+func historyPolicyOf(configPath string) config.HistoryPolicy {
+	parserConfig, err := parser.ExtractParserConfig(configPath)
+	if err != nil {
+		return config.HistoryPolicy{}
+	}
+	return parserConfig.ParserConfig.Parser.HistoryPolicy()
+}
+
 func generateRandomSecret(length int) string {
 	bytes := make([]byte, length)
 	if _, err := rand.Read(bytes); err != nil {
@@ -124,55 +134,7 @@ func generateRandomSecret(length int) string {
 }
 
 // ValidateConfigWithSingBox validates configuration file using sing-box check command.
-// Works on all platforms (Windows, macOS, Linux) with console window hidden.
-// Returns nil if valid or if sing-box is not available (graceful degradation).
+// Delegates to config.ValidateConfigWithSingBox (shared with subscription updates).
 func ValidateConfigWithSingBox(configPath, singBoxPath string) error {
-	// Skip validation if sing-box path is not provided
-	if singBoxPath == "" {
-		debuglog.DebugLog("Skipping sing-box validation: singBoxPath is empty")
-		return nil
-	}
-
-	// Check if sing-box executable exists
-	if _, err := os.Stat(singBoxPath); os.IsNotExist(err) {
-		debuglog.DebugLog("Skipping sing-box validation: executable not found at %s", singBoxPath)
-		return nil
-	}
-
-	// Prepare command
-	cmd := exec.Command(singBoxPath, "check", "-c", configPath)
-
-	// Hide console window on all platforms
-	platform.PrepareCommand(cmd)
-
-	// Capture output
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	debuglog.DebugLog("Running validation: %s check -c %s", singBoxPath, configPath)
-
-	// Run validation
-	err := cmd.Run()
-
-	if err != nil {
-		// Validation failed - extract meaningful error message
-		errorMsg := stderr.String()
-		if errorMsg == "" {
-			errorMsg = stdout.String()
-		}
-		if errorMsg == "" {
-			errorMsg = err.Error()
-		}
-
-		debuglog.ErrorLog("Config validation failed: %v", err)
-		debuglog.LogTextFragment("ConfigValidator", debuglog.LevelError,
-			"Validation error output", errorMsg, 500)
-
-		return fmt.Errorf("sing-box config validation failed: %s", errorMsg)
-	}
-
-	debuglog.InfoLog("Config validation passed successfully")
-
-	return nil
+	return config.ValidateConfigWithSingBox(configPath, singBoxPath)
 }
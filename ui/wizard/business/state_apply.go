@@ -0,0 +1,219 @@
+//go:build cgo
+
+// Package business содержит бизнес-логику визарда конфигурации.
+//
+// Файл state_apply.go содержит восстановление модели визарда из сохранённого состояния
+// и применение состояния без GUI:
+//   - RestoreModelFromState - восстановление WizardModel из WizardStateFile (шаги 2–7 LoadState)
+//   - ApplyWizardState - полный цикл визарда без окна: восстановление, парсинг подписок,
+//     генерация config.json по шаблону, сохранение с историей версий и проверка sing-box check
+//
+// Используется в:
+//   - presentation/presenter_state.go - LoadState восстанавливает модель через RestoreModelFromState
+//   - cli - команда wizard-apply вызывает ApplyWizardState
+package business
+
+import (
+	"fmt"
+	"strings"
+
+	"singbox-launcher/core/config"
+	"singbox-launcher/internal/debuglog"
+	wizardmodels "singbox-launcher/ui/wizard/models"
+)
+
+// RestoreModelFromState восстанавливает WizardModel из файла состояния.
+// model.TemplateData должен быть загружен: выбор правил сопоставляется с правилами шаблона по label.
+func RestoreModelFromState(model *wizardmodels.WizardModel, stateFile *wizardmodels.WizardStateFile) error {
+	if stateFile == nil {
+		return fmt.Errorf("state file cannot be nil")
+	}
+	// Валидация шаблона (шаг 1)
+	if model.TemplateData == nil {
+		return fmt.Errorf("template data not available")
+	}
+
+	// Восстановление parser_config (шаг 2)
+	if stateFile.ParserConfig.ParserConfig.Proxies == nil {
+		return fmt.Errorf("invalid parser_config: Proxies is nil")
+	}
+	model.ParserConfig = &stateFile.ParserConfig
+	parserConfigJSON, err := SerializeParserConfig(&stateFile.ParserConfig)
+	if err != nil {
+		return fmt.Errorf("failed to serialize parser_config: %w", err)
+	}
+	model.ParserConfigJSON = parserConfigJSON
+
+	// Извлечение SourceURLs (шаг 3)
+	model.SourceURLs = sourceURLsFromParserConfig(&stateFile.ParserConfig)
+
+	// Восстановление config_params (шаг 4)
+	restoreConfigParams(model, stateFile.ConfigParams)
+
+	// Восстановление SelectableRuleStates (шаг 5)
+	restoreSelectableRuleStates(model, stateFile.SelectableRuleStates)
+
+	// Восстановление CustomRules (шаг 6)
+	model.CustomRules = make([]*wizardmodels.RuleState, 0, len(stateFile.CustomRules))
+	for i := range stateFile.CustomRules {
+		model.CustomRules = append(model.CustomRules, stateFile.CustomRules[i].ToRuleState())
+	}
+
+	// Установка флага для парсинга (шаг 7)
+	model.PreviewNeedsParse = true
+	return nil
+}
+
+// sourceURLsFromParserConfig объединяет Source и Connections всех источников в одну строку (по строке на ссылку).
+func sourceURLsFromParserConfig(parserConfig *config.ParserConfig) string {
+	if parserConfig == nil || len(parserConfig.ParserConfig.Proxies) == 0 {
+		return ""
+	}
+
+	lines := make([]string, 0)
+	for _, proxySource := range parserConfig.ParserConfig.Proxies {
+		if proxySource.Source != "" {
+			lines = append(lines, proxySource.Source)
+		}
+		lines = append(lines, proxySource.Connections...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// restoreConfigParams восстанавливает route.final из config_params (fallback на шаблон).
+func restoreConfigParams(model *wizardmodels.WizardModel, configParams []wizardmodels.ConfigParam) {
+	finalOutbound := ""
+	for _, param := range configParams {
+		if param.Name == "route.final" {
+			finalOutbound = param.Value
+			break
+		}
+	}
+	if finalOutbound == "" && model.TemplateData != nil {
+		finalOutbound = model.TemplateData.DefaultFinal
+	}
+	model.SelectedFinalOutbound = finalOutbound
+
+	// TODO: Сохранить остальные параметры для применения при генерации конфига
+	// (например, experimental.clash_api.secret)
+}
+
+// restoreSelectableRuleStates сопоставляет сохранённые состояния с правилами из шаблона по label.
+// Правила без совпадения в шаблоне игнорируются (могли быть удалены из шаблона).
+// Правила из шаблона без сохранённого состояния получают значения по умолчанию.
+func restoreSelectableRuleStates(model *wizardmodels.WizardModel, persistedRules []wizardmodels.PersistedSelectableRuleState) {
+	debuglog.DebugLog("restoreSelectableRuleStates: restoring %d rules", len(persistedRules))
+
+	savedByLabel := make(map[string]wizardmodels.PersistedSelectableRuleState)
+	for _, pr := range persistedRules {
+		savedByLabel[pr.Label] = pr
+		debuglog.DebugLog("restoreSelectableRuleStates: saved rule label=%s, enabled=%v, selected_outbound=%s", pr.Label, pr.Enabled, pr.SelectedOutbound)
+	}
+
+	templateRules := model.TemplateData.SelectableRules
+	model.SelectableRuleStates = make([]*wizardmodels.RuleState, 0, len(templateRules))
+	for i := range templateRules {
+		rule := &templateRules[i]
+		rs := &wizardmodels.RuleState{
+			Rule: *rule,
+		}
+
+		if saved, ok := savedByLabel[rule.Label]; ok {
+			// Восстанавливаем выбор пользователя
+			rs.Enabled = saved.Enabled
+			rs.SelectedOutbound = saved.SelectedOutbound
+			debuglog.DebugLog("restoreSelectableRuleStates: matched rule label=%s, enabled=%v, selected_outbound=%s", rule.Label, saved.Enabled, saved.SelectedOutbound)
+		} else {
+			// Новое правило — используем default из шаблона
+			rs.Enabled = rule.IsDefault
+			rs.SelectedOutbound = rule.DefaultOutbound
+		}
+
+		model.SelectableRuleStates = append(model.SelectableRuleStates, rs)
+	}
+}
+
+// EnsureRuleOutboundsSelected подставляет outbound по умолчанию в правила, чей выбор отсутствует
+// среди доступных outbounds (как при обновлении списков выбора на вкладке Rules), и выбирает final.
+func EnsureRuleOutboundsSelected(model *wizardmodels.WizardModel) {
+	options := EnsureDefaultAvailableOutbounds(GetAvailableOutbounds(model))
+	available := make(map[string]bool, len(options))
+	for _, opt := range options {
+		available[opt] = true
+	}
+
+	ensureSelected := func(ruleState *wizardmodels.RuleState) {
+		if !ruleState.Rule.HasOutbound {
+			return
+		}
+		if ruleState.SelectedOutbound != "" && available[ruleState.SelectedOutbound] {
+			return
+		}
+		candidate := ruleState.Rule.DefaultOutbound
+		if candidate == "" || !available[candidate] {
+			candidate = options[0]
+		}
+		ruleState.SelectedOutbound = candidate
+	}
+	for _, ruleState := range model.SelectableRuleStates {
+		ensureSelected(ruleState)
+	}
+	for _, ruleState := range model.CustomRules {
+		ensureSelected(ruleState)
+	}
+
+	EnsureFinalSelected(model, options)
+}
+
+// ApplyResult — результат ApplyWizardState.
+type ApplyResult struct {
+	ConfigPath      string // Путь к записанному config.json
+	Nodes           int    // Количество узлов из источников
+	Selectors       int    // Количество сгенерированных селекторов (локальных и глобальных)
+	ValidationError error  // Ошибка sing-box check (config.json уже записан)
+}
+
+// ApplyWizardState выполняет сохранение визарда без GUI: восстанавливает модель из состояния,
+// загружает подписки, строит config.json по шаблону, сохраняет его (со снимком в истории версий)
+// и проверяет через sing-box check. Ошибка проверки возвращается в ApplyResult.ValidationError.
+func ApplyWizardState(model *wizardmodels.WizardModel, stateFile *wizardmodels.WizardStateFile, configService ConfigService, fileService FileServiceInterface, singBoxPath string) (*ApplyResult, error) {
+	if err := RestoreModelFromState(model, stateFile); err != nil {
+		return nil, err
+	}
+
+	// Парсинг подписок — как перед сохранением в визарде
+	model.AutoParseInProgress = true
+	if err := ParseAndPreview(model, headlessUIUpdater{}, configService); err != nil {
+		return nil, fmt.Errorf("failed to parse sources: %w", err)
+	}
+	EnsureRuleOutboundsSelected(model)
+
+	configText, err := BuildTemplateConfig(model, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build config: %w", err)
+	}
+
+	configPath, err := SaveConfigWithBackup(fileService, configText)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ApplyResult{
+		ConfigPath:      configPath,
+		Nodes:           model.OutboundStats.NodesCount,
+		Selectors:       model.OutboundStats.LocalSelectorsCount + model.OutboundStats.GlobalSelectorsCount,
+		ValidationError: ValidateConfigWithSingBox(configPath, singBoxPath),
+	}, nil
+}
+
+// headlessUIUpdater — UIUpdater без GUI: ошибки возвращает сам ParseAndPreview, прогресс не нужен.
+type headlessUIUpdater struct{}
+
+func (headlessUIUpdater) UpdateURLStatus(status string)           {}
+func (headlessUIUpdater) UpdateCheckURLProgress(progress float64) {}
+func (headlessUIUpdater) UpdateCheckURLButtonText(text string)    {}
+func (headlessUIUpdater) UpdateOutboundsPreview(text string)      {}
+func (headlessUIUpdater) UpdateParserConfig(text string)          {}
+func (headlessUIUpdater) UpdateTemplatePreview(text string)       {}
+func (headlessUIUpdater) UpdateSaveProgress(progress float64)     {}
+func (headlessUIUpdater) UpdateSaveButtonText(text string)        {}
@@ -0,0 +1,60 @@
+//go:build cgo
+
+package business
+
+import (
+	"testing"
+
+	"singbox-launcher/core/config"
+	wizardmodels "singbox-launcher/ui/wizard/models"
+	wizardtemplate "singbox-launcher/ui/wizard/template"
+)
+
+func TestRestoreModelFromState(t *testing.T) {
+	model := wizardmodels.NewWizardModel()
+	model.TemplateData = &wizardtemplate.TemplateData{
+		DefaultFinal: "direct-out",
+		SelectableRules: []wizardtemplate.TemplateSelectableRule{
+			{Label: "Block ads", IsDefault: true, DefaultOutbound: "reject"},
+			{Label: "Russian sites", DefaultOutbound: "direct-out"},
+		},
+	}
+
+	stateFile := &wizardmodels.WizardStateFile{
+		Version: wizardmodels.WizardStateVersion,
+		SelectableRuleStates: []wizardmodels.PersistedSelectableRuleState{
+			{Label: "Russian sites", Enabled: true, SelectedOutbound: "proxy-out"},
+			{Label: "Removed from template", Enabled: true},
+		},
+	}
+	stateFile.ParserConfig.ParserConfig.Proxies = []config.ProxySource{
+		{Source: "https://example.com/sub", Connections: []string{"vless://a@b:443#n1"}},
+	}
+
+	if err := RestoreModelFromState(model, stateFile); err != nil {
+		t.Fatalf("RestoreModelFromState() error = %v", err)
+	}
+
+	if model.SourceURLs != "https://example.com/sub\nvless://a@b:443#n1" {
+		t.Errorf("SourceURLs = %q", model.SourceURLs)
+	}
+	if model.SelectedFinalOutbound != "direct-out" {
+		t.Errorf("SelectedFinalOutbound = %q, want template default", model.SelectedFinalOutbound)
+	}
+	if model.ParserConfigJSON == "" || !model.PreviewNeedsParse {
+		t.Errorf("parser config JSON and PreviewNeedsParse must be set")
+	}
+	if len(model.SelectableRuleStates) != 2 {
+		t.Fatalf("expected 2 rule states (one per template rule), got %d", len(model.SelectableRuleStates))
+	}
+	if rs := model.SelectableRuleStates[0]; !rs.Enabled || rs.SelectedOutbound != "reject" {
+		t.Errorf("rule without saved state should use template defaults, got enabled=%v outbound=%q", rs.Enabled, rs.SelectedOutbound)
+	}
+	if rs := model.SelectableRuleStates[1]; !rs.Enabled || rs.SelectedOutbound != "proxy-out" {
+		t.Errorf("saved rule state not restored, got enabled=%v outbound=%q", rs.Enabled, rs.SelectedOutbound)
+	}
+
+	if err := RestoreModelFromState(wizardmodels.NewWizardModel(), stateFile); err == nil {
+		t.Errorf("expected an error when template data is not loaded")
+	}
+}
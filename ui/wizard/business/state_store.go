@@ -4,7 +4,7 @@
 //
 // StateStore предоставляет методы для:
 //   - Сохранения состояния в файл (SaveWizardState, SaveCurrentState)
-//   - Загрузки состояния из файла (LoadWizardState, LoadCurrentState)
+//   - Загрузки состояния из файла (LoadWizardState, LoadCurrentState, LoadStateFile)
 //   - Получения списка всех состояний (ListWizardStates)
 //   - Валидации ID состояния (ValidateStateID)
 //
@@ -14,6 +14,7 @@
 //
 // Используется в:
 //   - presentation/presenter.go - для сохранения/загрузки состояний через презентер
+//   - cli - команда wizard-apply загружает состояние по ID или из файла
 package business
 
 import (
@@ -43,8 +44,9 @@ type StateStore struct {
 }
 
 // NewStateStore создает новый StateStore.
+// Состояния хранятся рядом с config.json активного профиля, у каждого профиля свои.
 func NewStateStore(fileService FileServiceInterface) *StateStore {
-	statesDir := filepath.Join(fileService.ExecDir(), "bin", WizardStatesDir)
+	statesDir := filepath.Join(filepath.Dir(fileService.ConfigPath()), WizardStatesDir)
 	return &StateStore{
 		fileService: fileService,
 		statesDir:  statesDir,
@@ -192,6 +194,11 @@ func (ss *StateStore) LoadWizardState(id string) (*wizardmodels.WizardStateFile,
 	return ss.loadStateFromFile(filePath, id)
 }
 
+// LoadStateFile загружает состояние из произвольного файла (например, для команды wizard-apply).
+func (ss *StateStore) LoadStateFile(filePath string) (*wizardmodels.WizardStateFile, error) {
+	return ss.loadStateFromFile(filePath, "")
+}
+
 // LoadCurrentState загружает состояние из state.json.
 func (ss *StateStore) LoadCurrentState() (*wizardmodels.WizardStateFile, error) {
 	filePath := ss.getStateFilePath("")
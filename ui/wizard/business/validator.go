@@ -7,6 +7,7 @@
 //   - ValidateOutbound - валидация конфигурации outbound (tag, type, длина)
 //   - ValidateRule - валидация правил маршрутизации
 //   - ValidateJSON - валидация JSON структуры и размера
+//   - ValidateParserConfigSchema - проверка ParserConfig по JSON Schema (путь и строка/столбец ошибки)
 //   - ValidateJSONSize, ValidateHTTPResponseSize - проверка размеров данных
 //
 // Эти функции являются чистыми функциями валидации (pure functions) - они не зависят от GUI
@@ -31,6 +32,7 @@ import (
 
 	"singbox-launcher/core/config"
 	"singbox-launcher/core/config/parser"
+	"singbox-launcher/core/config/schema"
 	wizardutils "singbox-launcher/ui/wizard/utils"
 )
 
@@ -56,7 +58,14 @@ func ValidateParserConfig(parserConfig *config.ParserConfig) error {
 	}
 
 	// Validate each proxy source
+	sourceIDs := make(map[string]int)
 	for i, proxy := range parserConfig.ParserConfig.Proxies {
+		if proxy.ID != "" {
+			if other, exists := sourceIDs[proxy.ID]; exists {
+				return fmt.Errorf("proxy %d: duplicate id %q (also used by proxy %d)", i, proxy.ID, other)
+			}
+			sourceIDs[proxy.ID] = i
+		}
 		if proxy.Source != "" {
 			if err := ValidateURL(proxy.Source); err != nil {
 				return fmt.Errorf("proxy source %d: invalid URL: %w", i, err)
@@ -216,10 +225,41 @@ func ValidateParserConfigJSON(jsonText string) error {
 		return fmt.Errorf("invalid ParserConfig JSON: %w", err)
 	}
 
+	if schemaErrors := ValidateParserConfigSchema(jsonText); len(schemaErrors) > 0 {
+		return fmt.Errorf("ParserConfig does not match schema: %s", FormatSchemaErrors(schemaErrors, 0))
+	}
+
 	var parserConfig config.ParserConfig
 	if err := json.Unmarshal(jsonBytes, &parserConfig); err != nil {
 		return fmt.Errorf("failed to parse ParserConfig JSON: %w", err)
 	}
+	// "proxies": null is rejected by the schema; a config without the list simply has no sources yet
+	if parserConfig.ParserConfig.Proxies == nil {
+		parserConfig.ParserConfig.Proxies = []config.ProxySource{}
+	}
 
 	return ValidateParserConfig(&parserConfig)
 }
+
+// ValidateParserConfigSchema checks ParserConfig JSON text against the ParserConfig JSON Schema.
+// Errors carry a JSON pointer and line/column in jsonText (comments and trailing commas are allowed).
+func ValidateParserConfigSchema(jsonText string) []schema.ValidationError {
+	if strings.TrimSpace(jsonText) == "" {
+		return nil
+	}
+	return schema.ValidateParserConfig([]byte(jsonText))
+}
+
+// FormatSchemaErrors joins schema errors one per line.
+// limit > 0 keeps only the first errors and adds a line with the number of the rest.
+func FormatSchemaErrors(errs []schema.ValidationError, limit int) string {
+	lines := make([]string, 0, len(errs))
+	for i, e := range errs {
+		if limit > 0 && i == limit {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(errs)-limit))
+			break
+		}
+		lines = append(lines, e.Error())
+	}
+	return strings.Join(lines, "\n")
+}
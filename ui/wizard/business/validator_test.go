@@ -23,10 +23,7 @@ func TestValidateParserConfig(t *testing.T) {
 					Version   int                     `json:"version,omitempty"`
 					Proxies   []config.ProxySource    `json:"proxies"`
 					Outbounds []config.OutboundConfig `json:"outbounds"`
-					Parser    struct {
-						Reload      string `json:"reload,omitempty"`
-						LastUpdated string `json:"last_updated,omitempty"`
-					} `json:"parser,omitempty"`
+					Parser    config.ParserSettings   `json:"parser,omitempty"`
 				}{
 					Version: 2,
 					Proxies: []config.ProxySource{
@@ -57,10 +54,7 @@ func TestValidateParserConfig(t *testing.T) {
 					Version   int                     `json:"version,omitempty"`
 					Proxies   []config.ProxySource    `json:"proxies"`
 					Outbounds []config.OutboundConfig `json:"outbounds"`
-					Parser    struct {
-						Reload      string `json:"reload,omitempty"`
-						LastUpdated string `json:"last_updated,omitempty"`
-					} `json:"parser,omitempty"`
+					Parser    config.ParserSettings   `json:"parser,omitempty"`
 				}{
 					Proxies: nil,
 				},
@@ -74,10 +68,7 @@ func TestValidateParserConfig(t *testing.T) {
 					Version   int                     `json:"version,omitempty"`
 					Proxies   []config.ProxySource    `json:"proxies"`
 					Outbounds []config.OutboundConfig `json:"outbounds"`
-					Parser    struct {
-						Reload      string `json:"reload,omitempty"`
-						LastUpdated string `json:"last_updated,omitempty"`
-					} `json:"parser,omitempty"`
+					Parser    config.ParserSettings   `json:"parser,omitempty"`
 				}{
 					Proxies: []config.ProxySource{
 						{
@@ -95,10 +86,7 @@ func TestValidateParserConfig(t *testing.T) {
 					Version   int                     `json:"version,omitempty"`
 					Proxies   []config.ProxySource    `json:"proxies"`
 					Outbounds []config.OutboundConfig `json:"outbounds"`
-					Parser    struct {
-						Reload      string `json:"reload,omitempty"`
-						LastUpdated string `json:"last_updated,omitempty"`
-					} `json:"parser,omitempty"`
+					Parser    config.ParserSettings   `json:"parser,omitempty"`
 				}{
 					Proxies: []config.ProxySource{
 						{
@@ -109,6 +97,24 @@ func TestValidateParserConfig(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "ParserConfig with duplicate source ids",
+			config: &config.ParserConfig{
+				ParserConfig: struct {
+					Version   int                     `json:"version,omitempty"`
+					Proxies   []config.ProxySource    `json:"proxies"`
+					Outbounds []config.OutboundConfig `json:"outbounds"`
+					Parser    config.ParserSettings   `json:"parser,omitempty"`
+				}{
+					Version: 5,
+					Proxies: []config.ProxySource{
+						{ID: "main", Source: "https://a.example.com/sub"},
+						{ID: "main", Source: "https://b.example.com/sub"},
+					},
+				},
+			},
+			expectError: true,
+		},
 		{
 			name: "ParserConfig with invalid outbound",
 			config: &config.ParserConfig{
@@ -116,10 +122,7 @@ func TestValidateParserConfig(t *testing.T) {
 					Version   int                     `json:"version,omitempty"`
 					Proxies   []config.ProxySource    `json:"proxies"`
 					Outbounds []config.OutboundConfig `json:"outbounds"`
-					Parser    struct {
-						Reload      string `json:"reload,omitempty"`
-						LastUpdated string `json:"last_updated,omitempty"`
-					} `json:"parser,omitempty"`
+					Parser    config.ParserSettings   `json:"parser,omitempty"`
 				}{
 					Proxies: []config.ProxySource{},
 					Outbounds: []config.OutboundConfig{
@@ -463,6 +466,21 @@ func TestValidateParserConfigJSON(t *testing.T) {
 			jsonText:    `{"ParserConfig": {"proxies": null}}`,
 			expectError: true,
 		},
+		{
+			name:        "group_by parent without type (defaults to selector)",
+			jsonText:    `{"ParserConfig":{"outbounds":[{"tag":"auto-{$group}","type":"urltest","group_by":{"key":"flag","parent":{"tag":"countries"}}}]}}`,
+			expectError: false,
+		},
+		{
+			name:        "group_by parent without tag",
+			jsonText:    `{"ParserConfig":{"outbounds":[{"tag":"auto-{$group}","type":"urltest","group_by":{"key":"flag","parent":{"type":"selector"}}}]}}`,
+			expectError: true,
+		},
+		{
+			name:        "Schema violation",
+			jsonText:    `{"ParserConfig": {"proxies": [{"source": "https://example.com/sub", "enabled": "no"}]}}`,
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -481,6 +499,27 @@ func TestValidateParserConfigJSON(t *testing.T) {
 	}
 }
 
+// TestValidateParserConfigSchema tests schema errors and their formatting
+func TestValidateParserConfigSchema(t *testing.T) {
+	if errs := ValidateParserConfigSchema("  "); errs != nil {
+		t.Errorf("Expected no errors for empty text, got %v", errs)
+	}
+
+	jsonText := "{\n  \"ParserConfig\": {\n    \"proxies\": [{\"sorce\": 1}, {\"limit\": -1}]\n  }\n}"
+	errs := ValidateParserConfigSchema(jsonText)
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 errors, got %v", errs)
+	}
+	if errs[0].Pointer != "/ParserConfig/proxies/0/sorce" || errs[0].Line != 3 {
+		t.Errorf("Unexpected first error: %v", errs[0])
+	}
+
+	formatted := FormatSchemaErrors(errs, 1)
+	if !strings.Contains(formatted, "line 3, col 27: /ParserConfig/proxies/0/sorce") || !strings.HasSuffix(formatted, "... and 1 more") {
+		t.Errorf("Unexpected formatted errors: %q", formatted)
+	}
+}
+
 // TestValidateRule tests ValidateRule function
 func TestValidateRule(t *testing.T) {
 	tests := []struct {
@@ -395,10 +395,7 @@ func (wsf *WizardStateFile) UnmarshalJSON(data []byte) error {
 			Version   int                     `json:"version"`
 			Proxies   []config.ProxySource    `json:"proxies"`
 			Outbounds []config.OutboundConfig `json:"outbounds"`
-			Parser    struct {
-				Reload      string `json:"reload,omitempty"`
-				LastUpdated string `json:"last_updated,omitempty"`
-			} `json:"parser,omitempty"`
+			Parser    config.ParserSettings   `json:"parser,omitempty"`
 		}
 		if err := json.Unmarshal(basic.ParserConfig, &simplified); err == nil && simplified.Proxies != nil {
 			// Упрощенная структура - оборачиваем в ParserConfig
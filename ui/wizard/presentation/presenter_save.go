@@ -7,7 +7,7 @@
 //  1. Проверяет, что ParserConfig и SourceURLs заполнены
 //  2. При необходимости запускает парсинг конфигурации (если PreviewNeedsParse)
 //  3. Генерирует финальную конфигурацию из шаблона и модели (BuildTemplateConfig)
-//  4. Сохраняет конфигурацию в файл с созданием бэкапа (SaveConfigWithBackup)
+//  4. Сохраняет конфигурацию в файл со снимком в истории версий (SaveConfigWithBackup)
 //  5. Показывает диалог успешного сохранения и закрывает визард
 //
 // Все операции выполняются асинхронно в отдельной горутине с обновлением прогресс-бара.
@@ -279,7 +279,7 @@ func (p *WizardPresenter) saveStateAndShowSuccessDialog(configPath string, valid
 
 	ac := core.GetController()
 	// Получаем путь к state.json для логирования
-	statesDir := filepath.Join(ac.FileService.ExecDir, "bin", wizardbusiness.WizardStatesDir)
+	statesDir := filepath.Join(filepath.Dir(ac.FileService.ConfigPath()), wizardbusiness.WizardStatesDir)
 	statePath := filepath.Join(statesDir, wizardmodels.StateFileName)
 
 	p.UpdateUI(func() {
@@ -22,11 +22,9 @@ package presentation
 import (
 	"fmt"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"singbox-launcher/core"
-	"singbox-launcher/core/config"
 	"singbox-launcher/internal/debuglog"
 	wizardbusiness "singbox-launcher/ui/wizard/business"
 	wizardmodels "singbox-launcher/ui/wizard/models"
@@ -126,7 +124,7 @@ func (p *WizardPresenter) SaveCurrentState() error {
 
 	ac := core.GetController()
 	// Получаем путь к state.json для логирования
-	statesDir := filepath.Join(ac.FileService.ExecDir, "bin", wizardbusiness.WizardStatesDir)
+	statesDir := filepath.Join(filepath.Dir(ac.FileService.ConfigPath()), wizardbusiness.WizardStatesDir)
 	statePath := filepath.Join(statesDir, wizardmodels.StateFileName)
 
 	debuglog.InfoLog("SaveCurrentState: saving to state.json at %s", statePath)
@@ -160,40 +158,16 @@ func (p *WizardPresenter) SaveStateAs(comment, id string) error {
 }
 
 // LoadState загружает состояние в модель согласно детальной последовательности восстановления.
-// Выполняет 9-шаговую последовательность восстановления WizardModel согласно спецификации.
+// Шаги 1–7 (восстановление WizardModel) выполняет wizardbusiness.RestoreModelFromState,
+// затем модель синхронизируется с GUI.
 func (p *WizardPresenter) LoadState(stateFile *wizardmodels.WizardStateFile) error {
-	if stateFile == nil {
-		return fmt.Errorf("state file cannot be nil")
-	}
-
 	timing := debuglog.StartTiming("loadState")
 	defer timing.EndWithDefer()
 
-	// Валидация шаблона (шаг 1)
-	if p.model.TemplateData == nil {
-		return fmt.Errorf("template data not available")
-	}
-
-	// Восстановление parser_config (шаг 2)
-	if err := p.restoreParserConfig(stateFile); err != nil {
+	if err := wizardbusiness.RestoreModelFromState(p.model, stateFile); err != nil {
 		return err
 	}
 
-	// Извлечение SourceURLs (шаг 3)
-	p.model.SourceURLs = p.extractSourceURLsFromParserConfig(&stateFile.ParserConfig)
-
-	// Восстановление config_params (шаг 4)
-	p.restoreConfigParams(stateFile.ConfigParams)
-
-	// Восстановление SelectableRuleStates (шаг 5)
-	p.restoreSelectableRuleStates(stateFile.SelectableRuleStates)
-
-	// Восстановление CustomRules (шаг 6)
-	p.restoreCustomRules(stateFile.CustomRules)
-
-	// Установка флага для парсинга (шаг 7)
-	p.model.PreviewNeedsParse = true
-
 	// Синхронизация GUI (шаг 8)
 	// SyncModelToGUI() также пересоздаст вкладку Rules, если она уже создана
 	p.SyncModelToGUI()
@@ -207,124 +181,6 @@ func (p *WizardPresenter) LoadState(stateFile *wizardmodels.WizardStateFile) err
 	return nil
 }
 
-// restoreParserConfig восстанавливает parser_config из состояния (шаг 2).
-func (p *WizardPresenter) restoreParserConfig(stateFile *wizardmodels.WizardStateFile) error {
-	if stateFile.ParserConfig.ParserConfig.Proxies == nil {
-		return fmt.Errorf("invalid parser_config: Proxies is nil")
-	}
-
-	p.model.ParserConfig = &stateFile.ParserConfig
-
-	// Сериализуем parser_config в JSON строку
-	parserConfigJSON, err := wizardbusiness.SerializeParserConfig(&stateFile.ParserConfig)
-	if err != nil {
-		return fmt.Errorf("failed to serialize parser_config: %w", err)
-	}
-	p.model.ParserConfigJSON = parserConfigJSON
-
-	return nil
-}
-
-// restoreSelectableRuleStates восстанавливает SelectableRuleStates из состояния (шаг 5).
-// Сопоставляет сохранённые состояния с правилами из шаблона по label.
-// Правила без совпадения в шаблоне игнорируются (могли быть удалены из шаблона).
-// Правила из шаблона без сохранённого состояния получают значения по умолчанию.
-func (p *WizardPresenter) restoreSelectableRuleStates(persistedRules []wizardmodels.PersistedSelectableRuleState) {
-	debuglog.DebugLog("restoreSelectableRuleStates: restoring %d rules", len(persistedRules))
-
-	// Создаём индекс сохранённых состояний по label
-	savedByLabel := make(map[string]wizardmodels.PersistedSelectableRuleState)
-	for _, pr := range persistedRules {
-		savedByLabel[pr.Label] = pr
-		debuglog.DebugLog("restoreSelectableRuleStates: saved rule label=%s, enabled=%v, selected_outbound=%s", pr.Label, pr.Enabled, pr.SelectedOutbound)
-	}
-
-	// Для каждого правила из шаблона ищем сохранённое состояние
-	templateRules := p.model.TemplateData.SelectableRules
-	p.model.SelectableRuleStates = make([]*wizardmodels.RuleState, 0, len(templateRules))
-	for i := range templateRules {
-		rule := &templateRules[i]
-		rs := &wizardmodels.RuleState{
-			Rule: *rule,
-		}
-
-		if saved, ok := savedByLabel[rule.Label]; ok {
-			// Восстанавливаем выбор пользователя
-			rs.Enabled = saved.Enabled
-			rs.SelectedOutbound = saved.SelectedOutbound
-			debuglog.DebugLog("restoreSelectableRuleStates: matched rule label=%s, enabled=%v, selected_outbound=%s", rule.Label, saved.Enabled, saved.SelectedOutbound)
-		} else {
-			// Новое правило — используем default из шаблона
-			rs.Enabled = rule.IsDefault
-			rs.SelectedOutbound = rule.DefaultOutbound
-		}
-
-		p.model.SelectableRuleStates = append(p.model.SelectableRuleStates, rs)
-	}
-}
-
-// restoreCustomRules восстанавливает CustomRules из состояния (шаг 6).
-func (p *WizardPresenter) restoreCustomRules(persistedRules []wizardmodels.PersistedCustomRule) {
-	p.model.CustomRules = make([]*wizardmodels.RuleState, 0, len(persistedRules))
-	for i := range persistedRules {
-		ruleState := persistedRules[i].ToRuleState()
-		p.model.CustomRules = append(p.model.CustomRules, ruleState)
-	}
-}
-
-// extractSourceURLsFromParserConfig извлекает SourceURLs из ParserConfig.
-// Объединяет Source и Connections из всех ProxySource в одну строку, разделенную переносами строк.
-func (p *WizardPresenter) extractSourceURLsFromParserConfig(parserConfig *config.ParserConfig) string {
-	if parserConfig == nil || len(parserConfig.ParserConfig.Proxies) == 0 {
-		return ""
-	}
-
-	lines := make([]string, 0)
-	for _, proxySource := range parserConfig.ParserConfig.Proxies {
-		if proxySource.Source != "" {
-			lines = append(lines, proxySource.Source)
-		}
-		lines = append(lines, proxySource.Connections...)
-	}
-
-	return strings.Join(lines, "\n")
-}
-
-// restoreConfigParams восстанавливает config_params и маппинг в модель.
-func (p *WizardPresenter) restoreConfigParams(configParams []wizardmodels.ConfigParam) {
-	// Ищем route.final в параметрах
-	finalOutbound := p.findConfigParamValue(configParams, "route.final")
-
-	// Используем значение из параметров, если задано, иначе fallback на шаблон
-	if finalOutbound != "" {
-		p.model.SelectedFinalOutbound = finalOutbound
-	} else {
-		p.model.SelectedFinalOutbound = p.getDefaultFinalOutbound()
-	}
-
-	// TODO: Сохранить остальные параметры для применения при генерации конфига
-	// (например, experimental.clash_api.secret)
-}
-
-// findConfigParamValue ищет значение параметра по имени.
-// Возвращает пустую строку, если параметр не найден.
-func (p *WizardPresenter) findConfigParamValue(configParams []wizardmodels.ConfigParam, name string) string {
-	for _, param := range configParams {
-		if param.Name == name {
-			return param.Value
-		}
-	}
-	return ""
-}
-
-// getDefaultFinalOutbound возвращает значение по умолчанию для final outbound из шаблона.
-func (p *WizardPresenter) getDefaultFinalOutbound() string {
-	if p.model.TemplateData != nil && p.model.TemplateData.DefaultFinal != "" {
-		return p.model.TemplateData.DefaultFinal
-	}
-	return ""
-}
-
 // GetStateStore создает новый StateStore для работы с состояниями.
 // Публичный метод для использования в диалогах и других компонентах.
 //
@@ -3,6 +3,7 @@
 // Файл presenter_sync.go содержит методы синхронизации данных между моделью и GUI:
 //   - SyncModelToGUI - обновляет виджеты GUI из модели данных (SourceURLs, ParserConfigJSON, SelectedFinalOutbound)
 //   - SyncGUIToModel - обновляет модель данных из виджетов GUI (обратная синхронизация)
+//   - AddSource - добавляет источник в поле источников (импорт из командной строки)
 //
 // Эти методы обеспечивают двустороннюю синхронизацию между WizardModel и GUIState,
 // что является ключевой частью архитектуры MVP.
@@ -14,9 +15,11 @@
 //   - wizard.go - SyncModelToGUI вызывается при инициализации визарда для установки начальных значений
 //   - presenter_save.go - SyncGUIToModel вызывается перед сохранением для получения актуальных данных
 //   - presenter_async.go - SyncGUIToModel вызывается перед парсингом для получения актуальных данных
+//   - wizard.go - AddSource вызывается при импорте источника, переданного при запуске лаунчера
 package presentation
 
 import (
+	"singbox-launcher/internal/debuglog"
 	wizardbusiness "singbox-launcher/ui/wizard/business"
 )
 
@@ -82,3 +85,26 @@ func (p *WizardPresenter) SyncGUIToModel() {
 		p.MarkAsChanged()
 	}
 }
+
+// AddSource добавляет источник (URL подписки или ссылку на ноду) в поле источников и применяет его
+// к ParserConfig (wizardbusiness.AddSource); name — имя профиля из deep link.
+// Возвращает true, если список источников изменился. Должен вызываться в UI-потоке.
+func (p *WizardPresenter) AddSource(source, name string) bool {
+	// Несохранённые правки пользователя в полях не должны потеряться
+	p.SyncGUIToModel()
+	added, err := wizardbusiness.AddSource(p.model, p, source, name)
+	if err != nil {
+		debuglog.ErrorLog("AddSource: failed to apply source to ParserConfig: %v", err)
+	}
+	if !added {
+		return false
+	}
+	p.model.PreviewNeedsParse = true
+	p.MarkAsChanged()
+	p.UpdateUI(func() {
+		if p.guiState.SourceURLEntry != nil {
+			p.guiState.SourceURLEntry.SetText(p.model.SourceURLs)
+		}
+	})
+	return true
+}
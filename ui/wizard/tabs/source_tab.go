@@ -3,7 +3,8 @@
 // Файл source_tab.go содержит функцию CreateSourceTab, которая создает UI первого таба визарда:
 //   - Ввод URL подписки или прямых ссылок (SourceURLEntry)
 //   - Проверка URL (CheckURLButton, URLStatusLabel, CheckURLProgress)
-//   - Редактирование ParserConfig (ParserConfigEntry)
+//   - Список источников: включение/отключение и имена (ParserConfig версии 5)
+//   - Редактирование ParserConfig (ParserConfigEntry) с ошибками проверки по JSON Schema под полем
 //   - Preview сгенерированных outbounds (OutboundsPreview)
 //   - Кнопка парсинга (ParseButton)
 //
@@ -37,6 +38,7 @@ import (
 	wizardbusiness "singbox-launcher/ui/wizard/business"
 	wizarddialogs "singbox-launcher/ui/wizard/dialogs"
 	wizardpresentation "singbox-launcher/ui/wizard/presentation"
+	wizardtemplate "singbox-launcher/ui/wizard/template"
 )
 
 // CreateSourceTab creates the Sources & ParserConfig tab UI.
@@ -149,11 +151,27 @@ func CreateSourceTab(presenter *wizardpresentation.WizardPresenter) fyne.CanvasO
 		guiState.URLStatusLabel, // Status
 	)
 
+	// Sources list: enable/disable and rename sources without removing them from ParserConfig
+	sourcesList := container.NewVBox()
+	sourcesLabel := widget.NewLabel("Sources:")
+	sourcesLabel.Importance = widget.MediumImportance
+	sourcesContainer := container.NewVBox(sourcesLabel, sourcesList)
+	sourcesContainer.Hide()
+
 	// Section 2: ParserConfig
 	guiState.ParserConfigEntry = widget.NewMultiLineEntry()
 	guiState.ParserConfigEntry.SetPlaceHolder("Enter ParserConfig JSON here...")
 	guiState.ParserConfigEntry.Wrapping = fyne.TextWrapOff
-	guiState.ParserConfigEntry.OnChanged = func(string) {
+	// Schema errors of ParserConfig (and of wizard_template.json) shown under the editor
+	parserErrorsLabel := widget.NewLabel("")
+	parserErrorsLabel.Importance = widget.DangerImportance
+	parserErrorsLabel.Wrapping = fyne.TextWrapWord
+	parserErrorsLabel.Hide()
+
+	guiState.ParserConfigEntry.OnChanged = func(text string) {
+		// The list and the errors follow both user edits and programmatic updates of ParserConfig
+		refreshSourcesList(presenter, sourcesContainer, sourcesList, text)
+		refreshParserConfigErrors(presenter, parserErrorsLabel, text)
 		if guiState.ParserConfigUpdating {
 			return
 		}
@@ -190,7 +208,7 @@ func CreateSourceTab(presenter *wizardpresentation.WizardPresenter) fyne.CanvasO
 	chatButton := widget.NewButton("🧠 ChatGPT", func() {
 
 		promptHeader := `
-ou are a senior sing-box and ParserConfig v4 expert.
+ou are a senior sing-box and ParserConfig v5 expert.
 
 Reference documentation (must be followed):
 https://github.com/Leadaxe/singbox-launcher/blob/main/docs/ParserConfig.md
@@ -200,7 +218,7 @@ Produce a final, production-ready ParserConfig that is logically structured, saf
 
 Hard requirements (must follow exactly):
 
-1. Use ParserConfig version 4.
+1. Use ParserConfig version 5.
 2. Use multiple proxy sources.
 3. For EACH proxy source:
    - Define a meaningful "tag_prefix" that clearly reflects:
@@ -317,6 +335,7 @@ Here is the current configuration to review:
 	parserContainer := container.NewVBox(
 		headerRow,
 		parserConfigWithHeight,
+		parserErrorsLabel,
 	)
 
 	// Section 3: Preview Generated Outbounds
@@ -365,6 +384,7 @@ Here is the current configuration to review:
 	content := container.NewVBox(
 		widget.NewSeparator(),
 		urlContainer,
+		sourcesContainer,
 		widget.NewSeparator(),
 		parserContainer,
 		widget.NewSeparator(),
@@ -378,3 +398,94 @@ Here is the current configuration to review:
 
 	return scrollContainer
 }
+
+// refreshSourcesList перестраивает список источников по тексту ParserConfig:
+// флажок включения с именем источника и кнопка переименования.
+// Список скрыт, если ParserConfig не разбирается или источников нет.
+func refreshSourcesList(presenter *wizardpresentation.WizardPresenter, sourcesContainer, sourcesList *fyne.Container, parserConfigText string) {
+	sourcesList.RemoveAll()
+	proxies, err := wizardbusiness.ListProxySources(parserConfigText)
+	if err != nil {
+		// ParserConfig is being edited and may be invalid - keep the list hidden until it parses
+		sourcesContainer.Hide()
+		return
+	}
+
+	for i := range proxies {
+		proxySource := proxies[i]
+		if proxySource.Source == "" && len(proxySource.Connections) == 0 {
+			continue
+		}
+		id := proxySource.ID
+		label := proxySource.DisplayName(i)
+		if proxySource.Source == "" {
+			label += fmt.Sprintf(" (%d connection(s))", len(proxySource.Connections))
+		}
+
+		enabledCheck := widget.NewCheck(label, nil)
+		enabledCheck.SetChecked(proxySource.IsEnabled())
+		enabledCheck.OnChanged = func(enabled bool) {
+			if err := wizardbusiness.SetProxySourceEnabled(presenter.Model(), presenter, id, enabled); err != nil {
+				debuglog.ErrorLog("source_tab: failed to change source %s: %v", id, err)
+				return
+			}
+			presenter.RefreshOutboundOptions()
+		}
+
+		renameButton := widget.NewButton("✏️", func() {
+			showRenameSourceDialog(presenter, id, proxySource.Name)
+		})
+		sourcesList.Add(container.NewBorder(nil, nil, nil, renameButton, enabledCheck))
+	}
+
+	if len(sourcesList.Objects) == 0 {
+		sourcesContainer.Hide()
+		return
+	}
+	sourcesContainer.Show()
+	sourcesList.Refresh()
+}
+
+// maxInlineSchemaErrors — сколько ошибок схемы показывать под полем ParserConfig
+const maxInlineSchemaErrors = 5
+
+// refreshParserConfigErrors показывает под полем ParserConfig ошибки проверки по JSON Schema
+// (строка, столбец и путь) и ошибки wizard_template.json. Без ошибок метка скрыта.
+func refreshParserConfigErrors(presenter *wizardpresentation.WizardPresenter, errorsLabel *widget.Label, parserConfigText string) {
+	var lines []string
+	if errs := wizardbusiness.ValidateParserConfigSchema(parserConfigText); len(errs) > 0 {
+		lines = append(lines, "⚠️ ParserConfig:\n"+wizardbusiness.FormatSchemaErrors(errs, maxInlineSchemaErrors))
+	}
+	if templateData := presenter.Model().TemplateData; templateData != nil && len(templateData.SchemaErrors) > 0 {
+		templateErrors := templateData.SchemaErrors
+		if len(templateErrors) > maxInlineSchemaErrors {
+			templateErrors = append(templateErrors[:maxInlineSchemaErrors:maxInlineSchemaErrors],
+				fmt.Sprintf("... and %d more", len(templateData.SchemaErrors)-maxInlineSchemaErrors))
+		}
+		lines = append(lines, "⚠️ "+wizardtemplate.TemplateFileName+":\n"+strings.Join(templateErrors, "\n"))
+	}
+
+	if len(lines) == 0 {
+		errorsLabel.Hide()
+		return
+	}
+	errorsLabel.SetText(strings.Join(lines, "\n"))
+	errorsLabel.Show()
+}
+
+// showRenameSourceDialog показывает диалог ввода имени источника
+func showRenameSourceDialog(presenter *wizardpresentation.WizardPresenter, id string, currentName string) {
+	guiState := presenter.GUIState()
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(currentName)
+	nameEntry.SetPlaceHolder("e.g. Work, Backup")
+	items := []*widget.FormItem{widget.NewFormItem("Name", nameEntry)}
+	dialog.ShowForm("Source name", "Save", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := wizardbusiness.SetProxySourceName(presenter.Model(), presenter, id, nameEntry.Text); err != nil {
+			dialog.ShowError(err, guiState.Window)
+		}
+	}, guiState.Window)
+}
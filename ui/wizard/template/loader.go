@@ -10,7 +10,7 @@
 //   - params — платформозависимые параметры (применяются по runtime.GOOS)
 //
 // LoadTemplateData выполняет:
-//  1. Чтение и валидацию JSON файла шаблона
+//  1. Чтение и валидацию JSON файла шаблона (в том числе по JSON Schema из core/config/schema)
 //  2. Применение params для текущей платформы (replace/prepend/append)
 //  3. Фильтрацию selectable_rules по platforms
 //  4. Извлечение defaultFinal из config.route.final
@@ -30,6 +30,7 @@ import (
 	"runtime"
 	"strings"
 
+	"singbox-launcher/core/config/schema"
 	"singbox-launcher/internal/debuglog"
 )
 
@@ -58,6 +59,10 @@ type TemplateData struct {
 
 	// DefaultFinal — outbound по умолчанию из config.route.final.
 	DefaultFinal string
+
+	// SchemaErrors — ошибки проверки wizard_template.json по JSON Schema
+	// ("line L, col C: /path: сообщение"). Шаблон при этом всё равно загружается.
+	SchemaErrors []string
 }
 
 // TemplateSelectableRule — правило маршрутизации, управляемое пользователем в визарде.
@@ -134,6 +139,13 @@ func LoadTemplateData(execDir string) (*TemplateData, error) {
 	// Удаление UTF-8 BOM если присутствует
 	raw = stripUTF8BOM(raw)
 
+	// Проверка по JSON Schema: ошибки показываются в визарде, но не мешают загрузке
+	var schemaErrors []string
+	for _, schemaErr := range schema.ValidateWizardTemplate(raw) {
+		debuglog.WarnLog("TemplateLoader: %s: %v", TemplateFileName, schemaErr)
+		schemaErrors = append(schemaErrors, schemaErr.Error())
+	}
+
 	// Десериализация корневой структуры шаблона
 	var root struct {
 		ParserConfig    json.RawMessage      `json:"parser_config"`
@@ -192,6 +204,7 @@ func LoadTemplateData(execDir string) (*TemplateData, error) {
 		ConfigOrder:     configOrder,
 		SelectableRules: selectableRules,
 		DefaultFinal:    defaultFinal,
+		SchemaErrors:    schemaErrors,
 	}, nil
 }
 
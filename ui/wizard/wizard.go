@@ -20,6 +20,7 @@
 //
 // Используется в:
 //   - core/ui/ui.go - вызывается при открытии визарда из главного окна приложения
+//   - ui/core_dashboard_tab.go - ImportSource для источников из командной строки
 //
 // Координирует:
 //   - models - создает WizardModel
@@ -117,9 +118,16 @@ func ShowConfigWizard(parent fyne.Window) {
 				}
 			}
 		}
+		ac.UIService.AddWizardSourceFunc = func(source, name string) {
+			if presenter.AddSource(source, name) {
+				debuglog.InfoLog("ConfigWizard: source added from the command line")
+			}
+			wizardWindow.RequestFocus()
+		}
 		wizardWindow.SetOnClosed(func() {
 			ac.UIService.WizardWindow = nil
 			ac.UIService.FocusOpenRuleDialogs = nil
+			ac.UIService.AddWizardSourceFunc = nil
 			if ac.UIService.OnStateChange != nil {
 				ac.UIService.OnStateChange()
 			}
@@ -157,6 +165,20 @@ func ShowConfigWizard(parent fyne.Window) {
 	initializeWizardContent(presenter, guiState, wizardWindow, model, templateData)
 }
 
+// ImportSource opens the configuration wizard (or focuses the open one) and adds
+// source - a subscription URL or a node link - to its sources; name (from a deep link)
+// becomes the name of the new source. The user reviews the result and saves it as usual;
+// nothing is written to config.json here.
+func ImportSource(parent fyne.Window, source, name string) {
+	ShowConfigWizard(parent)
+	ac := core.GetController()
+	if ac == nil || ac.UIService == nil || ac.UIService.AddWizardSourceFunc == nil {
+		debuglog.WarnLog("ImportSource: wizard is not open, source ignored")
+		return
+	}
+	ac.UIService.AddWizardSourceFunc(source, name)
+}
+
 // loadConfigFromFile загружает конфигурацию из config.json или шаблона (текущее поведение).
 func loadConfigFromFile(presenter *wizardpresentation.WizardPresenter, fileService wizardbusiness.FileServiceInterface, templateData *wizardtemplate.TemplateData, model *wizardmodels.WizardModel, wizardWindow fyne.Window) {
 	loadedConfig, parserConfigJSON, sourceURLs, err := wizardbusiness.LoadConfigFromFile(fileService, templateData)